@@ -0,0 +1,91 @@
+// pedidos-admin - Ferramenta de linha de comando para implantação e manutenção:
+// criação do primeiro administrador, troca de senha, dados de demonstração,
+// importação/exportação de cadastros, limpeza do banco e manutenção dos anexos.
+//
+// Usa a mesma configuração (.env) e a mesma conexão (com migrações) do servidor.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/config"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/database"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/storage"
+	"gorm.io/gorm"
+)
+
+// command - Subcomando da CLI. Run lê as opções antes de conectar ao banco
+// (assim "-h" e opções inválidas não exigem banco disponível).
+type command struct {
+	Name        string
+	Description string
+	Run         func(args []string, connect func() *gorm.DB) error
+}
+
+var commands = []command{
+	{"create-admin", "cria um usuário administrador (ex: o primeiro acesso)", runCreateAdmin},
+	{"reset-password", "define uma nova senha e encerra as sessões do usuário", runResetPassword},
+	{"seed-demo", "cria setores, usuários, produtos, fornecedores e requisições de demonstração", runSeedDemo},
+	{"export", "exporta sectors, products, suppliers ou users para JSON", runExport},
+	{"import", "importa sectors, products, suppliers ou users de um JSON (cria ou atualiza)", runImport},
+	{"purge-deleted", "remove definitivamente registros excluídos há mais de N dias", runPurgeDeleted},
+	{"verify-attachments", "confere os arquivos no armazenamento contra o banco", runVerifyAttachments},
+	{"migrate-storage", "copia os arquivos entre backends (local, s3) e atualiza as chaves no banco", runMigrateStorage},
+	{"scan-files", "verifica contra malware os arquivos em quarentena (ou todos)", runScanFiles},
+	{"generate-previews", "gera miniaturas e metadados dos anexos que ainda não têm", runGeneratePreviews},
+}
+
+// openStorage abre o armazenamento configurado no .env; backend não vazio substitui STORAGE_BACKEND
+func openStorage(backend string) (storage.Storage, error) {
+	cfg := config.LoadConfig().StorageConfig()
+	if backend != "" {
+		cfg.Backend = backend
+	}
+	return storage.New(cfg)
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Uso: pedidos-admin <comando> [opções]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Comandos:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-20s %s\n", cmd.Name, cmd.Description)
+	}
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Use pedidos-admin <comando> -h para ver as opções de cada comando.")
+}
+
+func main() {
+	if len(os.Args) < 2 || os.Args[1] == "-h" || os.Args[1] == "--help" || os.Args[1] == "help" {
+		usage()
+		os.Exit(2)
+	}
+
+	var selected *command
+	for i := range commands {
+		if commands[i].Name == os.Args[1] {
+			selected = &commands[i]
+		}
+	}
+	if selected == nil {
+		fmt.Fprintf(os.Stderr, "Comando desconhecido: %s\n\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+
+	connect := func() *gorm.DB {
+		appConfig := config.LoadConfig()
+		return database.Connect(appConfig)
+	}
+
+	if err := selected.Run(os.Args[2:], connect); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(2)
+		}
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/config"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/handlers"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/preview"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/scanner"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/storage"
+	"gorm.io/gorm"
+)
+
+// purgeTables - Tabelas com exclusão lógica, dos dependentes para os referenciados.
+// FileColumn (e ThumbnailColumn) indicam chaves de arquivos no armazenamento, removidos junto com o registro.
+var purgeTables = []struct {
+	Table           string
+	FileColumn      string
+	ThumbnailColumn string
+}{
+	{"item_receipts", "", ""},
+	{"purchase_order_lines", "", ""},
+	{"item_budgets", "", ""},
+	{"attachments", "file_path", "thumbnail_path"},
+	{"request_approval_steps", "", ""},
+	{"request_comments", "", ""},
+	{"request_items", "", ""},
+	{"purchase_requests", "", ""},
+	{"purchase_orders", "", ""},
+	{"product_registration_requests", "", ""},
+	{"approval_policy_steps", "", ""},
+	{"approval_policies", "", ""},
+	{"sector_budgets", "", ""},
+	{"products", "", ""},
+	{"suppliers", "", ""},
+	{"users", "", ""},
+	{"sectors", "", ""},
+}
+
+// purgeBatchSize - Quantidade de registros removidos por comando DELETE
+const purgeBatchSize = 500
+
+// purgeRow - Registro candidato à remoção definitiva
+type purgeRow struct {
+	ID        uint
+	File      string
+	Thumbnail string
+}
+
+// runPurgeDeleted remove definitivamente os registros excluídos (deleted_at) há mais de N dias.
+// Registros ainda referenciados por outros (chave estrangeira) são mantidos e contados.
+func runPurgeDeleted(args []string, connect func() *gorm.DB) error {
+	fsFlags := newFlagSet("purge-deleted", "-days N [-dry-run]")
+	days := fsFlags.Int("days", 0, "remove registros excluídos há mais de N dias (obrigatório)")
+	dryRun := fsFlags.Bool("dry-run", false, "apenas conta o que seria removido")
+	if err := fsFlags.Parse(args); err != nil {
+		return err
+	}
+	if *days < 1 {
+		fsFlags.Usage()
+		return errors.New("-days deve ser maior que zero")
+	}
+
+	db := connect()
+	store, err := openStorage("")
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	cutoff := time.Now().AddDate(0, 0, -*days)
+
+	var totalPurged, totalKept int
+	for _, t := range purgeTables {
+		columns := "id"
+		if t.FileColumn != "" {
+			columns += ", " + t.FileColumn + " AS file"
+		}
+		if t.ThumbnailColumn != "" {
+			columns += ", COALESCE(" + t.ThumbnailColumn + ", '') AS thumbnail"
+		}
+		var rows []purgeRow
+		if err := db.Table(t.Table).
+			Select(columns).
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+			Order("id").
+			Scan(&rows).Error; err != nil {
+			return fmt.Errorf("%s: %w", t.Table, err)
+		}
+		if len(rows) == 0 {
+			continue
+		}
+		if *dryRun {
+			fmt.Printf("  %-30s %d registro(s)\n", t.Table, len(rows))
+			totalPurged += len(rows)
+			continue
+		}
+
+		purged, kept := purgeRows(db, t.Table, rows)
+		totalPurged += len(purged)
+		totalKept += kept
+		for _, row := range purged {
+			for _, key := range []string{row.File, row.Thumbnail} {
+				if key == "" {
+					continue
+				}
+				if err := store.Delete(ctx, key); err != nil {
+					fmt.Fprintf(os.Stderr, "⚠️  Não foi possível remover o arquivo %s: %v\n", key, err)
+				}
+			}
+		}
+		fmt.Printf("  %-30s %d removido(s), %d mantido(s) por ainda estarem referenciados\n", t.Table, len(purged), kept)
+	}
+
+	if *dryRun {
+		fmt.Printf("Simulação: %d registro(s) excluído(s) antes de %s seriam removidos\n", totalPurged, cutoff.Format("02/01/2006"))
+		return nil
+	}
+	fmt.Printf("✅ %d registro(s) removido(s) definitivamente; %d mantido(s)\n", totalPurged, totalKept)
+	return nil
+}
+
+// purgeRows remove os registros em lotes; se um lote falhar (registro ainda referenciado),
+// tenta um a um para remover os demais
+func purgeRows(db *gorm.DB, table string, rows []purgeRow) ([]purgeRow, int) {
+	var purged []purgeRow
+	kept := 0
+	for start := 0; start < len(rows); start += purgeBatchSize {
+		end := start + purgeBatchSize
+		if end > len(rows) {
+			end = len(rows)
+		}
+		batch := rows[start:end]
+
+		ids := make([]uint, len(batch))
+		for i, row := range batch {
+			ids[i] = row.ID
+		}
+		if err := db.Exec("DELETE FROM "+table+" WHERE id IN ?", ids).Error; err == nil {
+			purged = append(purged, batch...)
+			continue
+		}
+
+		for _, row := range batch {
+			if err := db.Exec("DELETE FROM "+table+" WHERE id = ?", row.ID).Error; err != nil {
+				kept++
+				continue
+			}
+			purged = append(purged, row)
+		}
+	}
+	return purged, kept
+}
+
+// runVerifyAttachments confere os anexos: registros cujo arquivo não existe no armazenamento
+// e arquivos do armazenamento sem registro no banco (órfãos)
+func runVerifyAttachments(args []string, connect func() *gorm.DB) error {
+	fsFlags := newFlagSet("verify-attachments", "[-delete-orphans]")
+	deleteOrphans := fsFlags.Bool("delete-orphans", false, "remove os arquivos órfãos")
+	if err := fsFlags.Parse(args); err != nil {
+		return err
+	}
+
+	db := connect()
+	store, err := openStorage("")
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	files, err := loadStoredFiles(db)
+	if err != nil {
+		return err
+	}
+
+	// Chaves referenciadas, incluindo registros com exclusão lógica (o arquivo ainda pertence a eles)
+	referenced := map[string]bool{}
+	missing := 0
+	for _, f := range files {
+		referenced[f.Key] = true
+		if f.Deleted {
+			continue
+		}
+		if _, err := store.Stat(ctx, f.Key); err != nil {
+			missing++
+			if errors.Is(err, storage.ErrNotFound) {
+				fmt.Printf("❌ %s %d: arquivo ausente (%s)\n", f.Kind, f.ID, f.Key)
+			} else {
+				fmt.Printf("❌ %s %d: erro ao consultar %s: %v\n", f.Kind, f.ID, f.Key, err)
+			}
+		}
+	}
+
+	orphans := 0
+	err = store.List(ctx, "", func(info storage.ObjectInfo) error {
+		if referenced[info.Key] {
+			return nil
+		}
+		orphans++
+		if !*deleteOrphans {
+			fmt.Printf("⚠️  Arquivo órfão (sem registro no banco): %s\n", info.Key)
+			return nil
+		}
+		if err := store.Delete(ctx, info.Key); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  Não foi possível remover %s: %v\n", info.Key, err)
+			return nil
+		}
+		fmt.Printf("🗑️  Órfão removido: %s\n", info.Key)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("erro ao listar arquivos em %s: %w", store.Name(), err)
+	}
+
+	fmt.Printf("Armazenamento %s: %d arquivo(s) referenciado(s), %d ausente(s), %d órfão(s)\n",
+		store.Name(), len(files), missing, orphans)
+	if missing > 0 {
+		return fmt.Errorf("%d arquivo(s) referenciado(s) não encontrado(s)", missing)
+	}
+	return nil
+}
+
+// runScanFiles verifica contra malware os anexos e notas fiscais em quarentena; com -all,
+// verifica também os já liberados (ex: arquivos enviados antes da verificação existir)
+func runScanFiles(args []string, connect func() *gorm.DB) error {
+	fsFlags := newFlagSet("scan-files", "[-all]")
+	all := fsFlags.Bool("all", false, "verifica todos os arquivos, não apenas os em quarentena")
+	if err := fsFlags.Parse(args); err != nil {
+		return err
+	}
+
+	scan, err := scanner.New(config.LoadConfig().ScannerConfig())
+	if err != nil {
+		return err
+	}
+	if _, noop := scan.(scanner.Noop); noop {
+		return errors.New("nenhum scanner configurado (defina SCANNER_BACKEND=clamd no .env)")
+	}
+
+	db := connect()
+	store, err := openStorage("")
+	if err != nil {
+		return err
+	}
+
+	scanned, infected, failed, err := handlers.RescanFiles(context.Background(), db, store, scan, *all)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Verificação (%s): %d arquivo(s) verificado(s), %d infectado(s), %d com erro\n", scan.Name(), scanned, infected, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d arquivo(s) continuam em quarentena", failed)
+	}
+	return nil
+}
+
+// runGeneratePreviews gera miniaturas e metadados dos anexos enviados antes da pré-visualização
+// existir (ou de todos, com -all, ex: depois de instalar o pdftoppm)
+func runGeneratePreviews(args []string, connect func() *gorm.DB) error {
+	fsFlags := newFlagSet("generate-previews", "[-all]")
+	all := fsFlags.Bool("all", false, "gera novamente para todos os anexos, não apenas os sem pré-visualização")
+	if err := fsFlags.Parse(args); err != nil {
+		return err
+	}
+
+	previews := preview.New(config.LoadConfig().PreviewConfig())
+	db := connect()
+	store, err := openStorage("")
+	if err != nil {
+		return err
+	}
+
+	processed, failed, err := handlers.GenerateAttachmentPreviews(context.Background(), db, store, previews, *all)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Pré-visualização (%s): %d anexo(s) processado(s), %d com erro\n", previews.Name(), processed, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d anexo(s) sem pré-visualização", failed)
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/models"
+	"gorm.io/gorm"
+)
+
+// Dados de demonstração (criados apenas quando ainda não existem)
+var (
+	demoSectors = []struct {
+		Name, CostCenter, Parent string
+	}{
+		{"Administração", "ADM", ""},
+		{"Manutenção", "MAN", ""},
+		{"Elétrica", "MAN-ELE", "Manutenção"},
+		{"TI", "TI", ""},
+	}
+
+	demoUsers = []struct {
+		Name, Email, Role, Sector string
+	}{
+		{"Comprador Demo", "comprador@demo.local", "buyer", "Administração"},
+		{"Aprovador Demo", "aprovador@demo.local", "approver", "Administração"},
+		{"João (Manutenção)", "joao@demo.local", models.RoleRequester, "Manutenção"},
+		{"Ana (Elétrica)", "ana@demo.local", models.RoleRequester, "Elétrica"},
+		{"Carlos (TI)", "carlos@demo.local", models.RoleRequester, "TI"},
+	}
+
+	demoProducts = []struct {
+		Name, Description, Unit, Sector string
+	}{
+		{"Papel A4", "Resma com 500 folhas, 75 g/m²", "resma", "Administração"},
+		{"Caneta esferográfica azul", "Caixa com 50 unidades", "caixa", "Administração"},
+		{"Luva nitrílica", "Luva descartável sem pó, tamanho M", "caixa", "Manutenção"},
+		{"Graxa multiuso", "Graxa à base de lítio", "kg", "Manutenção"},
+		{"Cabo flexível 2,5 mm", "Rolo com 100 m", "rolo", "Elétrica"},
+		{"Disjuntor 20 A", "Disjuntor monopolar curva C", "peça", "Elétrica"},
+		{"Mouse USB", "Mouse óptico com fio", "peça", "TI"},
+		{"Cabo de rede Cat6", "Cabo UTP azul", "metro", "TI"},
+	}
+
+	demoSuppliers = []models.Supplier{
+		{Name: "Papelaria Central Ltda", CNPJ: "11.222.333/0001-81", Contact: "Marta", Phone: "(11) 3333-1000", Email: "vendas@papelariacentral.demo"},
+		{Name: "Elétrica Forte Distribuidora", CNPJ: "22.333.444/0001-05", Contact: "Roberto", Phone: "(11) 3333-2000", Email: "contato@eletricaforte.demo"},
+		{Name: "InfoShop Suprimentos", CNPJ: "33.444.555/0001-10", Contact: "Paula", Phone: "(11) 3333-3000", Email: "comercial@infoshop.demo"},
+	}
+
+	// Requisições pendentes: e-mail do solicitante -> produtos e quantidades
+	demoRequests = []struct {
+		Requester    string
+		Observations string
+		Items        []demoItem
+	}{
+		{"joao@demo.local", "Reposição do almoxarifado da manutenção", []demoItem{{"Luva nitrílica", 10}, {"Graxa multiuso", 5}}},
+		{"ana@demo.local", "Troca do quadro de distribuição do galpão 2", []demoItem{{"Cabo flexível 2,5 mm", 3}, {"Disjuntor 20 A", 12}}},
+		{"carlos@demo.local", "Novas estações de trabalho", []demoItem{{"Mouse USB", 8}, {"Cabo de rede Cat6", 150}}},
+	}
+)
+
+// demoItem - Item de uma requisição de demonstração
+type demoItem struct {
+	Product  string
+	Quantity int
+}
+
+// runSeedDemo cria os dados de demonstração que ainda não existem
+func runSeedDemo(args []string, connect func() *gorm.DB) error {
+	fs := newFlagSet("seed-demo", "[-password SENHA]")
+	password := fs.String("password", "Demo@1234", "senha dos usuários de demonstração")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	db := connect()
+
+	hash, err := hashPassword(db, *password)
+	if err != nil {
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		created := map[string]int{}
+
+		sectors := map[string]models.Sector{}
+		for _, s := range demoSectors {
+			sector := models.Sector{Name: s.Name}
+			result := tx.Where(models.Sector{Name: s.Name}).Attrs(models.Sector{CostCenter: s.CostCenter}).FirstOrCreate(&sector)
+			if result.Error != nil {
+				return fmt.Errorf("erro ao criar setor %s: %w", s.Name, result.Error)
+			}
+			if s.Parent != "" && sector.ParentID == nil {
+				parentID := sectors[s.Parent].ID
+				if err := tx.Model(&sector).Update("parent_id", parentID).Error; err != nil {
+					return fmt.Errorf("erro ao definir setor pai de %s: %w", s.Name, err)
+				}
+			}
+			created["setores"] += int(result.RowsAffected)
+			sectors[s.Name] = sector
+		}
+
+		users := map[string]models.User{}
+		for _, u := range demoUsers {
+			user := models.User{}
+			result := tx.Where(models.User{Email: u.Email}).
+				Attrs(models.User{Name: u.Name, Role: u.Role, SectorID: sectors[u.Sector].ID, PasswordHash: hash}).
+				FirstOrCreate(&user)
+			if result.Error != nil {
+				return fmt.Errorf("erro ao criar usuário %s: %w", u.Email, result.Error)
+			}
+			created["usuários"] += int(result.RowsAffected)
+			users[u.Email] = user
+		}
+
+		products := map[string]models.Product{}
+		for _, p := range demoProducts {
+			product := models.Product{}
+			result := tx.Where(models.Product{Name: p.Name, SectorID: sectors[p.Sector].ID}).
+				Attrs(models.Product{Description: p.Description, Unit: p.Unit, Status: "available"}).
+				FirstOrCreate(&product)
+			if result.Error != nil {
+				return fmt.Errorf("erro ao criar produto %s: %w", p.Name, result.Error)
+			}
+			created["produtos"] += int(result.RowsAffected)
+			products[p.Name] = product
+		}
+
+		for _, s := range demoSuppliers {
+			supplier := models.Supplier{}
+			result := tx.Where(models.Supplier{CNPJ: s.CNPJ}).Attrs(s).FirstOrCreate(&supplier)
+			if result.Error != nil {
+				return fmt.Errorf("erro ao criar fornecedor %s: %w", s.Name, result.Error)
+			}
+			created["fornecedores"] += int(result.RowsAffected)
+		}
+
+		for _, r := range demoRequests {
+			requester := users[r.Requester]
+
+			// Uma requisição por solicitante de demonstração
+			var count int64
+			if err := tx.Model(&models.PurchaseRequest{}).Where("requester_id = ?", requester.ID).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				continue
+			}
+
+			request := models.PurchaseRequest{
+				RequesterID:          requester.ID,
+				SectorID:             requester.SectorID,
+				Status:               models.StatusPending,
+				Priority:             models.PriorityNormal,
+				Observations:         r.Observations,
+				SectorApprovalStatus: models.SectorApprovalNotRequired,
+			}
+			for _, item := range r.Items {
+				request.Items = append(request.Items, models.RequestItem{
+					ProductID: products[item.Product].ID,
+					Quantity:  item.Quantity,
+					Status:    models.ItemStatusPending,
+				})
+			}
+			if err := tx.Create(&request).Error; err != nil {
+				return fmt.Errorf("erro ao criar requisição de %s: %w", r.Requester, err)
+			}
+			history := models.RequestStatusHistory{
+				PurchaseRequestID: request.ID,
+				ToStatus:          request.Status,
+				ChangedBy:         &requester.ID,
+				Notes:             "Requisição criada (dados de demonstração)",
+			}
+			if err := tx.Create(&history).Error; err != nil {
+				return err
+			}
+			created["requisições"]++
+		}
+
+		fmt.Printf("✅ Dados de demonstração: %d setores, %d usuários, %d produtos, %d fornecedores e %d requisições criados\n",
+			created["setores"], created["usuários"], created["produtos"], created["fornecedores"], created["requisições"])
+		if created["usuários"] > 0 {
+			fmt.Println("   Usuários *@demo.local com a senha informada em -password")
+		}
+		return nil
+	})
+}
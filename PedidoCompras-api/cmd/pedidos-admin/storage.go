@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/storage"
+	"gorm.io/gorm"
+)
+
+// storedFile - Referência a um arquivo do armazenamento gravada no banco
+type storedFile struct {
+	Kind    string // descrição para as mensagens
+	Table   string
+	Column  string
+	ID      uint
+	Key     string
+	Deleted bool
+	Dir     string // pasta padrão do arquivo no armazenamento
+}
+
+// attachmentDirSQL - Pasta padrão dos anexos (mesma regra de models.Attachment.StorageDir)
+const attachmentDirSQL = `CASE WHEN purchase_request_id IS NOT NULL THEN 'requests/' || purchase_request_id
+	WHEN owner_type = 'supplier' THEN 'suppliers/' || owner_id
+	ELSE 'product-requests/' || owner_id END`
+
+// storedFileSources - Colunas que guardam chaves do armazenamento (DirSQL: pasta padrão do arquivo)
+var storedFileSources = []struct {
+	Kind, Table, Column, DirSQL string
+}{
+	{"Anexo", "attachments", "file_path", attachmentDirSQL},
+	{"Miniatura do anexo", "attachments", "thumbnail_path", "(" + attachmentDirSQL + ") || '/thumbnails'"},
+	{"Logo da empresa", "company_settings", "logo_path", "'logos'"},
+}
+
+// loadStoredFiles lista todas as chaves gravadas, incluindo registros com exclusão lógica
+func loadStoredFiles(db *gorm.DB) ([]storedFile, error) {
+	var files []storedFile
+	for _, src := range storedFileSources {
+		var rows []struct {
+			ID      uint
+			FileKey string
+			Deleted bool
+			Dir     string
+		}
+		err := db.Table(src.Table).
+			Select("id, " + src.Column + " AS file_key, deleted_at IS NOT NULL AS deleted, " + src.DirSQL + " AS dir").
+			Where("COALESCE(" + src.Column + ", '') <> ''").
+			Order("id").
+			Scan(&rows).Error
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", src.Table, err)
+		}
+		for _, row := range rows {
+			files = append(files, storedFile{
+				Kind:    src.Kind,
+				Table:   src.Table,
+				Column:  src.Column,
+				ID:      row.ID,
+				Key:     row.FileKey,
+				Deleted: row.Deleted,
+				Dir:     row.Dir,
+			})
+		}
+	}
+	return files, nil
+}
+
+// runMigrateStorage copia os arquivos de um backend para outro e grava as novas chaves.
+// Arquivos fora da pasta padrão (ex: anexos antigos na raiz de uploads, notas fiscais em invoices/)
+// são reorganizados em requests/<id>/, suppliers/<id>/, product-requests/<id>/ e logos/. Pode ser executado novamente para retomar uma migração.
+func runMigrateStorage(args []string, connect func() *gorm.DB) error {
+	fsFlags := newFlagSet("migrate-storage", "-from local|s3 -to local|s3 [-delete-source] [-dry-run]")
+	from := fsFlags.String("from", "", "backend de origem (local ou s3)")
+	to := fsFlags.String("to", "", "backend de destino (local ou s3)")
+	deleteSource := fsFlags.Bool("delete-source", false, "remove o arquivo da origem depois de copiado")
+	dryRun := fsFlags.Bool("dry-run", false, "apenas lista o que seria copiado")
+	if err := fsFlags.Parse(args); err != nil {
+		return err
+	}
+	if *from == "" || *to == "" {
+		fsFlags.Usage()
+		return errors.New("-from e -to são obrigatórios")
+	}
+
+	db := connect()
+	source, err := openStorage(*from)
+	if err != nil {
+		return err
+	}
+	target, err := openStorage(*to)
+	if err != nil {
+		return err
+	}
+	sameBackend := source.Name() == target.Name()
+	ctx := context.Background()
+
+	files, err := loadStoredFiles(db)
+	if err != nil {
+		return err
+	}
+
+	var copied, skipped, failed int
+	for _, f := range files {
+		newKey := f.Key
+		if !strings.HasPrefix(f.Key, f.Dir+"/") {
+			newKey = path.Join(f.Dir, path.Base(f.Key))
+		}
+		if sameBackend && newKey == f.Key {
+			skipped++
+			continue
+		}
+		if *dryRun {
+			fmt.Printf("  %s %d: %s -> %s\n", f.Kind, f.ID, f.Key, newKey)
+			copied++
+			continue
+		}
+
+		if err := migrateStoredFile(ctx, db, source, target, f, newKey); err != nil {
+			failed++
+			fmt.Printf("❌ %s %d (%s): %v\n", f.Kind, f.ID, f.Key, err)
+			continue
+		}
+		copied++
+
+		if *deleteSource {
+			if err := source.Delete(ctx, f.Key); err != nil {
+				fmt.Printf("⚠️  %s %d: não foi possível remover %s da origem: %v\n", f.Kind, f.ID, f.Key, err)
+			}
+		}
+	}
+
+	if *dryRun {
+		fmt.Printf("Simulação: %d arquivo(s) seriam copiados de %s para %s; %d já no lugar\n", copied, source.Name(), target.Name(), skipped)
+		return nil
+	}
+	fmt.Printf("✅ %d arquivo(s) copiado(s) de %s para %s; %d já no lugar; %d com erro\n", copied, source.Name(), target.Name(), skipped, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d arquivo(s) não migrado(s)", failed)
+	}
+	return nil
+}
+
+// migrateStoredFile copia um arquivo (se ainda não estiver no destino com o mesmo tamanho)
+// e grava a nova chave no registro
+func migrateStoredFile(ctx context.Context, db *gorm.DB, source, target storage.Storage, f storedFile, newKey string) error {
+	sourceInfo, sourceErr := source.Stat(ctx, f.Key)
+	targetInfo, targetErr := target.Stat(ctx, newKey)
+
+	switch {
+	case sourceErr == nil && targetErr == nil && sourceInfo.Size == targetInfo.Size:
+		// já copiado por uma execução anterior
+	case sourceErr == nil:
+		size, err := storage.Copy(ctx, source, f.Key, target, newKey)
+		if err != nil {
+			return fmt.Errorf("erro ao copiar: %w", err)
+		}
+		info, err := target.Stat(ctx, newKey)
+		if err != nil {
+			return fmt.Errorf("erro ao conferir a cópia: %w", err)
+		}
+		if info.Size != size {
+			return fmt.Errorf("cópia incompleta (%d de %d bytes)", info.Size, size)
+		}
+	case errors.Is(sourceErr, storage.ErrNotFound) && targetErr == nil:
+		// origem já removida por uma execução anterior com -delete-source
+	default:
+		return fmt.Errorf("arquivo de origem indisponível: %w", sourceErr)
+	}
+
+	if newKey == f.Key {
+		return nil
+	}
+	return db.Table(f.Table).Where("id = ?", f.ID).Update(f.Column, newKey).Error
+}
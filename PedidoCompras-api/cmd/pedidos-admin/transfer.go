@@ -0,0 +1,407 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/models"
+	"gorm.io/gorm"
+)
+
+// Registros de importação/exportação: as referências usam nomes (setor, setor pai)
+// em vez de IDs, para que o arquivo possa ser carregado em outro banco.
+
+type sectorRecord struct {
+	Name       string `json:"name"`
+	CostCenter string `json:"costCenter,omitempty"`
+	Parent     string `json:"parent,omitempty"`
+}
+
+type productRecord struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Unit        string `json:"unit"`
+	Sector      string `json:"sector"`
+	Status      string `json:"status,omitempty"`
+}
+
+type supplierRecord struct {
+	Name         string `json:"name"`
+	CNPJ         string `json:"cnpj,omitempty"`
+	Contact      string `json:"contact,omitempty"`
+	Phone        string `json:"phone,omitempty"`
+	Email        string `json:"email,omitempty"`
+	Observations string `json:"observations,omitempty"`
+}
+
+// userRecord - Usuários são exportados sem senha; os importados precisam de reset-password
+type userRecord struct {
+	Name   string `json:"name"`
+	Email  string `json:"email"`
+	Role   string `json:"role"`
+	Sector string `json:"sector"`
+}
+
+// transferEntities - Entidades aceitas por export e import
+var transferEntities = []string{"sectors", "products", "suppliers", "users"}
+
+// parseTransferArgs lê a entidade (primeiro argumento) e as opções
+func parseTransferArgs(name, fileFlag, fileHelp string, args []string) (string, string, error) {
+	fs := newFlagSet(name, "<"+strings.Join(transferEntities, "|")+"> [-"+fileFlag+" ARQUIVO]")
+	file := fs.String(fileFlag, "-", fileHelp)
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		if err := fs.Parse(args); err != nil {
+			return "", "", err
+		}
+		fs.Usage()
+		return "", "", errors.New("informe a entidade: " + strings.Join(transferEntities, ", "))
+	}
+	entity := args[0]
+	if err := fs.Parse(args[1:]); err != nil {
+		return "", "", err
+	}
+	if !containsEntity(entity) {
+		return "", "", fmt.Errorf("entidade inválida: %s (use %s)", entity, strings.Join(transferEntities, ", "))
+	}
+	return entity, *file, nil
+}
+
+func containsEntity(entity string) bool {
+	for _, e := range transferEntities {
+		if e == entity {
+			return true
+		}
+	}
+	return false
+}
+
+// runExport grava os registros da entidade em JSON (saída padrão ou -out)
+func runExport(args []string, connect func() *gorm.DB) error {
+	entity, file, err := parseTransferArgs("export", "out", "arquivo de saída (- para a saída padrão)", args)
+	if err != nil {
+		return err
+	}
+
+	db := connect()
+
+	sectorNames, err := loadSectorNames(db)
+	if err != nil {
+		return err
+	}
+
+	var records interface{}
+	switch entity {
+	case "sectors":
+		var sectors []models.Sector
+		if err := db.Order("name").Find(&sectors).Error; err != nil {
+			return err
+		}
+		list := make([]sectorRecord, 0, len(sectors))
+		for _, s := range sectors {
+			record := sectorRecord{Name: s.Name, CostCenter: s.CostCenter}
+			if s.ParentID != nil {
+				record.Parent = sectorNames[*s.ParentID]
+			}
+			list = append(list, record)
+		}
+		records = list
+	case "products":
+		var products []models.Product
+		if err := db.Order("name").Find(&products).Error; err != nil {
+			return err
+		}
+		list := make([]productRecord, 0, len(products))
+		for _, p := range products {
+			list = append(list, productRecord{Name: p.Name, Description: p.Description, Unit: p.Unit, Sector: sectorNames[p.SectorID], Status: p.Status})
+		}
+		records = list
+	case "suppliers":
+		var suppliers []models.Supplier
+		if err := db.Order("name").Find(&suppliers).Error; err != nil {
+			return err
+		}
+		list := make([]supplierRecord, 0, len(suppliers))
+		for _, s := range suppliers {
+			list = append(list, supplierRecord{Name: s.Name, CNPJ: s.CNPJ, Contact: s.Contact, Phone: s.Phone, Email: s.Email, Observations: s.Observations})
+		}
+		records = list
+	case "users":
+		var users []models.User
+		if err := db.Order("name").Find(&users).Error; err != nil {
+			return err
+		}
+		list := make([]userRecord, 0, len(users))
+		for _, u := range users {
+			list = append(list, userRecord{Name: u.Name, Email: u.Email, Role: u.Role, Sector: sectorNames[u.SectorID]})
+		}
+		records = list
+	}
+
+	var out io.Writer = os.Stdout
+	if file != "-" {
+		f, err := os.Create(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(records); err != nil {
+		return err
+	}
+	if file != "-" {
+		fmt.Fprintf(os.Stderr, "✅ %s exportados para %s\n", entity, file)
+	}
+	return nil
+}
+
+// runImport cria ou atualiza os registros do JSON, em uma única transação.
+// Chaves: setor e fornecedor pelo nome (fornecedor pelo CNPJ quando informado),
+// produto pelo nome + setor e usuário pelo e-mail.
+func runImport(args []string, connect func() *gorm.DB) error {
+	entity, file, err := parseTransferArgs("import", "in", "arquivo de entrada (- para a entrada padrão)", args)
+	if err != nil {
+		return err
+	}
+
+	var in io.Reader = os.Stdin
+	if file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+	decoder := json.NewDecoder(in)
+	decoder.DisallowUnknownFields()
+
+	var importFn func(tx *gorm.DB) (int, int, error)
+	switch entity {
+	case "sectors":
+		var records []sectorRecord
+		if err := decoder.Decode(&records); err != nil {
+			return fmt.Errorf("JSON inválido: %w", err)
+		}
+		importFn = func(tx *gorm.DB) (int, int, error) { return importSectors(tx, records) }
+	case "products":
+		var records []productRecord
+		if err := decoder.Decode(&records); err != nil {
+			return fmt.Errorf("JSON inválido: %w", err)
+		}
+		importFn = func(tx *gorm.DB) (int, int, error) { return importProducts(tx, records) }
+	case "suppliers":
+		var records []supplierRecord
+		if err := decoder.Decode(&records); err != nil {
+			return fmt.Errorf("JSON inválido: %w", err)
+		}
+		importFn = func(tx *gorm.DB) (int, int, error) { return importSuppliers(tx, records) }
+	case "users":
+		var records []userRecord
+		if err := decoder.Decode(&records); err != nil {
+			return fmt.Errorf("JSON inválido: %w", err)
+		}
+		importFn = func(tx *gorm.DB) (int, int, error) { return importUsers(tx, records) }
+	}
+
+	db := connect()
+
+	var created, updated int
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		created, updated, err = importFn(tx)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("importação cancelada: %w", err)
+	}
+
+	fmt.Printf("✅ %s: %d criados, %d atualizados\n", entity, created, updated)
+	return nil
+}
+
+// loadSectorNames mapeia ID -> nome dos setores (incluindo excluídos, para exportar referências)
+func loadSectorNames(db *gorm.DB) (map[uint]string, error) {
+	var sectors []models.Sector
+	if err := db.Unscoped().Select("id", "name").Find(&sectors).Error; err != nil {
+		return nil, err
+	}
+	names := make(map[uint]string, len(sectors))
+	for _, s := range sectors {
+		names[s.ID] = s.Name
+	}
+	return names, nil
+}
+
+// sectorIDByName busca o setor pelo nome
+func sectorIDByName(tx *gorm.DB, name string) (uint, error) {
+	var sector models.Sector
+	if err := tx.Where("name = ?", name).First(&sector).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return 0, fmt.Errorf("setor %q não encontrado (importe os setores antes)", name)
+		}
+		return 0, err
+	}
+	return sector.ID, nil
+}
+
+// importSectors importa os setores; setores pais podem vir depois dos filhos no arquivo
+func importSectors(tx *gorm.DB, records []sectorRecord) (int, int, error) {
+	var created, updated int
+	for _, r := range records {
+		if r.Name == "" {
+			return created, updated, errors.New("setor sem nome")
+		}
+		sector := models.Sector{}
+		result := tx.Where(models.Sector{Name: r.Name}).Attrs(models.Sector{CostCenter: r.CostCenter}).FirstOrCreate(&sector)
+		if result.Error != nil {
+			return created, updated, fmt.Errorf("setor %s: %w", r.Name, result.Error)
+		}
+		if result.RowsAffected > 0 {
+			created++
+		} else {
+			if err := tx.Model(&sector).Update("cost_center", r.CostCenter).Error; err != nil {
+				return created, updated, fmt.Errorf("setor %s: %w", r.Name, err)
+			}
+			updated++
+		}
+	}
+
+	// Segunda passada: hierarquia
+	for _, r := range records {
+		var parentID *uint
+		if r.Parent == r.Name {
+			return created, updated, fmt.Errorf("setor %s não pode ser pai de si mesmo", r.Name)
+		}
+		if r.Parent != "" {
+			id, err := sectorIDByName(tx, r.Parent)
+			if err != nil {
+				return created, updated, err
+			}
+			parentID = &id
+		}
+		if err := tx.Model(&models.Sector{}).Where("name = ?", r.Name).Update("parent_id", parentID).Error; err != nil {
+			return created, updated, fmt.Errorf("setor %s: %w", r.Name, err)
+		}
+	}
+	return created, updated, nil
+}
+
+func importProducts(tx *gorm.DB, records []productRecord) (int, int, error) {
+	var created, updated int
+	for _, r := range records {
+		if r.Name == "" || r.Unit == "" {
+			return created, updated, fmt.Errorf("produto %q sem nome ou unidade", r.Name)
+		}
+		sectorID, err := sectorIDByName(tx, r.Sector)
+		if err != nil {
+			return created, updated, err
+		}
+		status := r.Status
+		if status == "" {
+			status = "available"
+		}
+
+		product := models.Product{}
+		result := tx.Where(models.Product{Name: r.Name, SectorID: sectorID}).
+			Attrs(models.Product{Description: r.Description, Unit: r.Unit, Status: status}).
+			FirstOrCreate(&product)
+		if result.Error != nil {
+			return created, updated, fmt.Errorf("produto %s: %w", r.Name, result.Error)
+		}
+		if result.RowsAffected > 0 {
+			created++
+			continue
+		}
+		if err := tx.Model(&product).Updates(map[string]interface{}{
+			"description": r.Description, "unit": r.Unit, "status": status,
+		}).Error; err != nil {
+			return created, updated, fmt.Errorf("produto %s: %w", r.Name, err)
+		}
+		updated++
+	}
+	return created, updated, nil
+}
+
+func importSuppliers(tx *gorm.DB, records []supplierRecord) (int, int, error) {
+	var created, updated int
+	for _, r := range records {
+		if r.Name == "" {
+			return created, updated, errors.New("fornecedor sem nome")
+		}
+		key := models.Supplier{Name: r.Name}
+		if r.CNPJ != "" {
+			key = models.Supplier{CNPJ: r.CNPJ}
+		}
+		values := models.Supplier{Name: r.Name, CNPJ: r.CNPJ, Contact: r.Contact, Phone: r.Phone, Email: r.Email, Observations: r.Observations}
+
+		supplier := models.Supplier{}
+		result := tx.Where(key).Attrs(values).FirstOrCreate(&supplier)
+		if result.Error != nil {
+			return created, updated, fmt.Errorf("fornecedor %s: %w", r.Name, result.Error)
+		}
+		if result.RowsAffected > 0 {
+			created++
+			continue
+		}
+		if err := tx.Model(&supplier).Updates(map[string]interface{}{
+			"name": r.Name, "cnpj": r.CNPJ, "contact": r.Contact, "phone": r.Phone,
+			"email": r.Email, "observations": r.Observations,
+		}).Error; err != nil {
+			return created, updated, fmt.Errorf("fornecedor %s: %w", r.Name, err)
+		}
+		updated++
+	}
+	return created, updated, nil
+}
+
+// importUsers cria usuários sem senha utilizável (defina com reset-password);
+// usuários existentes têm nome, papel e setor atualizados
+func importUsers(tx *gorm.DB, records []userRecord) (int, int, error) {
+	var created, updated int
+	for _, r := range records {
+		if r.Email == "" || r.Name == "" {
+			return created, updated, fmt.Errorf("usuário %q sem nome ou e-mail", r.Email)
+		}
+		var roleCount int64
+		if err := tx.Model(&models.Role{}).Where("name = ?", r.Role).Count(&roleCount).Error; err != nil {
+			return created, updated, err
+		}
+		if roleCount == 0 {
+			return created, updated, fmt.Errorf("usuário %s: papel %q não existe", r.Email, r.Role)
+		}
+		sectorID, err := sectorIDByName(tx, r.Sector)
+		if err != nil {
+			return created, updated, err
+		}
+
+		user := models.User{}
+		result := tx.Where(models.User{Email: r.Email}).
+			Attrs(models.User{Name: r.Name, Role: r.Role, SectorID: sectorID, PasswordHash: "!"}).
+			FirstOrCreate(&user)
+		if result.Error != nil {
+			return created, updated, fmt.Errorf("usuário %s: %w", r.Email, result.Error)
+		}
+		if result.RowsAffected > 0 {
+			created++
+			continue
+		}
+		changes := map[string]interface{}{"name": r.Name, "role": r.Role, "sector_id": sectorID}
+		if user.Role != r.Role || user.SectorID != sectorID {
+			// papel e setor vão no token: força novo login
+			changes["tokens_valid_after"] = time.Now()
+		}
+		if err := tx.Model(&user).Updates(changes).Error; err != nil {
+			return created, updated, fmt.Errorf("usuário %s: %w", r.Email, err)
+		}
+		updated++
+	}
+	return created, updated, nil
+}
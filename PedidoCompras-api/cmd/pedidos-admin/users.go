@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/models"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// passwordEnv - Variável de ambiente alternativa à opção -password (evita a senha no histórico do shell)
+const passwordEnv = "PEDIDOS_ADMIN_PASSWORD"
+
+// newFlagSet cria o conjunto de opções de um comando
+func newFlagSet(name, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Uso: pedidos-admin %s %s\n\nOpções:\n", name, usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// readPassword usa a senha da opção, da variável PEDIDOS_ADMIN_PASSWORD ou da entrada padrão
+func readPassword(flagValue string) (string, error) {
+	if flagValue != "" {
+		return flagValue, nil
+	}
+	if value := os.Getenv(passwordEnv); value != "" {
+		return value, nil
+	}
+	fmt.Fprint(os.Stderr, "Senha: ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", errors.New("senha não informada (use -password, " + passwordEnv + " ou a entrada padrão)")
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// hashPassword valida a senha contra a política configurada e gera o hash
+func hashPassword(db *gorm.DB, password string) (string, error) {
+	var settings models.SystemSettings
+	if err := db.First(&settings).Error; err != nil && err != gorm.ErrRecordNotFound {
+		return "", fmt.Errorf("erro ao carregar política de senhas: %w", err)
+	} else if err == gorm.ErrRecordNotFound {
+		settings = models.SystemSettings{MinPasswordLength: 6, RequireLowercase: true}
+	}
+
+	if violations := settings.ValidatePassword(password); len(violations) > 0 {
+		messages := make([]string, 0, len(violations))
+		for _, v := range violations {
+			messages = append(messages, v.Message)
+		}
+		return "", fmt.Errorf("a senha não atende à política de senhas: %s", strings.Join(messages, "; "))
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("erro ao processar senha: %w", err)
+	}
+	return string(hash), nil
+}
+
+// runCreateAdmin cria um administrador; sem -sector usa (ou cria) o setor "Administração"
+func runCreateAdmin(args []string, connect func() *gorm.DB) error {
+	fs := newFlagSet("create-admin", "-name NOME -email EMAIL [-password SENHA] [-sector SETOR]")
+	name := fs.String("name", "", "nome do administrador")
+	email := fs.String("email", "", "e-mail (login)")
+	password := fs.String("password", "", "senha (ou "+passwordEnv+", ou entrada padrão)")
+	sectorName := fs.String("sector", "Administração", "nome do setor do administrador (criado se não existir)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *name == "" || *email == "" {
+		fs.Usage()
+		return errors.New("-name e -email são obrigatórios")
+	}
+
+	db := connect()
+
+	var count int64
+	if err := db.Model(&models.User{}).Where("email = ?", *email).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("o e-mail %s já está em uso (use reset-password para trocar a senha)", *email)
+	}
+
+	plain, err := readPassword(*password)
+	if err != nil {
+		return err
+	}
+	hash, err := hashPassword(db, plain)
+	if err != nil {
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		var sector models.Sector
+		if err := tx.Where(models.Sector{Name: *sectorName}).FirstOrCreate(&sector).Error; err != nil {
+			return fmt.Errorf("erro ao buscar setor: %w", err)
+		}
+
+		now := time.Now()
+		user := models.User{
+			Name:              *name,
+			Email:             *email,
+			PasswordHash:      hash,
+			Role:              models.RoleAdmin,
+			SectorID:          sector.ID,
+			PasswordChangedAt: &now,
+		}
+		if err := tx.Create(&user).Error; err != nil {
+			return fmt.Errorf("erro ao criar usuário: %w", err)
+		}
+
+		fmt.Printf("✅ Administrador criado: %s <%s> (ID %d, setor %s)\n", user.Name, user.Email, user.ID, sector.Name)
+		return nil
+	})
+}
+
+// runResetPassword troca a senha, encerra as sessões e invalida os tokens já emitidos
+func runResetPassword(args []string, connect func() *gorm.DB) error {
+	fs := newFlagSet("reset-password", "-email EMAIL [-password SENHA]")
+	email := fs.String("email", "", "e-mail do usuário")
+	password := fs.String("password", "", "nova senha (ou "+passwordEnv+", ou entrada padrão)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *email == "" {
+		fs.Usage()
+		return errors.New("-email é obrigatório")
+	}
+
+	db := connect()
+
+	var user models.User
+	if err := db.Where("email = ?", *email).First(&user).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return fmt.Errorf("usuário %s não encontrado", *email)
+		}
+		return err
+	}
+
+	plain, err := readPassword(*password)
+	if err != nil {
+		return err
+	}
+	hash, err := hashPassword(db, plain)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var revoked int64
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"password_hash":       hash,
+			"password_changed_at": now,
+			"tokens_valid_after":  now,
+		}).Error; err != nil {
+			return err
+		}
+
+		result := tx.Model(&models.UserSession{}).
+			Where("user_id = ? AND revoked_at IS NULL", user.ID).
+			Updates(map[string]interface{}{"revoked_at": now, "revoked_reason": "password-reset"})
+		if result.Error != nil {
+			return result.Error
+		}
+		revoked = result.RowsAffected
+
+		return tx.Model(&models.RefreshToken{}).
+			Where("user_id = ? AND revoked_at IS NULL", user.ID).
+			Update("revoked_at", now).Error
+	})
+	if err != nil {
+		return fmt.Errorf("erro ao atualizar senha: %w", err)
+	}
+
+	fmt.Printf("✅ Senha de %s alterada; %d sessão(ões) encerrada(s)\n", user.Email, revoked)
+	return nil
+}
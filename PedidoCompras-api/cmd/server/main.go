@@ -1,7 +1,11 @@
 package main
 
 import (
+	"os"
+	"time"
+
 	"github.com/gin-gonic/gin"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/audit"
 	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/config"
 	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/database"
 	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/routes"
@@ -10,9 +14,18 @@ import (
 func main() {
 	//carregar .env
 	appConfig := config.LoadConfig()
+
+	// subcomando de migrações: server migrate up|down|status
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(runMigrate(appConfig, os.Args[2:]))
+	}
+
 	// conectar ao banco
 	databaseConnection := database.Connect(appConfig)
 
+	// limpeza diária do log de auditoria (SystemSettings.LogRetentionDays)
+	audit.StartRetentionJob(databaseConnection, 24*time.Hour)
+
 	//criar router
 	router := gin.Default()
 
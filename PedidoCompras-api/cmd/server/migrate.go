@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/config"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/database"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/migrations"
+)
+
+const migrateUsage = `Uso: server migrate <comando>
+
+  up [n]     aplica as migrações pendentes (ou apenas as próximas n)
+  down [n]   reverte as últimas n migrações aplicadas (padrão 1)
+  status     lista as migrações e a versão atual do banco`
+
+// runMigrate executa o subcomando "migrate" e retorna o código de saída
+func runMigrate(appConfig *config.Config, args []string) int {
+	if len(args) == 0 || len(args) > 2 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	steps := 0
+	if len(args) == 2 {
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 1 || args[0] == "status" {
+			fmt.Fprintln(os.Stderr, migrateUsage)
+			return 2
+		}
+		steps = n
+	}
+
+	databaseConnection := database.Open(appConfig)
+
+	switch args[0] {
+	case "up":
+		ran, err := migrations.Up(databaseConnection, steps)
+		for _, m := range ran {
+			fmt.Printf("✅ %04d_%s aplicada\n", m.Version, m.Name)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			return 1
+		}
+		if len(ran) == 0 {
+			fmt.Println("Nenhuma migração pendente")
+		}
+	case "down":
+		ran, err := migrations.Down(databaseConnection, steps)
+		for _, m := range ran {
+			fmt.Printf("↩️  %04d_%s revertida\n", m.Version, m.Name)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			return 1
+		}
+		if len(ran) == 0 {
+			fmt.Println("Nenhuma migração aplicada")
+		}
+	case "status":
+		statuses, err := migrations.StatusList(databaseConnection)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			return 1
+		}
+		current, _ := migrations.Current(databaseConnection)
+		latest, _ := migrations.Latest()
+		fmt.Printf("Versão do banco: %d (mais recente disponível: %d)\n\n", current, latest)
+		for _, s := range statuses {
+			state := "pendente"
+			switch {
+			case s.Unknown:
+				state = "DESCONHECIDA (aplicada por uma versão mais nova)"
+			case s.AppliedAt != nil:
+				state = "aplicada em " + s.AppliedAt.Format("02/01/2006 15:04:05")
+			}
+			fmt.Printf("  %04d_%-30s %s\n", s.Version, s.Name, state)
+		}
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+	return 0
+}
@@ -0,0 +1,221 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/models"
+	"gorm.io/gorm"
+)
+
+// Actor - Quem executou a alteração (vem dos claims do JWT)
+type Actor struct {
+	ID    *uint
+	Name  string
+	Email string
+	Role  string
+}
+
+// Entry - Dados de um registro de auditoria a ser gravado
+type Entry struct {
+	Actor      Actor
+	Entity     string
+	EntityID   *uint
+	Action     string
+	Before     map[string]interface{}
+	After      map[string]interface{}
+	Method     string
+	Path       string
+	StatusCode int
+	IPAddress  string
+	UserAgent  string
+}
+
+// Campos que nunca vão para o log
+var sensitiveFields = []string{"password", "hash", "token", "secret"}
+
+// Campos que mudam em toda gravação e só poluem o diff
+var ignoredFields = map[string]bool{"UpdatedAt": true}
+
+const settingsCacheTTL = time.Minute
+
+var (
+	settingsMu       sync.Mutex
+	cachedSettings   *models.SystemSettings
+	settingsLoadedAt time.Time
+)
+
+// currentSettings devolve as configurações do sistema com cache curto,
+// para não consultar o banco a cada requisição auditada
+func currentSettings(db *gorm.DB) models.SystemSettings {
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+
+	if cachedSettings != nil && time.Since(settingsLoadedAt) < settingsCacheTTL {
+		return *cachedSettings
+	}
+
+	// Padrões equivalentes aos da tabela
+	settings := models.SystemSettings{AuditLogEnabled: true, LogRetentionDays: 90}
+	var stored models.SystemSettings
+	if err := db.First(&stored).Error; err == nil {
+		settings = stored
+	}
+
+	cachedSettings = &settings
+	settingsLoadedAt = time.Now()
+	return settings
+}
+
+// InvalidateSettings descarta o cache (chamado quando as configurações mudam)
+func InvalidateSettings() {
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+	cachedSettings = nil
+}
+
+// Enabled indica se a auditoria está ligada em SystemSettings.AuditLogEnabled
+func Enabled(db *gorm.DB) bool {
+	return currentSettings(db).AuditLogEnabled
+}
+
+// Record grava o registro de auditoria (não faz nada se a auditoria estiver desligada)
+func Record(db *gorm.DB, entry Entry) error {
+	if !Enabled(db) {
+		return nil
+	}
+	return write(db, entry)
+}
+
+// write grava o registro sem consultar a configuração
+func write(db *gorm.DB, entry Entry) error {
+	changes, err := json.Marshal(Diff(entry.Before, entry.After))
+	if err != nil {
+		return err
+	}
+
+	log := models.AuditLog{
+		ActorID:    entry.Actor.ID,
+		ActorName:  entry.Actor.Name,
+		ActorEmail: entry.Actor.Email,
+		ActorRole:  entry.Actor.Role,
+		Entity:     entry.Entity,
+		EntityID:   entry.EntityID,
+		Action:     entry.Action,
+		Changes:    string(changes),
+		Method:     entry.Method,
+		Path:       truncate(entry.Path, 255),
+		StatusCode: entry.StatusCode,
+		IPAddress:  truncate(entry.IPAddress, 45),
+		UserAgent:  truncate(entry.UserAgent, 500),
+	}
+
+	return db.Create(&log).Error
+}
+
+// Snapshot carrega a entidade e devolve seus campos simples (sem associações)
+func Snapshot(db *gorm.DB, model interface{}, conds ...interface{}) (map[string]interface{}, error) {
+	if err := db.First(model, conds...).Error; err != nil {
+		return nil, err
+	}
+	return ToMap(model)
+}
+
+// ToMap converte a entidade em mapa de campos simples
+func ToMap(value interface{}) (map[string]interface{}, error) {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, fmt.Errorf("entidade não é um objeto JSON: %w", err)
+	}
+
+	for key, v := range fields {
+		switch v.(type) {
+		case map[string]interface{}, []interface{}:
+			// Associações carregadas não fazem parte da entidade
+			delete(fields, key)
+		}
+	}
+
+	return fields, nil
+}
+
+// FieldChange - Valor de um campo antes e depois da alteração
+type FieldChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// Diff devolve apenas os campos que mudaram entre os dois estados.
+// Em criações (before nil) ou exclusões (after nil) lista todos os campos.
+// Campos sensíveis aparecem apenas como alterados, sem os valores.
+func Diff(before, after map[string]interface{}) map[string]FieldChange {
+	changes := map[string]FieldChange{}
+
+	for key, b := range before {
+		if ignoredFields[key] {
+			continue
+		}
+		a, exists := after[key]
+		if after != nil && exists && equalJSON(a, b) {
+			continue
+		}
+		changes[key] = redact(key, FieldChange{Before: b, After: a})
+	}
+
+	for key, a := range after {
+		if ignoredFields[key] {
+			continue
+		}
+		if _, exists := before[key]; exists {
+			continue
+		}
+		changes[key] = redact(key, FieldChange{Before: nil, After: a})
+	}
+
+	return changes
+}
+
+func equalJSON(a, b interface{}) bool {
+	ja, _ := json.Marshal(a)
+	jb, _ := json.Marshal(b)
+	return string(ja) == string(jb)
+}
+
+// redact oculta os valores de campos sensíveis
+func redact(field string, change FieldChange) FieldChange {
+	if !isSensitive(field) {
+		return change
+	}
+	if change.Before != nil {
+		change.Before = "[oculto]"
+	}
+	if change.After != nil {
+		change.After = "[oculto]"
+	}
+	return change
+}
+
+func isSensitive(field string) bool {
+	lower := strings.ToLower(field)
+	for _, s := range sensitiveFields {
+		if strings.Contains(lower, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func truncate(value string, max int) string {
+	if len(value) > max {
+		return value[:max]
+	}
+	return value
+}
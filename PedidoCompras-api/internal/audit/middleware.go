@@ -0,0 +1,312 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/models"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/utils"
+	"gorm.io/gorm"
+)
+
+// route - Como auditar uma rota de escrita
+type route struct {
+	Entity string
+	Action string
+	// Parâmetro da URL com o ID da entidade; vazio = criação (ID vem da resposta)
+	IDParam string
+	// Entidade é o próprio usuário logado (ex: /profile)
+	IDFromActor bool
+	// Entidade única (configurações), carregada com First sem ID
+	Singleton bool
+	// Chave da resposta com a lista de entidades criadas (uma entrada por entidade)
+	CreatedList string
+	NewModel    func() interface{}
+}
+
+func purchaseRequest() interface{} { return &models.PurchaseRequest{} }
+func requestItem() interface{}     { return &models.RequestItem{} }
+func itemBudget() interface{}      { return &models.ItemBudget{} }
+func itemReceipt() interface{}     { return &models.ItemReceipt{} }
+func user() interface{}            { return &models.User{} }
+func supplier() interface{}        { return &models.Supplier{} }
+func product() interface{}         { return &models.Product{} }
+func sector() interface{}          { return &models.Sector{} }
+func sectorBudget() interface{}    { return &models.SectorBudget{} }
+func attachment() interface{}      { return &models.Attachment{} }
+func purchaseOrder() interface{}   { return &models.PurchaseOrder{} }
+func approvalPolicy() interface{}  { return &models.ApprovalPolicy{} }
+func companySettings() interface{} { return &models.CompanySettings{} }
+func systemSettings() interface{}  { return &models.SystemSettings{} }
+func role() interface{}            { return &models.Role{} }
+
+// routes - Rotas auditadas, indexadas por "MÉTODO caminho-registrado-no-gin"
+var routes = map[string]route{
+	// Requisições
+	"POST /api/v1/requests":                      {Entity: "purchase_request", Action: "create", NewModel: purchaseRequest},
+	"PATCH /api/v1/requests/:id":                 {Entity: "purchase_request", Action: "update", IDParam: "id", NewModel: purchaseRequest},
+	"PATCH /api/v1/requests/:id/review":          {Entity: "purchase_request", Action: "review", IDParam: "id", NewModel: purchaseRequest},
+	"PATCH /api/v1/requests/:id/priority":        {Entity: "purchase_request", Action: "set-priority", IDParam: "id", NewModel: purchaseRequest},
+	"DELETE /api/v1/requests/:id/priority":       {Entity: "purchase_request", Action: "remove-priority", IDParam: "id", NewModel: purchaseRequest},
+	"POST /api/v1/requests/:id/toggle-urgent":    {Entity: "purchase_request", Action: "toggle-urgent", IDParam: "id", NewModel: purchaseRequest},
+	"POST /api/v1/requests/:id/approvals/decide": {Entity: "purchase_request", Action: "approval-decision", IDParam: "id", NewModel: purchaseRequest},
+	"POST /api/v1/requests/:id/complete":         {Entity: "purchase_request", Action: "complete", IDParam: "id", NewModel: purchaseRequest},
+	"POST /api/v1/requests/:id/reopen":           {Entity: "purchase_request", Action: "reopen", IDParam: "id", NewModel: purchaseRequest},
+	"POST /api/v1/requests/:id/sector-approval":  {Entity: "purchase_request", Action: "sector-approval", IDParam: "id", NewModel: purchaseRequest},
+
+	// Itens
+	"POST /api/v1/requests/:id/items":                 {Entity: "request_item", Action: "create", NewModel: requestItem},
+	"PATCH /api/v1/requests/:id/items/:itemId":        {Entity: "request_item", Action: "update", IDParam: "itemId", NewModel: requestItem},
+	"DELETE /api/v1/requests/:id/items/:itemId":       {Entity: "request_item", Action: "delete", IDParam: "itemId", NewModel: requestItem},
+	"PATCH /api/v1/requests/:id/items/:itemId/review": {Entity: "request_item", Action: "review", IDParam: "itemId", NewModel: requestItem},
+
+	// Anexos
+	"POST /api/v1/requests/:id/attachments":                 {Entity: "attachment", Action: "create", NewModel: attachment},
+	"PUT /api/v1/requests/:id/attachments/:attachmentId":    {Entity: "attachment", Action: "replace", IDParam: "attachmentId", NewModel: attachment},
+	"DELETE /api/v1/requests/:id/attachments/:attachmentId": {Entity: "attachment", Action: "delete", IDParam: "attachmentId", NewModel: attachment},
+	"POST /api/v1/attachments":                              {Entity: "attachment", Action: "create", NewModel: attachment},
+	"PUT /api/v1/attachments/:id":                           {Entity: "attachment", Action: "replace", IDParam: "id", NewModel: attachment},
+	"DELETE /api/v1/attachments/:id":                        {Entity: "attachment", Action: "delete", IDParam: "id", NewModel: attachment},
+	"POST /api/v1/receipts/:receiptId/invoice":              {Entity: "attachment", Action: "upload-invoice", NewModel: attachment},
+
+	// Orçamentos
+	"POST /api/v1/requests/:id/items/:itemId/budgets": {Entity: "item_budget", Action: "create", NewModel: itemBudget},
+	"PATCH /api/v1/budgets/:budgetID":                 {Entity: "item_budget", Action: "update", IDParam: "budgetID", NewModel: itemBudget},
+	"DELETE /api/v1/budgets/:budgetID":                {Entity: "item_budget", Action: "delete", IDParam: "budgetID", NewModel: itemBudget},
+	"PATCH /api/v1/budgets/:budgetID/select":          {Entity: "item_budget", Action: "select", IDParam: "budgetID", NewModel: itemBudget},
+
+	// Recebimentos
+	"POST /api/v1/requests/:id/items/:itemId/receipts": {Entity: "item_receipt", Action: "create", NewModel: itemReceipt},
+
+	// Pedidos de compra
+	"POST /api/v1/purchase-orders":             {Entity: "purchase_order", Action: "create", CreatedList: "orders", NewModel: purchaseOrder},
+	"PATCH /api/v1/purchase-orders/:id/status": {Entity: "purchase_order", Action: "update-status", IDParam: "id", NewModel: purchaseOrder},
+
+	// Políticas de aprovação
+	"POST /api/v1/approval-policies":       {Entity: "approval_policy", Action: "create", NewModel: approvalPolicy},
+	"PUT /api/v1/approval-policies/:id":    {Entity: "approval_policy", Action: "update", IDParam: "id", NewModel: approvalPolicy},
+	"DELETE /api/v1/approval-policies/:id": {Entity: "approval_policy", Action: "delete", IDParam: "id", NewModel: approvalPolicy},
+
+	// Usuários
+	"POST /api/v1/users":                {Entity: "user", Action: "create", NewModel: user},
+	"PUT /api/v1/users/:id":             {Entity: "user", Action: "update", IDParam: "id", NewModel: user},
+	"DELETE /api/v1/users/:id":          {Entity: "user", Action: "delete", IDParam: "id", NewModel: user},
+	"PATCH /api/v1/users/:id/promote":   {Entity: "user", Action: "promote", IDParam: "id", NewModel: user},
+	"POST /api/v1/users/:id/logout-all": {Entity: "user", Action: "logout-all", IDParam: "id", NewModel: user},
+	"POST /api/v1/users/:id/unlock":     {Entity: "user", Action: "unlock-login", IDParam: "id", NewModel: user},
+	"POST /api/v1/requesters":           {Entity: "user", Action: "create", NewModel: user},
+	"PUT /api/v1/profile":               {Entity: "user", Action: "update-profile", IDFromActor: true, NewModel: user},
+	"PATCH /api/v1/profile/password":    {Entity: "user", Action: "change-password", IDFromActor: true, NewModel: user},
+
+	// Papéis e permissões
+	"POST /api/v1/roles":       {Entity: "role", Action: "create", NewModel: role},
+	"PUT /api/v1/roles/:id":    {Entity: "role", Action: "update", IDParam: "id", NewModel: role},
+	"DELETE /api/v1/roles/:id": {Entity: "role", Action: "delete", IDParam: "id", NewModel: role},
+
+	// Setores
+	"POST /api/v1/sectors":            {Entity: "sector", Action: "create", NewModel: sector},
+	"PATCH /api/v1/sectors/:id":       {Entity: "sector", Action: "update", IDParam: "id", NewModel: sector},
+	"DELETE /api/v1/sectors/:id":      {Entity: "sector", Action: "delete", IDParam: "id", NewModel: sector},
+	"PUT /api/v1/sectors/:id/manager": {Entity: "sector", Action: "assign-manager", IDParam: "id", NewModel: sector},
+	"PATCH /api/v1/sectors/:id/move":  {Entity: "sector", Action: "move", IDParam: "id", NewModel: sector},
+
+	// Verbas dos setores
+	"POST /api/v1/sector-budgets":       {Entity: "sector_budget", Action: "create", NewModel: sectorBudget},
+	"PUT /api/v1/sector-budgets/:id":    {Entity: "sector_budget", Action: "update", IDParam: "id", NewModel: sectorBudget},
+	"DELETE /api/v1/sector-budgets/:id": {Entity: "sector_budget", Action: "delete", IDParam: "id", NewModel: sectorBudget},
+
+	// Fornecedores
+	"POST /api/v1/suppliers":       {Entity: "supplier", Action: "create", NewModel: supplier},
+	"PATCH /api/v1/suppliers/:id":  {Entity: "supplier", Action: "update", IDParam: "id", NewModel: supplier},
+	"DELETE /api/v1/suppliers/:id": {Entity: "supplier", Action: "delete", IDParam: "id", NewModel: supplier},
+
+	// Produtos
+	"POST /api/v1/products":       {Entity: "product", Action: "create", NewModel: product},
+	"PATCH /api/v1/products/:id":  {Entity: "product", Action: "update", IDParam: "id", NewModel: product},
+	"DELETE /api/v1/products/:id": {Entity: "product", Action: "delete", IDParam: "id", NewModel: product},
+
+	// Configurações
+	"PUT /api/v1/settings/company":       {Entity: "company_settings", Action: "update", Singleton: true, NewModel: companySettings},
+	"POST /api/v1/settings/company/logo": {Entity: "company_settings", Action: "upload-logo", Singleton: true, NewModel: companySettings},
+	"PUT /api/v1/settings/system":        {Entity: "system_settings", Action: "update", Singleton: true, NewModel: systemSettings},
+}
+
+// Limite da resposta guardada para descobrir o ID de entidades criadas
+const maxCapturedBody = 1 << 20
+
+// bodyCaptureWriter guarda uma cópia da resposta enquanto ela é enviada
+type bodyCaptureWriter struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w *bodyCaptureWriter) Write(data []byte) (int, error) {
+	if w.body.Len()+len(data) <= maxCapturedBody {
+		w.body.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+// Middleware registra no log de auditoria as chamadas de escrita bem-sucedidas
+// das rotas mapeadas em routes. Deve ser registrado nos grupos protegidos, depois
+// do AuthMiddleware, para não consultar o banco em chamadas não autenticadas.
+func Middleware(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		spec, ok := routes[c.Request.Method+" "+c.FullPath()]
+		if !ok || !Enabled(db) {
+			c.Next()
+			return
+		}
+
+		// Estado anterior (quando a entidade já existe)
+		var before map[string]interface{}
+		if id, ok := entityKey(c, spec); ok {
+			before, _ = snapshotEntity(db, spec, id)
+		}
+
+		var capture *bodyCaptureWriter
+		if spec.IDParam == "" && !spec.IDFromActor && !spec.Singleton {
+			capture = &bodyCaptureWriter{ResponseWriter: c.Writer, body: &bytes.Buffer{}}
+			c.Writer = capture
+		}
+
+		c.Next()
+
+		status := c.Writer.Status()
+		if status < http.StatusOK || status >= http.StatusBadRequest {
+			return
+		}
+
+		// Criação em lote: uma entrada por entidade criada
+		if capture != nil && spec.CreatedList != "" {
+			for _, id := range createdIDs(capture.body.Bytes(), spec.CreatedList) {
+				record(c, db, spec, status, nil, id, true)
+			}
+			return
+		}
+
+		// Dados do ator só existem depois do AuthMiddleware do grupo
+		id, ok := entityKey(c, spec)
+		if !ok && capture != nil {
+			id = createdID(capture.body.Bytes())
+			ok = id != 0
+		}
+		record(c, db, spec, status, before, id, ok)
+	}
+}
+
+// record grava a entrada de auditoria com o estado atual da entidade (quando conhecida)
+func record(c *gin.Context, db *gorm.DB, spec route, status int, before map[string]interface{}, id uint, ok bool) {
+	var after map[string]interface{}
+	var entityID *uint
+	if ok {
+		after, _ = snapshotEntity(db, spec, id)
+		if spec.Singleton {
+			id = idFromSnapshot(after, before)
+		}
+		if id != 0 {
+			entityID = &id
+		}
+	}
+
+	entry := Entry{
+		Actor:      actorFromContext(c),
+		Entity:     spec.Entity,
+		EntityID:   entityID,
+		Action:     spec.Action,
+		Before:     before,
+		After:      after,
+		Method:     c.Request.Method,
+		Path:       c.Request.URL.Path,
+		StatusCode: status,
+		IPAddress:  c.ClientIP(),
+		UserAgent:  c.Request.UserAgent(),
+	}
+
+	// A auditoria estava ligada quando a chamada começou: grava mesmo que
+	// a própria chamada a tenha desligado
+	if err := write(db, entry); err != nil {
+		fmt.Printf("❌ Erro ao gravar auditoria (%s %s): %v\n", entry.Method, entry.Path, err)
+	}
+}
+
+// entityKey devolve o ID da entidade alvo da rota (ok = false quando ainda não existe)
+func entityKey(c *gin.Context, spec route) (uint, bool) {
+	switch {
+	case spec.Singleton:
+		return 0, true
+	case spec.IDFromActor:
+		id := utils.ParseUint(c.GetString("userID"))
+		return id, id != 0
+	case spec.IDParam != "":
+		id := utils.ParseUint(c.Param(spec.IDParam))
+		return id, id != 0
+	}
+	return 0, false
+}
+
+// snapshotEntity carrega o estado atual da entidade (entidades únicas não têm ID na rota)
+func snapshotEntity(db *gorm.DB, spec route, id uint) (map[string]interface{}, error) {
+	if spec.Singleton {
+		return Snapshot(db, spec.NewModel())
+	}
+	return Snapshot(db, spec.NewModel(), id)
+}
+
+// createdID extrai o ID da entidade criada a partir da resposta JSON
+func createdID(body []byte) uint {
+	var response map[string]interface{}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return 0
+	}
+	for _, key := range []string{"ID", "id"} {
+		if value, ok := response[key].(float64); ok && value > 0 {
+			return uint(value)
+		}
+	}
+	return 0
+}
+
+// createdIDs extrai os IDs das entidades criadas listadas em key na resposta JSON
+func createdIDs(body []byte, key string) []uint {
+	var response map[string][]map[string]interface{}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil
+	}
+	var ids []uint
+	for _, entity := range response[key] {
+		for _, field := range []string{"ID", "id"} {
+			if value, ok := entity[field].(float64); ok && value > 0 {
+				ids = append(ids, uint(value))
+				break
+			}
+		}
+	}
+	return ids
+}
+
+// idFromSnapshot recupera o ID de entidades únicas (configurações)
+func idFromSnapshot(snapshots ...map[string]interface{}) uint {
+	for _, snapshot := range snapshots {
+		if value, ok := snapshot["ID"].(float64); ok && value > 0 {
+			return uint(value)
+		}
+	}
+	return 0
+}
+
+// actorFromContext monta o ator a partir dos dados injetados pelo AuthMiddleware
+func actorFromContext(c *gin.Context) Actor {
+	actor := Actor{
+		Name:  c.GetString("userName"),
+		Email: c.GetString("userEmail"),
+		Role:  c.GetString("role"),
+	}
+	if id := utils.ParseUint(c.GetString("userID")); id != 0 {
+		actor.ID = &id
+	}
+	return actor
+}
@@ -0,0 +1,39 @@
+package audit
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/models"
+	"gorm.io/gorm"
+)
+
+// PurgeExpired remove os registros mais antigos que SystemSettings.LogRetentionDays.
+// Retenção 0 (ou negativa) mantém os registros indefinidamente.
+func PurgeExpired(db *gorm.DB) (int64, error) {
+	retentionDays := currentSettings(db).LogRetentionDays
+	if retentionDays <= 0 {
+		return 0, nil
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -retentionDays)
+	result := db.Where("created_at < ?", cutoff).Delete(&models.AuditLog{})
+	return result.RowsAffected, result.Error
+}
+
+// StartRetentionJob executa a limpeza na inicialização e depois a cada intervalo
+func StartRetentionJob(db *gorm.DB, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if removed, err := PurgeExpired(db); err != nil {
+				fmt.Printf("❌ Erro na limpeza do log de auditoria: %v\n", err)
+			} else if removed > 0 {
+				fmt.Printf("🧹 Log de auditoria: %d registros expirados removidos\n", removed)
+			}
+			<-ticker.C
+		}
+	}()
+}
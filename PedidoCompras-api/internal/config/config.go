@@ -3,8 +3,14 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/preview"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/scanner"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/storage"
 )
 
 type Config struct {
@@ -16,6 +22,48 @@ type Config struct {
 	DBName       string
 	DBSSLMode    string
 	JWTSecretKey string
+
+	// Migrações na inicialização (DB_MIGRATIONS): auto (padrão) aplica as pendentes;
+	// check recusa iniciar se o banco não estiver exatamente na versão do binário
+	DBMigrations string
+
+	// Validade dos refresh tokens (REFRESH_TOKEN_DAYS, padrão 7)
+	RefreshTokenDays int
+
+	// URL do frontend, usada nos links enviados por e-mail (APP_BASE_URL)
+	AppBaseURL string
+	// Validade do link de redefinição de senha (PASSWORD_RESET_MINUTES, padrão 30)
+	PasswordResetMinutes int
+
+	// Servidor de e-mail; sem SMTP_HOST os e-mails vão apenas para o log
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUser     string
+	SMTPPassword string
+	SMTPFrom     string
+	SMTPFromName string
+	SMTPTLSMode  string // starttls (padrão), tls ou none
+
+	// Armazenamento dos arquivos enviados: local (padrão, STORAGE_LOCAL_DIR) ou s3
+	StorageBackend  string
+	StorageLocalDir string
+	S3Endpoint      string // ex: http://minio:9000; vazio usa a AWS
+	S3Region        string
+	S3Bucket        string
+	S3AccessKey     string
+	S3SecretKey     string
+	S3PathStyle     bool // S3_PATH_STYLE=true para MinIO
+	S3Prefix        string
+
+	// Verificação de malware dos arquivos enviados: none (padrão) ou clamd
+	ScannerBackend     string
+	ClamdAddress       string // tcp://host:porta ou unix:///caminho (padrão tcp://127.0.0.1:3310)
+	ScanTimeoutSeconds int    // SCAN_TIMEOUT_SECONDS, padrão 120
+
+	// Miniaturas dos anexos: tamanho (PREVIEW_MAX_SIZE, padrão 320px) e renderizador
+	// da primeira página dos PDFs (PDF_PREVIEW_RENDERER: caminho do pdftoppm ou none)
+	PreviewMaxSize     int
+	PDFPreviewRenderer string
 }
 
 func LoadConfig() *Config {
@@ -32,5 +80,86 @@ func LoadConfig() *Config {
 		DBName:       os.Getenv("DB_NAME"),
 		DBSSLMode:    os.Getenv("DB_SSL"),
 		JWTSecretKey: os.Getenv("JWT_ACCESS_SECRET_KEY"),
+		DBMigrations: os.Getenv("DB_MIGRATIONS"),
+
+		RefreshTokenDays: getEnvInt("REFRESH_TOKEN_DAYS", 7),
+
+		AppBaseURL:           strings.TrimRight(os.Getenv("APP_BASE_URL"), "/"),
+		PasswordResetMinutes: getEnvInt("PASSWORD_RESET_MINUTES", 30),
+
+		SMTPHost:     os.Getenv("SMTP_HOST"),
+		SMTPPort:     getEnvInt("SMTP_PORT", 587),
+		SMTPUser:     os.Getenv("SMTP_USER"),
+		SMTPPassword: os.Getenv("SMTP_PASSWORD"),
+		SMTPFrom:     os.Getenv("SMTP_FROM"),
+		SMTPFromName: os.Getenv("SMTP_FROM_NAME"),
+		SMTPTLSMode:  os.Getenv("SMTP_TLS"),
+
+		StorageBackend:  os.Getenv("STORAGE_BACKEND"),
+		StorageLocalDir: os.Getenv("STORAGE_LOCAL_DIR"),
+		S3Endpoint:      os.Getenv("S3_ENDPOINT"),
+		S3Region:        os.Getenv("S3_REGION"),
+		S3Bucket:        os.Getenv("S3_BUCKET"),
+		S3AccessKey:     os.Getenv("S3_ACCESS_KEY"),
+		S3SecretKey:     os.Getenv("S3_SECRET_KEY"),
+		S3PathStyle:     getEnvBool("S3_PATH_STYLE", false),
+		S3Prefix:        os.Getenv("S3_PREFIX"),
+
+		ScannerBackend:     os.Getenv("SCANNER_BACKEND"),
+		ClamdAddress:       os.Getenv("CLAMD_ADDRESS"),
+		ScanTimeoutSeconds: getEnvInt("SCAN_TIMEOUT_SECONDS", 120),
+
+		PreviewMaxSize:     getEnvInt("PREVIEW_MAX_SIZE", preview.DefaultMaxSize),
+		PDFPreviewRenderer: os.Getenv("PDF_PREVIEW_RENDERER"),
+	}
+}
+
+// StorageConfig monta a configuração do armazenamento de arquivos
+func (c *Config) StorageConfig() storage.Config {
+	return storage.Config{
+		Backend:     c.StorageBackend,
+		LocalDir:    c.StorageLocalDir,
+		S3Endpoint:  c.S3Endpoint,
+		S3Region:    c.S3Region,
+		S3Bucket:    c.S3Bucket,
+		S3AccessKey: c.S3AccessKey,
+		S3SecretKey: c.S3SecretKey,
+		S3PathStyle: c.S3PathStyle,
+		S3Prefix:    c.S3Prefix,
+	}
+}
+
+// ScannerConfig monta a configuração da verificação de malware
+func (c *Config) ScannerConfig() scanner.Config {
+	return scanner.Config{
+		Backend:      c.ScannerBackend,
+		ClamdAddress: c.ClamdAddress,
+		Timeout:      time.Duration(c.ScanTimeoutSeconds) * time.Second,
+	}
+}
+
+// PreviewConfig monta a configuração das pré-visualizações dos anexos
+func (c *Config) PreviewConfig() preview.Config {
+	return preview.Config{
+		MaxSize:     c.PreviewMaxSize,
+		PDFRenderer: c.PDFPreviewRenderer,
+	}
+}
+
+// getEnvInt lê um inteiro do ambiente, usando o padrão quando ausente ou inválido
+func getEnvInt(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
+
+// getEnvBool lê um booleano do ambiente (true/false, 1/0), usando o padrão quando ausente ou inválido
+func getEnvBool(key string, fallback bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return fallback
 	}
+	return value
 }
@@ -0,0 +1,644 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/models"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/notifications"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/rbac"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/utils"
+	"gorm.io/gorm"
+)
+
+type approvalPolicyStepInput struct {
+	Name           string `json:"name" binding:"required"`
+	ApproverType   string `json:"approverType" binding:"required,oneof=user role"`
+	ApproverUserID *uint  `json:"approverUserId"`
+	ApproverRole   string `json:"approverRole"`
+}
+
+type approvalPolicyInput struct {
+	Name              string                    `json:"name" binding:"required"`
+	Active            *bool                     `json:"active"`
+	SectorID          *uint                     `json:"sectorId"`
+	MinEstimatedValue float64                   `json:"minEstimatedValue" binding:"min=0"`
+	UrgentOnly        bool                      `json:"urgentOnly"`
+	Priority          int                       `json:"priority"`
+	Steps             []approvalPolicyStepInput `json:"steps" binding:"required,min=1,dive"`
+}
+
+type decideApprovalInput struct {
+	Decision string `json:"decision" binding:"required,oneof=approved rejected"`
+	Comment  string `json:"comment"`
+}
+
+// ListApprovalPolicies lista as políticas de aprovação cadastradas
+func ListApprovalPolicies(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var policies []models.ApprovalPolicy
+		if err := db.Preload("Sector").
+			Preload("Steps", func(db *gorm.DB) *gorm.DB { return db.Order("step_order") }).
+			Preload("Steps.ApproverUser").
+			Order("priority, id").
+			Find(&policies).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao listar políticas de aprovação"})
+			return
+		}
+
+		c.JSON(http.StatusOK, policies)
+	}
+}
+
+// CreateApprovalPolicy cadastra uma nova política de aprovação com suas etapas
+func CreateApprovalPolicy(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input approvalPolicyInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		steps, err := buildApprovalPolicySteps(db, input.Steps)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		policy := models.ApprovalPolicy{
+			Name:              input.Name,
+			Active:            input.Active == nil || *input.Active,
+			SectorID:          input.SectorID,
+			MinEstimatedValue: input.MinEstimatedValue,
+			UrgentOnly:        input.UrgentOnly,
+			Priority:          input.Priority,
+			Steps:             steps,
+		}
+
+		if err := db.Create(&policy).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao criar política de aprovação"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, policy)
+	}
+}
+
+// UpdateApprovalPolicy substitui as condições e etapas de uma política
+func UpdateApprovalPolicy(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var policy models.ApprovalPolicy
+		if err := db.First(&policy, c.Param("id")).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Política de aprovação não encontrada"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar política de aprovação"})
+			}
+			return
+		}
+
+		var input approvalPolicyInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		steps, err := buildApprovalPolicySteps(db, input.Steps)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		tx := db.Begin()
+		defer func() {
+			if r := recover(); r != nil {
+				tx.Rollback()
+			}
+		}()
+
+		// Etapas já instanciadas nas requisições guardam uma cópia, então podemos substituir as da política
+		if err := tx.Where("approval_policy_id = ?", policy.ID).Delete(&models.ApprovalPolicyStep{}).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar etapas"})
+			return
+		}
+
+		policy.Name = input.Name
+		if input.Active != nil {
+			policy.Active = *input.Active
+		}
+		policy.SectorID = input.SectorID
+		policy.MinEstimatedValue = input.MinEstimatedValue
+		policy.UrgentOnly = input.UrgentOnly
+		policy.Priority = input.Priority
+		policy.Steps = steps
+
+		if err := tx.Save(&policy).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar política de aprovação"})
+			return
+		}
+
+		if err := tx.Commit().Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao finalizar atualização"})
+			return
+		}
+
+		c.JSON(http.StatusOK, policy)
+	}
+}
+
+// DeleteApprovalPolicy remove uma política (soft delete)
+func DeleteApprovalPolicy(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var policy models.ApprovalPolicy
+		if err := db.First(&policy, c.Param("id")).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Política de aprovação não encontrada"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar política de aprovação"})
+			}
+			return
+		}
+
+		if err := db.Delete(&policy).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao excluir política de aprovação"})
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// ListRequestApprovals lista as etapas de aprovação de uma requisição
+func ListRequestApprovals(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request models.PurchaseRequest
+		if err := db.First(&request, c.Param("id")).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Requisição não encontrada"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar requisição"})
+			}
+			return
+		}
+
+		// Quem pode ver a requisição ou aprovador de alguma etapa
+		userID := utils.ParseUint(c.GetString("userID"))
+		steps, err := loadApprovalSteps(db, request.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar etapas de aprovação"})
+			return
+		}
+		if !canViewRequest(db, c, &request) && !isApproverOf(steps, userID, approverRole(c)) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Acesso negado"})
+			return
+		}
+
+		estimatedValue, err := estimateRequestValue(db, request.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao calcular valor estimado"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"requestId":      request.ID,
+			"estimatedValue": estimatedValue,
+			"steps":          steps,
+			"currentStep":    currentApprovalStep(steps),
+		})
+	}
+}
+
+// ListPendingApprovals lista as etapas que aguardam decisão do usuário logado
+func ListPendingApprovals(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := utils.ParseUint(c.GetString("userID"))
+		role := approverRole(c)
+
+		var steps []models.RequestApprovalStep
+		if err := db.Where("status = ?", models.ApprovalStepPending).
+			Where("(approver_type = ? AND approver_user_id = ?) OR (approver_type = ? AND approver_role = ?)",
+				models.ApproverTypeUser, userID, models.ApproverTypeRole, role).
+			Order("purchase_request_id, sequence").
+			Find(&steps).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar aprovações pendentes"})
+			return
+		}
+
+		// Só interessa a etapa corrente de cada requisição (a de menor sequência ainda pendente)
+		var current []models.RequestApprovalStep
+		for _, step := range steps {
+			var earlierPending int64
+			db.Model(&models.RequestApprovalStep{}).
+				Where("purchase_request_id = ? AND status = ? AND sequence < ?",
+					step.PurchaseRequestID, models.ApprovalStepPending, step.Sequence).
+				Count(&earlierPending)
+			if earlierPending == 0 {
+				current = append(current, step)
+			}
+		}
+
+		c.JSON(http.StatusOK, current)
+	}
+}
+
+// DecideRequestApproval registra a decisão do aprovador da etapa corrente
+func DecideRequestApproval(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := utils.ParseUint(c.GetString("userID"))
+		role := approverRole(c)
+
+		var input decideApprovalInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var request models.PurchaseRequest
+		if err := db.First(&request, c.Param("id")).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Requisição não encontrada"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar requisição"})
+			}
+			return
+		}
+
+		steps, err := loadApprovalSteps(db, request.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar etapas de aprovação"})
+			return
+		}
+
+		step := currentApprovalStep(steps)
+		if step == nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "Não há etapas de aprovação pendentes para esta requisição"})
+			return
+		}
+		if !step.CanBeDecidedBy(userID, role) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Você não é o aprovador da etapa atual"})
+			return
+		}
+		if input.Decision == models.ApprovalStepApproved && request.SectorApprovalBlocking() {
+			respondSectorApprovalPending(c, &request)
+			return
+		}
+
+		tx := db.Begin()
+		defer func() {
+			if r := recover(); r != nil {
+				tx.Rollback()
+			}
+		}()
+
+		now := time.Now()
+		oldStatus := request.Status
+		step.Status = input.Decision
+		step.DecidedBy = &userID
+		step.DecidedAt = &now
+		step.Comment = input.Comment
+		if err := tx.Omit("ApproverUser", "Decider").Save(step).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao registrar decisão"})
+			return
+		}
+
+		var nextStep *models.RequestApprovalStep
+		budgetWarning := ""
+		if input.Decision == models.ApprovalStepRejected {
+			// Rejeição encerra a cadeia
+			if err := tx.Model(&models.RequestApprovalStep{}).
+				Where("purchase_request_id = ? AND status = ?", request.ID, models.ApprovalStepPending).
+				Update("status", models.ApprovalStepSkipped).Error; err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao encerrar cadeia de aprovação"})
+				return
+			}
+			if err := request.TransitionTo(models.StatusRejected); err != nil {
+				tx.Rollback()
+				respondTransitionError(c, err)
+				return
+			}
+			request.ReviewedAt = &now
+			request.ReviewedBy = &userID
+		} else {
+			steps, err = loadApprovalSteps(tx, request.ID)
+			if err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar etapas de aprovação"})
+				return
+			}
+			nextStep = currentApprovalStep(steps)
+			if nextStep == nil {
+				// Última etapa aprovada: se o valor mudou desde a instanciação, a cadeia é refeita
+				required, newStep, err := ensureApprovalChain(tx, &request)
+				if err != nil {
+					tx.Rollback()
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao verificar cadeia de aprovação"})
+					return
+				}
+				if required {
+					nextStep = newStep
+				}
+			}
+			if nextStep == nil {
+				// Orçamentos excluídos ou rejeitados durante a cadeia não podem furar o mínimo
+				shortfalls, err := checkMinimumQuotes(tx, request.ID)
+				if err != nil {
+					tx.Rollback()
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao verificar orçamentos"})
+					return
+				}
+				if len(shortfalls) > 0 {
+					tx.Rollback()
+					c.JSON(http.StatusUnprocessableEntity, minimumQuotesError(shortfalls))
+					return
+				}
+
+				// Requisição aprovada, se a verba do setor permitir
+				warning, ok := enforceSectorBudgets(c, tx, &request, models.StatusApproved)
+				if !ok {
+					tx.Rollback()
+					return
+				}
+				budgetWarning = warning
+
+				if err := request.TransitionTo(models.StatusApproved); err != nil {
+					tx.Rollback()
+					respondTransitionError(c, err)
+					return
+				}
+				request.ReviewedAt = &now
+				request.ReviewedBy = &userID
+			}
+		}
+
+		if err := tx.Save(&request).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar requisição"})
+			return
+		}
+		if err := recordStatusTransition(tx, request.ID, nil, oldStatus, request.Status, c.GetString("userID"),
+			appendBudgetWarning(fmt.Sprintf("Etapa de aprovação '%s': %s", step.Name, input.Comment), budgetWarning)); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao registrar histórico"})
+			return
+		}
+		if err := syncBudgetCommitments(tx, request.ID, c.GetString("userID")); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar verba do setor"})
+			return
+		}
+
+		if err := tx.Commit().Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao finalizar decisão"})
+			return
+		}
+
+		steps, _ = loadApprovalSteps(db, request.ID)
+		c.JSON(http.StatusOK, gin.H{
+			"requestId":     request.ID,
+			"requestStatus": request.Status,
+			"steps":         steps,
+			"currentStep":   currentApprovalStep(steps),
+		})
+
+		if nextStep != nil {
+			notifyApprovalPending(db, nextStep)
+		} else {
+			notifications.Publish(
+				fmt.Sprintf("review-request:%d:%s", request.ID, request.Status),
+			)
+		}
+	}
+}
+
+// buildApprovalPolicySteps valida e converte as etapas recebidas
+func buildApprovalPolicySteps(db *gorm.DB, input []approvalPolicyStepInput) ([]models.ApprovalPolicyStep, error) {
+	var steps []models.ApprovalPolicyStep
+	for i, s := range input {
+		step := models.ApprovalPolicyStep{
+			StepOrder:    i + 1,
+			Name:         s.Name,
+			ApproverType: s.ApproverType,
+		}
+
+		switch s.ApproverType {
+		case models.ApproverTypeUser:
+			if s.ApproverUserID == nil {
+				return nil, fmt.Errorf("etapa %d: approverUserId é obrigatório para aprovador do tipo user", i+1)
+			}
+			var user models.User
+			if err := db.First(&user, *s.ApproverUserID).Error; err != nil {
+				return nil, fmt.Errorf("etapa %d: aprovador não encontrado", i+1)
+			}
+			step.ApproverUserID = s.ApproverUserID
+		case models.ApproverTypeRole:
+			if s.ApproverRole == "" {
+				return nil, fmt.Errorf("etapa %d: approverRole é obrigatório para aprovador do tipo role", i+1)
+			}
+			var role models.Role
+			if err := db.Where("name = ?", s.ApproverRole).First(&role).Error; err != nil {
+				return nil, fmt.Errorf("etapa %d: papel %q não encontrado", i+1, s.ApproverRole)
+			}
+			if !rbac.Permissions(db, role.Name)[models.PermApprovalsDecide] {
+				return nil, fmt.Errorf("etapa %d: o papel %q não tem a permissão %s", i+1, s.ApproverRole, models.PermApprovalsDecide)
+			}
+			step.ApproverRole = s.ApproverRole
+		}
+
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// estimateRequestValue calcula o valor da requisição usado no roteamento das aprovações,
+// com o mesmo custo por item dos empenhos de verba (requestItemCosts)
+func estimateRequestValue(db *gorm.DB, requestID uint) (float64, error) {
+	items, err := requestItemCosts(db, requestID)
+	if err != nil {
+		return 0, err
+	}
+	return requestCostTotal(items), nil
+}
+
+// ensureApprovalChain garante que a cadeia de aprovação da requisição foi instanciada
+// para o valor atual. Cadeias encerradas (etapa rejeitada ou ignorada por uma rejeição direta)
+// ou instanciadas com outro valor são refeitas do zero.
+// Retorna true quando ainda existem etapas pendentes (a requisição não pode ser aprovada).
+func ensureApprovalChain(tx *gorm.DB, request *models.PurchaseRequest) (bool, *models.RequestApprovalStep, error) {
+	steps, err := loadApprovalSteps(tx, request.ID)
+	if err != nil {
+		return false, nil, err
+	}
+	estimatedValue, err := estimateRequestValue(tx, request.ID)
+	if err != nil {
+		return false, nil, err
+	}
+
+	// Cadeia já instanciada, em andamento ou concluída, para o mesmo valor
+	if len(steps) > 0 && !approvalChainFinished(steps) && !approvalChainOutdated(steps, estimatedValue) {
+		current := currentApprovalStep(steps)
+		return current != nil, current, nil
+	}
+
+	// Remove a cadeia anterior (exclusão lógica: as decisões continuam no banco)
+	if len(steps) > 0 {
+		if err := tx.Where("purchase_request_id = ?", request.ID).Delete(&models.RequestApprovalStep{}).Error; err != nil {
+			return false, nil, err
+		}
+	}
+
+	var policies []models.ApprovalPolicy
+	if err := tx.Preload("Steps", func(db *gorm.DB) *gorm.DB { return db.Order("step_order") }).
+		Where("active = ?", true).
+		Order("priority, id").
+		Find(&policies).Error; err != nil {
+		return false, nil, err
+	}
+
+	var newSteps []models.RequestApprovalStep
+	for _, policy := range policies {
+		if !policy.Matches(request, estimatedValue) {
+			continue
+		}
+		for _, ps := range policy.Steps {
+			newSteps = append(newSteps, models.RequestApprovalStep{
+				PurchaseRequestID: request.ID,
+				ApprovalPolicyID:  policy.ID,
+				PolicyStepID:      ps.ID,
+				Sequence:          len(newSteps) + 1,
+				RequestValue:      estimatedValue,
+				Name:              ps.Name,
+				ApproverType:      ps.ApproverType,
+				ApproverUserID:    ps.ApproverUserID,
+				ApproverRole:      ps.ApproverRole,
+				Status:            models.ApprovalStepPending,
+			})
+		}
+	}
+
+	// Nenhuma política aplicável: aprovação em etapa única (comportamento padrão)
+	if len(newSteps) == 0 {
+		return false, nil, nil
+	}
+
+	if err := tx.Create(&newSteps).Error; err != nil {
+		return false, nil, err
+	}
+
+	return true, &newSteps[0], nil
+}
+
+// loadApprovalSteps carrega as etapas da requisição em ordem
+func loadApprovalSteps(db *gorm.DB, requestID uint) ([]models.RequestApprovalStep, error) {
+	var steps []models.RequestApprovalStep
+	err := db.Preload("ApproverUser").
+		Preload("Decider").
+		Where("purchase_request_id = ?", requestID).
+		Order("sequence").
+		Find(&steps).Error
+	return steps, err
+}
+
+// currentApprovalStep retorna a primeira etapa pendente (ou nil quando não há)
+func currentApprovalStep(steps []models.RequestApprovalStep) *models.RequestApprovalStep {
+	sort.Slice(steps, func(i, j int) bool { return steps[i].Sequence < steps[j].Sequence })
+	for i := range steps {
+		if steps[i].IsPending() {
+			return &steps[i]
+		}
+	}
+	return nil
+}
+
+// approvalChainFinished indica se a cadeia foi encerrada por uma rejeição
+// (etapa rejeitada pelo aprovador ou ignorada pela rejeição direta da requisição)
+func approvalChainFinished(steps []models.RequestApprovalStep) bool {
+	for _, step := range steps {
+		if step.Status == models.ApprovalStepRejected || step.Status == models.ApprovalStepSkipped {
+			return true
+		}
+	}
+	return false
+}
+
+// approvalChainOutdated indica se o valor da requisição mudou desde que a cadeia foi instanciada
+func approvalChainOutdated(steps []models.RequestApprovalStep, estimatedValue float64) bool {
+	return len(steps) > 0 && math.Abs(steps[0].RequestValue-estimatedValue) >= 0.005
+}
+
+// approverRole devolve o papel com que o usuário logado decide etapas atribuídas a papéis:
+// vazio quando o papel dele não tem a permissão approvals:decide
+func approverRole(c *gin.Context) string {
+	if !rbac.Has(c, models.PermApprovalsDecide) {
+		return ""
+	}
+	return c.GetString("role")
+}
+
+// isApproverOf indica se o usuário é aprovador de alguma etapa da cadeia
+func isApproverOf(steps []models.RequestApprovalStep, userID uint, role string) bool {
+	for i := range steps {
+		if steps[i].CanBeDecidedBy(userID, role) {
+			return true
+		}
+	}
+	return false
+}
+
+// notifyApprovalPending avisa o(s) aprovador(es) da etapa via SSE
+func notifyApprovalPending(db *gorm.DB, step *models.RequestApprovalStep) {
+	message := fmt.Sprintf("approval-pending:%d:%d", step.PurchaseRequestID, step.ID)
+
+	switch step.ApproverType {
+	case models.ApproverTypeUser:
+		if step.ApproverUserID != nil {
+			notifications.Publish(message, utils.UintToString(*step.ApproverUserID))
+		}
+	case models.ApproverTypeRole:
+		var userIDs []uint
+		db.Model(&models.User{}).Where("role = ?", step.ApproverRole).Pluck("id", &userIDs)
+		targets := make([]string, 0, len(userIDs))
+		for _, id := range userIDs {
+			targets = append(targets, strconv.FormatUint(uint64(id), 10))
+		}
+		if len(targets) > 0 {
+			notifications.Publish(message, targets...)
+		}
+	}
+}
+
+// skipPendingApprovalSteps encerra as etapas pendentes quando a requisição é rejeitada diretamente
+func skipPendingApprovalSteps(db *gorm.DB, requestID uint) error {
+	return db.Model(&models.RequestApprovalStep{}).
+		Where("purchase_request_id = ? AND status = ?", requestID, models.ApprovalStepPending).
+		Update("status", models.ApprovalStepSkipped).Error
+}
+
+// respondApprovalChainPending responde quando a aprovação depende da cadeia de etapas
+func respondApprovalChainPending(c *gin.Context, db *gorm.DB, request *models.PurchaseRequest, step *models.RequestApprovalStep) {
+	steps, err := loadApprovalSteps(db, request.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar etapas de aprovação"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":       "Requisição aguardando as etapas da cadeia de aprovação",
+		"requestId":     request.ID,
+		"requestStatus": request.Status,
+		"steps":         steps,
+		"currentStep":   currentApprovalStep(steps),
+	})
+
+	if step != nil {
+		notifyApprovalPending(db, step)
+	}
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"database/sql/driver"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/models"
+)
+
+var approvalStepColumns = []string{"id", "purchase_request_id", "sequence", "name", "approver_type", "approver_role", "status"}
+
+// A última etapa aprovada volta a conferir o mínimo de orçamentos antes de aprovar a requisição
+func TestDecideRequestApprovalLastStepChecksMinimumQuotes(t *testing.T) {
+	tests := []struct {
+		name       string
+		shortfalls [][]driver.Value
+		code       int
+	}{
+		{"orçamento removido durante a cadeia", [][]driver.Value{{int64(5), "Papel A4", int64(1), int64(2)}}, http.StatusUnprocessableEntity},
+		{"orçamentos suficientes", nil, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, db := newFakeDB(t)
+			f.on(`FROM "purchase_requests"`, pendingRequestColumns, pendingRequestRow())
+			// Primeira leitura: etapa pendente; depois da decisão, a cadeia está concluída
+			f.onOnce(`FROM "request_approval_steps"`, approvalStepColumns,
+				[]driver.Value{int64(1), int64(42), int64(1), "Diretor", models.ApproverTypeRole, "director", models.ApprovalStepPending})
+			f.on(`FROM "request_approval_steps"`, approvalStepColumns,
+				[]driver.Value{int64(1), int64(42), int64(1), "Diretor", models.ApproverTypeRole, "director", models.ApprovalStepApproved})
+			f.on(`FROM "system_settings"`, []string{"id", "min_quotes_per_item"}, []driver.Value{int64(1), int64(2)})
+			f.on("LEFT JOIN products p", []string{"item_id", "product_name", "quote_count", "required"}, tt.shortfalls...)
+
+			w := performRequest(DecideRequestApproval(db), http.MethodPost, "/requests/42/approvals/decide",
+				`{"decision":"approved","comment":"ok"}`, gin.Params{{Key: "id", Value: "42"}},
+				"director", models.PermApprovalsDecide)
+
+			if w.Code != tt.code {
+				t.Fatalf("status %d, want %d: %s", w.Code, tt.code, w.Body.String())
+			}
+			body := decodeBody(t, w)
+			if tt.code == http.StatusUnprocessableEntity {
+				if items, _ := body["itemsBelowMinimum"].([]interface{}); len(items) != 1 {
+					t.Errorf("itemsBelowMinimum = %v", body["itemsBelowMinimum"])
+				}
+				return
+			}
+			if body["requestStatus"] != models.StatusApproved {
+				t.Errorf("requestStatus = %v, want approved", body["requestStatus"])
+			}
+		})
+	}
+}
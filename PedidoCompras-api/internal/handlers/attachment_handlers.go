@@ -3,84 +3,118 @@ package handlers
 import (
 	"fmt"
 	"net/http"
-	"os"
-	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/models"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/preview"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/scanner"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/storage"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/utils"
 )
 
-// UploadAttachment recebe multipart/form-data e salva o arquivo no disco + DB.
-func UploadAttachment(db *gorm.DB) gin.HandlerFunc {
+// UploadOwnerAttachment recebe multipart/form-data com file, ownerType, ownerId e category
+// (quote, invoice, spec, contract, photo; padrão other) e anexa o arquivo ao dono.
+func UploadOwnerAttachment(db *gorm.DB, store storage.Storage, scan scanner.Scanner, previews *preview.Generator) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// 1) ID da requisição
-		requestIDParam := c.Param("id")
-		requestID, err := strconv.ParseUint(requestIDParam, 10, 64)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "ID de requisição inválido"})
+		owner, ok := loadAttachmentOwner(db, c, c.PostForm("ownerType"), c.PostForm("ownerId"))
+		if !ok {
 			return
 		}
+		createAttachment(c, db, store, scan, previews, owner, c.PostForm("category"))
+	}
+}
 
-		// 2) arquivo do form
-		file, err := c.FormFile("file")
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Falha ao ler arquivo: " + err.Error()})
+// ListOwnerAttachments lista os anexos de um dono (?ownerType=&ownerId=, opcional &category=)
+func ListOwnerAttachments(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		owner, ok := loadAttachmentOwner(db, c, c.Query("ownerType"), c.Query("ownerId"))
+		if !ok {
 			return
 		}
+		if !canViewOwner(db, c, owner) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Acesso negado"})
+			return
+		}
+
+		query := db.Where("owner_type = ? AND owner_id = ?", owner.Type, owner.ID)
+		if category := c.Query("category"); category != "" {
+			query = query.Where("category = ?", category)
+		}
 
-		// 3) cria pasta uploads se não existir
-		uploadDir := "uploads"
-		if err := os.MkdirAll(uploadDir, os.ModePerm); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Não foi possível criar diretório de upload"})
+		var attachments []models.Attachment
+		if err := query.Order("id").Find(&attachments).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar anexos"})
 			return
 		}
+		c.JSON(http.StatusOK, attachments)
+	}
+}
 
-		// 4) gera nome único e salva
-		timestamp := time.Now().UnixNano()
-		filename := fmt.Sprintf("%d_%s", timestamp, filepath.Base(file.Filename))
-		fullpath := filepath.Join(uploadDir, filename)
-		if err := c.SaveUploadedFile(file, fullpath); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao salvar arquivo"})
+// DownloadAttachmentByID faz stream do arquivo (?inline=true exibe no navegador)
+func DownloadAttachmentByID(db *gorm.DB, store storage.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		attachment, _, ok := loadAttachmentByID(db, c)
+		if !ok {
 			return
 		}
+		serveAttachment(c, store, attachment)
+	}
+}
 
-		// 5) persiste no DB
-		attachment := models.Attachment{
-			PurchaseRequestID: uint(requestID),
-			FileName:          file.Filename,
-			FilePath:          fullpath,
+// ReplaceAttachmentByID substitui o arquivo de um anexo (campo category opcional)
+func ReplaceAttachmentByID(db *gorm.DB, store storage.Storage, scan scanner.Scanner, previews *preview.Generator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		attachment, owner, ok := loadAttachmentByID(db, c)
+		if !ok {
+			return
 		}
-		if err := db.Create(&attachment).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao criar registro de anexo"})
+		replaceAttachment(c, db, store, scan, previews, owner, attachment)
+	}
+}
+
+// DeleteAttachmentByID remove o anexo (exclusão lógica)
+func DeleteAttachmentByID(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		attachment, owner, ok := loadAttachmentByID(db, c)
+		if !ok {
 			return
 		}
+		deleteAttachment(c, db, owner, attachment)
+	}
+}
 
-		// 6) retorna metadados
-		c.JSON(http.StatusCreated, gin.H{
-			"id":        attachment.ID,
-			"fileName":  attachment.FileName,
-			"createdAt": attachment.CreatedAt,
-		})
+// UploadAttachment recebe multipart/form-data e anexa o arquivo à requisição.
+// O anexo entra em quarentena e só fica disponível para download depois de verificado;
+// imagens e PDFs ganham miniatura e metadados (dimensões, páginas).
+func UploadAttachment(db *gorm.DB, store storage.Storage, scan scanner.Scanner, previews *preview.Generator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		owner, ok := loadAttachmentOwner(db, c, models.AttachmentOwnerRequest, c.Param("id"))
+		if !ok {
+			return
+		}
+		createAttachment(c, db, store, scan, previews, owner, c.PostForm("category"))
 	}
 }
 
-// ListAttachments retorna todos os anexos de uma requisição.
+// ListAttachments retorna todos os anexos de uma requisição, incluindo os
+// dos seus itens, orçamentos e recebimentos.
 func ListAttachments(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		requestIDParam := c.Param("id")
-		requestID, err := strconv.ParseUint(requestIDParam, 10, 64)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "ID de requisição inválido"})
+		request, ok := loadAttachmentRequest(db, c)
+		if !ok {
 			return
 		}
 
 		var attachments []models.Attachment
 		if err := db.
-			Where("purchase_request_id = ?", requestID).
+			Where("purchase_request_id = ?", request.ID).
+			Order("id").
 			Find(&attachments).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar anexos"})
 			return
@@ -90,27 +124,318 @@ func ListAttachments(db *gorm.DB) gin.HandlerFunc {
 }
 
 // DownloadAttachment faz stream do arquivo para o cliente.
-func DownloadAttachment(db *gorm.DB) gin.HandlerFunc {
+// Com ?inline=true, PDFs e imagens são exibidos no navegador em vez de baixados.
+func DownloadAttachment(db *gorm.DB, store storage.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		attachment, _, ok := loadRequestAttachment(db, c)
+		if !ok {
+			return
+		}
+		serveAttachment(c, store, attachment)
+	}
+}
+
+// ReplaceAttachment substitui o arquivo de um anexo (mesmo registro, novo conteúdo).
+// Permitido a quem enviou o anexo e a quem gerencia o dono; o log de auditoria guarda o antes e o depois.
+// O novo arquivo passa pela mesma verificação de malware e pré-visualização do upload.
+func ReplaceAttachment(db *gorm.DB, store storage.Storage, scan scanner.Scanner, previews *preview.Generator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		attachment, owner, ok := loadRequestAttachment(db, c)
+		if !ok {
+			return
+		}
+		replaceAttachment(c, db, store, scan, previews, owner, attachment)
+	}
+}
+
+// DeleteAttachment remove o anexo (exclusão lógica). O arquivo fica no armazenamento
+// até a limpeza definitiva (pedidos-admin purge-deleted).
+func DeleteAttachment(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		attachIDParam := c.Param("attachmentId")
-		attachID, err := strconv.ParseUint(attachIDParam, 10, 64)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "ID de anexo inválido"})
+		attachment, owner, ok := loadRequestAttachment(db, c)
+		if !ok {
 			return
 		}
+		deleteAttachment(c, db, owner, attachment)
+	}
+}
+
+// createAttachment valida, grava, verifica e gera a pré-visualização de um novo anexo do dono
+func createAttachment(c *gin.Context, db *gorm.DB, store storage.Storage, scan scanner.Scanner, previews *preview.Generator, owner *attachmentOwner, category string) {
+	if !canAttachToOwner(db, c, owner) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Acesso negado"})
+		return
+	}
+	category, ok := attachmentCategory(c, category)
+	if !ok {
+		return
+	}
+
+	// 1) arquivo do form, validado contra a política de anexos
+	file, contentType, ok := readValidatedUpload(db, c)
+	if !ok {
+		return
+	}
+
+	// 2) gera chave única na pasta do dono e salva
+	userID := utils.ParseUint(c.GetString("userID"))
+	attachment := models.Attachment{
+		OwnerType:         owner.Type,
+		OwnerID:           owner.ID,
+		Category:          category,
+		PurchaseRequestID: owner.requestID(),
+		FileName:          file.Filename,
+		ContentType:       contentType,
+		Size:              file.Size,
+		UploadedByID:      &userID,
+		ScanStatus:        models.ScanStatusQuarantined,
+	}
+	attachment.FilePath = storage.NewKey(attachment.StorageDir(), file.Filename)
+	if err := saveUpload(c, store, attachment.FilePath, file, contentType); err != nil {
+		fmt.Printf("❌ Erro ao salvar anexo em %s: %v\n", store.Name(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao salvar arquivo"})
+		return
+	}
 
-		var attachment models.Attachment
-		if err := db.First(&attachment, attachID).Error; err != nil {
-			if err == gorm.ErrRecordNotFound {
-				c.JSON(http.StatusNotFound, gin.H{"error": "Anexo não encontrado"})
-			} else {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar anexo"})
-			}
+	// 3) persiste no DB
+	if err := db.Create(&attachment).Error; err != nil {
+		removeStored(c, store, attachment.FilePath)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao criar registro de anexo"})
+		return
+	}
+
+	// 4) verificação de malware e pré-visualização
+	if !scanNewAttachment(c, db, store, scan, previews, &attachment) {
+		return
+	}
+
+	// 5) retorna metadados
+	c.JSON(http.StatusCreated, gin.H{
+		"id":           attachment.ID,
+		"ownerType":    attachment.OwnerType,
+		"ownerId":      attachment.OwnerID,
+		"category":     attachment.Category,
+		"fileName":     attachment.FileName,
+		"contentType":  attachment.ContentType,
+		"size":         attachment.Size,
+		"scanStatus":   attachment.ScanStatus,
+		"width":        attachment.Width,
+		"height":       attachment.Height,
+		"pageCount":    attachment.PageCount,
+		"hasThumbnail": attachment.ThumbnailPath != "",
+		"createdAt":    attachment.CreatedAt,
+	})
+}
+
+// replaceAttachment troca o arquivo de um anexo existente
+func replaceAttachment(c *gin.Context, db *gorm.DB, store storage.Storage, scan scanner.Scanner, previews *preview.Generator, owner *attachmentOwner, attachment *models.Attachment) {
+	if !canModifyAttachment(c, owner, attachment) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Apenas quem enviou o anexo pode substituí-lo"})
+		return
+	}
+	category := attachment.Category
+	if value, sent := c.GetPostForm("category"); sent {
+		var ok bool
+		if category, ok = attachmentCategory(c, value); !ok {
 			return
 		}
+	}
+
+	file, contentType, ok := readValidatedUpload(db, c)
+	if !ok {
+		return
+	}
+
+	key := storage.NewKey(attachment.StorageDir(), file.Filename)
+	if err := saveUpload(c, store, key, file, contentType); err != nil {
+		fmt.Printf("❌ Erro ao salvar anexo em %s: %v\n", store.Name(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao salvar arquivo"})
+		return
+	}
+
+	// A versão anterior fica como anexo excluído (histórico): o arquivo só é apagado
+	// pelo purge-deleted, como nos anexos removidos
+	previous := *attachment
+	previous.ID = 0
+	previous.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+
+	userID := utils.ParseUint(c.GetString("userID"))
+	attachment.Category = category
+	attachment.FileName = file.Filename
+	attachment.FilePath = key
+	attachment.ContentType = contentType
+	attachment.Size = file.Size
+	attachment.UploadedByID = &userID
+	attachment.ScanStatus = models.ScanStatusQuarantined
+	attachment.ScanSignature = ""
+	attachment.Width, attachment.Height, attachment.PageCount = 0, 0, 0
+	attachment.ThumbnailPath = ""
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Create(&previous).Error; err != nil {
+			return err
+		}
+		return tx.Omit(clause.Associations).Save(attachment).Error
+	})
+	if err != nil {
+		removeStored(c, store, key)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar anexo"})
+		return
+	}
+
+	if !scanNewAttachment(c, db, store, scan, previews, attachment) {
+		return
+	}
+	c.JSON(http.StatusOK, attachment)
+}
+
+// deleteAttachment faz a exclusão lógica do anexo
+func deleteAttachment(c *gin.Context, db *gorm.DB, owner *attachmentOwner, attachment *models.Attachment) {
+	if !canModifyAttachment(c, owner, attachment) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Apenas quem enviou o anexo pode removê-lo"})
+		return
+	}
+
+	if err := db.Delete(attachment).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao remover anexo"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Anexo removido com sucesso"})
+}
+
+// scanNewAttachment verifica o arquivo recém-gravado e, se estiver limpo,
+// gera a pré-visualização. Responde 422 e devolve false quando há malware.
+func scanNewAttachment(c *gin.Context, db *gorm.DB, store storage.Storage, scan scanner.Scanner, previews *preview.Generator, attachment *models.Attachment) bool {
+	attachment.ScanStatus, attachment.ScanSignature = scanStoredFile(c.Request.Context(), db, store, scan, attachment.ID, attachment.FilePath)
+	if attachment.ScanStatus == models.ScanStatusInfected {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":      "Arquivo bloqueado: malware detectado (" + attachment.ScanSignature + ")",
+			"id":         attachment.ID,
+			"scanStatus": attachment.ScanStatus,
+		})
+		return false
+	}
+
+	// arquivos ainda em quarentena (scanner indisponível) não passam pelo gerador de pré-visualização
+	if attachment.ScanStatus != models.ScanStatusClean {
+		return true
+	}
+	if err := generateAttachmentPreview(c.Request.Context(), db, store, previews, attachment); err != nil {
+		fmt.Printf("❌ Erro ao gerar pré-visualização do anexo %d: %v\n", attachment.ID, err)
+	}
+	return true
+}
+
+// serveAttachment envia o arquivo de um anexo liberado pela verificação de malware
+func serveAttachment(c *gin.Context, store storage.Storage, attachment *models.Attachment) {
+	if !checkScanStatus(c, attachment.ScanStatus) {
+		return
+	}
+
+	disposition := "attachment"
+	if inline, _ := strconv.ParseBool(c.Query("inline")); inline && inlineTypes[attachment.ContentType] {
+		disposition = "inline"
+		// o arquivo exibido não executa scripts nem acessa a sessão da aplicação
+		c.Header("Content-Security-Policy", "sandbox")
+	}
+	serveStored(c, store, attachment.FilePath, attachment.ContentType, disposition, attachment.FileName)
+}
+
+// attachmentCategory valida a categoria informada (vazia = other); responde 400 e devolve false se inválida
+func attachmentCategory(c *gin.Context, category string) (string, bool) {
+	category = strings.ToLower(strings.TrimSpace(category))
+	if category == "" {
+		return models.AttachmentCategoryOther, true
+	}
+	if !models.IsValidAttachmentCategory(category) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":      "Categoria de anexo inválida",
+			"categories": models.AttachmentCategories,
+		})
+		return "", false
+	}
+	return category, true
+}
+
+// loadAttachmentRequest carrega a requisição da rota e aplica a mesma regra
+// de acesso de GetPurchaseRequest (responde 400/403/404 e devolve false em caso de erro)
+func loadAttachmentRequest(db *gorm.DB, c *gin.Context) (*models.PurchaseRequest, bool) {
+	requestID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de requisição inválido"})
+		return nil, false
+	}
+
+	var request models.PurchaseRequest
+	if err := db.First(&request, requestID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Requisição não encontrada"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar requisição"})
+		}
+		return nil, false
+	}
+
+	if !canViewRequest(db, c, &request) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Acesso negado"})
+		return nil, false
+	}
+	return &request, true
+}
 
-		// serve o arquivo
-		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", attachment.FileName))
-		c.File(attachment.FilePath)
+// loadRequestAttachment carrega o anexo da rota /requests/:id/attachments/:attachmentId,
+// que deve pertencer à requisição (diretamente ou por um item, orçamento ou recebimento)
+func loadRequestAttachment(db *gorm.DB, c *gin.Context) (*models.Attachment, *attachmentOwner, bool) {
+	request, ok := loadAttachmentRequest(db, c)
+	if !ok {
+		return nil, nil, false
+	}
+	attachID, err := strconv.ParseUint(c.Param("attachmentId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de anexo inválido"})
+		return nil, nil, false
+	}
+
+	var attachment models.Attachment
+	if err := db.Where("purchase_request_id = ?", request.ID).First(&attachment, attachID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Anexo não encontrado"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar anexo"})
+		}
+		return nil, nil, false
+	}
+	return authorizeAttachment(db, c, &attachment)
+}
+
+// loadAttachmentByID carrega o anexo da rota /attachments/:id e confere o acesso ao dono
+func loadAttachmentByID(db *gorm.DB, c *gin.Context) (*models.Attachment, *attachmentOwner, bool) {
+	attachID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de anexo inválido"})
+		return nil, nil, false
+	}
+
+	var attachment models.Attachment
+	if err := db.First(&attachment, attachID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Anexo não encontrado"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar anexo"})
+		}
+		return nil, nil, false
+	}
+	return authorizeAttachment(db, c, &attachment)
+}
+
+// authorizeAttachment carrega o dono do anexo e aplica a regra de acesso dele (404/403)
+func authorizeAttachment(db *gorm.DB, c *gin.Context, attachment *models.Attachment) (*models.Attachment, *attachmentOwner, bool) {
+	owner, ok := loadAttachmentOwner(db, c, attachment.OwnerType, utils.UintToString(attachment.OwnerID))
+	if !ok {
+		return nil, nil, false
+	}
+	if !canViewOwner(db, c, owner) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Acesso negado"})
+		return nil, nil, false
 	}
+	return attachment, owner, true
 }
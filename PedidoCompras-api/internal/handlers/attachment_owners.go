@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/models"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/rbac"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/utils"
+)
+
+// attachmentOwner - Registro dono de um anexo, já carregado
+type attachmentOwner struct {
+	Type        string
+	ID          uint
+	Request     *models.PurchaseRequest // requisição do dono (request, item, budget, receipt)
+	RequesterID uint                    // solicitante do pedido de cadastro (product_request)
+}
+
+// requestID - Requisição gravada no anexo (vazio para fornecedores e pedidos de cadastro)
+func (o *attachmentOwner) requestID() *uint {
+	if o.Request == nil {
+		return nil
+	}
+	id := o.Request.ID
+	return &id
+}
+
+// loadAttachmentOwner carrega o dono pelo tipo e ID (texto da rota, query ou formulário).
+// Responde 400/404 e devolve false quando o tipo é inválido ou o registro não existe.
+func loadAttachmentOwner(db *gorm.DB, c *gin.Context, ownerType, rawID string) (*attachmentOwner, bool) {
+	id, err := strconv.ParseUint(rawID, 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID do dono do anexo inválido"})
+		return nil, false
+	}
+	owner := &attachmentOwner{Type: ownerType, ID: uint(id)}
+
+	var requestID uint
+	switch ownerType {
+	case models.AttachmentOwnerRequest:
+		requestID = owner.ID
+	case models.AttachmentOwnerItem:
+		var item models.RequestItem
+		err = db.Select("id, purchase_request_id").First(&item, owner.ID).Error
+		requestID = item.PurchaseRequestID
+	case models.AttachmentOwnerBudget:
+		var budget models.ItemBudget
+		err = db.Select("id, purchase_request_id").First(&budget, owner.ID).Error
+		requestID = budget.PurchaseRequestID
+	case models.AttachmentOwnerReceipt:
+		var receipt models.ItemReceipt
+		err = db.Preload("RequestItem").First(&receipt, owner.ID).Error
+		requestID = receipt.RequestItem.PurchaseRequestID
+	case models.AttachmentOwnerSupplier:
+		err = db.Select("id").First(&models.Supplier{}, owner.ID).Error
+	case models.AttachmentOwnerProductRequest:
+		var productRequest models.ProductRegistrationRequest
+		err = db.Select("id, requester_id").First(&productRequest, owner.ID).Error
+		owner.RequesterID = productRequest.RequesterID
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":      "Tipo de dono de anexo inválido",
+			"ownerTypes": []string{models.AttachmentOwnerRequest, models.AttachmentOwnerItem, models.AttachmentOwnerBudget, models.AttachmentOwnerReceipt, models.AttachmentOwnerSupplier, models.AttachmentOwnerProductRequest},
+		})
+		return nil, false
+	}
+
+	if err == nil && requestID != 0 {
+		owner.Request = &models.PurchaseRequest{}
+		err = db.First(owner.Request, requestID).Error
+	}
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Dono do anexo não encontrado"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar dono do anexo"})
+		}
+		return nil, false
+	}
+	return owner, true
+}
+
+// canViewOwner - Quem pode ver os anexos: a mesma regra de acesso do dono
+func canViewOwner(db *gorm.DB, c *gin.Context, owner *attachmentOwner) bool {
+	switch owner.Type {
+	case models.AttachmentOwnerReceipt:
+		return rbac.Has(c, models.PermReceiptsView) || canViewRequest(db, c, owner.Request)
+	case models.AttachmentOwnerSupplier:
+		return true // fornecedores são visíveis a todos os usuários autenticados
+	case models.AttachmentOwnerProductRequest:
+		return rbac.Has(c, models.PermProductRequestsReview) ||
+			owner.RequesterID == utils.ParseUint(c.GetString("userID"))
+	default:
+		return canViewRequest(db, c, owner.Request)
+	}
+}
+
+// canAttachToOwner - Quem pode enviar anexos: na requisição e nos itens, quem a vê;
+// nos demais, quem gerencia o dono (ou o solicitante do pedido de cadastro)
+func canAttachToOwner(db *gorm.DB, c *gin.Context, owner *attachmentOwner) bool {
+	switch owner.Type {
+	case models.AttachmentOwnerRequest, models.AttachmentOwnerItem:
+		return canViewRequest(db, c, owner.Request)
+	case models.AttachmentOwnerProductRequest:
+		return canViewOwner(db, c, owner)
+	default:
+		return rbac.Has(c, ownerManagePermission(owner.Type))
+	}
+}
+
+// canModifyAttachment - Quem enviou o anexo ou quem gerencia o dono
+// (anexos antigos, sem autor registrado, ficam com o solicitante)
+func canModifyAttachment(c *gin.Context, owner *attachmentOwner, attachment *models.Attachment) bool {
+	if rbac.Has(c, ownerManagePermission(owner.Type)) {
+		return true
+	}
+	userID := utils.ParseUint(c.GetString("userID"))
+	if attachment.UploadedByID != nil {
+		return *attachment.UploadedByID == userID
+	}
+	if owner.Request != nil {
+		return owner.Request.RequesterID == userID
+	}
+	return owner.RequesterID != 0 && owner.RequesterID == userID
+}
+
+// ownerManagePermission - Permissão de quem gerencia os anexos de cada tipo de dono
+func ownerManagePermission(ownerType string) string {
+	switch ownerType {
+	case models.AttachmentOwnerBudget:
+		return models.PermBudgetsManage
+	case models.AttachmentOwnerReceipt:
+		return models.PermReceiptsCreate
+	case models.AttachmentOwnerSupplier:
+		return models.PermSuppliersManage
+	case models.AttachmentOwnerProductRequest:
+		return models.PermProductRequestsReview
+	default:
+		return models.PermRequestsReview
+	}
+}
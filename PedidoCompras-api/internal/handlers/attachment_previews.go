@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/models"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/preview"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/storage"
+)
+
+// maxPreviewSourceSize - Arquivos maiores não geram pré-visualização
+const maxPreviewSourceSize = 50 << 20
+
+// inlineTypes - Tipos que o navegador pode exibir diretamente (?inline=true).
+// Texto fica de fora: HTML/SVG enviados como .txt seriam interpretados no domínio da aplicação.
+var inlineTypes = map[string]bool{
+	"application/pdf": true,
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/gif":       true,
+	"image/webp":      true,
+}
+
+// generateAttachmentPreview grava dimensões, páginas e miniatura do anexo.
+// Anexos antigos sem ContentType têm o tipo detectado pelo conteúdo e registrado.
+// Falhas apenas vão para o log: o anexo continua disponível sem pré-visualização.
+func generateAttachmentPreview(ctx context.Context, db *gorm.DB, store storage.Storage, previews *preview.Generator, attachment *models.Attachment) error {
+	reader, _, err := store.Open(ctx, attachment.FilePath)
+	if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(io.LimitReader(reader, maxPreviewSourceSize+1))
+	reader.Close()
+	if err != nil {
+		return err
+	}
+	if len(data) > maxPreviewSourceSize {
+		return nil
+	}
+
+	contentType := attachment.ContentType
+	if contentType == "" {
+		contentType = models.DetectAttachmentType(strings.ToLower(path.Ext(attachment.FileName)), data)
+	}
+	if !preview.Supports(contentType) {
+		return nil
+	}
+
+	result, err := previews.Generate(ctx, contentType, data)
+	if err != nil {
+		return err
+	}
+
+	thumbnailKey := ""
+	if len(result.Thumbnail) > 0 {
+		base := strings.TrimSuffix(path.Base(attachment.FilePath), path.Ext(attachment.FilePath))
+		thumbnailKey = storage.NewKey(attachment.StorageDir()+"/thumbnails", base+".jpg")
+		if err := store.Put(ctx, thumbnailKey, bytes.NewReader(result.Thumbnail), int64(len(result.Thumbnail)), "image/jpeg"); err != nil {
+			return err
+		}
+	}
+
+	// a chave no filtro evita gravar a pré-visualização se o arquivo foi substituído no meio tempo
+	update := db.Model(&models.Attachment{}).
+		Where("id = ? AND file_path = ?", attachment.ID, attachment.FilePath).
+		Updates(map[string]interface{}{
+			"content_type":   contentType,
+			"width":          result.Width,
+			"height":         result.Height,
+			"page_count":     result.PageCount,
+			"thumbnail_path": thumbnailKey,
+		})
+	if update.Error != nil || update.RowsAffected == 0 {
+		if thumbnailKey != "" {
+			store.Delete(ctx, thumbnailKey)
+		}
+		return update.Error
+	}
+
+	if previous := attachment.ThumbnailPath; previous != "" && previous != thumbnailKey {
+		if err := store.Delete(ctx, previous); err != nil {
+			fmt.Printf("❌ Não foi possível remover a miniatura %s: %v\n", previous, err)
+		}
+	}
+	attachment.ContentType = contentType
+	attachment.Width, attachment.Height, attachment.PageCount = result.Width, result.Height, result.PageCount
+	attachment.ThumbnailPath = thumbnailKey
+	return nil
+}
+
+// GetAttachmentThumbnail devolve a miniatura JPEG do anexo (imagens e PDFs),
+// com a mesma regra de acesso do dono do anexo
+func GetAttachmentThumbnail(db *gorm.DB, store storage.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		attachment, _, ok := loadAttachmentByID(db, c)
+		if !ok {
+			return
+		}
+		if !checkScanStatus(c, attachment.ScanStatus) {
+			return
+		}
+		if attachment.ThumbnailPath == "" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Pré-visualização não disponível para este anexo"})
+			return
+		}
+
+		// a chave muda a cada substituição do anexo, então a miniatura pode ficar em cache
+		c.Header("Cache-Control", "private, max-age=86400")
+		serveStored(c, store, attachment.ThumbnailPath, "image/jpeg", "", "")
+	}
+}
+
+// GenerateAttachmentPreviews gera as pré-visualizações dos anexos liberados que ainda
+// não têm (ou de todos, com all). Devolve quantos foram processados e quantos falharam.
+func GenerateAttachmentPreviews(ctx context.Context, db *gorm.DB, store storage.Storage, previews *preview.Generator, all bool) (processed, failed int, err error) {
+	query := db.Where("scan_status = ?", models.ScanStatusClean)
+	if !all {
+		query = query.Where("COALESCE(thumbnail_path, '') = '' AND COALESCE(page_count, 0) = 0")
+	}
+
+	var attachments []models.Attachment
+	if err := query.Order("id").Find(&attachments).Error; err != nil {
+		return 0, 0, err
+	}
+	for i := range attachments {
+		if err := generateAttachmentPreview(ctx, db, store, previews, &attachments[i]); err != nil {
+			fmt.Printf("❌ Anexo %d (%s): %v\n", attachments[i].ID, attachments[i].FilePath, err)
+			failed++
+			continue
+		}
+		processed++
+	}
+	return processed, failed, nil
+}
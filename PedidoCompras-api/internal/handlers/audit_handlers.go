@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/models"
+	"gorm.io/gorm"
+)
+
+// auditLogFilters - Filtros aceitos em GET /audit
+type auditLogFilters struct {
+	ActorID   string `form:"actorId"`
+	Entity    string `form:"entity"`
+	EntityID  string `form:"entityId"`
+	Action    string `form:"action"`
+	StartDate string `form:"startDate"`
+	EndDate   string `form:"endDate"`
+	Page      string `form:"page"`
+	PageSize  string `form:"pageSize"`
+}
+
+// ListAuditLogs - Lista o log de auditoria com filtros (audit:view)
+func ListAuditLogs(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var filters auditLogFilters
+		if err := c.ShouldBindQuery(&filters); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		query := db.Model(&models.AuditLog{})
+
+		if filters.ActorID != "" {
+			actorID, err := strconv.ParseUint(filters.ActorID, 10, 32)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "actorId inválido"})
+				return
+			}
+			query = query.Where("actor_id = ?", actorID)
+		}
+		if filters.Entity != "" {
+			query = query.Where("entity = ?", filters.Entity)
+		}
+		if filters.EntityID != "" {
+			entityID, err := strconv.ParseUint(filters.EntityID, 10, 32)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "entityId inválido"})
+				return
+			}
+			query = query.Where("entity_id = ?", entityID)
+		}
+		if filters.Action != "" {
+			query = query.Where("action = ?", filters.Action)
+		}
+		if filters.StartDate != "" {
+			startDate, err := time.Parse("2006-01-02", filters.StartDate)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Data de início inválida"})
+				return
+			}
+			query = query.Where("created_at >= ?", startDate)
+		}
+		if filters.EndDate != "" {
+			endDate, err := time.Parse("2006-01-02", filters.EndDate)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Data de fim inválida"})
+				return
+			}
+			query = query.Where("created_at < ?", endDate.AddDate(0, 0, 1))
+		}
+
+		// Paginação
+		page := 1
+		pageSize := 50
+		if p, err := strconv.Atoi(filters.Page); err == nil && p > 0 {
+			page = p
+		}
+		if ps, err := strconv.Atoi(filters.PageSize); err == nil && ps > 0 && ps <= 200 {
+			pageSize = ps
+		}
+
+		var total int64
+		if err := query.Count(&total).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao contar registros de auditoria"})
+			return
+		}
+
+		var logs []models.AuditLog
+		if err := query.Order("created_at DESC, id DESC").
+			Offset((page - 1) * pageSize).
+			Limit(pageSize).
+			Find(&logs).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar registros de auditoria"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"logs": logs,
+			"pagination": models.PaginationInfo{
+				Page:       page,
+				PageSize:   pageSize,
+				TotalPages: int(math.Ceil(float64(total) / float64(pageSize))),
+				TotalItems: int(total),
+			},
+		})
+	}
+}
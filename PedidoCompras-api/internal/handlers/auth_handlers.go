@@ -1,8 +1,8 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
-	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -25,6 +25,9 @@ type MyClaims struct {
 	Email      string `json:"email"`      // ✅ ADICIONADO
 	SectorID   uint   `json:"sectorId"`   // ✅ ADICIONADO
 	SectorName string `json:"sectorName"` // ✅ ADICIONADO
+
+	// Senha expirada: o token só permite trocar a senha
+	PasswordExpired bool `json:"pwdExpired,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -37,6 +40,21 @@ func Login(databaseConnection *gorm.DB, appConfig *config.Config) gin.HandlerFun
 			return
 		}
 
+		settings, err := loadSystemSettings(databaseConnection)
+		if err != nil {
+			context.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao carregar configurações"})
+			return
+		}
+
+		// Origem bloqueada por excesso de falhas: nem chega a consultar a conta
+		ip := context.ClientIP()
+		if lock := activeLockout(databaseConnection, ipThrottleKey(ip), time.Now()); lock != nil {
+			recordLoginAttempt(databaseConnection, context, dados.Email, nil, false, models.LoginReasonIPLocked)
+			notifySecurityEvent("suspicious-login", ipThrottleKey(ip))
+			respondLoginLocked(context, lock, "Muitas tentativas de login a partir deste endereço. Tente novamente mais tarde")
+			return
+		}
+
 		// Buscar usuário por e-mail COM o setor carregado
 		var usuario models.User
 		resultado := databaseConnection.
@@ -44,43 +62,77 @@ func Login(databaseConnection *gorm.DB, appConfig *config.Config) gin.HandlerFun
 			Where("email = ?", dados.Email).
 			First(&usuario)
 		if resultado.Error != nil {
+			handleLoginFailure(databaseConnection, context, settings, dados.Email, nil, models.LoginReasonUnknownUser)
 			context.JSON(http.StatusUnauthorized, gin.H{"error": "Credenciais inválidas"})
 			return
 		}
 
+		// Conta bloqueada: recusa sem verificar a senha
+		userKey := userThrottleKey(usuario.ID)
+		if lock := activeLockout(databaseConnection, userKey, time.Now()); lock != nil {
+			recordLoginAttempt(databaseConnection, context, dados.Email, &usuario.ID, false, models.LoginReasonAccountLocked)
+			notifySecurityEvent("suspicious-login", userKey)
+			respondLoginLocked(context, lock, "Conta temporariamente bloqueada por excesso de tentativas. Tente novamente mais tarde")
+			return
+		}
+
 		// Comparar hash da senha
 		if err := bcrypt.CompareHashAndPassword(
 			[]byte(usuario.PasswordHash),
 			[]byte(dados.Password),
 		); err != nil {
+			handleLoginFailure(databaseConnection, context, settings, dados.Email, &usuario, models.LoginReasonInvalidPassword)
 			context.JSON(http.StatusUnauthorized, gin.H{"error": "Credenciais inválidas"})
 			return
 		}
 
-		// ✅ CRIAR CLAIMS COM DADOS COMPLETOS
-		myClaims := MyClaims{
-			Role:       usuario.Role,
-			Name:       usuario.Name,        // Nome do usuário
-			Email:      usuario.Email,       // Email do usuário
-			SectorID:   usuario.SectorID,    // ID do setor
-			SectorName: usuario.Sector.Name, // Nome do setor
-			RegisteredClaims: jwt.RegisteredClaims{
-				Subject:   strconv.FormatUint(uint64(usuario.ID), 10),
-				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
-				IssuedAt:  jwt.NewNumericDate(time.Now()),
-			},
+		// Credenciais válidas: zera o contador da conta (o do IP segue valendo,
+		// para que uma conta válida não sirva para liberar ataques a outras)
+		if err := resetLoginThrottle(databaseConnection, userKey); err != nil {
+			fmt.Printf("❌ Erro ao zerar falhas de login (%s): %v\n", userKey, err)
+		}
+		recordLoginAttempt(databaseConnection, context, dados.Email, &usuario.ID, true, models.LoginReasonOK)
+
+		// Senha expirada: encerra as sessões do usuário e emite apenas um token restrito
+		// à troca de senha, sem refresh token
+		if usuario.PasswordExpired(settings.PasswordExpirationDays, time.Now()) {
+			session, err := startPasswordChangeSession(databaseConnection, context, usuario.ID)
+			if err != nil {
+				context.JSON(http.StatusInternalServerError, gin.H{"error": "Não foi possível iniciar sessão"})
+				return
+			}
+			tokenString, expiresIn, err := issueAccessToken(databaseConnection, appConfig, usuario, session.ID, true)
+			if err != nil {
+				context.JSON(http.StatusInternalServerError, gin.H{"error": "Não foi possível gerar token"})
+				return
+			}
+			context.JSON(http.StatusForbidden, gin.H{
+				"error":           "Senha expirada. Altere sua senha para continuar",
+				"passwordExpired": true,
+				"token":           tokenString,
+				"expiresIn":       expiresIn,
+			})
+			return
+		}
+
+		// ✅ CRIAR SESSÃO (REFRESH TOKEN) E ACCESS TOKEN COM DADOS COMPLETOS
+		session, refreshToken, err := startSession(databaseConnection, appConfig, context, usuario.ID)
+		if err != nil {
+			context.JSON(http.StatusInternalServerError, gin.H{"error": "Não foi possível iniciar sessão"})
+			return
 		}
-		tokenObj := jwt.NewWithClaims(jwt.SigningMethodHS256, myClaims)
 
-		tokenString, err := tokenObj.SignedString([]byte(appConfig.JWTSecretKey))
+		tokenString, expiresIn, err := issueAccessToken(databaseConnection, appConfig, usuario, session.ID, false)
 		if err != nil {
 			context.JSON(http.StatusInternalServerError, gin.H{"error": "Não foi possível gerar token"})
 			return
 		}
 
-		// ✅ RETORNAR DADOS COMPLETOS DO USUÁRIO JUNTO COM O TOKEN
+		// ✅ RETORNAR DADOS COMPLETOS DO USUÁRIO JUNTO COM OS TOKENS
 		context.JSON(http.StatusOK, gin.H{
-			"token": tokenString,
+			"token":        tokenString,
+			"refreshToken": refreshToken,
+			"expiresIn":    expiresIn,
 			"user": gin.H{
 				"id":       usuario.ID,
 				"name":     usuario.Name,
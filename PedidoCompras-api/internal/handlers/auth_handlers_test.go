@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/config"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/models"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Senha expirada: o token restrito não vem com sessão renovável e as sessões
+// abertas do usuário são encerradas
+func TestLoginPasswordExpiredSession(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("Compras#2025"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		changedAt time.Time
+		status    int
+		refresh   bool
+	}{
+		{"senha vencida", time.Now().AddDate(0, 0, -120), http.StatusForbidden, false},
+		{"senha em dia", time.Now().AddDate(0, 0, -10), http.StatusOK, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, db := newFakeDB(t)
+			f.on(`FROM "system_settings"`, []string{"id", "password_expiration_days", "session_timeout_minutes"},
+				[]driver.Value{int64(1), int64(90), int64(60)})
+			f.on(`FROM "users"`, []string{"id", "name", "email", "password_hash", "role", "sector_id", "created_at", "password_changed_at"},
+				[]driver.Value{int64(7), "Ana", "ana@empresa.com", string(hash), models.RoleRequester, int64(3), time.Now().AddDate(-1, 0, 0), tt.changedAt})
+
+			gin.SetMode(gin.TestMode)
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", strings.NewReader(`{"email":"ana@empresa.com","password":"Compras#2025"}`))
+			c.Request.Header.Set("Content-Type", "application/json")
+			Login(db, &config.Config{JWTSecretKey: "segredo"})(c)
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body.String())
+			}
+			body := decodeBody(t, w)
+			if token, _ := body["token"].(string); token == "" {
+				t.Error("resposta sem access token")
+			}
+			_, hasRefresh := body["refreshToken"]
+			if hasRefresh != tt.refresh {
+				t.Errorf("refreshToken na resposta = %v, want %v", hasRefresh, tt.refresh)
+			}
+			if got := f.executed(`INSERT INTO "refresh_tokens"`); got != tt.refresh {
+				t.Errorf("refresh token gravado = %v, want %v", got, tt.refresh)
+			}
+			if !f.executed(`INSERT INTO "user_sessions"`) {
+				t.Error("sessão não foi criada")
+			}
+
+			revoked := f.index(`UPDATE "user_sessions"`, "revoked_at", "user_id")
+			if tt.refresh {
+				if revoked >= 0 {
+					t.Error("login com senha em dia não deve encerrar as sessões")
+				}
+				return
+			}
+			if revoked < 0 || revoked > f.index(`INSERT INTO "user_sessions"`) {
+				t.Error("as sessões abertas devem ser encerradas antes da sessão restrita")
+			}
+			if body["passwordExpired"] != true {
+				t.Errorf("passwordExpired = %v", body["passwordExpired"])
+			}
+		})
+	}
+}
@@ -1,9 +1,15 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"math"
 	"net/http"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/models"
@@ -59,11 +65,30 @@ func UpdateBudget(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
+		tx := db.Begin()
+		defer func() {
+			if r := recover(); r != nil {
+				tx.Rollback()
+			}
+		}()
+
 		budget.UnitPrice = input.UnitPrice
-		if err := db.Save(&budget).Error; err != nil {
+		if err := tx.Save(&budget).Error; err != nil {
+			tx.Rollback()
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar orçamento"})
 			return
 		}
+
+		// Requisições já aprovadas têm o empenho da verba ajustado ao novo preço
+		if _, ok := applyBudgetChange(c, tx, budget.PurchaseRequestID, c.GetString("userID")); !ok {
+			tx.Rollback()
+			return
+		}
+
+		if err := tx.Commit().Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao finalizar atualização"})
+			return
+		}
 		c.JSON(http.StatusOK, budget)
 	}
 }
@@ -77,25 +102,9 @@ func DeleteBudget(db *gorm.DB) gin.HandlerFunc {
 		fmt.Printf("  - URL: %s\n", c.Request.URL.Path)
 		fmt.Printf("  - User Agent: %s\n", c.Request.UserAgent())
 
-		// Debug dos dados extraídos pelo middleware
+		// Acesso controlado na rota (budgets:manage)
 		userID := c.GetString("userID")
-		role := c.GetString("role")
-
-		fmt.Printf("  - userID extraído: '%s'\n", userID)
-		fmt.Printf("  - role extraído: '%s'\n", role)
-		fmt.Printf("  - role type: %T\n", role)
-		fmt.Printf("  - role length: %d\n", len(role))
-		fmt.Printf("  - role == 'admin': %t\n", role == "admin")
-		fmt.Printf("  - role == 'requester': %t\n", role == "requester")
-
-		// Debug de todos os valores do contexto
-		fmt.Printf("  - Todos os valores do contexto:\n")
-		for key, value := range c.Keys {
-			fmt.Printf("    %s: %v (type: %T)\n", key, value, value)
-		}
-
-		// ✅ MUDANÇA: Permitir qualquer usuário autenticado
-		// (remover verificação de admin temporariamente)
+		fmt.Printf("  - userID: '%s'\n", userID)
 
 		budgetIDParam := c.Param("budgetID")
 		fmt.Printf("  - budgetID param: '%s'\n", budgetIDParam)
@@ -124,13 +133,33 @@ func DeleteBudget(db *gorm.DB) gin.HandlerFunc {
 
 		fmt.Printf("  - ✅ Orçamento encontrado: %+v\n", budget)
 
+		tx := db.Begin()
+		defer func() {
+			if r := recover(); r != nil {
+				tx.Rollback()
+			}
+		}()
+
 		// Remove o orçamento (soft delete)
-		if err := db.Delete(&budget).Error; err != nil {
+		if err := tx.Delete(&budget).Error; err != nil {
+			tx.Rollback()
 			fmt.Printf("  - ❌ Erro ao deletar orçamento: %v\n", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao deletar orçamento"})
 			return
 		}
 
+		// Sem o orçamento o preço de referência do item muda (verba reavaliada na mesma transação)
+		if _, ok := applyBudgetChange(c, tx, budget.PurchaseRequestID, userID); !ok {
+			tx.Rollback()
+			fmt.Printf("  - ❌ Verba da requisição %d não permite a exclusão\n", budget.PurchaseRequestID)
+			return
+		}
+
+		if err := tx.Commit().Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao finalizar exclusão"})
+			return
+		}
+
 		fmt.Printf("  - ✅ Orçamento deletado com sucesso: %d\n", budgetID)
 
 		// Retorna status 204 (No Content) para indicar sucesso
@@ -159,3 +188,338 @@ func ListRequestBudgets(db *gorm.DB) gin.HandlerFunc {
 		c.JSON(http.StatusOK, budgets)
 	}
 }
+
+// SelectBudget marca um orçamento como vencedor do item (desmarcando os demais)
+func SelectBudget(db *gorm.DB) gin.HandlerFunc {
+	type selectBudgetInput struct {
+		Justification string `json:"justification"`
+	}
+
+	return func(c *gin.Context) {
+		budgetID, err := strconv.ParseUint(c.Param("budgetID"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ID de orçamento inválido"})
+			return
+		}
+
+		// Corpo opcional: a justificativa só é exigida fora do menor preço
+		var input selectBudgetInput
+		if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var budget models.ItemBudget
+		if err := db.First(&budget, budgetID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Orçamento não encontrado"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar orçamento"})
+			}
+			return
+		}
+
+		// Justificativa obrigatória quando o orçamento escolhido não é o de menor preço
+		var lowestPrice float64
+		if err := db.Model(&models.ItemBudget{}).
+			Where("request_item_id = ?", budget.RequestItemID).
+			Select("MIN(unit_price)").
+			Scan(&lowestPrice).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao comparar orçamentos"})
+			return
+		}
+		if budget.UnitPrice > lowestPrice && strings.TrimSpace(input.Justification) == "" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":       "Justificativa é obrigatória quando o orçamento escolhido não é o de menor preço",
+				"lowestPrice": lowestPrice,
+			})
+			return
+		}
+
+		selectedBy, _ := strconv.ParseUint(c.GetString("userID"), 10, 64)
+		selectedByUint := uint(selectedBy)
+		now := time.Now()
+
+		tx := db.Begin()
+		defer func() {
+			if r := recover(); r != nil {
+				tx.Rollback()
+			}
+		}()
+
+		// Desmarca os demais orçamentos do mesmo item
+		if err := tx.Model(&models.ItemBudget{}).
+			Where("request_item_id = ? AND id <> ?", budget.RequestItemID, budget.ID).
+			Updates(map[string]interface{}{
+				"selected":                false,
+				"selection_justification": "",
+				"selected_by":             nil,
+				"selected_at":             nil,
+			}).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar orçamentos"})
+			return
+		}
+
+		budget.Selected = true
+		budget.SelectionJustification = strings.TrimSpace(input.Justification)
+		budget.SelectedBy = &selectedByUint
+		budget.SelectedAt = &now
+		if err := tx.Save(&budget).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao selecionar orçamento"})
+			return
+		}
+
+		// O empenho da verba segue o preço do orçamento selecionado
+		if _, ok := applyBudgetChange(c, tx, budget.PurchaseRequestID, c.GetString("userID")); !ok {
+			tx.Rollback()
+			return
+		}
+
+		if err := tx.Commit().Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao finalizar seleção"})
+			return
+		}
+
+		if err := db.Preload("Supplier").Preload("RequestItem").First(&budget, budget.ID).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao carregar orçamento"})
+			return
+		}
+
+		c.JSON(http.StatusOK, budget)
+	}
+}
+
+// budgetMatrixCell - Cotação de um fornecedor para um item (célula da matriz)
+type budgetMatrixCell struct {
+	BudgetID   uint    `json:"budgetId"`
+	UnitPrice  float64 `json:"unitPrice"`
+	TotalPrice float64 `json:"totalPrice"`
+	Selected   bool    `json:"selected"`
+	IsLowest   bool    `json:"isLowest"`
+}
+
+// budgetMatrixItem - Linha da matriz (um item da requisição)
+type budgetMatrixItem struct {
+	ItemID           uint                `json:"itemId"`
+	ProductName      string              `json:"productName"`
+	Unit             string              `json:"unit"`
+	Quantity         int                 `json:"quantity"`
+	Status           string              `json:"status"`
+	QuoteCount       int                 `json:"quoteCount"`
+	MeetsMinimum     bool                `json:"meetsMinimum"`
+	LowestPrice      *float64            `json:"lowestPrice"`
+	HighestPrice     *float64            `json:"highestPrice"`
+	Spread           *float64            `json:"spread"`
+	SpreadPercent    *float64            `json:"spreadPercent"`
+	SelectedBudgetID *uint               `json:"selectedBudgetId"`
+	Quotes           []*budgetMatrixCell `json:"quotes"` // alinhado com "suppliers"; null quando não há cotação
+}
+
+// budgetMatrixSupplier - Coluna da matriz (um fornecedor)
+type budgetMatrixSupplier struct {
+	SupplierID    uint    `json:"supplierId"`
+	SupplierName  string  `json:"supplierName"`
+	ItemsQuoted   int     `json:"itemsQuoted"`
+	Total         float64 `json:"total"`
+	LowestCount   int     `json:"lowestCount"`
+	SelectedCount int     `json:"selectedCount"`
+	SelectedTotal float64 `json:"selectedTotal"`
+}
+
+// GetRequestBudgetComparison retorna a matriz item × fornecedor dos orçamentos de uma requisição
+func GetRequestBudgetComparison(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		reqID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+			return
+		}
+
+		var request models.PurchaseRequest
+		if err := db.Preload("Items.Product").First(&request, reqID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Requisição não encontrada"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar requisição"})
+			}
+			return
+		}
+
+		// Sem permissão para ver todas, não é o dono nem gestor do setor: bloquear
+		if !canViewRequest(db, c, &request) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Acesso negado"})
+			return
+		}
+
+		var budgets []models.ItemBudget
+		if err := db.Where("purchase_request_id = ?", reqID).
+			Preload("Supplier").
+			Find(&budgets).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao listar orçamentos"})
+			return
+		}
+
+		settings, err := loadSystemSettings(db)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar configurações"})
+			return
+		}
+
+		c.JSON(http.StatusOK, buildBudgetComparison(request, budgets, settings.MinQuotesPerItem))
+	}
+}
+
+// buildBudgetComparison monta a matriz de comparação a partir dos itens e orçamentos
+func buildBudgetComparison(request models.PurchaseRequest, budgets []models.ItemBudget, minQuotes int) gin.H {
+	// Colunas: fornecedores ordenados por nome
+	supplierIndex := make(map[uint]int)
+	var suppliers []*budgetMatrixSupplier
+	for _, b := range budgets {
+		if _, ok := supplierIndex[b.SupplierID]; !ok {
+			supplierIndex[b.SupplierID] = len(suppliers)
+			suppliers = append(suppliers, &budgetMatrixSupplier{SupplierID: b.SupplierID, SupplierName: b.Supplier.Name})
+		}
+	}
+	sort.Slice(suppliers, func(i, j int) bool { return suppliers[i].SupplierName < suppliers[j].SupplierName })
+	for i, s := range suppliers {
+		supplierIndex[s.SupplierID] = i
+	}
+
+	budgetsByItem := make(map[uint][]models.ItemBudget)
+	for _, b := range budgets {
+		budgetsByItem[b.RequestItemID] = append(budgetsByItem[b.RequestItemID], b)
+	}
+
+	var items []budgetMatrixItem
+	itemsBelowMinimum := 0
+	for _, item := range request.Items {
+		row := budgetMatrixItem{
+			ItemID:      item.ID,
+			ProductName: item.Product.Name,
+			Unit:        item.Product.Unit,
+			Quantity:    item.Quantity,
+			Status:      item.Status,
+			Quotes:      make([]*budgetMatrixCell, len(suppliers)),
+		}
+
+		lowest, highest := math.MaxFloat64, 0.0
+		for _, b := range budgetsByItem[item.ID] {
+			if b.UnitPrice < lowest {
+				lowest = b.UnitPrice
+			}
+			if b.UnitPrice > highest {
+				highest = b.UnitPrice
+			}
+		}
+
+		for _, b := range budgetsByItem[item.ID] {
+			col := supplierIndex[b.SupplierID]
+			cell := &budgetMatrixCell{
+				BudgetID:   b.ID,
+				UnitPrice:  b.UnitPrice,
+				TotalPrice: b.UnitPrice * float64(item.Quantity),
+				Selected:   b.Selected,
+				IsLowest:   b.UnitPrice == lowest,
+			}
+			// Mais de uma cotação do mesmo fornecedor: mantém a de menor preço
+			if existing := row.Quotes[col]; existing == nil || (!existing.Selected && (cell.Selected || cell.UnitPrice < existing.UnitPrice)) {
+				row.Quotes[col] = cell
+			}
+			row.QuoteCount++
+
+			supplier := suppliers[col]
+			if b.Selected {
+				budgetID := b.ID
+				row.SelectedBudgetID = &budgetID
+				supplier.SelectedCount++
+				supplier.SelectedTotal += cell.TotalPrice
+			}
+		}
+
+		for col, cell := range row.Quotes {
+			if cell == nil {
+				continue
+			}
+			suppliers[col].ItemsQuoted++
+			suppliers[col].Total += cell.TotalPrice
+			if cell.IsLowest {
+				suppliers[col].LowestCount++
+			}
+		}
+
+		if row.QuoteCount > 0 {
+			spread := highest - lowest
+			row.LowestPrice = &lowest
+			row.HighestPrice = &highest
+			row.Spread = &spread
+			if lowest > 0 {
+				spreadPercent := spread / lowest * 100
+				row.SpreadPercent = &spreadPercent
+			}
+		}
+		row.MeetsMinimum = row.QuoteCount >= minQuotes
+		if !row.MeetsMinimum && item.Status != "rejected" {
+			itemsBelowMinimum++
+		}
+
+		items = append(items, row)
+	}
+
+	return gin.H{
+		"requestId":         request.ID,
+		"minQuotesRequired": minQuotes,
+		"itemsBelowMinimum": itemsBelowMinimum,
+		"suppliers":         suppliers,
+		"items":             items,
+	}
+}
+
+// quoteShortfall - Item que ainda não atingiu o número mínimo de orçamentos
+type quoteShortfall struct {
+	ItemID      uint   `json:"itemId"`
+	ProductName string `json:"productName"`
+	QuoteCount  int    `json:"quoteCount"`
+	Required    int    `json:"required"`
+}
+
+// checkMinimumQuotes verifica se os itens não rejeitados da requisição têm o mínimo de orçamentos configurado
+func checkMinimumQuotes(db *gorm.DB, requestID uint) ([]quoteShortfall, error) {
+	settings, err := loadSystemSettings(db)
+	if err != nil {
+		return nil, err
+	}
+	if settings.MinQuotesPerItem <= 0 {
+		return nil, nil
+	}
+
+	var shortfalls []quoteShortfall
+	err = db.Table("request_items ri").
+		Select(`
+			ri.id as item_id,
+			p.name as product_name,
+			COUNT(ib.id) as quote_count,
+			? as required
+		`, settings.MinQuotesPerItem).
+		Joins("LEFT JOIN products p ON p.id = ri.product_id").
+		Joins("LEFT JOIN item_budgets ib ON ib.request_item_id = ri.id AND ib.deleted_at IS NULL").
+		Where("ri.purchase_request_id = ? AND ri.deleted_at IS NULL AND ri.status <> ?", requestID, "rejected").
+		Group("ri.id, p.name").
+		Having("COUNT(ib.id) < ?", settings.MinQuotesPerItem).
+		Scan(&shortfalls).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return shortfalls, nil
+}
+
+// minimumQuotesError monta a resposta padrão quando faltam orçamentos
+func minimumQuotesError(shortfalls []quoteShortfall) gin.H {
+	return gin.H{
+		"error": fmt.Sprintf("Requisição não pode ser aprovada: %d item(ns) sem o número mínimo de orçamentos (%d)",
+			len(shortfalls), shortfalls[0].Required),
+		"itemsBelowMinimum": shortfalls,
+	}
+}
@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/rbac"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// fakeDB - Banco roteirizado para testes de handlers sem PostgreSQL: cada consulta
+// devolve as linhas da primeira regra cujo trecho aparece no SQL (ou nenhuma linha)
+// e todos os comandos ficam registrados para as verificações do teste
+type fakeDB struct {
+	mu         sync.Mutex
+	rules      []fakeRule
+	statements []string
+}
+
+type fakeRule struct {
+	match   string
+	columns []string
+	rows    [][]driver.Value
+	once    bool // descartada depois da primeira consulta
+}
+
+// newFakeDB cria o banco roteirizado e o *gorm.DB (dialeto PostgreSQL) sobre ele
+func newFakeDB(t *testing.T) (*fakeDB, *gorm.DB) {
+	t.Helper()
+	fake := &fakeDB{}
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sql.OpenDB(fake)}), &gorm.Config{
+		Logger: logger.Discard,
+	})
+	if err != nil {
+		t.Fatalf("gorm.Open: %v", err)
+	}
+	return fake, db
+}
+
+// on devolve rows (na ordem de columns) para as consultas que contêm match
+func (f *fakeDB) on(match string, columns []string, rows ...[]driver.Value) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rules = append(f.rules, fakeRule{match: match, columns: columns, rows: rows})
+}
+
+// onOnce é como on, mas vale só para a primeira consulta; as seguintes seguem as outras regras
+func (f *fakeDB) onOnce(match string, columns []string, rows ...[]driver.Value) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rules = append(f.rules, fakeRule{match: match, columns: columns, rows: rows, once: true})
+}
+
+// executed indica se algum comando executado contém todos os trechos
+func (f *fakeDB) executed(parts ...string) bool {
+	return f.index(parts...) >= 0
+}
+
+// index devolve a posição do primeiro comando executado que contém todos os trechos (ou -1)
+func (f *fakeDB) index(parts ...string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+next:
+	for i, statement := range f.statements {
+		for _, part := range parts {
+			if !strings.Contains(statement, part) {
+				continue next
+			}
+		}
+		return i
+	}
+	return -1
+}
+
+func (f *fakeDB) record(query string) *fakeRule {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.statements = append(f.statements, query)
+	for i, rule := range f.rules {
+		if strings.Contains(query, rule.match) {
+			if rule.once {
+				f.rules = append(f.rules[:i:i], f.rules[i+1:]...)
+			}
+			return &rule
+		}
+	}
+	return nil
+}
+
+// driver.Connector
+func (f *fakeDB) Connect(context.Context) (driver.Conn, error) { return &fakeConn{db: f}, nil }
+func (f *fakeDB) Driver() driver.Driver                        { return fakeDriver{} }
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) { return nil, driver.ErrSkip }
+
+type fakeConn struct{ db *fakeDB }
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{db: c.db, query: query}, nil
+}
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return fakeTx{}, nil }
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	return newFakeRows(c.db.record(query)), nil
+}
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	c.db.record(query)
+	return driver.RowsAffected(1), nil
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeStmt struct {
+	db    *fakeDB
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+func (s *fakeStmt) Exec([]driver.Value) (driver.Result, error) {
+	s.db.record(s.query)
+	return driver.RowsAffected(1), nil
+}
+func (s *fakeStmt) Query([]driver.Value) (driver.Rows, error) {
+	return newFakeRows(s.db.record(s.query)), nil
+}
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func newFakeRows(rule *fakeRule) *fakeRows {
+	if rule == nil {
+		return &fakeRows{}
+	}
+	return &fakeRows{columns: rule.columns, rows: rule.rows}
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+// performRequest chama o handler como um usuário autenticado com as permissões informadas
+func performRequest(handler gin.HandlerFunc, method, target, body string, params gin.Params, role string, permissions ...string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(method, target, strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Params = params
+
+	granted := map[string]bool{}
+	for _, permission := range permissions {
+		granted[permission] = true
+	}
+	c.Set("userID", "7")
+	c.Set("role", role)
+	c.Set(rbac.ContextKey, granted)
+
+	handler(c)
+	return w
+}
+
+// decodeBody lê a resposta JSON do handler
+func decodeBody(t *testing.T, w *httptest.ResponseRecorder) map[string]interface{} {
+	t.Helper()
+	var body map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("resposta não é JSON (%d): %s", w.Code, w.Body.String())
+	}
+	return body
+}
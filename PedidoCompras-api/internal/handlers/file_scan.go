@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/models"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/notifications"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/preview"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/rbac"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/scanner"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/storage"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/utils"
+)
+
+// scanStoredFile verifica o arquivo recém-gravado de um anexo e registra o resultado.
+// Se o scanner falhar, o arquivo continua em quarentena e é verificado
+// novamente por StartQuarantineScanJob. Devolve a situação final e a ameaça encontrada.
+func scanStoredFile(ctx context.Context, db *gorm.DB, store storage.Storage, scan scanner.Scanner, attachmentID uint, key string) (string, string) {
+	result, err := scanner.ScanStored(ctx, scan, store, key)
+	if err != nil {
+		fmt.Printf("❌ Erro ao verificar %s (anexo %d): %v\n", key, attachmentID, err)
+		return models.ScanStatusQuarantined, ""
+	}
+
+	status := models.ScanStatusClean
+	if result.Infected {
+		status = models.ScanStatusInfected
+	}
+	// a chave no filtro evita gravar o resultado se o arquivo foi substituído durante a verificação
+	if err := db.Model(&models.Attachment{}).
+		Where("id = ? AND file_path = ?", attachmentID, key).
+		Updates(map[string]interface{}{
+			"scan_status":    status,
+			"scan_signature": result.Signature,
+		}).Error; err != nil {
+		fmt.Printf("❌ Erro ao gravar verificação de %s (anexo %d): %v\n", key, attachmentID, err)
+		return models.ScanStatusQuarantined, ""
+	}
+
+	if result.Infected {
+		notifyInfectedFile(db, attachmentID, result.Signature)
+	}
+	return status, result.Signature
+}
+
+// notifyInfectedFile avisa os usuários conectados com security:manage sobre o arquivo bloqueado
+func notifyInfectedFile(db *gorm.DB, attachmentID uint, signature string) {
+	userIDs, err := rbac.UserIDs(db, models.PermSecurityManage)
+	if err != nil {
+		fmt.Printf("❌ Erro ao buscar destinatários do aviso de malware: %v\n", err)
+		return
+	}
+	if len(userIDs) == 0 {
+		return
+	}
+
+	targets := make([]string, len(userIDs))
+	for i, userID := range userIDs {
+		targets[i] = utils.UintToString(userID)
+	}
+	notifications.Publish(fmt.Sprintf("malware-detected:attachment:%d:%s", attachmentID, signature), targets...)
+}
+
+// checkScanStatus bloqueia o download de arquivos não verificados ou infectados
+// (responde 409/403 e devolve false)
+func checkScanStatus(c *gin.Context, status string) bool {
+	switch status {
+	case models.ScanStatusClean:
+		return true
+	case models.ScanStatusInfected:
+		c.JSON(http.StatusForbidden, gin.H{"error": "Arquivo bloqueado: malware detectado"})
+	default:
+		c.JSON(http.StatusConflict, gin.H{"error": "Arquivo em verificação antivírus. Tente novamente em instantes"})
+	}
+	return false
+}
+
+// RescanFiles verifica os anexos em quarentena (ou todos, com all) e devolve
+// quantos foram verificados, quantos estão infectados e quantos falharam
+func RescanFiles(ctx context.Context, db *gorm.DB, store storage.Storage, scan scanner.Scanner, all bool) (scanned, infected, failed int, err error) {
+	query := db.Model(&models.Attachment{}).Select("id, file_path")
+	if !all {
+		query = query.Where("scan_status = ?", models.ScanStatusQuarantined)
+	}
+
+	var attachments []models.Attachment
+	if err := query.Order("id").Find(&attachments).Error; err != nil {
+		return 0, 0, 0, err
+	}
+
+	for _, attachment := range attachments {
+		status, _ := scanStoredFile(ctx, db, store, scan, attachment.ID, attachment.FilePath)
+		switch status {
+		case models.ScanStatusQuarantined:
+			failed++
+		case models.ScanStatusInfected:
+			infected++
+			scanned++
+		default:
+			scanned++
+		}
+	}
+	return scanned, infected, failed, nil
+}
+
+// StartQuarantineScanJob verifica novamente, na inicialização e a cada intervalo, os
+// arquivos em quarentena (scanner indisponível no upload ou arquivos anteriores à
+// verificação) e gera a pré-visualização dos que foram liberados
+func StartQuarantineScanJob(db *gorm.DB, store storage.Storage, scan scanner.Scanner, previews *preview.Generator, interval time.Duration) {
+	run := func() {
+		scanned, infected, failed, err := RescanFiles(context.Background(), db, store, scan, false)
+		if err != nil {
+			fmt.Printf("❌ Erro ao verificar arquivos em quarentena: %v\n", err)
+		} else if scanned+failed > 0 {
+			fmt.Printf("🛡️  Quarentena: %d arquivo(s) verificado(s), %d infectado(s), %d pendente(s)\n", scanned, infected, failed)
+		}
+		if scanned > infected {
+			if _, _, err := GenerateAttachmentPreviews(context.Background(), db, store, previews, false); err != nil {
+				fmt.Printf("❌ Erro ao gerar pré-visualizações dos arquivos liberados: %v\n", err)
+			}
+		}
+	}
+
+	go func() {
+		run()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			run()
+		}
+	}()
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/models"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/rbac"
 	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/utils"
 	"gorm.io/gorm"
 )
@@ -14,8 +15,6 @@ import (
 func ListItemsForRequest(databaseConnection *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		requestID := c.Param("id")
-		userRole := c.GetString("role")
-		userID := c.GetString("userID")
 
 		// Verifica se o usuário pode acessar essa requisição
 		var requisicao models.PurchaseRequest
@@ -28,8 +27,8 @@ func ListItemsForRequest(databaseConnection *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
-		// Se não admin e não dono, bloquear
-		if userRole != "admin" && utils.UintToString(requisicao.RequesterID) != userID {
+		// Sem permissão para ver todas, não é o dono nem gestor do setor: bloquear
+		if !canViewRequest(databaseConnection, c, &requisicao) {
 			c.JSON(http.StatusForbidden, gin.H{"error": "Acesso negado"})
 			return
 		}
@@ -47,9 +46,10 @@ func ListItemsForRequest(databaseConnection *gorm.DB) gin.HandlerFunc {
 }
 
 type createItemInput struct {
-	ProductID uint       `json:"productId" binding:"required"`
-	Quantity  int        `json:"quantity" binding:"required,min=1"`
-	Deadline  *time.Time `json:"deadline"`
+	ProductID          uint       `json:"productId" binding:"required"`
+	Quantity           int        `json:"quantity" binding:"required,min=1"`
+	Deadline           *time.Time `json:"deadline"`
+	EstimatedUnitPrice *float64   `json:"estimatedUnitPrice" binding:"omitempty,gt=0"` // padrão: último preço de compra
 }
 
 // CreateItem adiciona um novo item à requisição existente.
@@ -115,11 +115,15 @@ func CreateItem(databaseConnection *gorm.DB) gin.HandlerFunc {
 		}
 
 		novoItem := models.RequestItem{
-			PurchaseRequestID: utils.ParseUint(requestID),
-			ProductID:         dados.ProductID,
-			Quantity:          dados.Quantity,
-			Status:            "pending",
-			Deadline:          dados.Deadline,
+			PurchaseRequestID:  utils.ParseUint(requestID),
+			ProductID:          dados.ProductID,
+			Quantity:           dados.Quantity,
+			Status:             "pending",
+			Deadline:           dados.Deadline,
+			EstimatedUnitPrice: dados.EstimatedUnitPrice,
+		}
+		if novoItem.EstimatedUnitPrice == nil {
+			novoItem.EstimatedUnitPrice = lastPurchasePrice(databaseConnection, dados.ProductID)
 		}
 
 		if result := databaseConnection.Create(&novoItem); result.Error != nil {
@@ -138,16 +142,16 @@ func CreateItem(databaseConnection *gorm.DB) gin.HandlerFunc {
 }
 
 type updateItemInput struct {
-	Quantity   *int       `json:"quantity" binding:"omitempty,min=1"`
-	Deadline   *time.Time `json:"deadline"`
-	AdminNotes *string    `json:"adminNotes"` // apenas admin pode alterar
+	Quantity           *int       `json:"quantity" binding:"omitempty,min=1"`
+	Deadline           *time.Time `json:"deadline"`
+	AdminNotes         *string    `json:"adminNotes"` // apenas com requests:review
+	EstimatedUnitPrice *float64   `json:"estimatedUnitPrice" binding:"omitempty,gt=0"`
 }
 
 // UpdateItem altera campos de um item específico.
 func UpdateItem(databaseConnection *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		itemID := c.Param("itemId")
-		userRole := c.GetString("role")
 		userID := c.GetString("userID")
 
 		var dados updateItemInput
@@ -167,7 +171,7 @@ func UpdateItem(databaseConnection *gorm.DB) gin.HandlerFunc {
 		}
 
 		// Verifica permissões
-		if userRole != "admin" {
+		if !rbac.Has(c, models.PermRequestsReview) {
 			// Usuário comum só pode alterar itens da própria requisição
 			if utils.UintToString(item.PurchaseRequest.RequesterID) != userID {
 				c.JSON(http.StatusForbidden, gin.H{"error": "Acesso negado"})
@@ -182,7 +186,7 @@ func UpdateItem(databaseConnection *gorm.DB) gin.HandlerFunc {
 
 			// Usuário comum não pode alterar adminNotes
 			if dados.AdminNotes != nil {
-				c.JSON(http.StatusForbidden, gin.H{"error": "Apenas administradores podem alterar notas administrativas"})
+				c.JSON(http.StatusForbidden, gin.H{"error": "Sem permissão para alterar notas administrativas"})
 				return
 			}
 		}
@@ -194,15 +198,38 @@ func UpdateItem(databaseConnection *gorm.DB) gin.HandlerFunc {
 		if dados.Deadline != nil {
 			item.Deadline = dados.Deadline
 		}
-		if dados.AdminNotes != nil && userRole == "admin" {
+		if dados.EstimatedUnitPrice != nil {
+			item.EstimatedUnitPrice = dados.EstimatedUnitPrice
+		}
+		if dados.AdminNotes != nil && rbac.Has(c, models.PermRequestsReview) {
 			item.AdminNotes = *dados.AdminNotes
 		}
 
-		if result := databaseConnection.Save(&item); result.Error != nil {
+		tx := databaseConnection.Begin()
+		defer func() {
+			if r := recover(); r != nil {
+				tx.Rollback()
+			}
+		}()
+
+		if err := tx.Omit("PurchaseRequest").Save(&item).Error; err != nil {
+			tx.Rollback()
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar item"})
 			return
 		}
 
+		// Mudança de quantidade ou preço ajusta o empenho da verba em requisições aprovadas
+		// (respeitando o bloqueio da política de verba)
+		if _, ok := applyBudgetChange(c, tx, item.PurchaseRequestID, userID); !ok {
+			tx.Rollback()
+			return
+		}
+
+		if err := tx.Commit().Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao finalizar atualização"})
+			return
+		}
+
 		// Carrega o item atualizado com relacionamentos
 		if err := databaseConnection.Preload("Product").Preload("PurchaseRequest").First(&item, itemID).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao carregar item atualizado"})
@@ -217,7 +244,6 @@ func UpdateItem(databaseConnection *gorm.DB) gin.HandlerFunc {
 func DeleteItem(databaseConnection *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		itemID := c.Param("itemId")
-		userRole := c.GetString("role")
 		userID := c.GetString("userID")
 
 		var item models.RequestItem
@@ -231,7 +257,7 @@ func DeleteItem(databaseConnection *gorm.DB) gin.HandlerFunc {
 		}
 
 		// Verifica permissões
-		if userRole != "admin" {
+		if !rbac.Has(c, models.PermRequestsReview) {
 			// Usuário comum só pode deletar itens da própria requisição
 			if utils.UintToString(item.PurchaseRequest.RequesterID) != userID {
 				c.JSON(http.StatusForbidden, gin.H{"error": "Acesso negado"})
@@ -245,11 +271,28 @@ func DeleteItem(databaseConnection *gorm.DB) gin.HandlerFunc {
 			}
 		}
 
-		// Remove o item
-		if result := databaseConnection.Delete(&item); result.Error != nil {
+		tx := databaseConnection.Begin()
+		defer func() {
+			if r := recover(); r != nil {
+				tx.Rollback()
+			}
+		}()
+
+		// Remove o item e estorna o empenho dele
+		if err := tx.Delete(&item).Error; err != nil {
+			tx.Rollback()
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao deletar item"})
 			return
 		}
+		if _, ok := applyBudgetChange(c, tx, item.PurchaseRequestID, userID); !ok {
+			tx.Rollback()
+			return
+		}
+
+		if err := tx.Commit().Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao finalizar exclusão"})
+			return
+		}
 
 		c.Status(http.StatusNoContent)
 	}
@@ -259,8 +302,6 @@ func DeleteItem(databaseConnection *gorm.DB) gin.HandlerFunc {
 func GetItem(databaseConnection *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		itemID := c.Param("itemId")
-		userRole := c.GetString("role")
-		userID := c.GetString("userID")
 
 		var item models.RequestItem
 		if result := databaseConnection.
@@ -276,7 +317,7 @@ func GetItem(databaseConnection *gorm.DB) gin.HandlerFunc {
 		}
 
 		// Verifica permissões de acesso
-		if userRole != "admin" && utils.UintToString(item.PurchaseRequest.RequesterID) != userID {
+		if !canViewRequest(databaseConnection, c, &item.PurchaseRequest) {
 			c.JSON(http.StatusForbidden, gin.H{"error": "Acesso negado"})
 			return
 		}
@@ -0,0 +1,286 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/models"
+	"gorm.io/gorm"
+)
+
+// Limites da paginação das listagens
+const (
+	listDefaultPageSize = 50
+	listMaxPageSize     = 200
+)
+
+// listSpec - O que uma listagem aceita: campos de ordenação (nome na API -> coluna),
+// ordenação padrão, expressões da busca textual (q) e coluna do intervalo de datas
+type listSpec struct {
+	Table         string
+	SortFields    map[string]string
+	DefaultSort   string
+	SearchColumns []string
+	DateColumn    string
+}
+
+// listQuery - Parâmetros comuns das listagens:
+//
+//	page, pageSize         paginação por página (pageSize até 200)
+//	cursor                 paginação por cursor (ID do último registro, 0 para começar; ordenação apenas por id)
+//	sort                   campos separados por vírgula, "-" para decrescente (ex: -createdAt,id)
+//	q                      busca textual
+//	startDate, endDate     intervalo de datas (AAAA-MM-DD, inclusivo)
+//
+// Sem page, pageSize nem cursor a resposta continua sendo a lista pura (compatibilidade), limitada
+// à primeira página de 50 registros; o total vai no cabeçalho X-Total-Count.
+type listQuery struct {
+	spec      listSpec
+	Page      int
+	PageSize  int
+	Cursor    *uint
+	Order     []string
+	Search    string
+	StartDate *time.Time
+	EndDate   *time.Time
+	Paginated bool
+
+	cursorDesc bool
+}
+
+// parseListQuery lê os parâmetros comuns; responde 400 e retorna false quando inválidos
+func parseListQuery(c *gin.Context, spec listSpec) (*listQuery, bool) {
+	lq := &listQuery{spec: spec, Page: 1, PageSize: listDefaultPageSize}
+
+	if raw := c.Query("page"); raw != "" {
+		page, err := strconv.Atoi(raw)
+		if err != nil || page < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "page inválido"})
+			return nil, false
+		}
+		lq.Page = page
+		lq.Paginated = true
+	}
+	if raw := c.Query("pageSize"); raw != "" {
+		pageSize, err := strconv.Atoi(raw)
+		if err != nil || pageSize < 1 || pageSize > listMaxPageSize {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "pageSize deve estar entre 1 e 200"})
+			return nil, false
+		}
+		lq.PageSize = pageSize
+		lq.Paginated = true
+	}
+	if raw := c.Query("cursor"); raw != "" {
+		cursor, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "cursor inválido"})
+			return nil, false
+		}
+		if c.Query("page") != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Use page ou cursor, não ambos"})
+			return nil, false
+		}
+		id := uint(cursor)
+		lq.Cursor = &id
+		lq.Paginated = true
+	}
+
+	sortParam := c.Query("sort")
+	if sortParam == "" {
+		sortParam = spec.DefaultSort
+	}
+	sortedByID := false
+	for i, field := range strings.Split(sortParam, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		direction := "ASC"
+		if strings.HasPrefix(field, "-") {
+			direction = "DESC"
+			field = field[1:]
+		}
+		column, ok := spec.SortFields[field]
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":       "Campo de ordenação inválido: " + field,
+				"allowedSort": allowedSortFields(spec),
+			})
+			return nil, false
+		}
+		lq.Order = append(lq.Order, column+" "+direction)
+		if field == "id" {
+			sortedByID = i == 0
+			lq.cursorDesc = direction == "DESC"
+		}
+	}
+
+	// Cursor exige ordenação somente por id (o cursor é o ID do último registro da página)
+	if lq.Cursor != nil {
+		if c.Query("sort") != "" && (!sortedByID || len(lq.Order) != 1) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "cursor só pode ser usado com sort=id ou sort=-id"})
+			return nil, false
+		}
+		if c.Query("sort") == "" {
+			lq.Order = []string{spec.Table + ".id DESC"}
+			lq.cursorDesc = true
+			sortedByID = true
+		}
+	}
+	// Desempate estável pelo ID
+	if !sortedByID {
+		lq.Order = append(lq.Order, spec.Table+".id DESC")
+	}
+
+	lq.Search = strings.TrimSpace(c.Query("q"))
+
+	if raw := c.Query("startDate"); raw != "" {
+		startDate, err := time.Parse("2006-01-02", raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Data de início inválida"})
+			return nil, false
+		}
+		lq.StartDate = &startDate
+	}
+	if raw := c.Query("endDate"); raw != "" {
+		endDate, err := time.Parse("2006-01-02", raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Data de fim inválida"})
+			return nil, false
+		}
+		lq.EndDate = &endDate
+	}
+
+	return lq, true
+}
+
+// allowedSortFields lista os campos de ordenação aceitos (para a mensagem de erro)
+func allowedSortFields(spec listSpec) []string {
+	fields := make([]string, 0, len(spec.SortFields))
+	for field := range spec.SortFields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// filter aplica a busca textual e o intervalo de datas
+func (lq *listQuery) filter(query *gorm.DB) *gorm.DB {
+	if lq.Search != "" && len(lq.spec.SearchColumns) > 0 {
+		term := "%" + strings.ToLower(lq.Search) + "%"
+		conditions := make([]string, 0, len(lq.spec.SearchColumns)+1)
+		args := make([]interface{}, 0, len(lq.spec.SearchColumns)+1)
+		for _, column := range lq.spec.SearchColumns {
+			conditions = append(conditions, "LOWER("+column+") LIKE ?")
+			args = append(args, term)
+		}
+		// Busca por número também encontra o registro pelo ID
+		if id, err := strconv.ParseUint(lq.Search, 10, 32); err == nil {
+			conditions = append(conditions, lq.spec.Table+".id = ?")
+			args = append(args, id)
+		}
+		query = query.Where("("+strings.Join(conditions, " OR ")+")", args...)
+	}
+	if lq.spec.DateColumn != "" {
+		if lq.StartDate != nil {
+			query = query.Where(lq.spec.DateColumn+" >= ?", *lq.StartDate)
+		}
+		if lq.EndDate != nil {
+			query = query.Where(lq.spec.DateColumn+" < ?", lq.EndDate.AddDate(0, 0, 1))
+		}
+	}
+	return query
+}
+
+// find ordena, pagina e carrega os registros em dest com os preloads informados.
+// A página é resolvida primeiro só com os IDs, para que os preloads afetem apenas ela.
+func (lq *listQuery) find(query *gorm.DB, dest interface{}, preloads ...string) (models.PaginationInfo, error) {
+	info := models.PaginationInfo{Page: lq.Page, PageSize: lq.PageSize}
+	order := strings.Join(lq.Order, ", ")
+	base := lq.filter(query).Session(&gorm.Session{})
+	withPreloads := func(tx *gorm.DB) *gorm.DB {
+		for _, preload := range preloads {
+			tx = tx.Preload(preload)
+		}
+		return tx
+	}
+
+	var total int64
+	if err := base.Count(&total).Error; err != nil {
+		return info, err
+	}
+	info.TotalItems = int(total)
+	info.TotalPages = int(math.Ceil(float64(total) / float64(lq.PageSize)))
+
+	page := base.Order(order)
+	if lq.Cursor != nil {
+		info.Page = 0
+		if *lq.Cursor == 0 {
+			// cursor=0 inicia a navegação pela primeira página
+		} else if lq.cursorDesc {
+			page = page.Where(lq.spec.Table+".id < ?", *lq.Cursor)
+		} else {
+			page = page.Where(lq.spec.Table+".id > ?", *lq.Cursor)
+		}
+	} else {
+		page = page.Offset((lq.Page - 1) * lq.PageSize)
+	}
+
+	var ids []uint
+	if err := page.Limit(lq.PageSize+1).Pluck(lq.spec.Table+".id", &ids).Error; err != nil {
+		return info, err
+	}
+	if len(ids) > lq.PageSize {
+		ids = ids[:lq.PageSize]
+		if lq.Cursor != nil {
+			info.NextCursor = strconv.FormatUint(uint64(ids[len(ids)-1]), 10)
+		}
+	}
+	load := query.Session(&gorm.Session{NewDB: true}).Scopes(withPreloads).Order(order)
+	if len(ids) == 0 {
+		return info, load.Where("1 = 0").Find(dest).Error
+	}
+	return info, load.Where(lq.spec.Table+".id IN ?", ids).Find(dest).Error
+}
+
+// respondList responde a lista no envelope {chave, pagination} ou, sem paginação pedida, a lista pura
+// (primeira página) com o total no cabeçalho X-Total-Count
+func respondList(c *gin.Context, lq *listQuery, key string, items interface{}, info models.PaginationInfo) {
+	if !lq.Paginated {
+		c.Header("X-Total-Count", strconv.Itoa(info.TotalItems))
+		c.JSON(http.StatusOK, items)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{key: items, "pagination": info})
+}
+
+// queryUintParam lê um filtro numérico opcional da query; responde 400 quando inválido
+func queryUintParam(c *gin.Context, name string) (*uint, bool) {
+	raw := c.Query(name)
+	if raw == "" {
+		return nil, true
+	}
+	value, err := strconv.ParseUint(raw, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": name + " inválido"})
+		return nil, false
+	}
+	id := uint(value)
+	return &id, true
+}
+
+// queryListParam lê um filtro de múltiplos valores separados por vírgula (ex: status=pending,approved)
+func queryListParam(c *gin.Context, name string) []string {
+	var values []string
+	for _, v := range strings.Split(c.Query(name), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
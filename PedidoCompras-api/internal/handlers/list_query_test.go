@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+var testListSpec = listSpec{
+	Table: "suppliers",
+	SortFields: map[string]string{
+		"id":        "suppliers.id",
+		"name":      "suppliers.name",
+		"createdAt": "suppliers.created_at",
+	},
+	DefaultSort: "name",
+}
+
+func TestParseListQuery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name      string
+		query     string
+		wantErr   string // trecho da mensagem de erro; vazio quando válido
+		order     string
+		page      int
+		pageSize  int
+		cursor    int // -1 sem cursor
+		paginated bool
+	}{
+		{name: "padrão", order: "suppliers.name ASC,suppliers.id DESC", page: 1, pageSize: 50, cursor: -1},
+		{name: "página", query: "page=3&pageSize=20", order: "suppliers.name ASC,suppliers.id DESC", page: 3, pageSize: 20, cursor: -1, paginated: true},
+		{name: "só pageSize", query: "pageSize=200", order: "suppliers.name ASC,suppliers.id DESC", page: 1, pageSize: 200, cursor: -1, paginated: true},
+		{name: "ordenação composta", query: "sort=-createdAt,%20name", order: "suppliers.created_at DESC,suppliers.name ASC,suppliers.id DESC", page: 1, pageSize: 50, cursor: -1},
+		{name: "id primeiro dispensa desempate", query: "sort=-id,name", order: "suppliers.id DESC,suppliers.name ASC", page: 1, pageSize: 50, cursor: -1},
+		{name: "id depois desempata de novo", query: "sort=name,id", order: "suppliers.name ASC,suppliers.id ASC,suppliers.id DESC", page: 1, pageSize: 50, cursor: -1},
+		{name: "cursor sem sort usa -id", query: "cursor=0", order: "suppliers.id DESC", page: 1, pageSize: 50, cursor: 0, paginated: true},
+		{name: "cursor com sort=id", query: "cursor=120&sort=id", order: "suppliers.id ASC", page: 1, pageSize: 50, cursor: 120, paginated: true},
+		{name: "cursor com sort=-id", query: "cursor=120&sort=-id&pageSize=10", order: "suppliers.id DESC", page: 1, pageSize: 10, cursor: 120, paginated: true},
+
+		{name: "campo fora da lista", query: "sort=password", wantErr: "Campo de ordenação inválido: password"},
+		{name: "injeção na ordenação", query: "sort=name%3BDROP%20TABLE%20users", wantErr: "Campo de ordenação inválido"},
+		{name: "page zero", query: "page=0", wantErr: "page inválido"},
+		{name: "page não numérico", query: "page=abc", wantErr: "page inválido"},
+		{name: "pageSize acima do limite", query: "pageSize=201", wantErr: "pageSize deve estar entre 1 e 200"},
+		{name: "pageSize zero", query: "pageSize=0", wantErr: "pageSize deve estar entre 1 e 200"},
+		{name: "cursor negativo", query: "cursor=-1", wantErr: "cursor inválido"},
+		{name: "cursor com page", query: "cursor=10&page=2", wantErr: "Use page ou cursor, não ambos"},
+		{name: "cursor com outra ordenação", query: "cursor=10&sort=name", wantErr: "cursor só pode ser usado com sort=id ou sort=-id"},
+		{name: "cursor com id e outro campo", query: "cursor=10&sort=id,name", wantErr: "cursor só pode ser usado com sort=id ou sort=-id"},
+		{name: "data inválida", query: "startDate=2025-13-01", wantErr: "Data de início inválida"},
+		{name: "data fim inválida", query: "endDate=01/02/2025", wantErr: "Data de fim inválida"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/suppliers?"+tt.query, nil)
+
+			lq, ok := parseListQuery(c, testListSpec)
+
+			if tt.wantErr != "" {
+				if ok {
+					t.Fatalf("aceitou %q: %+v", tt.query, lq)
+				}
+				if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), tt.wantErr) {
+					t.Errorf("resposta %d %s, want 400 com %q", w.Code, w.Body.String(), tt.wantErr)
+				}
+				return
+			}
+
+			if !ok {
+				t.Fatalf("rejeitou %q: %d %s", tt.query, w.Code, w.Body.String())
+			}
+			if got := strings.Join(lq.Order, ","); got != tt.order {
+				t.Errorf("Order = %q, want %q", got, tt.order)
+			}
+			if lq.Page != tt.page || lq.PageSize != tt.pageSize || lq.Paginated != tt.paginated {
+				t.Errorf("page=%d pageSize=%d paginated=%v, want %d %d %v", lq.Page, lq.PageSize, lq.Paginated, tt.page, tt.pageSize, tt.paginated)
+			}
+			switch {
+			case tt.cursor < 0 && lq.Cursor != nil:
+				t.Errorf("Cursor = %d, want nenhum", *lq.Cursor)
+			case tt.cursor >= 0 && (lq.Cursor == nil || *lq.Cursor != uint(tt.cursor)):
+				t.Errorf("Cursor = %v, want %d", lq.Cursor, tt.cursor)
+			}
+		})
+	}
+}
+
+func TestParseListQueryFilters(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/suppliers?q=%20papel%20&startDate=2025-01-01&endDate=2025-01-31", nil)
+
+	lq, ok := parseListQuery(c, testListSpec)
+	if !ok {
+		t.Fatalf("rejeitou: %s", w.Body.String())
+	}
+	if lq.Search != "papel" {
+		t.Errorf("Search = %q", lq.Search)
+	}
+	if lq.StartDate == nil || lq.StartDate.Format("2006-01-02") != "2025-01-01" {
+		t.Errorf("StartDate = %v", lq.StartDate)
+	}
+	if lq.EndDate == nil || lq.EndDate.Format("2006-01-02") != "2025-01-31" {
+		t.Errorf("EndDate = %v", lq.EndDate)
+	}
+}
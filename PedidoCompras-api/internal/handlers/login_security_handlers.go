@@ -0,0 +1,270 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/models"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/notifications"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Quantidade de contas distintas com falha, vindas do mesmo IP na janela,
+// a partir da qual a origem é considerada suspeita (credential stuffing)
+const suspiciousDistinctAccounts = 3
+
+// ListLoginAttempts - Lista as tentativas de login (security:manage)
+func ListLoginAttempts(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		query := db.Model(&models.LoginAttempt{})
+		if email := c.Query("email"); email != "" {
+			query = query.Where("email = ?", email)
+		}
+		if ip := c.Query("ip"); ip != "" {
+			query = query.Where("ip_address = ?", ip)
+		}
+		if reason := c.Query("reason"); reason != "" {
+			query = query.Where("reason = ?", reason)
+		}
+		if success := c.Query("success"); success != "" {
+			query = query.Where("success = ?", success == "true")
+		}
+
+		page := 1
+		pageSize := 50
+		if p, err := strconv.Atoi(c.Query("page")); err == nil && p > 0 {
+			page = p
+		}
+		if ps, err := strconv.Atoi(c.Query("pageSize")); err == nil && ps > 0 && ps <= 200 {
+			pageSize = ps
+		}
+
+		var total int64
+		if err := query.Count(&total).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao contar tentativas de login"})
+			return
+		}
+
+		var attempts []models.LoginAttempt
+		if err := query.Order("created_at DESC, id DESC").
+			Offset((page - 1) * pageSize).
+			Limit(pageSize).
+			Find(&attempts).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar tentativas de login"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"attempts": attempts,
+			"pagination": models.PaginationInfo{
+				Page:       page,
+				PageSize:   pageSize,
+				TotalPages: int(math.Ceil(float64(total) / float64(pageSize))),
+				TotalItems: int(total),
+			},
+		})
+	}
+}
+
+// ListLoginLockouts - Lista bloqueios de login em vigor (security:manage)
+func ListLoginLockouts(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var lockouts []models.LoginThrottle
+		if err := db.Where("locked_until > ?", time.Now()).
+			Order("locked_until DESC").
+			Find(&lockouts).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar bloqueios"})
+			return
+		}
+
+		c.JSON(http.StatusOK, lockouts)
+	}
+}
+
+// UnlockUserLogin - Remove o bloqueio de login de um usuário (security:manage)
+func UnlockUserLogin(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var usuario models.User
+		if err := db.First(&usuario, c.Param("id")).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Usuário não encontrado"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar usuário"})
+			}
+			return
+		}
+
+		if err := resetLoginThrottle(db, userThrottleKey(usuario.ID)); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao desbloquear usuário"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Login do usuário desbloqueado"})
+	}
+}
+
+// UnlockIPLogin - Remove o bloqueio de login de um endereço IP (security:manage)
+func UnlockIPLogin(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ip := c.Param("ip")
+		if err := resetLoginThrottle(db, ipThrottleKey(ip)); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao desbloquear IP"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("Login desbloqueado para o IP %s", ip)})
+	}
+}
+
+func userThrottleKey(userID uint) string {
+	return "user:" + utils.UintToString(userID)
+}
+
+func ipThrottleKey(ip string) string {
+	return "ip:" + ip
+}
+
+// activeLockout devolve o bloqueio em vigor para a chave (nil se não houver)
+func activeLockout(db *gorm.DB, key string, now time.Time) *models.LoginThrottle {
+	var throttle models.LoginThrottle
+	if err := db.First(&throttle, "key = ?", key).Error; err != nil {
+		return nil
+	}
+	if !throttle.IsLocked(now) {
+		return nil
+	}
+	return &throttle
+}
+
+// registerLoginFailure contabiliza a falha para a chave e aplica o bloqueio quando
+// o limite é atingido. Retorna o estado atualizado e se um novo bloqueio foi criado.
+func registerLoginFailure(db *gorm.DB, settings models.SystemSettings, key string, maxAttempts int) (models.LoginThrottle, bool, error) {
+	var throttle models.LoginThrottle
+	var locked bool
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// Garante a linha e a trava para evitar contagem perdida em tentativas paralelas
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.LoginThrottle{Key: key}).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&throttle, "key = ?", key).Error; err != nil {
+			return err
+		}
+
+		locked = throttle.RegisterFailure(
+			time.Now(),
+			maxAttempts,
+			time.Duration(positiveOr(settings.LoginAttemptWindowMinutes, 15))*time.Minute,
+			time.Duration(positiveOr(settings.LockoutMinutes, 15))*time.Minute,
+			time.Duration(positiveOr(settings.MaxLockoutMinutes, 1440))*time.Minute,
+		)
+		return tx.Save(&throttle).Error
+	})
+
+	return throttle, locked, err
+}
+
+// resetLoginThrottle zera falhas e bloqueios da chave
+func resetLoginThrottle(db *gorm.DB, key string) error {
+	return db.Where("key = ?", key).Delete(&models.LoginThrottle{}).Error
+}
+
+// recordLoginAttempt registra a tentativa de login
+func recordLoginAttempt(db *gorm.DB, c *gin.Context, email string, userID *uint, success bool, reason string) {
+	userAgent := c.Request.UserAgent()
+	if len(userAgent) > 500 {
+		userAgent = userAgent[:500]
+	}
+
+	attempt := models.LoginAttempt{
+		Email:     email,
+		UserID:    userID,
+		IPAddress: c.ClientIP(),
+		UserAgent: userAgent,
+		Success:   success,
+		Reason:    reason,
+	}
+	if err := db.Create(&attempt).Error; err != nil {
+		fmt.Printf("❌ Erro ao registrar tentativa de login: %v\n", err)
+	}
+}
+
+// isSuspiciousIP verifica se o IP falhou em várias contas diferentes dentro da janela
+func isSuspiciousIP(db *gorm.DB, settings models.SystemSettings, ip string) bool {
+	since := time.Now().Add(-time.Duration(positiveOr(settings.LoginAttemptWindowMinutes, 15)) * time.Minute)
+
+	var distinctAccounts int64
+	db.Model(&models.LoginAttempt{}).
+		Where("ip_address = ? AND success = ? AND created_at >= ?", ip, false, since).
+		Distinct("email").
+		Count(&distinctAccounts)
+
+	return distinctAccounts >= suspiciousDistinctAccounts
+}
+
+// notifySecurityEvent avisa via SSE os usuários conectados com security:manage
+func notifySecurityEvent(event, subject string) {
+	notifications.PublishToPermission(fmt.Sprintf("security-%s:%s", event, subject), models.PermSecurityManage)
+}
+
+// respondLoginLocked responde 429 com o tempo restante do bloqueio
+func respondLoginLocked(c *gin.Context, throttle *models.LoginThrottle, message string) {
+	retryAfter := int(math.Ceil(time.Until(*throttle.LockedUntil).Seconds()))
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error":             message,
+		"lockedUntil":       throttle.LockedUntil,
+		"retryAfterSeconds": retryAfter,
+	})
+}
+
+func positiveOr(value, fallback int) int {
+	if value > 0 {
+		return value
+	}
+	return fallback
+}
+
+// handleLoginFailure registra a tentativa malsucedida e contabiliza a falha no IP e,
+// quando a conta existe, também na conta, avisando os administradores sobre bloqueios
+func handleLoginFailure(db *gorm.DB, c *gin.Context, settings models.SystemSettings, email string, usuario *models.User, reason string) {
+	ip := c.ClientIP()
+
+	var userID *uint
+	if usuario != nil {
+		userID = &usuario.ID
+	}
+	recordLoginAttempt(db, c, email, userID, false, reason)
+
+	ipKey := ipThrottleKey(ip)
+	if _, locked, err := registerLoginFailure(db, settings, ipKey, positiveOr(settings.MaxLoginAttemptsPerIP, 20)); err != nil {
+		fmt.Printf("❌ Erro ao contabilizar falha de login (%s): %v\n", ipKey, err)
+	} else if locked {
+		notifySecurityEvent("lockout", ipKey)
+	} else if isSuspiciousIP(db, settings, ip) {
+		notifySecurityEvent("suspicious-login", ipKey)
+	}
+
+	if usuario == nil {
+		return
+	}
+
+	userKey := userThrottleKey(usuario.ID)
+	if _, locked, err := registerLoginFailure(db, settings, userKey, positiveOr(settings.MaxLoginAttempts, 5)); err != nil {
+		fmt.Printf("❌ Erro ao contabilizar falha de login (%s): %v\n", userKey, err)
+	} else if locked {
+		notifySecurityEvent("lockout", userKey)
+	}
+}
@@ -6,7 +6,9 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/models"
 	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/notifications"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/rbac"
 )
 
 // NotificationsStream - SSE melhorado com gerenciamento robusto
@@ -14,7 +16,6 @@ func NotificationsStream() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// ✅ EXTRAIR DADOS COMPLETOS DO USUÁRIO
 		userID := c.GetString("userID")
-		userRole := c.GetString("role")
 		userName := c.GetString("userName")
 
 		if userID == "" || userName == "" {
@@ -22,7 +23,7 @@ func NotificationsStream() gin.HandlerFunc {
 			return
 		}
 
-		fmt.Printf("📡 Nova conexão SSE - User: %s (%s), Role: %s\n", userName, userID, userRole)
+		fmt.Printf("📡 Nova conexão SSE - User: %s (%s)\n", userName, userID)
 
 		// ✅ HEADERS SSE CORRETOS
 		c.Writer.Header().Set("Content-Type", "text/event-stream")
@@ -35,25 +36,25 @@ func NotificationsStream() gin.HandlerFunc {
 		// ✅ FLUSH INICIAL
 		c.Writer.Flush()
 
-		// ✅ REGISTRAR CLIENTE COM CONTEXTO
-		clientID, clientChan := notifications.RegisterClient(userID, userRole, userName, c.Request.Context())
+		// ✅ REGISTRAR CLIENTE COM AS PERMISSÕES (AVISOS POR PERMISSÃO, NÃO POR PAPEL)
+		clientID, clientChan := notifications.RegisterClient(userID, userName, rbac.List(c), c.Request.Context())
 		defer func() {
 			notifications.UnregisterClient(clientID)
 			fmt.Printf("📡 Conexão SSE encerrada - User: %s\n", userName)
 		}()
 
 		// ✅ ENVIAR EVENTO DE CONEXÃO
-		c.SSEvent("connected", fmt.Sprintf("Conectado como %s (%s)", userName, userRole))
+		c.SSEvent("connected", fmt.Sprintf("Conectado como %s", userName))
 		c.Writer.Flush()
 
 		// ✅ TICKERS PARA MANUTENÇÃO
 		pingTicker := time.NewTicker(30 * time.Second)
 		defer pingTicker.Stop()
 
-		// ✅ TIMEOUT CONFIGURÁVEL POR ROLE
+		// ✅ TIMEOUT CONFIGURÁVEL POR PERMISSÃO
 		timeoutDuration := 60 * time.Minute
-		if userRole == "admin" {
-			timeoutDuration = 2 * time.Hour // Admins ficam conectados mais tempo
+		if rbac.Has(c, models.PermRequestsReview) {
+			timeoutDuration = 2 * time.Hour // Quem revisa requisições fica conectado mais tempo
 		}
 		timeout := time.NewTimer(timeoutDuration)
 		defer timeout.Stop()
@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// checkPasswordPolicy valida a senha contra a política de SystemSettings.
+// Retorna a resposta de erro (com os erros por campo) ou nil quando a senha é válida.
+func checkPasswordPolicy(db *gorm.DB, field, password string) (gin.H, error) {
+	settings, err := loadSystemSettings(db)
+	if err != nil {
+		return nil, err
+	}
+
+	violations := settings.ValidatePassword(password)
+	if len(violations) == 0 {
+		return nil, nil
+	}
+
+	messages := make([]string, 0, len(violations))
+	for _, v := range violations {
+		messages = append(messages, v.Message)
+	}
+
+	return gin.H{
+		"error":      "A senha não atende à política de senhas",
+		"fields":     gin.H{field: messages},
+		"violations": violations,
+	}, nil
+}
@@ -0,0 +1,288 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"path"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/config"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/mailer"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/models"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/storage"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// Intervalo mínimo entre pedidos de redefinição para o mesmo usuário
+const passwordResetCooldown = time.Minute
+
+// Resposta única do "esqueci minha senha", para não revelar quais e-mails existem
+const forgotPasswordMessage = "Se o e-mail estiver cadastrado, você receberá as instruções para redefinir a senha"
+
+type forgotPasswordInput struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type resetPasswordInput struct {
+	Token           string `json:"token" binding:"required"`
+	NewPassword     string `json:"newPassword" binding:"required"`
+	ConfirmPassword string `json:"confirmPassword" binding:"required"`
+}
+
+// ForgotPassword - Envia por e-mail o link de redefinição de senha
+func ForgotPassword(db *gorm.DB, appConfig *config.Config, mail mailer.Mailer, store storage.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input forgotPasswordInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var user models.User
+		if err := db.Where("email = ?", input.Email).First(&user).Error; err != nil {
+			if err != gorm.ErrRecordNotFound {
+				fmt.Printf("❌ Erro ao buscar usuário para redefinição de senha: %v\n", err)
+			}
+			c.JSON(http.StatusOK, gin.H{"message": forgotPasswordMessage})
+			return
+		}
+
+		// Evita inundar a caixa do usuário com pedidos repetidos
+		var recent int64
+		db.Model(&models.PasswordResetToken{}).
+			Where("user_id = ? AND used_at IS NULL AND created_at > ?", user.ID, time.Now().Add(-passwordResetCooldown)).
+			Count(&recent)
+		if recent > 0 {
+			c.JSON(http.StatusOK, gin.H{"message": forgotPasswordMessage})
+			return
+		}
+
+		token, err := createPasswordResetToken(db, appConfig, user.ID, c.ClientIP())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao gerar link de redefinição"})
+			return
+		}
+
+		msg, err := buildPasswordResetEmail(c.Request.Context(), db, store, appConfig, user, token)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao montar e-mail de redefinição"})
+			return
+		}
+
+		// Envio em segundo plano: o tempo de resposta não revela se o e-mail existe
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			if err := mail.Send(ctx, msg); err != nil {
+				fmt.Printf("❌ Erro ao enviar e-mail de redefinição para %s: %v\n", user.Email, err)
+			}
+		}()
+
+		c.JSON(http.StatusOK, gin.H{"message": forgotPasswordMessage})
+	}
+}
+
+// ResetPassword - Define nova senha a partir do token recebido por e-mail
+func ResetPassword(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input resetPasswordInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if input.NewPassword != input.ConfirmPassword {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Nova senha e confirmação não coincidem"})
+			return
+		}
+
+		var resetToken models.PasswordResetToken
+		if err := db.Where("token_hash = ?", hashToken(input.Token)).
+			First(&resetToken).Error; err != nil || !resetToken.IsUsable(time.Now()) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Link de redefinição inválido ou expirado"})
+			return
+		}
+
+		// Validação de força da senha (política de SystemSettings)
+		policyErr, err := checkPasswordPolicy(db, "newPassword", input.NewPassword)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao carregar política de senhas"})
+			return
+		}
+		if policyErr != nil {
+			c.JSON(http.StatusBadRequest, policyErr)
+			return
+		}
+
+		newHashBytes, err := bcrypt.GenerateFromPassword([]byte(input.NewPassword), bcrypt.DefaultCost)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao processar nova senha"})
+			return
+		}
+
+		now := time.Now()
+		tx := db.Begin()
+		defer func() {
+			if r := recover(); r != nil {
+				tx.Rollback()
+			}
+		}()
+
+		// Consome o token de forma condicional: dois envios simultâneos não podem usá-lo
+		result := tx.Model(&models.PasswordResetToken{}).
+			Where("id = ? AND used_at IS NULL", resetToken.ID).
+			Update("used_at", now)
+		if result.Error != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao redefinir senha"})
+			return
+		}
+		if result.RowsAffected == 0 {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Link de redefinição inválido ou expirado"})
+			return
+		}
+
+		if err := tx.Model(&models.User{}).
+			Where("id = ?", resetToken.UserID).
+			Updates(map[string]interface{}{
+				"password_hash":       string(newHashBytes),
+				"password_changed_at": now,
+				"tokens_valid_after":  now,
+			}).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao redefinir senha"})
+			return
+		}
+
+		if err := tx.Commit().Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao salvar nova senha"})
+			return
+		}
+
+		// Encerra as sessões abertas e libera um eventual bloqueio por tentativas
+		if _, err := revokeAllUserSessions(db, resetToken.UserID, "password-reset"); err != nil {
+			fmt.Printf("❌ Erro ao encerrar sessões após redefinição de senha: %v\n", err)
+		}
+		if err := resetLoginThrottle(db, userThrottleKey(resetToken.UserID)); err != nil {
+			fmt.Printf("❌ Erro ao desbloquear login após redefinição de senha: %v\n", err)
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Senha redefinida com sucesso. Faça login com a nova senha"})
+	}
+}
+
+// createPasswordResetToken invalida os tokens pendentes do usuário e emite um novo
+func createPasswordResetToken(db *gorm.DB, appConfig *config.Config, userID uint, ip string) (string, error) {
+	token, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.PasswordResetToken{}).
+			Where("user_id = ? AND used_at IS NULL", userID).
+			Update("used_at", now).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&models.PasswordResetToken{
+			UserID:    userID,
+			TokenHash: hashToken(token),
+			ExpiresAt: now.Add(time.Duration(appConfig.PasswordResetMinutes) * time.Minute),
+			RequestIP: ip,
+		}).Error
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// passwordResetEmail - Dados do template do e-mail de redefinição
+type passwordResetEmail struct {
+	CompanyName string
+	UserName    string
+	ResetURL    string
+	Token       string
+	Minutes     int
+	HasLogo     bool
+}
+
+var passwordResetHTML = template.Must(template.New("password-reset").Parse(`<!DOCTYPE html>
+<html lang="pt-BR">
+<body style="font-family: Arial, sans-serif; color: #333;">
+  {{if .HasLogo}}<p><img src="cid:company-logo" alt="{{.CompanyName}}" style="max-height: 60px;"></p>{{end}}
+  <h2>{{.CompanyName}}</h2>
+  <p>Olá, {{.UserName}}.</p>
+  <p>Recebemos um pedido para redefinir a sua senha.</p>
+  {{if .ResetURL}}<p><a href="{{.ResetURL}}">Clique aqui para criar uma nova senha</a></p>
+  {{else}}<p>Use o código abaixo na tela de redefinição de senha:</p>
+  <p style="font-family: monospace; font-size: 14px;">{{.Token}}</p>{{end}}
+  <p>O link é válido por {{.Minutes}} minutos e só pode ser usado uma vez.</p>
+  <p>Se você não fez este pedido, ignore este e-mail: sua senha continua a mesma.</p>
+</body>
+</html>`))
+
+// buildPasswordResetEmail monta o e-mail com nome e logo de CompanySettings
+func buildPasswordResetEmail(ctx context.Context, db *gorm.DB, store storage.Storage, appConfig *config.Config, user models.User, token string) (mailer.Message, error) {
+	company := models.CompanySettings{CompanyName: "PedidoCompras"}
+	if err := db.First(&company).Error; err != nil && err != gorm.ErrRecordNotFound {
+		return mailer.Message{}, err
+	}
+
+	data := passwordResetEmail{
+		CompanyName: company.CompanyName,
+		UserName:    user.Name,
+		Token:       token,
+		Minutes:     appConfig.PasswordResetMinutes,
+	}
+	if appConfig.AppBaseURL != "" {
+		data.ResetURL = appConfig.AppBaseURL + "/reset-password?token=" + url.QueryEscape(token)
+	}
+
+	msg := mailer.Message{
+		To:      []string{user.Email},
+		Subject: fmt.Sprintf("%s - Redefinição de senha", company.CompanyName),
+	}
+
+	// Logo embutido no e-mail (ignorado se o arquivo não estiver disponível)
+	if company.LogoPath != "" {
+		if logo, info, err := readStored(ctx, store, company.LogoPath); err == nil {
+			data.HasLogo = true
+			msg.Inline = append(msg.Inline, mailer.InlineFile{
+				ContentID:   "company-logo",
+				Filename:    path.Base(company.LogoPath),
+				ContentType: info.ContentType,
+				Data:        logo,
+			})
+		}
+	}
+
+	var html bytes.Buffer
+	if err := passwordResetHTML.Execute(&html, data); err != nil {
+		return mailer.Message{}, err
+	}
+	msg.HTMLBody = html.String()
+
+	instructions := "Use o código abaixo na tela de redefinição de senha:\n" + token
+	if data.ResetURL != "" {
+		instructions = "Acesse o link abaixo para criar uma nova senha:\n" + data.ResetURL
+	}
+	msg.TextBody = fmt.Sprintf(
+		"%s\n\nOlá, %s.\n\nRecebemos um pedido para redefinir a sua senha.\n%s\n\n"+
+			"O link é válido por %d minutos e só pode ser usado uma vez.\n"+
+			"Se você não fez este pedido, ignore este e-mail: sua senha continua a mesma.\n",
+		company.CompanyName, user.Name, instructions, appConfig.PasswordResetMinutes,
+	)
+
+	return msg, nil
+}
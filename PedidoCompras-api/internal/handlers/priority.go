@@ -20,12 +20,6 @@ type setPriorityInput struct {
 // SetRequestPriority permite ao admin definir prioridade de uma requisição
 func SetRequestPriority(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Apenas admin pode definir prioridade
-		if c.GetString("role") != "admin" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Acesso restrito a administradores"})
-			return
-		}
-
 		requestID := c.Param("id")
 		userID := c.GetString("userID")
 
@@ -82,12 +76,6 @@ func SetRequestPriority(db *gorm.DB) gin.HandlerFunc {
 // RemoveRequestPriority volta uma requisição para prioridade normal
 func RemoveRequestPriority(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Apenas admin pode remover prioridade
-		if c.GetString("role") != "admin" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Acesso restrito a administradores"})
-			return
-		}
-
 		requestID := c.Param("id")
 
 		// Busca a requisição
@@ -133,12 +121,6 @@ func RemoveRequestPriority(db *gorm.DB) gin.HandlerFunc {
 // ToggleUrgentPriority alterna entre urgente e normal (ação rápida)
 func ToggleUrgentPriority(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Apenas admin pode alterar prioridade
-		if c.GetString("role") != "admin" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Acesso restrito a administradores"})
-			return
-		}
-
 		requestID := c.Param("id")
 		userID := c.GetString("userID")
 
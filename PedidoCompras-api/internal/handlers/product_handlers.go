@@ -5,6 +5,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/models"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/rbac"
 	"gorm.io/gorm"
 )
 
@@ -22,20 +23,46 @@ type updateProductInput struct {
 	Status      *string `json:"status" binding:"omitempty,oneof=available discontinued"`
 }
 
-// ListProducts retorna produtos do setor do usuário (ou todos se admin) - OTIMIZADO
+// productListSpec - Ordenação e busca aceitas em GET /products
+var productListSpec = listSpec{
+	Table: "products",
+	SortFields: map[string]string{
+		"id":        "products.id",
+		"name":      "products.name",
+		"status":    "products.status",
+		"createdAt": "products.created_at",
+		"updatedAt": "products.updated_at",
+	},
+	DefaultSort:   "name",
+	SearchColumns: []string{"products.name", "products.description"},
+	DateColumn:    "products.created_at",
+}
+
+// ListProducts retorna produtos do setor do usuário (ou todos com products:manage) - OTIMIZADO.
+// Filtros: status e sectorId (apenas com products:manage), além dos parâmetros comuns de listagem.
 func ListProducts(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		userRole := c.GetString("role")
 		userID := c.GetString("userID")
 
-		var products []models.Product
+		lq, ok := parseListQuery(c, productListSpec)
+		if !ok {
+			return
+		}
+
+		query := db.Model(&models.Product{})
 
-		if userRole == "admin" {
-			// Admin vê todos os produtos com preload otimizado
-			if err := db.Preload("Sector").Find(&products).Error; err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao consultar produtos"})
+		if rbac.Has(c, models.PermProductsManage) {
+			// Quem gerencia produtos vê todos e pode filtrar por setor e status
+			if statuses := queryListParam(c, "status"); len(statuses) > 0 {
+				query = query.Where("status IN ?", statuses)
+			}
+			sectorID, ok := queryUintParam(c, "sectorId")
+			if !ok {
 				return
 			}
+			if sectorID != nil {
+				query = query.Where("sector_id = ?", *sectorID)
+			}
 		} else {
 			// Usuário comum: busca o setor primeiro e depois filtra produtos
 			var user models.User
@@ -44,23 +71,24 @@ func ListProducts(db *gorm.DB) gin.HandlerFunc {
 				return
 			}
 
-			// Consulta otimizada: filtra por setor E status em uma única query
-			if err := db.Preload("Sector").
-				Where("sector_id = ? AND status = ?", user.SectorID, "available").
-				Find(&products).Error; err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao consultar produtos"})
-				return
-			}
+			// Filtra por setor E status em uma única query
+			query = query.Where("sector_id = ? AND status = ?", user.SectorID, "available")
+		}
+
+		var products []models.Product
+		pagination, err := lq.find(query, &products, "Sector")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao consultar produtos"})
+			return
 		}
 
-		c.JSON(http.StatusOK, products)
+		respondList(c, lq, "products", products, pagination)
 	}
 }
 
 // CreateProduct cria um novo produto
 func CreateProduct(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		userRole := c.GetString("role")
 		userID := c.GetString("userID")
 
 		var input createProductInput
@@ -70,8 +98,8 @@ func CreateProduct(db *gorm.DB) gin.HandlerFunc {
 		}
 
 		// Verifica permissões para criar produto
-		if userRole != "admin" {
-			// Se não for admin, verifica se está tentando criar produto para seu próprio setor
+		if !rbac.Has(c, models.PermProductsManage) {
+			// Sem products:manage, só pode criar produto para o próprio setor
 			var user models.User
 			if err := db.Select("sector_id").First(&user, userID).Error; err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar dados do usuário"})
@@ -127,7 +155,6 @@ func CreateProduct(db *gorm.DB) gin.HandlerFunc {
 func GetProduct(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		productID := c.Param("id")
-		userRole := c.GetString("role")
 		userID := c.GetString("userID")
 
 		var product models.Product
@@ -141,7 +168,7 @@ func GetProduct(db *gorm.DB) gin.HandlerFunc {
 		}
 
 		// Verifica permissão de acesso
-		if userRole != "admin" {
+		if !rbac.Has(c, models.PermProductsManage) {
 			var user models.User
 			if err := db.Select("sector_id").First(&user, userID).Error; err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar dados do usuário"})
@@ -162,7 +189,6 @@ func GetProduct(db *gorm.DB) gin.HandlerFunc {
 func UpdateProduct(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		productID := c.Param("id")
-		userRole := c.GetString("role")
 		userID := c.GetString("userID")
 
 		var product models.Product
@@ -176,7 +202,7 @@ func UpdateProduct(db *gorm.DB) gin.HandlerFunc {
 		}
 
 		// Verifica permissões para atualizar
-		if userRole != "admin" {
+		if !rbac.Has(c, models.PermProductsManage) {
 			var user models.User
 			if err := db.Select("sector_id").First(&user, userID).Error; err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar dados do usuário"})
@@ -207,9 +233,9 @@ func UpdateProduct(db *gorm.DB) gin.HandlerFunc {
 			updates["unit"] = *input.Unit
 		}
 		if input.Status != nil {
-			// Apenas admin pode alterar status
-			if userRole != "admin" {
-				c.JSON(http.StatusForbidden, gin.H{"error": "Apenas administradores podem alterar o status do produto"})
+			// Apenas quem gerencia produtos pode alterar status
+			if !rbac.Has(c, models.PermProductsManage) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Sem permissão para alterar o status do produto"})
 				return
 			}
 			updates["status"] = *input.Status
@@ -235,7 +261,6 @@ func UpdateProduct(db *gorm.DB) gin.HandlerFunc {
 func DeleteProduct(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		productID := c.Param("id")
-		userRole := c.GetString("role")
 		userID := c.GetString("userID")
 
 		var product models.Product
@@ -249,7 +274,7 @@ func DeleteProduct(db *gorm.DB) gin.HandlerFunc {
 		}
 
 		// Verifica permissões para deletar
-		if userRole != "admin" {
+		if !rbac.Has(c, models.PermProductsManage) {
 			var user models.User
 			if err := db.Select("sector_id").First(&user, userID).Error; err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar dados do usuário"})
@@ -9,6 +9,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/models"
 	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/notifications"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/rbac"
 	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/utils"
 	"gorm.io/gorm"
 )
@@ -31,11 +32,10 @@ type processProductRegistrationInput struct {
 func CreateProductRegistrationRequest(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userIDStr := c.GetString("userID")
-		userRole := c.GetString("role")
 
-		// Apenas usuários não-admin podem fazer solicitações
-		if userRole == "admin" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Administradores devem criar produtos diretamente"})
+		// Quem gerencia produtos cria diretamente, sem solicitação
+		if rbac.Has(c, models.PermProductsManage) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Quem gerencia produtos deve criá-los diretamente"})
 			return
 		}
 
@@ -110,35 +110,63 @@ func CreateProductRegistrationRequest(db *gorm.DB) gin.HandlerFunc {
 	}
 }
 
-// ListProductRegistrationRequests - Lista solicitações (admin vê todas, usuário vê suas)
+// productRequestListSpec - Ordenação e busca aceitas em GET /product-requests
+var productRequestListSpec = listSpec{
+	Table: "product_registration_requests",
+	SortFields: map[string]string{
+		"id":          "product_registration_requests.id",
+		"createdAt":   "product_registration_requests.created_at",
+		"processedAt": "product_registration_requests.processed_at",
+		"productName": "product_registration_requests.product_name",
+		"status":      "product_registration_requests.status",
+	},
+	DefaultSort: "-createdAt",
+	SearchColumns: []string{
+		"product_registration_requests.product_name",
+		"product_registration_requests.product_description",
+		"product_registration_requests.justification",
+	},
+	DateColumn: "product_registration_requests.created_at",
+}
+
+// ListProductRegistrationRequests - Lista solicitações (quem revisa vê todas, usuário vê suas).
+// Filtros: status e sectorId, além dos parâmetros comuns de listagem.
 func ListProductRegistrationRequests(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userID := c.GetString("userID")
-		userRole := c.GetString("role")
 
-		query := db.Preload("Requester").
-			Preload("Sector").
-			Preload("Processor").
-			Preload("CreatedProduct")
+		lq, ok := parseListQuery(c, productRequestListSpec)
+		if !ok {
+			return
+		}
+
+		query := db.Model(&models.ProductRegistrationRequest{})
 
 		// Filtros opcionais
-		status := c.Query("status")
-		if status != "" {
-			query = query.Where("status = ?", status)
+		if statuses := queryListParam(c, "status"); len(statuses) > 0 {
+			query = query.Where("status IN ?", statuses)
+		}
+		sectorID, ok := queryUintParam(c, "sectorId")
+		if !ok {
+			return
+		}
+		if sectorID != nil {
+			query = query.Where("sector_id = ?", *sectorID)
 		}
 
-		// Se não for admin, filtra por requester
-		if userRole != "admin" {
+		// Sem permissão de revisão, filtra por requester
+		if !rbac.Has(c, models.PermProductRequestsReview) {
 			query = query.Where("requester_id = ?", userID)
 		}
 
 		var requests []models.ProductRegistrationRequest
-		if err := query.Order("created_at DESC").Find(&requests).Error; err != nil {
+		pagination, err := lq.find(query, &requests, "Requester", "Sector", "Processor", "CreatedProduct")
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar solicitações"})
 			return
 		}
 
-		c.JSON(http.StatusOK, requests)
+		respondList(c, lq, "requests", requests, pagination)
 	}
 }
 
@@ -147,7 +175,6 @@ func GetProductRegistrationRequest(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		requestID := c.Param("id")
 		userID := c.GetString("userID")
-		userRole := c.GetString("role")
 
 		var request models.ProductRegistrationRequest
 		if err := db.Preload("Requester").
@@ -164,7 +191,7 @@ func GetProductRegistrationRequest(db *gorm.DB) gin.HandlerFunc {
 		}
 
 		// Verifica permissões
-		if userRole != "admin" && utils.UintToString(request.RequesterID) != userID {
+		if !rbac.Has(c, models.PermProductRequestsReview) && utils.UintToString(request.RequesterID) != userID {
 			c.JSON(http.StatusForbidden, gin.H{"error": "Acesso negado"})
 			return
 		}
@@ -176,12 +203,6 @@ func GetProductRegistrationRequest(db *gorm.DB) gin.HandlerFunc {
 // ProcessProductRegistrationRequest - Admin aprova/rejeita solicitação
 func ProcessProductRegistrationRequest(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Apenas admin pode processar
-		if c.GetString("role") != "admin" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Acesso restrito a administradores"})
-			return
-		}
-
 		requestID := c.Param("id")
 		userID := c.GetString("userID")
 
@@ -304,7 +325,6 @@ func UpdateProductRegistrationRequest(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		requestID := c.Param("id")
 		userID := c.GetString("userID")
-		userRole := c.GetString("role")
 
 		var request models.ProductRegistrationRequest
 		if err := db.First(&request, requestID).Error; err != nil {
@@ -317,7 +337,7 @@ func UpdateProductRegistrationRequest(db *gorm.DB) gin.HandlerFunc {
 		}
 
 		// Verifica permissões
-		if userRole != "admin" && utils.UintToString(request.RequesterID) != userID {
+		if !rbac.Has(c, models.PermProductRequestsReview) && utils.UintToString(request.RequesterID) != userID {
 			c.JSON(http.StatusForbidden, gin.H{"error": "Acesso negado"})
 			return
 		}
@@ -368,7 +388,6 @@ func DeleteProductRegistrationRequest(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		requestID := c.Param("id")
 		userID := c.GetString("userID")
-		userRole := c.GetString("role")
 
 		var request models.ProductRegistrationRequest
 		if err := db.First(&request, requestID).Error; err != nil {
@@ -380,8 +399,8 @@ func DeleteProductRegistrationRequest(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
-		// Verifica permissões (apenas o solicitante ou admin)
-		if userRole != "admin" && utils.UintToString(request.RequesterID) != userID {
+		// Verifica permissões (apenas o solicitante ou quem revisa solicitações)
+		if !rbac.Has(c, models.PermProductRequestsReview) && utils.UintToString(request.RequesterID) != userID {
 			c.JSON(http.StatusForbidden, gin.H{"error": "Acesso negado"})
 			return
 		}
@@ -406,14 +425,9 @@ func DeleteProductRegistrationRequest(db *gorm.DB) gin.HandlerFunc {
 	}
 }
 
-// GetProductRegistrationStats - Estatísticas das solicitações (apenas admin)
+// GetProductRegistrationStats - Estatísticas das solicitações (product-requests:review)
 func GetProductRegistrationStats(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		if c.GetString("role") != "admin" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Acesso restrito a administradores"})
-			return
-		}
-
 		type Stats struct {
 			TotalRequests    int64 `json:"totalRequests"`
 			PendingRequests  int64 `json:"pendingRequests"`
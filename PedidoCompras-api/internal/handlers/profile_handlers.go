@@ -3,6 +3,7 @@ package handlers
 import (
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/models"
@@ -17,7 +18,7 @@ type updateProfileInput struct {
 
 type changePasswordInput struct {
 	CurrentPassword string `json:"currentPassword" binding:"required"`
-	NewPassword     string `json:"newPassword" binding:"required,min=6"`
+	NewPassword     string `json:"newPassword" binding:"required"`
 	ConfirmPassword string `json:"confirmPassword" binding:"required"`
 }
 
@@ -162,9 +163,20 @@ func ChangePassword(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
-		// Validação de força da senha (mínimo 6 caracteres já validado pelo binding)
-		if len(input.NewPassword) < 6 {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Nova senha deve ter pelo menos 6 caracteres"})
+		// Validação de força da senha (política de SystemSettings)
+		policyErr, err := checkPasswordPolicy(db, "newPassword", input.NewPassword)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao carregar política de senhas"})
+			return
+		}
+		if policyErr != nil {
+			c.JSON(http.StatusBadRequest, policyErr)
+			return
+		}
+
+		// A nova senha precisa ser diferente da atual
+		if input.NewPassword == input.CurrentPassword {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "A nova senha deve ser diferente da atual"})
 			return
 		}
 
@@ -185,12 +197,24 @@ func ChangePassword(db *gorm.DB) gin.HandlerFunc {
 		}
 
 		// Atualiza a senha
+		now := time.Now()
 		user.PasswordHash = string(newHashBytes)
+		user.PasswordChangedAt = &now
 		if err := db.Save(&user).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar senha"})
 			return
 		}
 
+		// Token restrito (senha expirada): encerra a sessão e exige novo login
+		if c.GetBool("passwordExpired") {
+			revokeSession(db, c.GetString("sessionID"), "password-changed")
+			c.JSON(http.StatusOK, gin.H{
+				"message":         "Senha alterada com sucesso. Faça login novamente",
+				"reloginRequired": true,
+			})
+			return
+		}
+
 		c.JSON(http.StatusOK, gin.H{"message": "Senha alterada com sucesso"})
 	}
 }
@@ -0,0 +1,409 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/models"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/notifications"
+	"gorm.io/gorm"
+)
+
+type createPurchaseOrdersInput struct {
+	RequestIDs []uint `json:"requestIds" binding:"required,min=1"`
+	Notes      string `json:"notes"`
+}
+
+type updatePurchaseOrderStatusInput struct {
+	Status             string `json:"status" binding:"required,oneof=issued acknowledged cancelled"`
+	CancellationReason string `json:"cancellationReason"`
+}
+
+// skippedOrderItem - Item aprovado que não entrou em nenhum pedido de compra
+type skippedOrderItem struct {
+	RequestID uint   `json:"requestId"`
+	ItemID    uint   `json:"itemId"`
+	Reason    string `json:"reason"`
+}
+
+// activePurchaseOrderStatuses - Status de pedidos que ainda "seguram" seus itens
+var activePurchaseOrderStatuses = []string{
+	models.PurchaseOrderStatusDraft,
+	models.PurchaseOrderStatusIssued,
+	models.PurchaseOrderStatusAcknowledged,
+	models.PurchaseOrderStatusPartiallyReceived,
+	models.PurchaseOrderStatusReceived,
+}
+
+// CreatePurchaseOrders gera pedidos de compra (um por fornecedor) a partir dos itens aprovados das requisições
+func CreatePurchaseOrders(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, _ := strconv.ParseUint(c.GetString("userID"), 10, 64)
+
+		var input createPurchaseOrdersInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// 1) Valida as requisições
+		var requests []models.PurchaseRequest
+		if err := db.Where("id IN ?", input.RequestIDs).Find(&requests).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar requisições"})
+			return
+		}
+		if len(requests) != len(input.RequestIDs) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Algumas requisições não foram encontradas"})
+			return
+		}
+		for _, req := range requests {
+			if req.Status != models.StatusApproved && req.Status != models.StatusPartial {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": fmt.Sprintf("Requisição %d não está aprovada (status: %s)", req.ID, req.Status),
+				})
+				return
+			}
+		}
+
+		// 2) Busca os itens aprovados
+		var items []models.RequestItem
+		if err := db.Where("purchase_request_id IN ? AND status = ?", input.RequestIDs, "approved").
+			Order("purchase_request_id, id").
+			Find(&items).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar itens aprovados"})
+			return
+		}
+
+		tx := db.Begin()
+		defer func() {
+			if r := recover(); r != nil {
+				tx.Rollback()
+			}
+		}()
+
+		// Garante numeração sequencial sem buracos/duplicidade entre gerações concorrentes
+		if err := tx.Exec("LOCK TABLE purchase_orders IN EXCLUSIVE MODE").Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao reservar numeração de pedidos"})
+			return
+		}
+
+		// 3) Agrupa por fornecedor do orçamento vencedor
+		linesBySupplier := make(map[uint][]models.PurchaseOrderLine)
+		var skipped []skippedOrderItem
+
+		for _, item := range items {
+			// Item já está em um pedido ativo?
+			var existingLines int64
+			if err := tx.Model(&models.PurchaseOrderLine{}).
+				Joins("JOIN purchase_orders po ON po.id = purchase_order_lines.purchase_order_id AND po.deleted_at IS NULL").
+				Where("purchase_order_lines.request_item_id = ? AND po.status IN ?", item.ID, activePurchaseOrderStatuses).
+				Count(&existingLines).Error; err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao verificar pedidos existentes"})
+				return
+			}
+			if existingLines > 0 {
+				skipped = append(skipped, skippedOrderItem{item.PurchaseRequestID, item.ID, "Item já consta em um pedido de compra"})
+				continue
+			}
+
+			budget, err := findWinningBudget(tx, item.ID)
+			if err != nil {
+				if err == gorm.ErrRecordNotFound {
+					skipped = append(skipped, skippedOrderItem{item.PurchaseRequestID, item.ID, "Item sem orçamento"})
+					continue
+				}
+				tx.Rollback()
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar orçamentos"})
+				return
+			}
+
+			linesBySupplier[budget.SupplierID] = append(linesBySupplier[budget.SupplierID], models.PurchaseOrderLine{
+				PurchaseRequestID: item.PurchaseRequestID,
+				RequestItemID:     item.ID,
+				ItemBudgetID:      budget.ID,
+				Quantity:          item.Quantity,
+				UnitPrice:         budget.UnitPrice,
+				TotalPrice:        budget.UnitPrice * float64(item.Quantity),
+			})
+		}
+
+		if len(linesBySupplier) == 0 {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":        "Nenhum item disponível para gerar pedido de compra",
+				"skippedItems": skipped,
+			})
+			return
+		}
+
+		// Ordena fornecedores para que a numeração seja determinística
+		supplierIDs := make([]uint, 0, len(linesBySupplier))
+		for supplierID := range linesBySupplier {
+			supplierIDs = append(supplierIDs, supplierID)
+		}
+		sort.Slice(supplierIDs, func(i, j int) bool { return supplierIDs[i] < supplierIDs[j] })
+
+		var lastSequence uint
+		if err := tx.Unscoped().Model(&models.PurchaseOrder{}).
+			Select("COALESCE(MAX(sequence), 0)").
+			Scan(&lastSequence).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao calcular numeração de pedidos"})
+			return
+		}
+
+		// 4) Cria um pedido por fornecedor
+		var orderIDs []uint
+		for _, supplierID := range supplierIDs {
+			lastSequence++
+			order := models.PurchaseOrder{
+				Sequence:   lastSequence,
+				Number:     models.FormatPurchaseOrderNumber(lastSequence),
+				SupplierID: supplierID,
+				Status:     models.PurchaseOrderStatusDraft,
+				Notes:      input.Notes,
+				CreatedBy:  uint(userID),
+				Lines:      linesBySupplier[supplierID],
+			}
+			order.RecalculateTotals()
+
+			if err := tx.Create(&order).Error; err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao criar pedido de compra"})
+				return
+			}
+			orderIDs = append(orderIDs, order.ID)
+		}
+
+		if err := tx.Commit().Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao finalizar criação dos pedidos"})
+			return
+		}
+
+		// 5) Carrega os pedidos completos para retornar
+		var orders []models.PurchaseOrder
+		if err := preloadPurchaseOrder(db).Where("id IN ?", orderIDs).Order("sequence").Find(&orders).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao carregar pedidos criados"})
+			return
+		}
+
+		for _, order := range orders {
+			notifications.Publish(fmt.Sprintf("purchase-order-created:%d", order.ID))
+		}
+
+		c.JSON(http.StatusCreated, gin.H{
+			"orders":       orders,
+			"skippedItems": skipped,
+		})
+	}
+}
+
+// ListPurchaseOrders lista os pedidos de compra com filtros opcionais
+func ListPurchaseOrders(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		query := db.Preload("Supplier").Preload("Creator")
+
+		if status := c.Query("status"); status != "" {
+			query = query.Where("status = ?", status)
+		}
+		if supplierID := c.Query("supplierId"); supplierID != "" {
+			query = query.Where("supplier_id = ?", supplierID)
+		}
+		if requestID := c.Query("requestId"); requestID != "" {
+			query = query.Where("id IN (?)",
+				db.Model(&models.PurchaseOrderLine{}).Select("purchase_order_id").Where("purchase_request_id = ?", requestID))
+		}
+
+		var orders []models.PurchaseOrder
+		if err := query.Order("sequence DESC").Find(&orders).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao listar pedidos de compra"})
+			return
+		}
+
+		c.JSON(http.StatusOK, orders)
+	}
+}
+
+// GetPurchaseOrder retorna um pedido com linhas e quantidades pedidas x recebidas
+func GetPurchaseOrder(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var order models.PurchaseOrder
+		if err := preloadPurchaseOrder(db).First(&order, c.Param("id")).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Pedido de compra não encontrado"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar pedido de compra"})
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, order)
+	}
+}
+
+// UpdatePurchaseOrderStatus emite, confirma ou cancela um pedido de compra
+func UpdatePurchaseOrderStatus(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input updatePurchaseOrderStatusInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var order models.PurchaseOrder
+		if err := db.First(&order, c.Param("id")).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Pedido de compra não encontrado"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar pedido de compra"})
+			}
+			return
+		}
+
+		if !order.CanTransitionTo(input.Status) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":         fmt.Sprintf("Não é possível alterar o pedido de '%s' para '%s'", order.Status, input.Status),
+				"allowedStatus": order.AllowedNextStatuses(),
+			})
+			return
+		}
+
+		now := time.Now()
+		order.Status = input.Status
+		switch input.Status {
+		case models.PurchaseOrderStatusIssued:
+			order.IssuedAt = &now
+		case models.PurchaseOrderStatusAcknowledged:
+			order.AcknowledgedAt = &now
+		case models.PurchaseOrderStatusCancelled:
+			order.CancelledAt = &now
+			order.CancellationReason = input.CancellationReason
+		}
+
+		if err := db.Save(&order).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar pedido de compra"})
+			return
+		}
+
+		if err := preloadPurchaseOrder(db).First(&order, order.ID).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao carregar pedido de compra"})
+			return
+		}
+
+		c.JSON(http.StatusOK, order)
+
+		notifications.Publish(
+			fmt.Sprintf("purchase-order-status:%d:%s", order.ID, order.Status),
+		)
+	}
+}
+
+// preloadPurchaseOrder aplica os preloads usados nas respostas de pedido de compra
+func preloadPurchaseOrder(db *gorm.DB) *gorm.DB {
+	return db.Preload("Supplier").
+		Preload("Creator").
+		Preload("Lines.RequestItem.Product").
+		Preload("Lines.ItemBudget")
+}
+
+// findWinningBudget retorna o orçamento vencedor de um item (o selecionado ou, na falta dele, o de menor preço)
+func findWinningBudget(db *gorm.DB, itemID uint) (*models.ItemBudget, error) {
+	var budget models.ItemBudget
+	if err := db.Where("request_item_id = ?", itemID).
+		Order("selected DESC, unit_price ASC, id ASC").
+		First(&budget).Error; err != nil {
+		return nil, err
+	}
+	return &budget, nil
+}
+
+// findReceivablePurchaseOrderLine localiza a linha de pedido ativa que atende o item
+func findReceivablePurchaseOrderLine(db *gorm.DB, itemID uint, lineID *uint) (*models.PurchaseOrderLine, error) {
+	var line models.PurchaseOrderLine
+	query := db.Preload("PurchaseOrder").Where("request_item_id = ?", itemID)
+	if lineID != nil {
+		query = query.Where("id = ?", *lineID)
+	} else {
+		query = query.Where("purchase_order_id IN (?)",
+			db.Model(&models.PurchaseOrder{}).Select("id").Where("status IN ?", []string{
+				models.PurchaseOrderStatusIssued,
+				models.PurchaseOrderStatusAcknowledged,
+				models.PurchaseOrderStatusPartiallyReceived,
+			}))
+	}
+	if err := query.Order("id ASC").First(&line).Error; err != nil {
+		return nil, err
+	}
+	return &line, nil
+}
+
+// applyReceiptToPurchaseOrderLine soma o recebimento na linha e recalcula o status do pedido
+func applyReceiptToPurchaseOrderLine(tx *gorm.DB, line *models.PurchaseOrderLine, acceptedQuantity int) error {
+	if err := tx.Model(line).
+		Update("quantity_received", gorm.Expr("quantity_received + ?", acceptedQuantity)).Error; err != nil {
+		return err
+	}
+
+	var order models.PurchaseOrder
+	if err := tx.Preload("Lines").First(&order, line.PurchaseOrderID).Error; err != nil {
+		return err
+	}
+
+	newStatus := order.ReceivingStatus()
+	if newStatus == order.Status {
+		return nil
+	}
+
+	updates := map[string]interface{}{"status": newStatus}
+	if newStatus == models.PurchaseOrderStatusReceived {
+		updates["received_at"] = time.Now()
+	}
+	return tx.Model(&order).Updates(updates).Error
+}
+
+// GetPurchaseOrderSupplierSummary compara, por fornecedor, o que foi pedido com o que foi recebido
+func GetPurchaseOrderSupplierSummary(db *gorm.DB) gin.HandlerFunc {
+	type supplierSummary struct {
+		SupplierID       uint    `json:"supplierId"`
+		SupplierName     string  `json:"supplierName"`
+		TotalOrders      int     `json:"totalOrders"`
+		QuantityOrdered  int     `json:"quantityOrdered"`
+		QuantityReceived int     `json:"quantityReceived"`
+		QuantityPending  int     `json:"quantityPending"`
+		AmountOrdered    float64 `json:"amountOrdered"`
+		AmountReceived   float64 `json:"amountReceived"`
+	}
+
+	return func(c *gin.Context) {
+		var summaries []supplierSummary
+		err := db.Table("purchase_order_lines pol").
+			Select(`
+				po.supplier_id as supplier_id,
+				s.name as supplier_name,
+				COUNT(DISTINCT po.id) as total_orders,
+				COALESCE(SUM(pol.quantity), 0) as quantity_ordered,
+				COALESCE(SUM(pol.quantity_received), 0) as quantity_received,
+				COALESCE(SUM(pol.quantity - pol.quantity_received), 0) as quantity_pending,
+				COALESCE(SUM(pol.total_price), 0) as amount_ordered,
+				COALESCE(SUM(pol.quantity_received * pol.unit_price), 0) as amount_received
+			`).
+			Joins("JOIN purchase_orders po ON po.id = pol.purchase_order_id AND po.deleted_at IS NULL").
+			Joins("JOIN suppliers s ON s.id = po.supplier_id").
+			Where("pol.deleted_at IS NULL AND po.status <> ?", models.PurchaseOrderStatusCancelled).
+			Group("po.supplier_id, s.name").
+			Order("s.name").
+			Scan(&summaries).Error
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao gerar resumo por fornecedor"})
+			return
+		}
+
+		c.JSON(http.StatusOK, summaries)
+	}
+}
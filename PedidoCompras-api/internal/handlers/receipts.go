@@ -3,8 +3,6 @@ package handlers
 import (
 	"fmt"
 	"net/http"
-	"os"
-	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -12,6 +10,10 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/models"
 	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/notifications"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/preview"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/rbac"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/scanner"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/storage"
 	"github.com/xuri/excelize/v2"
 	"gorm.io/gorm"
 )
@@ -27,7 +29,12 @@ type createReceiptInput struct {
 	ReceiptCondition string     `json:"receiptCondition" binding:"omitempty,oneof=good damaged partial_damage"`
 	QualityChecked   bool       `json:"qualityChecked"`
 	QualityNotes     string     `json:"qualityNotes"`
-	RejectedQuantity int        `json:"rejectedQuantity"`
+	RejectedQuantity int        `json:"rejectedQuantity" binding:"min=0"`
+
+	// Linha do pedido de compra atendida (opcional: se omitida, usa o pedido ativo do item)
+	PurchaseOrderLineID *uint `json:"purchaseOrderLineId"`
+	// Aceita receber mais do que o pedido de compra encomendou (purchase-orders:manage)
+	AllowOverReceipt bool `json:"allowOverReceipt"`
 }
 
 // CreateReceipt registra o recebimento de um item
@@ -40,13 +47,6 @@ func CreateReceipt(db *gorm.DB) gin.HandlerFunc {
 		fmt.Printf("  - User Role: %s\n", c.GetString("role"))
 		fmt.Printf("  - User ID: %s\n", c.GetString("userID"))
 
-		// Apenas admin pode registrar recebimentos
-		if c.GetString("role") != "admin" {
-			fmt.Printf("  - ❌ Acesso negado: usuário não é admin\n")
-			c.JSON(http.StatusForbidden, gin.H{"error": "Acesso restrito a administradores"})
-			return
-		}
-
 		itemID := c.Param("itemId")
 		userID := c.GetString("userID")
 
@@ -121,6 +121,15 @@ func CreateReceipt(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
+		if input.RejectedQuantity > input.QuantityReceived {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Quantidade rejeitada não pode ser maior que a recebida"})
+			return
+		}
+		if input.AllowOverReceipt && !rbac.Has(c, models.PermPurchaseOrdersManage) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Sem permissão para receber acima do pedido de compra"})
+			return
+		}
+
 		// Calcula quantidade já recebida
 		var totalReceived int64
 		db.Model(&models.ItemReceipt{}).
@@ -142,6 +151,46 @@ func CreateReceipt(db *gorm.DB) gin.HandlerFunc {
 			input.ReceiptCondition = "good"
 		}
 
+		// Localiza a linha do pedido de compra que este recebimento atende
+		orderLine, err := findReceivablePurchaseOrderLine(db, item.ID, input.PurchaseOrderLineID)
+		if err != nil && err != gorm.ErrRecordNotFound {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar pedido de compra"})
+			return
+		}
+		if input.PurchaseOrderLineID != nil {
+			if orderLine == nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Linha de pedido de compra não encontrada para este item"})
+				return
+			}
+			if !orderLine.PurchaseOrder.CanReceive() {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": fmt.Sprintf("Pedido de compra %s não aceita recebimentos (status: %s)",
+						orderLine.PurchaseOrder.Number, orderLine.PurchaseOrder.Status),
+				})
+				return
+			}
+		}
+
+		// O total aceito na linha não passa do que foi encomendado, salvo liberação explícita
+		if orderLine != nil && !input.AllowOverReceipt {
+			accepted := input.QuantityReceived - input.RejectedQuantity
+			if orderLine.QuantityReceived+accepted > orderLine.Quantity {
+				c.JSON(http.StatusConflict, gin.H{
+					"error": fmt.Sprintf("Quantidade excede o pedido de compra %s. Encomendado: %d, Já recebido: %d, Tentando receber: %d",
+						orderLine.PurchaseOrder.Number, orderLine.Quantity, orderLine.QuantityReceived, accepted),
+					"purchaseOrderLineId": orderLine.ID,
+					"allowOverReceipt":    "envie allowOverReceipt=true para confirmar o recebimento acima do pedido",
+				})
+				return
+			}
+		}
+
+		// Sem fornecedor informado, assume o fornecedor do pedido de compra
+		if input.SupplierID == nil && orderLine != nil {
+			supplierID := orderLine.PurchaseOrder.SupplierID
+			input.SupplierID = &supplierID
+		}
+
 		// Converte userID para uint
 		receiverID, _ := strconv.ParseUint(userID, 10, 64)
 
@@ -161,16 +210,63 @@ func CreateReceipt(db *gorm.DB) gin.HandlerFunc {
 			QualityNotes:     input.QualityNotes,
 			RejectedQuantity: input.RejectedQuantity,
 		}
+		if orderLine != nil {
+			receipt.PurchaseOrderLineID = &orderLine.ID
+		}
+
+		tx := db.Begin()
+		defer func() {
+			if r := recover(); r != nil {
+				tx.Rollback()
+			}
+		}()
 
-		if err := db.Create(&receipt).Error; err != nil {
+		if err := tx.Create(&receipt).Error; err != nil {
+			tx.Rollback()
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao registrar recebimento"})
 			return
 		}
 
+		// Atualiza quantidade recebida na linha e status do pedido de compra
+		if orderLine != nil {
+			if err := applyReceiptToPurchaseOrderLine(tx, orderLine, input.QuantityReceived-input.RejectedQuantity); err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar pedido de compra"})
+				return
+			}
+		}
+
+		// Consome a verba do setor pelo preço do pedido de compra (ou do orçamento selecionado)
+		// e estorna o empenho correspondente
+		unitPrice := 0.0
+		if orderLine != nil {
+			unitPrice = orderLine.UnitPrice
+		} else if unitPrice, err = itemReferencePrice(tx, item.PurchaseRequestID, item.ID); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao calcular consumo da verba"})
+			return
+		}
+		if err := recordBudgetConsumption(tx, &item.PurchaseRequest, &receipt, unitPrice, userID); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao registrar consumo da verba"})
+			return
+		}
+		if err := syncBudgetCommitments(tx, item.PurchaseRequestID, userID); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar verba do setor"})
+			return
+		}
+
+		if err := tx.Commit().Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao finalizar registro do recebimento"})
+			return
+		}
+
 		// Carrega o recebimento completo
 		if err := db.Preload("RequestItem.Product").
 			Preload("Receiver").
 			Preload("Supplier").
+			Preload("PurchaseOrderLine.PurchaseOrder").
 			First(&receipt, receipt.ID).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao carregar recebimento"})
 			return
@@ -200,9 +296,8 @@ func ListItemReceipts(db *gorm.DB) gin.HandlerFunc {
 		}
 
 		// Verifica permissões
-		userRole := c.GetString("role")
 		userID := c.GetString("userID")
-		if userRole != "admin" && strconv.FormatUint(uint64(item.PurchaseRequest.RequesterID), 10) != userID {
+		if !rbac.Has(c, models.PermReceiptsView) && strconv.FormatUint(uint64(item.PurchaseRequest.RequesterID), 10) != userID {
 			c.JSON(http.StatusForbidden, gin.H{"error": "Acesso negado"})
 			return
 		}
@@ -238,9 +333,8 @@ func GetReceivingStatus(db *gorm.DB) gin.HandlerFunc {
 		}
 
 		// Verifica permissões
-		userRole := c.GetString("role")
 		userID := c.GetString("userID")
-		if userRole != "admin" && strconv.FormatUint(uint64(request.RequesterID), 10) != userID {
+		if !rbac.Has(c, models.PermReceiptsView) && strconv.FormatUint(uint64(request.RequesterID), 10) != userID {
 			c.JSON(http.StatusForbidden, gin.H{"error": "Acesso negado"})
 			return
 		}
@@ -299,64 +393,15 @@ func GetReceivingStatus(db *gorm.DB) gin.HandlerFunc {
 	}
 }
 
-// UploadReceiptAttachment faz upload da nota fiscal digitalizada
-func UploadReceiptAttachment(db *gorm.DB) gin.HandlerFunc {
+// UploadReceiptAttachment anexa uma página da nota fiscal digitalizada ao recebimento
+// (anexo com categoria invoice; várias páginas por recebimento)
+func UploadReceiptAttachment(db *gorm.DB, store storage.Storage, scan scanner.Scanner, previews *preview.Generator) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Apenas admin pode fazer upload
-		if c.GetString("role") != "admin" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Acesso restrito a administradores"})
+		owner, ok := loadAttachmentOwner(db, c, models.AttachmentOwnerReceipt, c.Param("receiptId"))
+		if !ok {
 			return
 		}
-
-		receiptID := c.Param("receiptId")
-
-		// Verifica se o recebimento existe
-		var receipt models.ItemReceipt
-		if err := db.First(&receipt, receiptID).Error; err != nil {
-			if err == gorm.ErrRecordNotFound {
-				c.JSON(http.StatusNotFound, gin.H{"error": "Recebimento não encontrado"})
-			} else {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar recebimento"})
-			}
-			return
-		}
-
-		// Processa o arquivo
-		file, err := c.FormFile("file")
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Falha ao ler arquivo: " + err.Error()})
-			return
-		}
-
-		// Cria diretório específico para notas fiscais
-		uploadDir := filepath.Join("uploads", "invoices")
-		if err := os.MkdirAll(uploadDir, os.ModePerm); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao criar diretório"})
-			return
-		}
-
-		// Gera nome único
-		timestamp := time.Now().UnixNano()
-		filename := fmt.Sprintf("%d_receipt_%s_%s", timestamp, receiptID, filepath.Base(file.Filename))
-		fullpath := filepath.Join(uploadDir, filename)
-
-		// Salva o arquivo
-		if err := c.SaveUploadedFile(file, fullpath); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao salvar arquivo"})
-			return
-		}
-
-		// Atualiza o caminho no registro
-		receipt.AttachmentPath = fullpath
-		if err := db.Save(&receipt).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar registro"})
-			return
-		}
-
-		c.JSON(http.StatusOK, gin.H{
-			"message":  "Arquivo enviado com sucesso",
-			"filePath": fullpath,
-		})
+		createAttachment(c, db, store, scan, previews, owner, models.AttachmentCategoryInvoice)
 	}
 }
 
@@ -398,61 +443,39 @@ func GetRequestReceiptsSummary(db *gorm.DB) gin.HandlerFunc {
 	}
 }
 
-// DownloadReceiptInvoice faz o download da nota fiscal anexada
-func DownloadReceiptInvoice(db *gorm.DB) gin.HandlerFunc {
+// DownloadReceiptInvoice faz o download da nota fiscal anexada mais recente
+// (todas as páginas: GET /attachments?ownerType=receipt&ownerId=...&category=invoice)
+func DownloadReceiptInvoice(db *gorm.DB, store storage.Storage) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		receiptID := c.Param("receiptId")
-
-		// Busca o recebimento
-		var receipt models.ItemReceipt
-		if err := db.Preload("RequestItem.PurchaseRequest").First(&receipt, receiptID).Error; err != nil {
-			if err == gorm.ErrRecordNotFound {
-				c.JSON(http.StatusNotFound, gin.H{"error": "Recebimento não encontrado"})
-			} else {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar recebimento"})
-			}
+		owner, ok := loadAttachmentOwner(db, c, models.AttachmentOwnerReceipt, c.Param("receiptId"))
+		if !ok {
 			return
 		}
-
-		// Verifica permissões
-		userRole := c.GetString("role")
-		userID := c.GetString("userID")
-		if userRole != "admin" &&
-			strconv.FormatUint(uint64(receipt.RequestItem.PurchaseRequest.RequesterID), 10) != userID {
+		if !canViewOwner(db, c, owner) {
 			c.JSON(http.StatusForbidden, gin.H{"error": "Acesso negado"})
 			return
 		}
 
-		// Verifica se existe arquivo anexado
-		if receipt.AttachmentPath == "" {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Nenhuma nota fiscal anexada"})
-			return
-		}
-
-		// Verifica se o arquivo existe
-		if _, err := os.Stat(receipt.AttachmentPath); os.IsNotExist(err) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Arquivo não encontrado no servidor"})
+		var invoice models.Attachment
+		if err := db.Where("owner_type = ? AND owner_id = ? AND category = ?",
+			owner.Type, owner.ID, models.AttachmentCategoryInvoice).
+			Order("id DESC").
+			First(&invoice).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Nenhuma nota fiscal anexada"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar nota fiscal"})
+			}
 			return
 		}
 
-		// Define o nome do arquivo para download
-		downloadName := fmt.Sprintf("NF_%s_%s.pdf", receipt.InvoiceNumber,
-			receipt.CreatedAt.Format("20060102"))
-
-		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", downloadName))
-		c.File(receipt.AttachmentPath)
+		serveAttachment(c, store, &invoice)
 	}
 }
 
 // ExportReceiptsExcel gera relatório Excel de recebimentos
 func ExportReceiptsExcel(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Apenas admin pode gerar relatórios
-		if c.GetString("role") != "admin" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Acesso restrito a administradores"})
-			return
-		}
-
 		// Filtros opcionais
 		startDate := c.Query("startDate") // formato: 2024-01-01
 		endDate := c.Query("endDate")
@@ -0,0 +1,396 @@
+package handlers
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/models"
+	"github.com/xuri/excelize/v2"
+)
+
+// Nomes das abas geradas na exportação do relatório de requisições
+const (
+	reportSheetSummary  = "Resumo"
+	reportSheetRequests = "Requisições"
+	reportSheetItems    = "Itens"
+)
+
+// reportChartSheet - Descreve uma aba de gráfico gerada a partir de uma série de RequestsReportCharts
+type reportChartSheet struct {
+	Name       string
+	Title      string
+	LabelTitle string
+	Type       excelize.ChartType
+	Data       []models.ChartDataPoint
+}
+
+// buildRequestsReportWorkbook - Monta a planilha completa do relatório de requisições
+func buildRequestsReportWorkbook(reportData *models.RequestsReportData, filters models.ReportFilters) (*excelize.File, error) {
+	f := excelize.NewFile()
+
+	// A planilha nova já vem com "Sheet1"; renomeia para a aba de resumo
+	if err := f.SetSheetName("Sheet1", reportSheetSummary); err != nil {
+		return nil, err
+	}
+
+	headerStyle, err := f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true, Color: "FFFFFF"},
+		Fill: excelize.Fill{Type: "pattern", Color: []string{"1F4E78"}, Pattern: 1},
+	})
+	if err != nil {
+		return nil, err
+	}
+	titleStyle, err := f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true, Size: 14},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := writeReportSummarySheet(f, reportData.Summary, filters, titleStyle); err != nil {
+		return nil, err
+	}
+
+	if err := writeReportRequestsSheet(f, reportData.Requests, headerStyle); err != nil {
+		return nil, err
+	}
+
+	if filters.IncludeItems {
+		if err := writeReportItemsSheet(f, reportData.Requests, headerStyle); err != nil {
+			return nil, err
+		}
+	}
+
+	chartSheets := []reportChartSheet{
+		{Name: "Status", Title: "Distribuição por Status", LabelTitle: "Status", Type: excelize.Pie, Data: reportData.Charts.StatusDistribution},
+		{Name: "Linha do Tempo", Title: "Requisições nos Últimos 30 Dias", LabelTitle: "Data", Type: excelize.Line, Data: reportData.Charts.TimelineDays},
+		{Name: "Setores", Title: "Ranking de Setores", LabelTitle: "Setor", Type: excelize.Bar, Data: reportData.Charts.SectorRanking},
+		{Name: "Prioridades", Title: "Distribuição por Prioridade", LabelTitle: "Prioridade", Type: excelize.Col, Data: reportData.Charts.PriorityBreakdown},
+	}
+	for _, chartSheet := range chartSheets {
+		if err := writeReportChartSheet(f, chartSheet, headerStyle); err != nil {
+			return nil, err
+		}
+	}
+
+	f.SetActiveSheet(0)
+	return f, nil
+}
+
+// writeReportSummarySheet - Escreve os indicadores de RequestsReportSummary e os filtros aplicados
+func writeReportSummarySheet(f *excelize.File, summary models.RequestsReportSummary, filters models.ReportFilters, titleStyle int) error {
+	sheet := reportSheetSummary
+
+	f.SetCellValue(sheet, "A1", "RELATÓRIO DE REQUISIÇÕES")
+	f.SetCellStyle(sheet, "A1", "A1", titleStyle)
+	f.SetCellValue(sheet, "A2", "Gerado em:")
+	f.SetCellValue(sheet, "B2", time.Now().Format("02/01/2006 15:04"))
+
+	rows := [][]interface{}{
+		{"Total de Requisições", summary.TotalRequests},
+		{"Pendentes", summary.PendingRequests},
+		{"Aprovadas", summary.ApprovedRequests},
+		{"Rejeitadas", summary.RejectedRequests},
+		{"Concluídas", summary.CompletedRequests},
+		{"Urgentes", summary.UrgentRequests},
+		{"Tempo Médio de Processamento (dias)", summary.AverageProcessDays},
+		{"Total de Itens", summary.TotalItems},
+		{"Setor Mais Ativo", summary.MostActiveSector},
+		{"Solicitante Mais Ativo", summary.MostActiveRequester},
+		{"Valor Estimado (R$)", summary.TotalEstimatedValue},
+		{"Valor Cotado (R$)", summary.TotalQuotedValue},
+		{"Valor Realizado (R$)", summary.TotalActualValue},
+		{"Valor Médio por Requisição (R$)", summary.AverageRequestValue},
+	}
+
+	f.SetCellValue(sheet, "A4", "INDICADORES")
+	row := 5
+	for _, r := range rows {
+		if err := f.SetSheetRow(sheet, fmt.Sprintf("A%d", row), &r); err != nil {
+			return err
+		}
+		row++
+	}
+
+	// Filtros aplicados (mesmos de parseReportFilters)
+	row++
+	f.SetCellValue(sheet, fmt.Sprintf("A%d", row), "FILTROS APLICADOS")
+	row++
+	for _, r := range describeReportFilters(filters) {
+		if err := f.SetSheetRow(sheet, fmt.Sprintf("A%d", row), &r); err != nil {
+			return err
+		}
+		row++
+	}
+
+	return f.SetColWidth(sheet, "A", "A", 38)
+}
+
+// describeReportFilters - Converte os filtros em linhas legíveis para a aba de resumo
+func describeReportFilters(filters models.ReportFilters) [][]interface{} {
+	var rows [][]interface{}
+
+	if filters.StartDate != nil {
+		rows = append(rows, []interface{}{"Data Inicial", filters.StartDate.Format("02/01/2006")})
+	}
+	if filters.EndDate != nil {
+		rows = append(rows, []interface{}{"Data Final", filters.EndDate.Format("02/01/2006")})
+	}
+	if filters.SectorID != nil {
+		rows = append(rows, []interface{}{"Setor (ID)", *filters.SectorID})
+	}
+	if filters.RequesterID != nil {
+		rows = append(rows, []interface{}{"Solicitante (ID)", *filters.RequesterID})
+	}
+	if filters.Status != nil {
+		rows = append(rows, []interface{}{"Status", *filters.Status})
+	}
+	if filters.Priority != nil {
+		rows = append(rows, []interface{}{"Prioridade", *filters.Priority})
+	}
+	if len(rows) == 0 {
+		rows = append(rows, []interface{}{"Nenhum filtro aplicado", ""})
+	}
+
+	return rows
+}
+
+// writeReportRequestsSheet - Escreve uma linha por requisição do relatório
+func writeReportRequestsSheet(f *excelize.File, requests []models.RequestReportItem, headerStyle int) error {
+	sheet := reportSheetRequests
+	if _, err := f.NewSheet(sheet); err != nil {
+		return err
+	}
+
+	headers := []string{
+		"ID", "Solicitante", "Email", "Setor", "Status", "Prioridade", "Criado Em",
+		"Revisado Em", "Concluído Em", "Dias de Processamento", "Total de Itens",
+		"Valor Estimado", "Valor Cotado", "Valor Realizado", "Observações", "Notas Admin",
+	}
+	if err := writeReportHeader(f, sheet, headers, headerStyle); err != nil {
+		return err
+	}
+
+	for r, req := range requests {
+		row := r + 2
+		values := []interface{}{
+			req.ID,
+			req.RequesterName,
+			req.RequesterEmail,
+			req.SectorName,
+			req.Status,
+			req.Priority,
+			req.CreatedAt.Format("02/01/2006 15:04"),
+			formatReportDate(req.ReviewedAt),
+			formatReportDate(req.CompletedAt),
+			"-",
+			req.TotalItems,
+			req.EstimatedValue,
+			req.QuotedValue,
+			req.ActualValue,
+			req.Observations,
+			req.AdminNotes,
+		}
+		if req.ProcessDays != nil {
+			values[9] = *req.ProcessDays
+		}
+		if err := f.SetSheetRow(sheet, fmt.Sprintf("A%d", row), &values); err != nil {
+			return err
+		}
+	}
+
+	return f.SetColWidth(sheet, "B", "D", 24)
+}
+
+// writeReportItemsSheet - Escreve os itens de cada requisição (quando IncludeItems está ativo)
+func writeReportItemsSheet(f *excelize.File, requests []models.RequestReportItem, headerStyle int) error {
+	sheet := reportSheetItems
+	if _, err := f.NewSheet(sheet); err != nil {
+		return err
+	}
+
+	headers := []string{
+		"Requisição", "Produto", "Quantidade", "Unidade", "Status", "Prazo",
+		"Preço Estimado", "Preço Cotado", "Valor Realizado",
+	}
+	if err := writeReportHeader(f, sheet, headers, headerStyle); err != nil {
+		return err
+	}
+
+	row := 2
+	for _, req := range requests {
+		for _, item := range req.Items {
+			values := []interface{}{
+				req.ID,
+				item.ProductName,
+				item.Quantity,
+				item.Unit,
+				item.Status,
+				formatReportDate(item.Deadline),
+				formatReportPrice(item.EstimatedUnitPrice),
+				formatReportPrice(item.QuotedUnitPrice),
+				item.ActualValue,
+			}
+			if err := f.SetSheetRow(sheet, fmt.Sprintf("A%d", row), &values); err != nil {
+				return err
+			}
+			row++
+		}
+	}
+
+	return f.SetColWidth(sheet, "B", "B", 32)
+}
+
+// writeReportChartSheet - Escreve a série de dados e adiciona o gráfico nativo do Excel
+func writeReportChartSheet(f *excelize.File, chartSheet reportChartSheet, headerStyle int) error {
+	sheet := chartSheet.Name
+	if _, err := f.NewSheet(sheet); err != nil {
+		return err
+	}
+
+	if err := writeReportHeader(f, sheet, []string{chartSheet.LabelTitle, "Quantidade"}, headerStyle); err != nil {
+		return err
+	}
+	if err := f.SetColWidth(sheet, "A", "A", 28); err != nil {
+		return err
+	}
+
+	// Sem dados não há gráfico: o Excel não aceita séries com intervalo vazio
+	if len(chartSheet.Data) == 0 {
+		f.SetCellValue(sheet, "A2", "Sem dados para os filtros informados")
+		return nil
+	}
+
+	for i, point := range chartSheet.Data {
+		row := i + 2
+		f.SetCellValue(sheet, fmt.Sprintf("A%d", row), point.Label)
+		f.SetCellValue(sheet, fmt.Sprintf("B%d", row), point.Value)
+	}
+
+	lastRow := len(chartSheet.Data) + 1
+	chart := &excelize.Chart{
+		Type: chartSheet.Type,
+		Series: []excelize.ChartSeries{
+			{
+				Name:       fmt.Sprintf("'%s'!$B$1", sheet),
+				Categories: fmt.Sprintf("'%s'!$A$2:$A$%d", sheet, lastRow),
+				Values:     fmt.Sprintf("'%s'!$B$2:$B$%d", sheet, lastRow),
+			},
+		},
+		Title:     []excelize.RichTextRun{{Text: chartSheet.Title}},
+		Legend:    excelize.ChartLegend{Position: "bottom"},
+		PlotArea:  excelize.ChartPlotArea{ShowVal: true},
+		Dimension: excelize.ChartDimension{Width: 640, Height: 360},
+	}
+	if chartSheet.Type == excelize.Pie {
+		chart.PlotArea.ShowPercent = true
+	} else {
+		chart.Legend.Position = "none"
+	}
+
+	return f.AddChart(sheet, "D2", chart)
+}
+
+// writeReportHeader - Escreve e formata a linha de cabeçalho de uma aba
+func writeReportHeader(f *excelize.File, sheet string, headers []string, headerStyle int) error {
+	for i, h := range headers {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+		f.SetCellValue(sheet, cell, h)
+	}
+	lastCell, _ := excelize.CoordinatesToCellName(len(headers), 1)
+	return f.SetCellStyle(sheet, "A1", lastCell, headerStyle)
+}
+
+// formatReportPrice - Preços opcionais para a planilha ("-" quando não informado)
+func formatReportPrice(price *float64) interface{} {
+	if price == nil {
+		return "-"
+	}
+	return *price
+}
+
+// formatReportDate - Formata datas opcionais para a planilha
+func formatReportDate(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Format("02/01/2006")
+}
+
+// formatReportDateTime - Formata data e hora opcionais para a planilha
+func formatReportDateTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return "-"
+	}
+	return t.Format("02/01/2006 15:04")
+}
+
+// buildSectorBudgetWorkbook - Monta a planilha do relatório de verbas (orçado x empenhado x realizado)
+func buildSectorBudgetWorkbook(statuses []models.SectorBudgetStatus, totals map[string]interface{}) (*excelize.File, error) {
+	f := excelize.NewFile()
+
+	sheet := "Verbas"
+	if err := f.SetSheetName("Sheet1", sheet); err != nil {
+		return nil, err
+	}
+
+	headerStyle, err := f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true, Color: "FFFFFF"},
+		Fill: excelize.Fill{Type: "pattern", Color: []string{"1F4E78"}, Pattern: 1},
+	})
+	if err != nil {
+		return nil, err
+	}
+	totalStyle, err := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return nil, err
+	}
+
+	headers := []string{
+		"Setor", "Centro de Custo", "Período", "Início", "Fim", "Política",
+		"Orçado", "Empenhado", "Realizado", "Disponível", "Uso (%)",
+	}
+	if err := writeReportHeader(f, sheet, headers, headerStyle); err != nil {
+		return nil, err
+	}
+
+	policies := map[string]string{
+		models.BudgetEnforcementWarn:  "Avisar",
+		models.BudgetEnforcementBlock: "Bloquear",
+	}
+	for r, s := range statuses {
+		values := []interface{}{
+			s.SectorName,
+			s.CostCenter,
+			s.PeriodLabel,
+			s.PeriodStart.Format("02/01/2006"),
+			s.PeriodEnd.AddDate(0, 0, -1).Format("02/01/2006"),
+			policies[s.Enforcement],
+			s.Amount,
+			s.Committed,
+			s.Consumed,
+			s.Available,
+			s.UsagePercent,
+		}
+		if err := f.SetSheetRow(sheet, fmt.Sprintf("A%d", r+2), &values); err != nil {
+			return nil, err
+		}
+	}
+
+	totalRow := len(statuses) + 2
+	values := []interface{}{
+		"TOTAL", "", "", "", "", "",
+		totals["amount"], totals["committed"], totals["consumed"], totals["available"], totals["usagePercent"],
+	}
+	if err := f.SetSheetRow(sheet, fmt.Sprintf("A%d", totalRow), &values); err != nil {
+		return nil, err
+	}
+	if err := f.SetCellStyle(sheet, fmt.Sprintf("A%d", totalRow), fmt.Sprintf("K%d", totalRow), totalStyle); err != nil {
+		return nil, err
+	}
+
+	if err := f.SetColWidth(sheet, "A", "A", 28); err != nil {
+		return nil, err
+	}
+	return f, f.SetColWidth(sheet, "B", "K", 16)
+}
@@ -8,6 +8,8 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/models"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/rbac"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/utils"
 	"gorm.io/gorm"
 )
 
@@ -27,12 +29,6 @@ type RequestsReportFilters struct {
 // GetRequestsReport - Gera relatório de requisições
 func GetRequestsReport(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Verificar se é admin (relatórios só para admins)
-		if c.GetString("role") != "admin" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Acesso restrito a administradores"})
-			return
-		}
-
 		// Parse dos filtros
 		var filters RequestsReportFilters
 		if err := c.ShouldBindQuery(&filters); err != nil {
@@ -46,6 +42,9 @@ func GetRequestsReport(db *gorm.DB) gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Filtros inválidos: %v", err)})
 			return
 		}
+		if !scopeReportFilters(c, db, &reportFilters) {
+			return
+		}
 
 		// Configurar paginação
 		page := 1
@@ -72,6 +71,38 @@ func GetRequestsReport(db *gorm.DB) gin.HandlerFunc {
 	}
 }
 
+// scopeReportFilters - Sem reports:view, restringe o relatório ao setor gerido pelo usuário
+// ou a um de seus subsetores (o SectorID forçado é aplicado por applyFilters em todas as consultas)
+func scopeReportFilters(c *gin.Context, db *gorm.DB, filters *models.ReportFilters) bool {
+	if rbac.Has(c, models.PermReportsView) {
+		return true
+	}
+
+	userID := utils.ParseUint(c.GetString("userID"))
+	managed := directlyManagedSectorIDs(db, userID)
+	if len(managed) == 0 {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Permissão insuficiente", "permission": models.PermReportsView})
+		return false
+	}
+
+	if filters.SectorID == nil {
+		if len(managed) > 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Informe o setor (sectorId) do relatório", "sectorIds": managed})
+			return false
+		}
+		filters.SectorID = &managed[0]
+		return true
+	}
+
+	for _, id := range sectorSubtreeIDs(db, managed) {
+		if id == *filters.SectorID {
+			return true
+		}
+	}
+	c.JSON(http.StatusForbidden, gin.H{"error": "Acesso negado ao relatório deste setor"})
+	return false
+}
+
 // parseReportFilters - Converte filtros string para estrutura
 func parseReportFilters(input RequestsReportFilters) (models.ReportFilters, error) {
 	filters := models.ReportFilters{}
@@ -162,7 +193,10 @@ func generateRequestsReport(db *gorm.DB, filters models.ReportFilters, page, pag
 	}
 	reportData.Summary = *summary
 
-	// Converter requisições
+	// Converter requisições (com os valores estimado, cotado e realizado)
+	if err := fillRequestValues(db, requests); err != nil {
+		return nil, err
+	}
 	reportData.Requests = convertRequestsToReport(requests)
 
 	// Gráficos
@@ -172,6 +206,26 @@ func generateRequestsReport(db *gorm.DB, filters models.ReportFilters, page, pag
 	}
 	reportData.Charts = *charts
 
+	// Valores (R$): totais do resumo e métricas por status, setor e prioridade
+	valueTotals, averageValue, valueMetrics, err := reportValueMetrics(db, filters)
+	if err != nil {
+		return nil, err
+	}
+	reportData.Summary.TotalEstimatedValue = valueTotals.Estimated
+	reportData.Summary.TotalQuotedValue = valueTotals.Quoted
+	reportData.Summary.TotalActualValue = valueTotals.Actual
+	reportData.Summary.AverageRequestValue = averageValue
+	reportData.Charts.ValueByStatus = valueMetrics["status"]
+	reportData.Charts.ValueBySector = valueMetrics["sector"]
+	reportData.Charts.ValueByPriority = valueMetrics["priority"]
+
+	// Árvore de setores com requisições e gastos acumulados
+	sectorTree, err := sectorRollup(db, filters)
+	if err != nil {
+		return nil, err
+	}
+	reportData.Sectors = sectorTree
+
 	// Paginação
 	totalPages := int((totalCount + int64(pageSize) - 1) / int64(pageSize))
 	reportData.Pagination = models.PaginationInfo{
@@ -184,6 +238,14 @@ func generateRequestsReport(db *gorm.DB, filters models.ReportFilters, page, pag
 	return reportData, nil
 }
 
+// reportSectorClause - Filtro de setor para as consultas SQL montadas à mão (fora de applyFilters)
+func reportSectorClause(filters models.ReportFilters, column string) (string, []interface{}) {
+	if filters.SectorID == nil {
+		return "", nil
+	}
+	return " AND " + column + " IN (" + sectorSubtreeSQL + ")", []interface{}{[]uint{*filters.SectorID}}
+}
+
 // applyFilters - Aplica filtros na query
 func applyFilters(query *gorm.DB, filters models.ReportFilters) *gorm.DB {
 	if filters.StartDate != nil {
@@ -194,8 +256,9 @@ func applyFilters(query *gorm.DB, filters models.ReportFilters) *gorm.DB {
 		query = query.Where("created_at <= ?", *filters.EndDate)
 	}
 
+	// Setor inclui os subsetores (totais acumulados pela árvore)
 	if filters.SectorID != nil {
-		query = query.Where("sector_id = ?", *filters.SectorID)
+		query = query.Where("sector_id IN ("+sectorSubtreeSQL+")", []uint{*filters.SectorID})
 	}
 
 	if filters.RequesterID != nil {
@@ -257,13 +320,15 @@ func generateSummary(db *gorm.DB, filters models.ReportFilters) (*models.Request
 	urgentQuery.Where("priority = ?", "urgent").Count(&urgentCount)
 	summary.UrgentRequests = int(urgentCount)
 
+	// Consultas abaixo respeitam o setor do filtro (relatório do gestor não expõe outros setores)
+	sectorClause, sectorArgs := reportSectorClause(filters, "pr.sector_id")
+
 	// Tempo médio de processamento
 	var avgDays float64
 	db.Raw(`
-		SELECT AVG(DATEDIFF(COALESCE(reviewed_at, NOW()), created_at)) 
-		FROM purchase_requests 
-		WHERE reviewed_at IS NOT NULL
-	`).Scan(&avgDays)
+		SELECT AVG(DATEDIFF(COALESCE(pr.reviewed_at, NOW()), pr.created_at)) 
+		FROM purchase_requests pr 
+		WHERE pr.reviewed_at IS NOT NULL`+sectorClause, sectorArgs...).Scan(&avgDays)
 	summary.AverageProcessDays = avgDays
 
 	// Total de itens
@@ -272,8 +337,7 @@ func generateSummary(db *gorm.DB, filters models.ReportFilters) (*models.Request
 		SELECT COUNT(*) 
 		FROM request_items ri 
 		INNER JOIN purchase_requests pr ON ri.purchase_request_id = pr.id
-		WHERE pr.deleted_at IS NULL
-	`).Scan(&totalItems)
+		WHERE pr.deleted_at IS NULL`+sectorClause, sectorArgs...).Scan(&totalItems)
 	summary.TotalItems = int(totalItems)
 
 	// Setor mais ativo
@@ -282,11 +346,11 @@ func generateSummary(db *gorm.DB, filters models.ReportFilters) (*models.Request
 		SELECT s.name 
 		FROM sectors s 
 		INNER JOIN purchase_requests pr ON pr.sector_id = s.id 
-		WHERE pr.deleted_at IS NULL 
+		WHERE pr.deleted_at IS NULL`+sectorClause+`
 		GROUP BY s.id, s.name 
 		ORDER BY COUNT(*) DESC 
 		LIMIT 1
-	`).Scan(&mostActiveSector)
+	`, sectorArgs...).Scan(&mostActiveSector)
 	summary.MostActiveSector = mostActiveSector
 
 	// Solicitante mais ativo
@@ -295,11 +359,11 @@ func generateSummary(db *gorm.DB, filters models.ReportFilters) (*models.Request
 		SELECT u.name 
 		FROM users u 
 		INNER JOIN purchase_requests pr ON pr.requester_id = u.id 
-		WHERE pr.deleted_at IS NULL 
+		WHERE pr.deleted_at IS NULL`+sectorClause+`
 		GROUP BY u.id, u.name 
 		ORDER BY COUNT(*) DESC 
 		LIMIT 1
-	`).Scan(&mostActiveRequester)
+	`, sectorArgs...).Scan(&mostActiveRequester)
 	summary.MostActiveRequester = mostActiveRequester
 
 	return summary, nil
@@ -321,6 +385,9 @@ func convertRequestsToReport(requests []models.PurchaseRequest) []models.Request
 			ReviewedAt:     req.ReviewedAt,
 			CompletedAt:    req.CompletedAt,
 			TotalItems:     len(req.Items),
+			EstimatedValue: req.EstimatedValue,
+			QuotedValue:    req.QuotedValue,
+			ActualValue:    req.ActualValue,
 			Observations:   req.Observations,
 			AdminNotes:     req.AdminNotes,
 		}
@@ -340,6 +407,10 @@ func convertRequestsToReport(requests []models.PurchaseRequest) []models.Request
 					Unit:        reqItem.Product.Unit,
 					Status:      reqItem.Status,
 					Deadline:    reqItem.Deadline,
+
+					EstimatedUnitPrice: reqItem.EstimatedUnitPrice,
+					QuotedUnitPrice:    reqItem.QuotedUnitPrice,
+					ActualValue:        reqItem.ActualValue,
 				}
 				item.Items = append(item.Items, detail)
 			}
@@ -368,29 +439,23 @@ func generateCharts(db *gorm.DB, filters models.ReportFilters) (*models.Requests
 
 	// Timeline por dias (últimos 30 dias)
 	var timelineData []models.ChartDataPoint
+	sectorClause, sectorArgs := reportSectorClause(filters, "sector_id")
 	db.Raw(`
 		SELECT DATE(created_at) as label, COUNT(*) as value 
 		FROM purchase_requests 
 		WHERE created_at >= DATE_SUB(NOW(), INTERVAL 30 DAY)
-		AND deleted_at IS NULL
+		AND deleted_at IS NULL`+sectorClause+`
 		GROUP BY DATE(created_at) 
 		ORDER BY DATE(created_at)
-	`).Scan(&timelineData)
+	`, sectorArgs...).Scan(&timelineData)
 	charts.TimelineDays = timelineData
 
-	// Ranking por setor
-	var sectorData []models.ChartDataPoint
-	err = applyFilters(db.Model(&models.PurchaseRequest{}), filters).
-		Select("sectors.name as label, COUNT(*) as value").
-		Joins("INNER JOIN sectors ON sectors.id = purchase_requests.sector_id").
-		Group("sectors.id, sectors.name").
-		Order("COUNT(*) DESC").
-		Limit(10).
-		Scan(&sectorData).Error
+	// Ranking por setor (acumulado pela árvore de setores)
+	sectorTree, err := sectorRollup(db, filters)
 	if err != nil {
 		return nil, err
 	}
-	charts.SectorRanking = sectorData
+	charts.SectorRanking = sectorRanking(sectorTree, filters)
 
 	// Distribuição por prioridade
 	var priorityData []models.ChartDataPoint
@@ -409,12 +474,6 @@ func generateCharts(db *gorm.DB, filters models.ReportFilters) (*models.Requests
 // ExportRequestsReport - Exporta relatório em Excel
 func ExportRequestsReport(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Verificar se é admin
-		if c.GetString("role") != "admin" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Acesso restrito a administradores"})
-			return
-		}
-
 		// Parse dos filtros
 		var filters RequestsReportFilters
 		if err := c.ShouldBindQuery(&filters); err != nil {
@@ -427,6 +486,9 @@ func ExportRequestsReport(db *gorm.DB) gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Filtros inválidos: %v", err)})
 			return
 		}
+		if !scopeReportFilters(c, db, &reportFilters) {
+			return
+		}
 
 		// Gerar relatório completo (sem paginação para export)
 		reportData, err := generateRequestsReport(db, reportFilters, 1, 10000)
@@ -435,12 +497,18 @@ func ExportRequestsReport(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
-		// TODO: Implementar geração de Excel usando uma lib como excelize
-		// Por agora, retorna JSON para download
-		filename := fmt.Sprintf("relatorio_requisicoes_%s.json", time.Now().Format("2006-01-02"))
+		// Monta a planilha com resumo, requisições, itens e gráficos
+		f, err := buildRequestsReportWorkbook(reportData, reportFilters)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Erro ao gerar planilha: %v", err)})
+			return
+		}
+		defer f.Close()
 
+		filename := fmt.Sprintf("relatorio_requisicoes_%s.xlsx", time.Now().Format("2006-01-02"))
+
+		c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
 		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
-		c.Header("Content-Type", "application/json")
-		c.JSON(http.StatusOK, reportData)
+		_ = f.Write(c.Writer)
 	}
 }
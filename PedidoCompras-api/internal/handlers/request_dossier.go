@@ -0,0 +1,504 @@
+package handlers
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/models"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/storage"
+)
+
+// maxDossierPeriodDays - Período máximo do dossiê por intervalo de datas
+const maxDossierPeriodDays = 366
+
+// dossierSummaryFile - Planilha com o resumo da requisição, na raiz de cada dossiê
+const dossierSummaryFile = "resumo.xlsx"
+
+// requestDossier - Dados de uma requisição reunidos para o dossiê de auditoria
+type requestDossier struct {
+	Request     models.PurchaseRequest
+	Budgets     []models.ItemBudget
+	Receipts    []models.ItemReceipt
+	History     []models.RequestStatusHistory
+	Attachments []models.Attachment
+
+	// Situação de cada anexo no ZIP (preenchida ao gravar os arquivos)
+	FileNotes map[uint]string
+}
+
+// ExportRequestDossier envia em streaming um ZIP com todos os anexos da requisição
+// (inclusive os dos itens, orçamentos e as notas fiscais dos recebimentos), organizados
+// em pastas, e a planilha resumo.xlsx com requisição, itens, orçamentos, aprovações e recebimentos
+func ExportRequestDossier(db *gorm.DB, store storage.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		request, ok := loadAttachmentRequest(db, c)
+		if !ok {
+			return
+		}
+
+		dossier, err := loadRequestDossier(db, request.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar dados da requisição"})
+			return
+		}
+
+		filename := fmt.Sprintf("dossie_requisicao_%d.zip", request.ID)
+		startDossierZip(c, filename)
+		zw := zip.NewWriter(c.Writer)
+		if err := writeRequestDossier(c, zw, store, "", dossier); err != nil {
+			fmt.Printf("❌ Erro ao gerar dossiê da requisição %d: %v\n", request.ID, err)
+			return
+		}
+		if err := zw.Close(); err != nil {
+			fmt.Printf("❌ Erro ao finalizar dossiê da requisição %d: %v\n", request.ID, err)
+		}
+	}
+}
+
+// ExportRequestDossiers gera um ZIP com um dossiê por requisição criada no período
+// (?startDate=AAAA-MM-DD&endDate=AAAA-MM-DD, opcional &sectorId=), uma pasta por requisição.
+// Inclui apenas as requisições visíveis ao usuário.
+func ExportRequestDossiers(db *gorm.DB, store storage.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		startDate, errStart := time.Parse("2006-01-02", c.Query("startDate"))
+		endDate, errEnd := time.Parse("2006-01-02", c.Query("endDate"))
+		if errStart != nil || errEnd != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Informe startDate e endDate no formato AAAA-MM-DD"})
+			return
+		}
+		endDate = endDate.AddDate(0, 0, 1) // inclui todo o último dia
+		if !endDate.After(startDate) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "A data final deve ser posterior à inicial"})
+			return
+		}
+		if endDate.Sub(startDate) > maxDossierPeriodDays*24*time.Hour {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Período máximo de %d dias", maxDossierPeriodDays)})
+			return
+		}
+
+		query := db.Model(&models.PurchaseRequest{}).
+			Where("purchase_requests.created_at >= ? AND purchase_requests.created_at < ?", startDate, endDate)
+		if sectorID := c.Query("sectorId"); sectorID != "" {
+			id, err := strconv.ParseUint(sectorID, 10, 64)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "ID de setor inválido"})
+				return
+			}
+			query = query.Where("purchase_requests.sector_id = ?", id)
+		}
+
+		var requestIDs []uint
+		if err := scopeVisibleRequests(db, c, query).Order("purchase_requests.id").Pluck("purchase_requests.id", &requestIDs).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar requisições"})
+			return
+		}
+		if len(requestIDs) == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Nenhuma requisição no período"})
+			return
+		}
+
+		filename := fmt.Sprintf("dossies_%s_%s.zip", startDate.Format("20060102"), endDate.AddDate(0, 0, -1).Format("20060102"))
+		startDossierZip(c, filename)
+		zw := zip.NewWriter(c.Writer)
+
+		// cada dossiê é carregado e gravado por vez, para não manter o período inteiro em memória
+		for _, requestID := range requestIDs {
+			dossier, err := loadRequestDossier(db, requestID)
+			if err != nil {
+				// o ZIP já começou a ser enviado: registra a falha dentro dele e segue
+				fmt.Printf("❌ Erro ao buscar dados da requisição %d para o dossiê: %v\n", requestID, err)
+				if err := writeDossierNote(zw, fmt.Sprintf("requisicao_%d/ERRO.txt", requestID), "Não foi possível carregar os dados desta requisição."); err != nil {
+					return
+				}
+				continue
+			}
+			if err := writeRequestDossier(c, zw, store, fmt.Sprintf("requisicao_%d/", requestID), dossier); err != nil {
+				fmt.Printf("❌ Erro ao gerar dossiê da requisição %d: %v\n", requestID, err)
+				return
+			}
+		}
+		if err := zw.Close(); err != nil {
+			fmt.Printf("❌ Erro ao finalizar dossiês do período: %v\n", err)
+		}
+	}
+}
+
+// startDossierZip envia os cabeçalhos do ZIP; o tamanho não é conhecido (chunked)
+func startDossierZip(c *gin.Context, filename string) {
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", contentDisposition("attachment", filename))
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Status(http.StatusOK)
+}
+
+// loadRequestDossier busca a requisição e tudo o que compõe o dossiê
+func loadRequestDossier(db *gorm.DB, requestID uint) (*requestDossier, error) {
+	dossier := &requestDossier{FileNotes: map[uint]string{}}
+
+	if err := db.
+		Preload("Requester").
+		Preload("Sector").
+		Preload("Items", func(tx *gorm.DB) *gorm.DB { return tx.Order("id") }).
+		Preload("Items.Product").
+		Preload("ApprovalSteps", func(tx *gorm.DB) *gorm.DB { return tx.Order("sequence") }).
+		Preload("ApprovalSteps.ApproverUser").
+		Preload("ApprovalSteps.Decider").
+		First(&dossier.Request, requestID).Error; err != nil {
+		return nil, err
+	}
+	requests := []models.PurchaseRequest{dossier.Request}
+	if err := fillRequestValues(db, requests); err != nil {
+		return nil, err
+	}
+	dossier.Request = requests[0]
+
+	if err := db.
+		Preload("Supplier").
+		Preload("RequestItem.Product").
+		Where("purchase_request_id = ?", requestID).
+		Order("request_item_id, id").
+		Find(&dossier.Budgets).Error; err != nil {
+		return nil, err
+	}
+
+	if err := db.
+		Preload("RequestItem.Product").
+		Preload("Receiver").
+		Preload("Supplier").
+		Joins("JOIN request_items ON request_items.id = item_receipts.request_item_id").
+		Where("request_items.purchase_request_id = ?", requestID).
+		Order("item_receipts.id").
+		Find(&dossier.Receipts).Error; err != nil {
+		return nil, err
+	}
+
+	if err := db.
+		Preload("Changer").
+		Where("purchase_request_id = ?", requestID).
+		Order("id").
+		Find(&dossier.History).Error; err != nil {
+		return nil, err
+	}
+
+	if err := db.
+		Where("purchase_request_id = ?", requestID).
+		Order("id").
+		Find(&dossier.Attachments).Error; err != nil {
+		return nil, err
+	}
+	return dossier, nil
+}
+
+// writeRequestDossier grava os anexos e, por último, o resumo (que registra o que entrou no ZIP).
+// Anexos em quarentena, infectados ou ausentes do armazenamento ficam de fora e são anotados no resumo.
+// Devolve erro apenas quando não é mais possível escrever no ZIP (ex: cliente desconectou).
+func writeRequestDossier(c *gin.Context, zw *zip.Writer, store storage.Storage, prefix string, dossier *requestDossier) error {
+	used := map[string]bool{}
+	for i := range dossier.Attachments {
+		attachment := &dossier.Attachments[i]
+		switch attachment.ScanStatus {
+		case models.ScanStatusInfected:
+			dossier.FileNotes[attachment.ID] = "Não incluído: malware detectado (" + attachment.ScanSignature + ")"
+			continue
+		case models.ScanStatusClean:
+		default:
+			dossier.FileNotes[attachment.ID] = "Não incluído: em verificação antivírus"
+			continue
+		}
+
+		name := prefix + dossierFileName(attachment, used)
+		written, err := copyStoredToZip(c, zw, store, attachment, name)
+		if err != nil {
+			return err
+		}
+		if !written {
+			dossier.FileNotes[attachment.ID] = "Não incluído: arquivo não encontrado no armazenamento"
+			continue
+		}
+		dossier.FileNotes[attachment.ID] = name
+	}
+
+	f, err := buildDossierWorkbook(dossier)
+	if err != nil {
+		fmt.Printf("❌ Erro ao montar resumo da requisição %d: %v\n", dossier.Request.ID, err)
+		return writeDossierNote(zw, prefix+"ERRO.txt", "Não foi possível gerar o resumo desta requisição.")
+	}
+	defer f.Close()
+
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: prefix + dossierSummaryFile, Method: zip.Deflate, Modified: time.Now()})
+	if err != nil {
+		return err
+	}
+	return f.Write(w)
+}
+
+// copyStoredToZip copia um anexo do armazenamento para o ZIP sem carregá-lo em memória.
+// Devolve false (sem erro) quando o arquivo não pode ser aberto no armazenamento.
+func copyStoredToZip(c *gin.Context, zw *zip.Writer, store storage.Storage, attachment *models.Attachment, name string) (bool, error) {
+	reader, _, err := store.Open(c.Request.Context(), attachment.FilePath)
+	if err != nil {
+		fmt.Printf("❌ Erro ao abrir anexo %d (%s) para o dossiê: %v\n", attachment.ID, attachment.FilePath, err)
+		return false, nil
+	}
+	defer reader.Close()
+
+	// PDFs e imagens já são comprimidos: gravados sem nova compressão
+	method := zip.Deflate
+	if attachment.ContentType == "application/pdf" || strings.HasPrefix(attachment.ContentType, "image/") {
+		method = zip.Store
+	}
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: method, Modified: attachment.UpdatedAt})
+	if err != nil {
+		return false, err
+	}
+	if _, err := io.Copy(w, reader); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// writeDossierNote grava um arquivo de texto curto no ZIP
+func writeDossierNote(zw *zip.Writer, name, text string) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, text+"\n")
+	return err
+}
+
+// dossierFileName monta o caminho do anexo no dossiê: pasta pelo dono e o ID do anexo
+// no nome, para que arquivos com o mesmo nome não colidam
+func dossierFileName(attachment *models.Attachment, used map[string]bool) string {
+	var folder string
+	switch attachment.OwnerType {
+	case models.AttachmentOwnerItem:
+		folder = fmt.Sprintf("itens/item_%d/", attachment.OwnerID)
+	case models.AttachmentOwnerBudget:
+		folder = fmt.Sprintf("orcamentos/orcamento_%d/", attachment.OwnerID)
+	case models.AttachmentOwnerReceipt:
+		folder = fmt.Sprintf("recebimentos/recebimento_%d/", attachment.OwnerID)
+	default:
+		folder = "requisicao/"
+	}
+
+	// nomes vindos do usuário: sem barras nem caracteres de controle
+	base := strings.Map(func(r rune) rune {
+		if r < 0x20 || r == '/' || r == '\\' {
+			return '_'
+		}
+		return r
+	}, path.Base(strings.ReplaceAll(attachment.FileName, "\\", "/")))
+	if base == "" || base == "." || base == ".." {
+		base = "arquivo"
+	}
+
+	name := fmt.Sprintf("%s%d_%s", folder, attachment.ID, base)
+	for n := 2; used[name]; n++ {
+		name = fmt.Sprintf("%s%d_%d_%s", folder, attachment.ID, n, base)
+	}
+	used[name] = true
+	return name
+}
+
+// buildDossierWorkbook - Monta a planilha de resumo do dossiê (uma aba por assunto)
+func buildDossierWorkbook(dossier *requestDossier) (*excelize.File, error) {
+	f := excelize.NewFile()
+	if err := f.SetSheetName("Sheet1", "Requisição"); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	headerStyle, err := f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true, Color: "FFFFFF"},
+		Fill: excelize.Fill{Type: "pattern", Color: []string{"1F4E78"}, Pattern: 1},
+	})
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	titleStyle, err := f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true, Size: 14},
+	})
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	request := dossier.Request
+	if err := writeDossierRequestSheet(f, &request, titleStyle); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	// Itens
+	itemRows := make([][]interface{}, 0, len(request.Items))
+	for _, item := range request.Items {
+		itemRows = append(itemRows, []interface{}{
+			item.ID, item.Product.Name, item.Quantity, item.Status, formatReportDate(item.Deadline),
+			formatReportPrice(item.EstimatedUnitPrice), item.EstimatedValue,
+			formatReportPrice(item.QuotedUnitPrice), item.QuotedValue, item.ActualValue,
+			item.AdminNotes, item.SuspensionReason,
+		})
+	}
+
+	// Orçamentos
+	budgetRows := make([][]interface{}, 0, len(dossier.Budgets))
+	for _, budget := range dossier.Budgets {
+		selected := "Não"
+		if budget.Selected {
+			selected = "Sim"
+		}
+		budgetRows = append(budgetRows, []interface{}{
+			budget.ID, budget.RequestItemID, budget.RequestItem.Product.Name, budget.Supplier.Name,
+			budget.UnitPrice, selected, budget.SelectionJustification, formatReportDateTime(budget.SelectedAt),
+			formatReportDateTime(&budget.CreatedAt),
+		})
+	}
+
+	// Aprovações: pré-aprovação do setor e cadeia de aprovação
+	approvalRows := [][]interface{}{{
+		"Pré-aprovação do setor", "-", request.SectorApprovalStatus, "-",
+		formatReportDateTime(request.SectorApprovedAt), request.SectorApprovalNotes,
+	}}
+	for _, step := range request.ApprovalSteps {
+		approver := step.ApproverRole
+		if step.ApproverUser != nil {
+			approver = step.ApproverUser.Name
+		}
+		decider := "-"
+		if step.Decider != nil {
+			decider = step.Decider.Name
+		}
+		approvalRows = append(approvalRows, []interface{}{
+			fmt.Sprintf("%d. %s", step.Sequence, step.Name), approver, step.Status, decider,
+			formatReportDateTime(step.DecidedAt), step.Comment,
+		})
+	}
+
+	// Recebimentos
+	receiptRows := make([][]interface{}, 0, len(dossier.Receipts))
+	for _, receipt := range dossier.Receipts {
+		supplier := "-"
+		if receipt.Supplier != nil {
+			supplier = receipt.Supplier.Name
+		}
+		receiptRows = append(receiptRows, []interface{}{
+			receipt.ID, receipt.RequestItemID, receipt.RequestItem.Product.Name, receipt.QuantityReceived,
+			receipt.RejectedQuantity, receipt.InvoiceNumber, formatReportDate(receipt.InvoiceDate), supplier,
+			receipt.LotNumber, receipt.ReceiptCondition, receipt.Receiver.Name,
+			formatReportDateTime(&receipt.CreatedAt), receipt.Notes,
+		})
+	}
+
+	// Histórico de status
+	historyRows := make([][]interface{}, 0, len(dossier.History))
+	for _, entry := range dossier.History {
+		target := "Requisição"
+		if entry.RequestItemID != nil {
+			target = fmt.Sprintf("Item %d", *entry.RequestItemID)
+		}
+		changer := "-"
+		if entry.Changer != nil {
+			changer = entry.Changer.Name
+		}
+		historyRows = append(historyRows, []interface{}{
+			formatReportDateTime(&entry.CreatedAt), target, entry.FromStatus, entry.ToStatus, changer, entry.Notes,
+		})
+	}
+
+	// Anexos e sua situação no ZIP
+	attachmentRows := make([][]interface{}, 0, len(dossier.Attachments))
+	for _, attachment := range dossier.Attachments {
+		attachmentRows = append(attachmentRows, []interface{}{
+			attachment.ID, attachment.OwnerType, attachment.OwnerID, attachment.Category,
+			attachment.FileName, attachment.Size, formatReportDateTime(&attachment.CreatedAt),
+			dossier.FileNotes[attachment.ID],
+		})
+	}
+
+	sheets := []struct {
+		Name    string
+		Headers []string
+		Rows    [][]interface{}
+	}{
+		{"Itens", []string{"ID", "Produto", "Quantidade", "Status", "Prazo", "Preço Estimado", "Valor Estimado", "Preço Cotado", "Valor Cotado", "Valor Realizado", "Observações", "Motivo da Suspensão"}, itemRows},
+		{"Orçamentos", []string{"ID", "Item", "Produto", "Fornecedor", "Preço Unitário", "Selecionado", "Justificativa", "Selecionado Em", "Criado Em"}, budgetRows},
+		{"Aprovações", []string{"Etapa", "Aprovador", "Status", "Decidido Por", "Decidido Em", "Comentário"}, approvalRows},
+		{"Recebimentos", []string{"ID", "Item", "Produto", "Qtd. Recebida", "Qtd. Rejeitada", "Nota Fiscal", "Data da NF", "Fornecedor", "Lote", "Condição", "Recebido Por", "Registrado Em", "Observações"}, receiptRows},
+		{"Histórico", []string{"Data", "Alvo", "De", "Para", "Alterado Por", "Observações"}, historyRows},
+		{"Anexos", []string{"ID", "Dono", "ID do Dono", "Categoria", "Arquivo", "Tamanho (bytes)", "Enviado Em", "No Dossiê"}, attachmentRows},
+	}
+	for _, sheet := range sheets {
+		if err := writeDossierTableSheet(f, sheet.Name, sheet.Headers, sheet.Rows, headerStyle); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+
+	f.SetActiveSheet(0)
+	return f, nil
+}
+
+// writeDossierRequestSheet - Dados gerais e valores da requisição
+func writeDossierRequestSheet(f *excelize.File, request *models.PurchaseRequest, titleStyle int) error {
+	sheet := "Requisição"
+
+	f.SetCellValue(sheet, "A1", fmt.Sprintf("DOSSIÊ DA REQUISIÇÃO #%d", request.ID))
+	f.SetCellStyle(sheet, "A1", "A1", titleStyle)
+	f.SetCellValue(sheet, "A2", "Gerado em:")
+	f.SetCellValue(sheet, "B2", time.Now().Format("02/01/2006 15:04"))
+
+	rows := [][]interface{}{
+		{"Solicitante", request.Requester.Name},
+		{"E-mail do Solicitante", request.Requester.Email},
+		{"Setor", request.Sector.Name},
+		{"Status", request.Status},
+		{"Prioridade", request.Priority},
+		{"Criada Em", formatReportDateTime(&request.CreatedAt)},
+		{"Revisada Em", formatReportDateTime(request.ReviewedAt)},
+		{"Concluída Em", formatReportDateTime(request.CompletedAt)},
+		{"Observações", request.Observations},
+		{"Observações do Administrador", request.AdminNotes},
+		{"Motivo da Prioridade", request.PriorityNotes},
+		{"Observações da Conclusão", request.CompletionNotes},
+		{"Valor Estimado (R$)", request.EstimatedValue},
+		{"Valor Cotado (R$)", request.QuotedValue},
+		{"Valor Realizado (R$)", request.ActualValue},
+	}
+	row := 4
+	for _, r := range rows {
+		if err := f.SetSheetRow(sheet, fmt.Sprintf("A%d", row), &r); err != nil {
+			return err
+		}
+		row++
+	}
+	return f.SetColWidth(sheet, "A", "A", 32)
+}
+
+// writeDossierTableSheet - Cria uma aba com cabeçalho e linhas
+func writeDossierTableSheet(f *excelize.File, sheet string, headers []string, rows [][]interface{}, headerStyle int) error {
+	if _, err := f.NewSheet(sheet); err != nil {
+		return err
+	}
+	if err := writeReportHeader(f, sheet, headers, headerStyle); err != nil {
+		return err
+	}
+	for i := range rows {
+		if err := f.SetSheetRow(sheet, fmt.Sprintf("A%d", i+2), &rows[i]); err != nil {
+			return err
+		}
+	}
+	lastCol, _ := excelize.ColumnNumberToName(len(headers))
+	return f.SetColWidth(sheet, "A", lastCol, 18)
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/models"
 	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/notifications"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/rbac"
 	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/utils"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
@@ -19,14 +20,15 @@ import (
 type createRequesterInput struct {
 	Name     string `json:"name"  binding:"required"`
 	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=6"`
+	Password string `json:"password" binding:"required"`
 	SectorID uint   `json:"sectorId" binding:"required"`
 }
 
 type RequestItemInput struct {
-	ProductID uint       `json:"productId" binding:"required"`
-	Quantity  int        `json:"quantity" binding:"required,min=1"`
-	Deadline  *time.Time `json:"deadline"`
+	ProductID          uint       `json:"productId" binding:"required"`
+	Quantity           int        `json:"quantity" binding:"required,min=1"`
+	Deadline           *time.Time `json:"deadline"`
+	EstimatedUnitPrice *float64   `json:"estimatedUnitPrice" binding:"omitempty,gt=0"` // padrão: último preço de compra
 }
 
 type createPurchaseRequestInput struct {
@@ -50,35 +52,87 @@ type reviewItemInput struct {
 	AdminNotes string `json:"adminNotes"`
 }
 
+// requestListSpec - Ordenação e busca aceitas em GET /requests
+var requestListSpec = listSpec{
+	Table: "purchase_requests",
+	SortFields: map[string]string{
+		"id":          "purchase_requests.id",
+		"createdAt":   "purchase_requests.created_at",
+		"updatedAt":   "purchase_requests.updated_at",
+		"status":      "purchase_requests.status",
+		"priority":    "CASE purchase_requests.priority WHEN 'urgent' THEN 4 WHEN 'high' THEN 3 WHEN 'normal' THEN 2 ELSE 1 END",
+		"reviewedAt":  "purchase_requests.reviewed_at",
+		"completedAt": "purchase_requests.completed_at",
+	},
+	DefaultSort: "-createdAt",
+	SearchColumns: []string{
+		"purchase_requests.observations",
+		"purchase_requests.admin_notes",
+		"(SELECT users.name FROM users WHERE users.id = purchase_requests.requester_id)",
+	},
+	DateColumn: "purchase_requests.created_at",
+}
+
+// ListPurchaseRequests lista as requisições visíveis ao usuário.
+// Filtros: status, priority, sectorId (inclui subsetores), requesterId e sectorApprovalStatus,
+// além dos parâmetros comuns de listagem (page, pageSize, cursor, sort, q, startDate, endDate).
 func ListPurchaseRequests(databaseConnection *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// 1) lê userID e role do contexto (setados no AuthMiddleware)
-		userID := c.GetString("userID")
-		userRole := c.GetString("role")
+		lq, ok := parseListQuery(c, requestListSpec)
+		if !ok {
+			return
+		}
 
-		// 2) monta query com preload dos relacionamentos
-		query := databaseConnection.
-			Preload("Requester.Sector").
-			Preload("Sector").
-			Preload("Items.Product").
-			Preload("Items")
+		// 1) monta a query
+		query := databaseConnection.Model(&models.PurchaseRequest{})
 
-		// 3) se não for admin, filtra por requester_id
-		if userRole != "admin" {
-			query = query.Where("requester_id = ?", userID)
+		// 2) sem requests:view-all, filtra pelas próprias requisições e pelas dos setores geridos
+		query = scopeVisibleRequests(databaseConnection, c, query)
+
+		// filtros opcionais
+		if statuses := queryListParam(c, "status"); len(statuses) > 0 {
+			query = query.Where("status IN ?", statuses)
+		}
+		if priorities := queryListParam(c, "priority"); len(priorities) > 0 {
+			query = query.Where("priority IN ?", priorities)
+		}
+		sectorID, ok := queryUintParam(c, "sectorId")
+		if !ok {
+			return
+		}
+		if sectorID != nil {
+			query = query.Where("sector_id IN ?", sectorSubtreeIDs(databaseConnection, []uint{*sectorID}))
+		}
+		requesterID, ok := queryUintParam(c, "requesterId")
+		if !ok {
+			return
+		}
+		if requesterID != nil {
+			query = query.Where("requester_id = ?", *requesterID)
+		}
+		// pré-aprovação do setor (ex: caixa de entrada do gestor)
+		if sectorApproval := c.Query("sectorApprovalStatus"); sectorApproval != "" {
+			query = query.Where("sector_approval_status = ?", sectorApproval)
 		}
 
-		// 4) executa a busca
+		// 3) executa a busca (preloads apenas da página)
 		var requisicoes []models.PurchaseRequest
-		if err := query.Find(&requisicoes).Error; err != nil {
+		pagination, err := lq.find(query, &requisicoes, "Requester.Sector", "Sector", "Items.Product")
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"error": "Erro ao consultar requisições",
 			})
 			return
 		}
 
-		// 5) retorna o array de requisições com todos os dados já preenchidos
-		c.JSON(http.StatusOK, requisicoes)
+		// 4) calcula os valores estimado, cotado e realizado
+		if err := fillRequestValues(databaseConnection, requisicoes); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao calcular valores das requisições"})
+			return
+		}
+
+		// 5) retorna as requisições (no envelope paginado quando a paginação foi pedida)
+		respondList(c, lq, "requests", requisicoes, pagination)
 	}
 }
 
@@ -135,12 +189,14 @@ func CreatePurchaseRequest(db *gorm.DB) gin.HandlerFunc {
 			}
 		}()
 
-		// 6) Cria a requisição principal
+		// 6) Cria a requisição principal (com pré-aprovação quando o setor tem gestor)
+		sectorApproval, sectorManagerID := initialSectorApproval(tx, user.SectorID, uint(userID))
 		novaReq := models.PurchaseRequest{
-			RequesterID:  uint(userID),
-			SectorID:     user.SectorID, // Usa o setor do usuário
-			Status:       "pending",
-			Observations: input.Observations,
+			RequesterID:          uint(userID),
+			SectorID:             user.SectorID, // Usa o setor do usuário
+			Status:               "pending",
+			Observations:         input.Observations,
+			SectorApprovalStatus: sectorApproval,
 		}
 		if err := tx.Create(&novaReq).Error; err != nil {
 			tx.Rollback()
@@ -148,14 +204,24 @@ func CreatePurchaseRequest(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
+		if err := recordStatusTransition(tx, novaReq.ID, nil, "", novaReq.Status, userIDStr, "Requisição criada"); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao registrar histórico"})
+			return
+		}
+
 		// 7) Cria os itens da requisição
 		for _, itemInput := range input.Items {
 			item := models.RequestItem{
-				PurchaseRequestID: novaReq.ID,
-				ProductID:         itemInput.ProductID,
-				Quantity:          itemInput.Quantity,
-				Status:            "pending",
-				Deadline:          itemInput.Deadline,
+				PurchaseRequestID:  novaReq.ID,
+				ProductID:          itemInput.ProductID,
+				Quantity:           itemInput.Quantity,
+				Status:             "pending",
+				Deadline:           itemInput.Deadline,
+				EstimatedUnitPrice: itemInput.EstimatedUnitPrice,
+			}
+			if item.EstimatedUnitPrice == nil {
+				item.EstimatedUnitPrice = lastPurchasePrice(tx, itemInput.ProductID)
 			}
 			if err := tx.Create(&item).Error; err != nil {
 				tx.Rollback()
@@ -182,6 +248,12 @@ func CreatePurchaseRequest(db *gorm.DB) gin.HandlerFunc {
 
 		// 10) Emite notificação SSE
 		notifications.Publish(fmt.Sprintf("new-request:%d", novaReq.ID))
+		if sectorManagerID != nil {
+			notifications.Publish(
+				fmt.Sprintf("sector-approval-pending:%d", novaReq.ID),
+				utils.UintToString(*sectorManagerID),
+			)
+		}
 
 		c.JSON(http.StatusCreated, requisicaoCompleta)
 	}
@@ -205,14 +277,19 @@ func GetPurchaseRequest(databaseConnection *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
-		// Se não admin e não dono, bloquear
-		if c.GetString("role") != "admin" &&
-			utils.UintToString(requisicao.RequesterID) != c.GetString("userID") {
+		// Sem permissão para ver todas, não é o dono nem gestor do setor: bloquear
+		if !canViewRequest(databaseConnection, c, &requisicao) {
 			c.JSON(http.StatusForbidden, gin.H{"error": "Acesso negado"})
 			return
 		}
 
-		c.JSON(http.StatusOK, requisicao)
+		requisicoes := []models.PurchaseRequest{requisicao}
+		if err := fillRequestValues(databaseConnection, requisicoes); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao calcular valores da requisição"})
+			return
+		}
+
+		c.JSON(http.StatusOK, requisicoes[0])
 	}
 }
 
@@ -220,7 +297,6 @@ func GetPurchaseRequest(databaseConnection *gorm.DB) gin.HandlerFunc {
 func UpdatePurchaseRequest(databaseConnection *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		requestID := c.Param("id")
-		userRole := c.GetString("role")
 		userID := c.GetString("userID")
 
 		var dados updatePurchaseRequestInput
@@ -234,9 +310,12 @@ func UpdatePurchaseRequest(databaseConnection *gorm.DB) gin.HandlerFunc {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Requisição não encontrada"})
 			return
 		}
+		oldStatus := requisicao.Status
+		budgetWarning := ""
+		var resubmittedTo *uint // gestor do setor avisado quando a requisição volta para ele
 
 		// Verifica permissões
-		if userRole != "admin" {
+		if !rbac.Has(c, models.PermRequestsReview) {
 			// Usuário comum só pode alterar observações da própria requisição
 			if utils.UintToString(requisicao.RequesterID) != userID {
 				c.JSON(http.StatusForbidden, gin.H{"error": "Acesso negado"})
@@ -244,14 +323,53 @@ func UpdatePurchaseRequest(databaseConnection *gorm.DB) gin.HandlerFunc {
 			}
 			// Usuário comum só pode alterar observações
 			if dados.Status != "" || dados.AdminNotes != "" {
-				c.JSON(http.StatusForbidden, gin.H{"error": "Apenas administradores podem alterar status e notas admin"})
+				c.JSON(http.StatusForbidden, gin.H{"error": "Sem permissão para alterar status e notas administrativas"})
 				return
 			}
 			requisicao.Observations = dados.Observations
 		} else {
-			// Admin pode alterar tudo
+			// Admin pode alterar tudo, respeitando as transições de status
+			if dados.Status != "" && dados.Status != requisicao.Status && !requisicao.CanTransitionTo(dados.Status) {
+				respondTransitionError(c, requisicao.TransitionTo(dados.Status))
+				return
+			}
+			if (dados.Status == models.StatusApproved || dados.Status == models.StatusPartial) && requisicao.SectorApprovalBlocking() {
+				respondSectorApprovalPending(c, &requisicao)
+				return
+			}
+			// Aprovação, inclusive parcial, exige o mínimo de orçamentos e a cadeia de aprovação concluída
+			if (dados.Status == models.StatusApproved || dados.Status == models.StatusPartial) && dados.Status != requisicao.Status {
+				shortfalls, err := checkMinimumQuotes(databaseConnection, requisicao.ID)
+				if err != nil {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao verificar orçamentos"})
+					return
+				}
+				if len(shortfalls) > 0 {
+					c.JSON(http.StatusBadRequest, minimumQuotesError(shortfalls))
+					return
+				}
+
+				// Políticas de aprovação: a requisição só é aprovada após todas as etapas
+				required, step, err := ensureApprovalChain(databaseConnection, &requisicao)
+				if err != nil {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao iniciar cadeia de aprovação"})
+					return
+				}
+				if required {
+					respondApprovalChainPending(c, databaseConnection, &requisicao, step)
+					return
+				}
+			}
+			if dados.Status == models.StatusRejected {
+				if err := skipPendingApprovalSteps(databaseConnection, requisicao.ID); err != nil {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao encerrar cadeia de aprovação"})
+					return
+				}
+			}
 			if dados.Status != "" {
-				requisicao.Status = dados.Status
+				requisicao.Status = dados.Status // transição validada acima
+				// Requisição rejeitada pelo gestor volta para ele ao ser reenviada
+				resubmittedTo = resubmitSectorApproval(databaseConnection, &requisicao)
 				now := time.Now()
 				requisicao.ReviewedAt = &now
 
@@ -268,10 +386,43 @@ func UpdatePurchaseRequest(databaseConnection *gorm.DB) gin.HandlerFunc {
 			}
 		}
 
-		if result := databaseConnection.Save(&requisicao); result.Error != nil {
+		tx := databaseConnection.Begin()
+		defer func() {
+			if r := recover(); r != nil {
+				tx.Rollback()
+			}
+		}()
+
+		// Verba do setor: bloqueia ou avisa quando a aprovação excede o saldo
+		// (verificada na mesma transação que empenha)
+		if (requisicao.Status == models.StatusApproved || requisicao.Status == models.StatusPartial) && requisicao.Status != oldStatus {
+			warning, ok := enforceSectorBudgets(c, tx, &requisicao, requisicao.Status)
+			if !ok {
+				tx.Rollback()
+				return
+			}
+			budgetWarning = warning
+		}
+
+		if err := tx.Save(&requisicao).Error; err != nil {
+			tx.Rollback()
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar requisição"})
 			return
 		}
+		if err := recordStatusTransition(tx, requisicao.ID, nil, oldStatus, requisicao.Status, userID, appendBudgetWarning(dados.AdminNotes, budgetWarning)); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao registrar histórico"})
+			return
+		}
+		if err := syncBudgetCommitments(tx, requisicao.ID, userID); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar verba do setor"})
+			return
+		}
+		if err := tx.Commit().Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao finalizar atualização"})
+			return
+		}
 
 		// Carrega requisição atualizada
 		if err := databaseConnection.Preload("Requester").
@@ -287,18 +438,18 @@ func UpdatePurchaseRequest(databaseConnection *gorm.DB) gin.HandlerFunc {
 		notifications.Publish(
 			fmt.Sprintf("update-request:%d:%s", requisicao.ID, requisicao.Status),
 		)
+		if resubmittedTo != nil {
+			notifications.Publish(
+				fmt.Sprintf("sector-approval-pending:%d", requisicao.ID),
+				utils.UintToString(*resubmittedTo),
+			)
+		}
 	}
 }
 
 // ReviewRequest - Endpoint específico para admin revisar requisição completa
 func ReviewRequest(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Apenas admin pode acessar
-		if c.GetString("role") != "admin" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Acesso restrito a administradores"})
-			return
-		}
-
 		requestID := c.Param("id")
 		userID := c.GetString("userID")
 
@@ -318,7 +469,57 @@ func ReviewRequest(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
-		// Atualiza status da requisição
+		// Valida a transição antes de qualquer outra regra
+		oldStatus := requisicao.Status
+		if input.Status != oldStatus && !requisicao.CanTransitionTo(input.Status) {
+			respondTransitionError(c, requisicao.TransitionTo(input.Status))
+			return
+		}
+
+		// Compras só aprovam depois da pré-aprovação do gestor do setor (nunca sobre a rejeição dele)
+		if input.Status != models.StatusRejected && requisicao.SectorApprovalBlocking() {
+			respondSectorApprovalPending(c, &requisicao)
+			return
+		}
+
+		// Aprovação, inclusive parcial, exige o número mínimo de orçamentos por item
+		// e a conclusão da cadeia de aprovação
+		if input.Status == models.StatusApproved || input.Status == models.StatusPartial {
+			shortfalls, err := checkMinimumQuotes(db, requisicao.ID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao verificar orçamentos"})
+				return
+			}
+			if len(shortfalls) > 0 {
+				c.JSON(http.StatusBadRequest, minimumQuotesError(shortfalls))
+				return
+			}
+
+			// Políticas de aprovação: a requisição só é aprovada após todas as etapas
+			required, step, err := ensureApprovalChain(db, &requisicao)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao iniciar cadeia de aprovação"})
+				return
+			}
+			if required {
+				if input.AdminNotes != "" {
+					requisicao.AdminNotes = input.AdminNotes
+					db.Model(&requisicao).Update("admin_notes", input.AdminNotes)
+				}
+				respondApprovalChainPending(c, db, &requisicao, step)
+				return
+			}
+		}
+
+		// Rejeição encerra qualquer cadeia de aprovação em andamento
+		if input.Status == models.StatusRejected {
+			if err := skipPendingApprovalSteps(db, requisicao.ID); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao encerrar cadeia de aprovação"})
+				return
+			}
+		}
+
+		// Atualiza status da requisição (transição validada acima)
 		requisicao.Status = input.Status
 		requisicao.AdminNotes = input.AdminNotes
 		now := time.Now()
@@ -328,10 +529,44 @@ func ReviewRequest(db *gorm.DB) gin.HandlerFunc {
 		reviewerIDUint := uint(reviewerID)
 		requisicao.ReviewedBy = &reviewerIDUint
 
-		if err := db.Save(&requisicao).Error; err != nil {
+		tx := db.Begin()
+		defer func() {
+			if r := recover(); r != nil {
+				tx.Rollback()
+			}
+		}()
+
+		// Verba do setor: bloqueia ou avisa quando a aprovação excede o saldo
+		// (verificada na mesma transação que empenha)
+		budgetWarning := ""
+		if input.Status == models.StatusApproved || input.Status == models.StatusPartial {
+			warning, ok := enforceSectorBudgets(c, tx, &requisicao, input.Status)
+			if !ok {
+				tx.Rollback()
+				return
+			}
+			budgetWarning = warning
+		}
+
+		if err := tx.Save(&requisicao).Error; err != nil {
+			tx.Rollback()
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar requisição"})
 			return
 		}
+		if err := recordStatusTransition(tx, requisicao.ID, nil, oldStatus, requisicao.Status, userID, appendBudgetWarning(input.AdminNotes, budgetWarning)); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao registrar histórico"})
+			return
+		}
+		if err := syncBudgetCommitments(tx, requisicao.ID, userID); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar verba do setor"})
+			return
+		}
+		if err := tx.Commit().Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao finalizar revisão"})
+			return
+		}
 
 		// Carrega requisição completa
 		if err := db.Preload("Requester").
@@ -353,12 +588,6 @@ func ReviewRequest(db *gorm.DB) gin.HandlerFunc {
 // ReviewRequestItem - Admin aprova/rejeita item específico
 func ReviewRequestItem(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Apenas admin pode acessar
-		if c.GetString("role") != "admin" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Acesso restrito a administradores"})
-			return
-		}
-
 		itemID := c.Param("itemId")
 
 		// ✅ INPUT COM STATUS SUSPENSO E MOTIVO
@@ -402,6 +631,19 @@ func ReviewRequestItem(db *gorm.DB) gin.HandlerFunc {
 		fmt.Printf("📦 Item encontrado - ID: %d, Status atual: %s, RequestID: %d\n",
 			item.ID, item.Status, item.PurchaseRequestID)
 
+		// ✅ COMPRAS SÓ APROVAM ITENS DEPOIS DA PRÉ-APROVAÇÃO DO GESTOR DO SETOR
+		if input.Status != models.ItemStatusRejected && item.PurchaseRequest.SectorApprovalBlocking() {
+			respondSectorApprovalPending(c, &item.PurchaseRequest)
+			return
+		}
+
+		// ✅ VALIDAR TRANSIÇÃO DO ITEM
+		oldItemStatus := item.Status
+		if err := item.TransitionTo(input.Status); err != nil {
+			respondTransitionError(c, err)
+			return
+		}
+
 		// ✅ INICIAR TRANSAÇÃO PARA ATOMICIDADE
 		tx := db.Begin()
 		defer func() {
@@ -410,8 +652,7 @@ func ReviewRequestItem(db *gorm.DB) gin.HandlerFunc {
 			}
 		}()
 
-		// Atualiza o item
-		item.Status = input.Status
+		// Atualiza o item (status já aplicado pela transição)
 		item.AdminNotes = input.AdminNotes
 
 		// ✅ ATUALIZAR MOTIVO DE SUSPENSÃO
@@ -431,6 +672,16 @@ func ReviewRequestItem(db *gorm.DB) gin.HandlerFunc {
 
 		fmt.Printf("✅ Item salvo com sucesso - Status: %s\n", item.Status)
 
+		itemNotes := input.AdminNotes
+		if input.Status == models.ItemStatusSuspended {
+			itemNotes = input.SuspensionReason
+		}
+		if err := recordStatusTransition(tx, item.PurchaseRequestID, &item.ID, oldItemStatus, item.Status, c.GetString("userID"), itemNotes); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao registrar histórico"})
+			return
+		}
+
 		// ✅ RECALCULAR STATUS DA REQUISIÇÃO - LÓGICA CORRIGIDA
 		var totalItems, approvedItems, rejectedItems, suspendedItems, pendingItems int64
 
@@ -442,6 +693,8 @@ func ReviewRequestItem(db *gorm.DB) gin.HandlerFunc {
 
 		// ✅ NOVA LÓGICA DE STATUS DA REQUISIÇÃO
 		var requisicao models.PurchaseRequest
+		var pendingApprovalStep *models.RequestApprovalStep
+		budgetWarning := ""
 		if err := tx.First(&requisicao, item.PurchaseRequestID).Error; err == nil {
 			oldStatus := requisicao.Status
 			newStatus := requisicao.Status // manter atual como padrão
@@ -468,9 +721,55 @@ func ReviewRequestItem(db *gorm.DB) gin.HandlerFunc {
 				item.PurchaseRequestID, totalItems, approvedItems, rejectedItems, suspendedItems, pendingItems)
 			fmt.Printf("📊 Status: %s -> %s\n", oldStatus, newStatus)
 
-			// ✅ SÓ ATUALIZAR SE MUDOU
+			// ✅ APROVAÇÃO (TOTAL OU PARCIAL) EXIGE O NÚMERO MÍNIMO DE ORÇAMENTOS
+			if (newStatus == models.StatusApproved || newStatus == models.StatusPartial) && newStatus != oldStatus {
+				shortfalls, err := checkMinimumQuotes(tx, item.PurchaseRequestID)
+				if err != nil {
+					tx.Rollback()
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao verificar orçamentos"})
+					return
+				}
+				if len(shortfalls) > 0 {
+					tx.Rollback()
+					c.JSON(http.StatusBadRequest, minimumQuotesError(shortfalls))
+					return
+				}
+
+				// ✅ POLÍTICAS DE APROVAÇÃO: MANTÉM PENDENTE ATÉ TODAS AS ETAPAS
+				required, step, err := ensureApprovalChain(tx, &requisicao)
+				if err != nil {
+					tx.Rollback()
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao iniciar cadeia de aprovação"})
+					return
+				}
+				if required {
+					newStatus = models.StatusPending
+					pendingApprovalStep = step
+				}
+			}
+
+			// ✅ VERBA DO SETOR: BLOQUEIA OU AVISA QUANDO A APROVAÇÃO EXCEDE O SALDO
+			if newStatus == models.StatusApproved || newStatus == models.StatusPartial {
+				warning, ok := enforceSectorBudgets(c, tx, &requisicao, newStatus)
+				if !ok {
+					tx.Rollback()
+					return
+				}
+				budgetWarning = warning
+			}
+
+			// ✅ SÓ ATUALIZAR SE MUDOU (E SE A TRANSIÇÃO FOR PERMITIDA)
 			if newStatus != oldStatus {
-				requisicao.Status = newStatus
+				if err := requisicao.TransitionTo(newStatus); err != nil {
+					tx.Rollback()
+					respondTransitionError(c, err)
+					return
+				}
+				if err := recordStatusTransition(tx, requisicao.ID, nil, oldStatus, newStatus, c.GetString("userID"), appendBudgetWarning("Recalculado pela revisão de itens", budgetWarning)); err != nil {
+					tx.Rollback()
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao registrar histórico"})
+					return
+				}
 				if err := tx.Save(&requisicao).Error; err != nil {
 					tx.Rollback()
 					fmt.Printf("❌ Erro ao atualizar status da requisição: %v\n", err)
@@ -481,6 +780,14 @@ func ReviewRequestItem(db *gorm.DB) gin.HandlerFunc {
 			}
 		}
 
+		// ✅ EMPENHAR/ESTORNAR A VERBA CONFORME O NOVO ESTADO DOS ITENS
+		if err := syncBudgetCommitments(tx, item.PurchaseRequestID, c.GetString("userID")); err != nil {
+			tx.Rollback()
+			fmt.Printf("❌ Erro ao atualizar verba do setor: %v\n", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar verba do setor"})
+			return
+		}
+
 		// ✅ CONFIRMAR TRANSAÇÃO
 		if err := tx.Commit().Error; err != nil {
 			fmt.Printf("❌ Erro ao fazer commit: %v\n", err)
@@ -503,6 +810,9 @@ func ReviewRequestItem(db *gorm.DB) gin.HandlerFunc {
 		notifications.Publish(
 			fmt.Sprintf("review-item:%d:%s", item.ID, item.Status),
 		)
+		if pendingApprovalStep != nil {
+			notifyApprovalPending(db, pendingApprovalStep)
+		}
 	}
 }
 
@@ -512,7 +822,7 @@ func ListRequesters(databaseConnection *gorm.DB) gin.HandlerFunc {
 		var requesters []models.User
 		if err := databaseConnection.
 			Preload("Sector").
-			Where("role = ?", "requester").
+			Where("role = ?", models.RoleRequester).
 			Find(&requesters).Error; err != nil {
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao consultar solicitantes"})
 			return
@@ -540,6 +850,17 @@ func CreateRequester(databaseConnection *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
+		// Valida a senha contra a política configurada
+		policyErr, err := checkPasswordPolicy(databaseConnection, "password", input.Password)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao carregar política de senhas"})
+			return
+		}
+		if policyErr != nil {
+			ctx.JSON(http.StatusBadRequest, policyErr)
+			return
+		}
+
 		hashDaSenha, err := bcrypt.GenerateFromPassword(
 			[]byte(input.Password),
 			bcrypt.DefaultCost,
@@ -549,12 +870,14 @@ func CreateRequester(databaseConnection *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
+		agora := time.Now()
 		novoSolicitante := models.User{
-			Name:         input.Name,
-			Email:        input.Email,
-			PasswordHash: string(hashDaSenha),
-			Role:         "requester", // força o papel de solicitante
-			SectorID:     input.SectorID,
+			Name:              input.Name,
+			Email:             input.Email,
+			PasswordHash:      string(hashDaSenha),
+			Role:              models.RoleRequester, // força o papel de solicitante
+			SectorID:          input.SectorID,
+			PasswordChangedAt: &agora,
 		}
 
 		if err := databaseConnection.Create(&novoSolicitante).Error; err != nil {
@@ -582,12 +905,6 @@ func CreateRequester(databaseConnection *gorm.DB) gin.HandlerFunc {
 // CompleteRequest - CORRIGIDO (removido partialItems desnecessário)
 func CompleteRequest(databaseConnection *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Apenas admin pode concluir requisições
-		if c.GetString("role") != "admin" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Acesso restrito a administradores"})
-			return
-		}
-
 		requestID := c.Param("id")
 		userID := c.GetString("userID")
 
@@ -653,7 +970,11 @@ func CompleteRequest(databaseConnection *gorm.DB) gin.HandlerFunc {
 			requestID, pendingItems, usefulItems)
 
 		// Marca como concluída
-		requisicao.Status = models.StatusCompleted
+		oldStatus := requisicao.Status
+		if err := requisicao.TransitionTo(models.StatusCompleted); err != nil {
+			respondTransitionError(c, err)
+			return
+		}
 		requisicao.CompletionNotes = input.CompletionNotes
 		now := time.Now()
 		requisicao.CompletedAt = &now
@@ -662,11 +983,28 @@ func CompleteRequest(databaseConnection *gorm.DB) gin.HandlerFunc {
 		completedByUint := uint(completedBy)
 		requisicao.CompletedBy = &completedByUint
 
-		if err := databaseConnection.Save(&requisicao).Error; err != nil {
+		tx := databaseConnection.Begin()
+		if err := tx.Omit("Items").Save(&requisicao).Error; err != nil {
+			tx.Rollback()
 			fmt.Printf("❌ Erro ao salvar conclusão: %v\n", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao concluir requisição"})
 			return
 		}
+		if err := recordStatusTransition(tx, requisicao.ID, nil, oldStatus, requisicao.Status, userID, input.CompletionNotes); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao registrar histórico"})
+			return
+		}
+		// Conclusão estorna o empenho do que não foi recebido
+		if err := syncBudgetCommitments(tx, requisicao.ID, userID); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar verba do setor"})
+			return
+		}
+		if err := tx.Commit().Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao finalizar conclusão"})
+			return
+		}
 
 		// Carrega requisição completa
 		if err := databaseConnection.Preload("Requester").
@@ -690,12 +1028,6 @@ func CompleteRequest(databaseConnection *gorm.DB) gin.HandlerFunc {
 // ReopenRequest reabre uma requisição concluída
 func ReopenRequest(databaseConnection *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Apenas admin pode reabrir requisições
-		if c.GetString("role") != "admin" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Acesso restrito a administradores"})
-			return
-		}
-
 		requestID := c.Param("id")
 
 		var requisicao models.PurchaseRequest
@@ -710,22 +1042,67 @@ func ReopenRequest(databaseConnection *gorm.DB) gin.HandlerFunc {
 
 		// Verifica se pode ser reaberta
 		if !requisicao.CanBeReopened() {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": "Apenas requisições concluídas podem ser reabertas",
+			c.JSON(http.StatusConflict, gin.H{
+				"error":         "Apenas requisições concluídas podem ser reabertas",
+				"allowedStatus": requisicao.AllowedNextStatuses(),
 			})
 			return
 		}
 
-		// Reabre a requisição
-		requisicao.Status = models.StatusApproved // ou StatusPartial dependendo dos itens
+		// Reabre a requisição: volta para parcial se algum item não foi aprovado
+		var notApprovedItems int64
+		databaseConnection.Model(&models.RequestItem{}).
+			Where("purchase_request_id = ? AND status <> ?", requisicao.ID, models.ItemStatusApproved).
+			Count(&notApprovedItems)
+
+		reopenStatus := models.StatusApproved
+		if notApprovedItems > 0 {
+			reopenStatus = models.StatusPartial
+		}
+
+		oldStatus := requisicao.Status
+		if err := requisicao.TransitionTo(reopenStatus); err != nil {
+			respondTransitionError(c, err)
+			return
+		}
 		requisicao.CompletionNotes = ""
 		requisicao.CompletedAt = nil
 		requisicao.CompletedBy = nil
 
-		if err := databaseConnection.Save(&requisicao).Error; err != nil {
+		tx := databaseConnection.Begin()
+		defer func() {
+			if r := recover(); r != nil {
+				tx.Rollback()
+			}
+		}()
+
+		// Reabrir volta a empenhar o saldo não recebido: a política de verba do setor vale aqui também
+		budgetWarning, ok := enforceSectorBudgets(c, tx, &requisicao, reopenStatus)
+		if !ok {
+			tx.Rollback()
+			return
+		}
+
+		if err := tx.Save(&requisicao).Error; err != nil {
+			tx.Rollback()
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao reabrir requisição"})
 			return
 		}
+		if err := recordStatusTransition(tx, requisicao.ID, nil, oldStatus, requisicao.Status, c.GetString("userID"),
+			appendBudgetWarning("Requisição reaberta", budgetWarning)); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao registrar histórico"})
+			return
+		}
+		if err := syncBudgetCommitments(tx, requisicao.ID, c.GetString("userID")); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar verba do setor"})
+			return
+		}
+		if err := tx.Commit().Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao finalizar reabertura"})
+			return
+		}
 
 		// Carrega requisição completa
 		if err := databaseConnection.Preload("Requester").
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"database/sql/driver"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/models"
+)
+
+// pendingRequestColumns - Requisição pendente, já liberada pelo gestor do setor
+var pendingRequestColumns = []string{"id", "status", "sector_id", "priority", "sector_approval_status"}
+
+func pendingRequestRow() []driver.Value {
+	return []driver.Value{int64(42), models.StatusPending, int64(3), "normal", models.SectorApprovalApproved}
+}
+
+func TestReviewRequestApprovalChecks(t *testing.T) {
+	tests := []struct {
+		name   string
+		status string
+		setup  func(f *fakeDB)
+		code   int
+		check  func(t *testing.T, f *fakeDB, body map[string]interface{})
+	}{
+		{
+			name:   "parcial sem o mínimo de orçamentos",
+			status: models.StatusPartial,
+			setup: func(f *fakeDB) {
+				f.on(`FROM "system_settings"`, []string{"id", "min_quotes_per_item"}, []driver.Value{int64(1), int64(2)})
+				f.on("LEFT JOIN products p", []string{"item_id", "product_name", "quote_count", "required"},
+					[]driver.Value{int64(5), "Papel A4", int64(1), int64(2)})
+			},
+			code: http.StatusBadRequest,
+			check: func(t *testing.T, f *fakeDB, body map[string]interface{}) {
+				if items, _ := body["itemsBelowMinimum"].([]interface{}); len(items) != 1 {
+					t.Errorf("itemsBelowMinimum = %v", body["itemsBelowMinimum"])
+				}
+			},
+		},
+		{
+			name:   "parcial com cadeia de aprovação aplicável",
+			status: models.StatusPartial,
+			setup: func(f *fakeDB) {
+				f.on(`FROM "approval_policies"`, []string{"id", "name", "active", "min_estimated_value"},
+					[]driver.Value{int64(1), "Diretoria", true, 0.0})
+				f.on(`FROM "approval_policy_steps"`, []string{"id", "approval_policy_id", "step_order", "name", "approver_type", "approver_role"},
+					[]driver.Value{int64(10), int64(1), int64(1), "Diretor", models.ApproverTypeRole, "director"})
+			},
+			code: http.StatusAccepted,
+			check: func(t *testing.T, f *fakeDB, body map[string]interface{}) {
+				if !f.executed(`INSERT INTO "request_approval_steps"`) {
+					t.Error("cadeia de aprovação não foi instanciada")
+				}
+				if body["requestStatus"] != models.StatusPending {
+					t.Errorf("requestStatus = %v", body["requestStatus"])
+				}
+			},
+		},
+		{
+			name:   "aprovação sem o mínimo de orçamentos",
+			status: models.StatusApproved,
+			setup: func(f *fakeDB) {
+				f.on(`FROM "system_settings"`, []string{"id", "min_quotes_per_item"}, []driver.Value{int64(1), int64(3)})
+				f.on("LEFT JOIN products p", []string{"item_id", "product_name", "quote_count", "required"},
+					[]driver.Value{int64(5), "Papel A4", int64(0), int64(3)})
+			},
+			code: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, db := newFakeDB(t)
+			f.on(`FROM "purchase_requests"`, pendingRequestColumns, pendingRequestRow())
+			tt.setup(f)
+
+			w := performRequest(ReviewRequest(db), http.MethodPatch, "/requests/42/review",
+				`{"status":"`+tt.status+`"}`, gin.Params{{Key: "id", Value: "42"}},
+				models.RoleAdmin, models.PermRequestsReview)
+
+			if w.Code != tt.code {
+				t.Fatalf("status %d, want %d: %s", w.Code, tt.code, w.Body.String())
+			}
+			// Nada de status nem empenho gravado antes de as verificações passarem
+			if f.executed(`UPDATE "purchase_requests"`) || f.executed(`INSERT INTO "sector_budget_entries"`) {
+				t.Error("requisição alterada apesar da verificação pendente")
+			}
+			if tt.check != nil {
+				tt.check(t, f, decodeBody(t, w))
+			}
+		})
+	}
+}
@@ -0,0 +1,301 @@
+package handlers
+
+import (
+	"sort"
+
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/models"
+	"gorm.io/gorm"
+)
+
+// requestItemValuesSQL - Preços e valor realizado de cada item das requisições informadas.
+// O realizado usa o preço da linha do pedido de compra do recebimento ou, sem pedido,
+// o do orçamento selecionado (e por último o estimado).
+const requestItemValuesSQL = `
+	SELECT ri.id AS item_id, ri.purchase_request_id AS request_id, ri.quantity, ri.status,
+		ri.estimated_unit_price, sel.unit_price AS quoted_unit_price,
+		COALESCE((
+			SELECT SUM((ir.quantity_received - ir.rejected_quantity) * COALESCE(pol.unit_price, sel.unit_price, ri.estimated_unit_price, 0))
+			FROM item_receipts ir
+			LEFT JOIN purchase_order_lines pol ON pol.id = ir.purchase_order_line_id
+			WHERE ir.request_item_id = ri.id AND ir.deleted_at IS NULL
+		), 0) AS actual_value
+	FROM request_items ri
+	LEFT JOIN item_budgets sel ON sel.request_item_id = ri.id AND sel.selected = TRUE AND sel.deleted_at IS NULL
+	WHERE ri.deleted_at IS NULL AND ri.purchase_request_id IN (?)`
+
+// requestItemCostSQL - Preço de referência de cada item: o orçamento selecionado, na falta
+// dele o de menor preço e, sem orçamentos, o preço estimado do item
+const requestItemCostSQL = `
+	SELECT ri.id, ri.quantity, ri.status, COALESCE((
+		SELECT ib.unit_price
+		FROM item_budgets ib
+		WHERE ib.request_item_id = ri.id AND ib.deleted_at IS NULL
+		ORDER BY ib.selected DESC, ib.unit_price ASC
+		LIMIT 1
+	), ri.estimated_unit_price, 0) AS unit_price
+	FROM request_items ri
+	WHERE ri.purchase_request_id = ? AND ri.deleted_at IS NULL`
+
+// requestItemCost - Item da requisição com o preço de referência. É a base única do valor
+// da requisição para as políticas de aprovação, a reconstrução da cadeia e os empenhos de verba.
+type requestItemCost struct {
+	ID        uint
+	Quantity  int
+	Status    string
+	UnitPrice float64
+}
+
+// Counts indica se o item entra no valor da requisição (rejeitados e suspensos ficam de fora)
+func (i requestItemCost) Counts() bool {
+	return i.Status != models.ItemStatusRejected && i.Status != models.ItemStatusSuspended
+}
+
+// Cost - Quantidade × preço de referência
+func (i requestItemCost) Cost() float64 {
+	return float64(i.Quantity) * i.UnitPrice
+}
+
+// requestItemCosts lista os itens da requisição com o preço de referência
+func requestItemCosts(db *gorm.DB, requestID uint) ([]requestItemCost, error) {
+	var items []requestItemCost
+	err := db.Raw(requestItemCostSQL, requestID).Scan(&items).Error
+	return items, err
+}
+
+// requestCostTotal soma o custo dos itens que entram no valor da requisição
+func requestCostTotal(items []requestItemCost) float64 {
+	total := 0.0
+	for _, item := range items {
+		if item.Counts() {
+			total += item.Cost()
+		}
+	}
+	return roundMoney(total)
+}
+
+// itemValueRow - Linha de requestItemValuesSQL
+type itemValueRow struct {
+	ItemID             uint
+	RequestID          uint
+	Quantity           int
+	Status             string
+	EstimatedUnitPrice *float64
+	QuotedUnitPrice    *float64
+	ActualValue        float64
+}
+
+// requestValueTotals - Totais de uma requisição
+type requestValueTotals struct {
+	Estimated float64
+	Quoted    float64
+	Actual    float64
+}
+
+// Reference retorna o valor de referência: realizado, cotado ou estimado, nesta ordem
+func (t requestValueTotals) Reference() float64 {
+	if t.Actual > 0 {
+		return t.Actual
+	}
+	if t.Quoted > 0 {
+		return t.Quoted
+	}
+	return t.Estimated
+}
+
+// loadItemValues busca os valores dos itens; requestIDs pode ser uma lista de IDs ou uma subconsulta
+func loadItemValues(db *gorm.DB, requestIDs interface{}) ([]itemValueRow, error) {
+	var rows []itemValueRow
+	err := db.Raw(requestItemValuesSQL, requestIDs).Scan(&rows).Error
+	return rows, err
+}
+
+// sumRequestValues soma os valores por requisição (estimado e cotado ignoram itens rejeitados)
+func sumRequestValues(rows []itemValueRow) map[uint]requestValueTotals {
+	totals := map[uint]requestValueTotals{}
+	for _, row := range rows {
+		t := totals[row.RequestID]
+		if row.Status != models.ItemStatusRejected {
+			if row.EstimatedUnitPrice != nil {
+				t.Estimated += float64(row.Quantity) * *row.EstimatedUnitPrice
+			}
+			if row.QuotedUnitPrice != nil {
+				t.Quoted += float64(row.Quantity) * *row.QuotedUnitPrice
+			}
+		}
+		t.Actual += row.ActualValue
+		totals[row.RequestID] = t
+	}
+	for id, t := range totals {
+		totals[id] = requestValueTotals{Estimated: roundMoney(t.Estimated), Quoted: roundMoney(t.Quoted), Actual: roundMoney(t.Actual)}
+	}
+	return totals
+}
+
+// fillRequestValues preenche os valores estimado, cotado e realizado das requisições e dos itens carregados
+func fillRequestValues(db *gorm.DB, requests []models.PurchaseRequest) error {
+	if len(requests) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, len(requests))
+	for i, r := range requests {
+		ids[i] = r.ID
+	}
+	rows, err := loadItemValues(db, ids)
+	if err != nil {
+		return err
+	}
+
+	byItem := make(map[uint]itemValueRow, len(rows))
+	for _, row := range rows {
+		byItem[row.ItemID] = row
+	}
+	totals := sumRequestValues(rows)
+
+	for i := range requests {
+		t := totals[requests[i].ID]
+		requests[i].EstimatedValue = t.Estimated
+		requests[i].QuotedValue = t.Quoted
+		requests[i].ActualValue = t.Actual
+
+		for j := range requests[i].Items {
+			item := &requests[i].Items[j]
+			row, ok := byItem[item.ID]
+			if !ok {
+				continue
+			}
+			if item.EstimatedUnitPrice != nil {
+				item.EstimatedValue = roundMoney(float64(item.Quantity) * *item.EstimatedUnitPrice)
+			}
+			item.QuotedUnitPrice = row.QuotedUnitPrice
+			if row.QuotedUnitPrice != nil {
+				item.QuotedValue = roundMoney(float64(item.Quantity) * *row.QuotedUnitPrice)
+			}
+			item.ActualValue = roundMoney(row.ActualValue)
+		}
+	}
+	return nil
+}
+
+// lastPurchasePrice retorna o último preço pago pelo produto: linha de pedido de compra
+// não cancelado mais recente ou, sem pedidos, o último orçamento selecionado
+func lastPurchasePrice(db *gorm.DB, productID uint) *float64 {
+	var prices []float64
+	db.Table("purchase_order_lines pol").
+		Joins("INNER JOIN purchase_orders po ON po.id = pol.purchase_order_id").
+		Joins("INNER JOIN request_items ri ON ri.id = pol.request_item_id").
+		Where("ri.product_id = ? AND pol.deleted_at IS NULL AND po.deleted_at IS NULL AND po.status <> ?", productID, models.PurchaseOrderStatusCancelled).
+		Order("pol.created_at DESC").
+		Limit(1).
+		Pluck("pol.unit_price", &prices)
+	if len(prices) == 0 {
+		db.Table("item_budgets ib").
+			Joins("INNER JOIN request_items ri ON ri.id = ib.request_item_id").
+			Where("ri.product_id = ? AND ib.selected = TRUE AND ib.deleted_at IS NULL", productID).
+			Order("ib.selected_at DESC").
+			Limit(1).
+			Pluck("ib.unit_price", &prices)
+	}
+	if len(prices) == 0 {
+		return nil
+	}
+	return &prices[0]
+}
+
+// requestValueRow - Requisição com seus agrupadores para as métricas de valor do relatório
+type requestValueRow struct {
+	ID       uint
+	Status   string
+	Priority string
+	SectorID uint
+}
+
+// reportValueMetrics calcula os totais do resumo e as métricas de valor por status, setor e
+// prioridade das requisições que atendem aos filtros
+func reportValueMetrics(db *gorm.DB, filters models.ReportFilters) (requestValueTotals, float64, map[string][]models.ValueDataPoint, error) {
+	var total requestValueTotals
+
+	var requests []requestValueRow
+	if err := applyFilters(db.Model(&models.PurchaseRequest{}), filters).
+		Select("id, status, priority, sector_id").
+		Scan(&requests).Error; err != nil {
+		return total, 0, nil, err
+	}
+
+	var sectors []models.Sector
+	if err := db.Unscoped().Select("id", "name").Find(&sectors).Error; err != nil {
+		return total, 0, nil, err
+	}
+	sectorNames := make(map[uint]string, len(sectors))
+	for _, s := range sectors {
+		sectorNames[s.ID] = s.Name
+	}
+
+	rows, err := loadItemValues(db, applyFilters(db.Model(&models.PurchaseRequest{}).Select("id"), filters))
+	if err != nil {
+		return total, 0, nil, err
+	}
+	totals := sumRequestValues(rows)
+
+	groups := map[string]map[string]*models.ValueDataPoint{
+		"status":   {},
+		"sector":   {},
+		"priority": {},
+	}
+	add := func(group, label string, t requestValueTotals) {
+		point, ok := groups[group][label]
+		if !ok {
+			point = &models.ValueDataPoint{Label: label}
+			groups[group][label] = point
+		}
+		point.Requests++
+		point.EstimatedValue += t.Estimated
+		point.QuotedValue += t.Quoted
+		point.ActualValue += t.Actual
+		point.TotalValue += t.Reference()
+	}
+
+	reference := 0.0
+	for _, r := range requests {
+		t := totals[r.ID]
+		total.Estimated += t.Estimated
+		total.Quoted += t.Quoted
+		total.Actual += t.Actual
+		reference += t.Reference()
+
+		priority := r.Priority
+		if priority == "" {
+			priority = "normal"
+		}
+		add("status", r.Status, t)
+		add("sector", sectorNames[r.SectorID], t)
+		add("priority", priority, t)
+	}
+
+	average := 0.0
+	if len(requests) > 0 {
+		average = roundMoney(reference / float64(len(requests)))
+	}
+	total = requestValueTotals{Estimated: roundMoney(total.Estimated), Quoted: roundMoney(total.Quoted), Actual: roundMoney(total.Actual)}
+
+	metrics := map[string][]models.ValueDataPoint{}
+	for group, points := range groups {
+		list := make([]models.ValueDataPoint, 0, len(points))
+		for _, p := range points {
+			p.EstimatedValue = roundMoney(p.EstimatedValue)
+			p.QuotedValue = roundMoney(p.QuotedValue)
+			p.ActualValue = roundMoney(p.ActualValue)
+			p.TotalValue = roundMoney(p.TotalValue)
+			p.AverageValue = roundMoney(p.TotalValue / float64(p.Requests))
+			list = append(list, *p)
+		}
+		sort.Slice(list, func(i, j int) bool {
+			if list[i].TotalValue != list[j].TotalValue {
+				return list[i].TotalValue > list[j].TotalValue
+			}
+			return list[i].Label < list[j].Label
+		})
+		metrics[group] = list
+	}
+	return total, average, metrics, nil
+}
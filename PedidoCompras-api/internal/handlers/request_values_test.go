@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"database/sql/driver"
+	"testing"
+
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/models"
+)
+
+func TestRequestCostTotal(t *testing.T) {
+	tests := []struct {
+		name  string
+		items []requestItemCost
+		want  float64
+	}{
+		{"sem itens", nil, 0},
+		{"soma quantidade × preço", []requestItemCost{
+			{ID: 1, Quantity: 10, Status: models.ItemStatusPending, UnitPrice: 12.5},
+			{ID: 2, Quantity: 3, Status: models.ItemStatusApproved, UnitPrice: 1000},
+		}, 3125},
+		{"rejeitados e suspensos ficam de fora", []requestItemCost{
+			{ID: 1, Quantity: 2, Status: models.ItemStatusApproved, UnitPrice: 100},
+			{ID: 2, Quantity: 5, Status: models.ItemStatusRejected, UnitPrice: 100},
+			{ID: 3, Quantity: 7, Status: models.ItemStatusSuspended, UnitPrice: 100},
+		}, 200},
+		{"arredonda para centavos", []requestItemCost{
+			{ID: 1, Quantity: 3, Status: models.ItemStatusPending, UnitPrice: 0.333},
+		}, 1},
+	}
+	for _, tt := range tests {
+		if got := requestCostTotal(tt.items); got != tt.want {
+			t.Errorf("%s: requestCostTotal = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// Antes de qualquer orçamento o valor vem do preço estimado dos itens, então políticas
+// com valor mínimo já se aplicam na primeira aprovação
+func TestEnsureApprovalChainUsesEstimatedPrices(t *testing.T) {
+	tests := []struct {
+		name     string
+		items    [][]driver.Value
+		required bool
+	}{
+		{"estimado acima do mínimo", [][]driver.Value{
+			{int64(1), int64(4), models.ItemStatusPending, 1500.0},
+		}, true},
+		{"estimado abaixo do mínimo", [][]driver.Value{
+			{int64(1), int64(2), models.ItemStatusPending, 1500.0},
+		}, false},
+		{"item suspenso não conta", [][]driver.Value{
+			{int64(1), int64(2), models.ItemStatusPending, 1500.0},
+			{int64(2), int64(10), models.ItemStatusSuspended, 1500.0},
+		}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, db := newFakeDB(t)
+			f.on("ri.estimated_unit_price, 0) AS unit_price", []string{"id", "quantity", "status", "unit_price"}, tt.items...)
+			f.on(`FROM "approval_policies"`, []string{"id", "name", "active", "min_estimated_value"},
+				[]driver.Value{int64(1), "Acima de R$ 5 mil", true, 5000.0})
+			f.on(`FROM "approval_policy_steps"`, []string{"id", "approval_policy_id", "step_order", "name", "approver_type", "approver_role"},
+				[]driver.Value{int64(10), int64(1), int64(1), "Diretor", models.ApproverTypeRole, "director"})
+
+			request := &models.PurchaseRequest{ID: 42, SectorID: 3, Status: models.StatusPending}
+			required, step, err := ensureApprovalChain(db, request)
+			if err != nil {
+				t.Fatalf("ensureApprovalChain: %v", err)
+			}
+			if required != tt.required {
+				t.Fatalf("required = %v, want %v", required, tt.required)
+			}
+			if tt.required && (step == nil || step.Name != "Diretor" || step.RequestValue != 6000) {
+				t.Errorf("etapa = %+v", step)
+			}
+		})
+	}
+}
@@ -0,0 +1,308 @@
+package handlers
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/models"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/rbac"
+	"gorm.io/gorm"
+)
+
+// Nome de papel: minúsculas, números e hífen (cabe em User.Role)
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9-]{1,19}$`)
+
+type createRoleInput struct {
+	Name        string   `json:"name" binding:"required"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+type updateRoleInput struct {
+	Description *string  `json:"description"`
+	Permissions []string `json:"permissions"` // quando enviado, substitui o conjunto atual
+}
+
+// ListRoles - Lista os papéis com suas permissões
+func ListRoles(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var roles []models.Role
+		if err := db.Preload("Permissions").Order("id").Find(&roles).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao consultar papéis"})
+			return
+		}
+
+		response := make([]gin.H, 0, len(roles))
+		for i := range roles {
+			response = append(response, roleResponse(db, &roles[i]))
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// ListPermissions - Catálogo de permissões disponíveis
+func ListPermissions() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, models.Permissions)
+	}
+}
+
+// GetMyPermissions - Permissões do usuário logado (para o frontend montar menus)
+func GetMyPermissions() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"role":        c.GetString("role"),
+			"permissions": rbac.List(c),
+		})
+	}
+}
+
+// CreateRole - Cria um papel com as permissões informadas (roles:manage)
+func CreateRole(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input createRoleInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if !roleNamePattern.MatchString(input.Name) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Nome do papel deve ter de 2 a 20 caracteres: letras minúsculas, números e hífen"})
+			return
+		}
+
+		permissions, invalid := normalizePermissions(input.Permissions)
+		if len(invalid) > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Permissões inválidas", "invalidPermissions": invalid})
+			return
+		}
+
+		if missing := permissionsNotHeld(c, permissions); len(missing) > 0 {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Você não pode conceder permissões que não possui", "permissions": missing})
+			return
+		}
+
+		var count int64
+		db.Model(&models.Role{}).Where("name = ?", input.Name).Count(&count)
+		if count > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "Já existe um papel com este nome"})
+			return
+		}
+
+		role := models.Role{Name: input.Name, Description: input.Description}
+		for _, permission := range permissions {
+			role.Permissions = append(role.Permissions, models.RolePermission{Permission: permission})
+		}
+		if err := db.Create(&role).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao criar papel"})
+			return
+		}
+
+		rbac.Invalidate()
+		c.JSON(http.StatusCreated, roleResponse(db, &role))
+	}
+}
+
+// UpdateRole - Altera descrição e/ou permissões de um papel (roles:manage)
+func UpdateRole(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var role models.Role
+		if err := db.Preload("Permissions").First(&role, c.Param("id")).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Papel não encontrado"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar papel"})
+			}
+			return
+		}
+
+		var input updateRoleInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if role.Name == models.RoleAdmin && input.Permissions != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "O papel admin possui todas as permissões e não pode ser alterado"})
+			return
+		}
+
+		permissions, invalid := normalizePermissions(input.Permissions)
+		if len(invalid) > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Permissões inválidas", "invalidPermissions": invalid})
+			return
+		}
+
+		// Quem não é administrador só altera papéis que não vão além das próprias permissões
+		if missing := permissionsNotHeld(c, role.PermissionNames()); len(missing) > 0 {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Você não pode alterar um papel com permissões que não possui", "permissions": missing})
+			return
+		}
+		if missing := permissionsNotHeld(c, permissions); len(missing) > 0 {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Você não pode conceder permissões que não possui", "permissions": missing})
+			return
+		}
+
+		tx := db.Begin()
+		defer func() {
+			if r := recover(); r != nil {
+				tx.Rollback()
+			}
+		}()
+
+		if input.Description != nil {
+			if err := tx.Model(&role).Update("description", *input.Description).Error; err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar papel"})
+				return
+			}
+		}
+
+		if input.Permissions != nil {
+			if err := tx.Where("role_id = ?", role.ID).Delete(&models.RolePermission{}).Error; err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar permissões"})
+				return
+			}
+			for _, permission := range permissions {
+				if err := tx.Create(&models.RolePermission{RoleID: role.ID, Permission: permission}).Error; err != nil {
+					tx.Rollback()
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar permissões"})
+					return
+				}
+			}
+		}
+
+		if err := tx.Commit().Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao salvar papel"})
+			return
+		}
+
+		rbac.Invalidate()
+
+		db.Preload("Permissions").First(&role, role.ID)
+		c.JSON(http.StatusOK, roleResponse(db, &role))
+	}
+}
+
+// DeleteRole - Remove um papel sem usuários vinculados (roles:manage)
+func DeleteRole(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var role models.Role
+		if err := db.First(&role, c.Param("id")).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Papel não encontrado"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar papel"})
+			}
+			return
+		}
+
+		if role.IsSystem {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Papéis do sistema não podem ser removidos"})
+			return
+		}
+
+		var users int64
+		db.Model(&models.User{}).Where("role = ?", role.Name).Count(&users)
+		if users > 0 {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":     "Existem usuários com este papel. Altere o papel deles antes de remover",
+				"userCount": users,
+			})
+			return
+		}
+
+		if err := db.Select("Permissions").Delete(&role).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao remover papel"})
+			return
+		}
+
+		rbac.Invalidate()
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// checkAssignableRole valida o papel a ser atribuído a um usuário: ele precisa existir
+// e só administradores podem conceder o papel admin. Retorna status 0 quando válido.
+func checkAssignableRole(db *gorm.DB, c *gin.Context, role string) (int, string) {
+	var count int64
+	if err := db.Model(&models.Role{}).Where("name = ?", role).Count(&count).Error; err != nil {
+		return http.StatusInternalServerError, "Erro ao verificar papel"
+	}
+	if count == 0 {
+		return http.StatusBadRequest, "Papel inválido"
+	}
+	if role == models.RoleAdmin && c.GetString("role") != models.RoleAdmin {
+		return http.StatusForbidden, "Apenas administradores podem conceder o papel de administrador"
+	}
+
+	granted := []string{}
+	for permission, ok := range rbac.Permissions(db, role) {
+		if ok {
+			granted = append(granted, permission)
+		}
+	}
+	if len(permissionsNotHeld(c, granted)) > 0 {
+		return http.StatusForbidden, "Você não pode atribuir um papel com permissões que não possui"
+	}
+	return 0, ""
+}
+
+// permissionsNotHeld lista as permissões que o usuário logado não possui (vazio para
+// administradores), para que ninguém conceda, por papéis, mais do que tem
+func permissionsNotHeld(c *gin.Context, permissions []string) []string {
+	if c.GetString("role") == models.RoleAdmin {
+		return nil
+	}
+	missing := []string{}
+	for _, permission := range permissions {
+		if !rbac.Has(c, permission) {
+			missing = append(missing, permission)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// checkManageableUser impede que quem não é administrador altere contas de administradores
+func checkManageableUser(c *gin.Context, target models.User) (int, string) {
+	if target.Role == models.RoleAdmin && c.GetString("role") != models.RoleAdmin {
+		return http.StatusForbidden, "Apenas administradores podem alterar contas de administradores"
+	}
+	return 0, ""
+}
+
+// normalizePermissions remove duplicadas e separa as que não existem no catálogo
+func normalizePermissions(input []string) (valid []string, invalid []string) {
+	seen := map[string]bool{}
+	for _, permission := range input {
+		if seen[permission] {
+			continue
+		}
+		seen[permission] = true
+		if models.IsValidPermission(permission) {
+			valid = append(valid, permission)
+		} else {
+			invalid = append(invalid, permission)
+		}
+	}
+	sort.Strings(valid)
+	return valid, invalid
+}
+
+func roleResponse(db *gorm.DB, role *models.Role) gin.H {
+	var users int64
+	db.Model(&models.User{}).Where("role = ?", role.Name).Count(&users)
+
+	return gin.H{
+		"id":          role.ID,
+		"name":        role.Name,
+		"description": role.Description,
+		"isSystem":    role.IsSystem,
+		"permissions": role.PermissionNames(),
+		"userCount":   users,
+	}
+}
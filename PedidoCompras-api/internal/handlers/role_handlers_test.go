@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"database/sql/driver"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/models"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/rbac"
+)
+
+// Quem gerencia papéis sem ser administrador não pode conceder, por um papel, permissões que não possui
+func TestCreateRolePrivilegeEscalation(t *testing.T) {
+	tests := []struct {
+		name        string
+		role        string
+		held        []string
+		permissions string
+		code        int
+	}{
+		{"concede users:manage sem possuí-la", "rh", []string{models.PermRolesManage, models.PermUsersView}, `["users:view","users:manage"]`, http.StatusForbidden},
+		{"concede roles:manage sem possuí-la", "rh", []string{models.PermUsersManage}, `["roles:manage"]`, http.StatusForbidden},
+		{"concede apenas o que possui", "rh", []string{models.PermRolesManage, models.PermUsersView}, `["users:view"]`, http.StatusCreated},
+		{"administrador concede qualquer permissão", models.RoleAdmin, nil, `["users:manage","roles:manage","security:manage"]`, http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, db := newFakeDB(t)
+
+			w := performRequest(CreateRole(db), http.MethodPost, "/roles",
+				`{"name":"novo-papel","permissions":`+tt.permissions+`}`, nil, tt.role, tt.held...)
+
+			if w.Code != tt.code {
+				t.Fatalf("status %d, want %d: %s", w.Code, tt.code, w.Body.String())
+			}
+			if created := f.executed(`INSERT INTO "roles"`); created != (tt.code == http.StatusCreated) {
+				t.Errorf("papel criado = %v", created)
+			}
+		})
+	}
+}
+
+func TestUpdateRolePrivilegeEscalation(t *testing.T) {
+	held := []string{models.PermRolesManage, models.PermReportsView}
+
+	tests := []struct {
+		name    string
+		current []driver.Value // permissão atual do papel editado
+		body    string
+		code    int
+	}{
+		{"acrescenta permissão que não possui", []driver.Value{int64(1), int64(5), models.PermReportsView}, `{"permissions":["reports:view","users:manage"]}`, http.StatusForbidden},
+		{"edita papel mais privilegiado", []driver.Value{int64(1), int64(5), models.PermUsersManage}, `{"description":"outro"}`, http.StatusForbidden},
+		{"ajusta dentro das próprias permissões", []driver.Value{int64(1), int64(5), models.PermReportsView}, `{"permissions":["reports:view","roles:manage"]}`, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, db := newFakeDB(t)
+			f.on(`FROM "roles"`, []string{"id", "name"}, []driver.Value{int64(5), "compras"})
+			f.on(`FROM "role_permissions"`, []string{"id", "role_id", "permission"}, tt.current)
+
+			w := performRequest(UpdateRole(db), http.MethodPut, "/roles/5", tt.body,
+				gin.Params{{Key: "id", Value: "5"}}, "rh", held...)
+
+			if w.Code != tt.code {
+				t.Fatalf("status %d, want %d: %s", w.Code, tt.code, w.Body.String())
+			}
+			changed := f.executed(`INSERT INTO "role_permissions"`) || f.executed(`UPDATE "roles"`)
+			if changed != (tt.code == http.StatusOK) {
+				t.Errorf("papel alterado = %v", changed)
+			}
+		})
+	}
+}
+
+func TestCheckAssignableRole(t *testing.T) {
+	tests := []struct {
+		name   string
+		caller string
+		held   []string
+		code   int
+	}{
+		{"atribui papel com permissões que não possui", "rh", []string{models.PermUsersManage}, http.StatusForbidden},
+		{"atribui papel contido nas próprias permissões", "rh", []string{models.PermUsersManage, models.PermReportsView, models.PermAuditView}, 0},
+		{"administrador atribui qualquer papel", models.RoleAdmin, nil, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rbac.Invalidate()
+			t.Cleanup(rbac.Invalidate)
+			f, db := newFakeDB(t)
+			f.on(`SELECT count(*) FROM "roles"`, []string{"count"}, []driver.Value{int64(1)})
+			f.on(`FROM "role_permissions"`, []string{"permission"},
+				[]driver.Value{models.PermReportsView}, []driver.Value{models.PermAuditView})
+
+			var status int
+			performRequest(func(c *gin.Context) {
+				status, _ = checkAssignableRole(db, c, "auditor")
+			}, http.MethodPut, "/users/9", "", nil, tt.caller, tt.held...)
+
+			if status != tt.code {
+				t.Errorf("status %d, want %d", status, tt.code)
+			}
+		})
+	}
+}
@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/models"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/rbac"
+	"gorm.io/gorm"
+)
+
+// Limites da busca
+const (
+	searchMinLength    = 2
+	searchMaxLength    = 200
+	searchDefaultLimit = 10
+	searchMaxLimit     = 50
+)
+
+// searchQuerySQL - Consulta de busca em português sem acentos (aceita "aspas", OR e -termo)
+const searchQuerySQL = "websearch_to_tsquery('" + models.SearchConfig + "', ?)"
+
+// searchFunc busca um tipo de registro, já aplicando o escopo do usuário
+type searchFunc func(db *gorm.DB, c *gin.Context, term string, limit int) (models.SearchGroup, error)
+
+var searchers = map[string]searchFunc{
+	models.SearchTypeRequests:  searchRequests,
+	models.SearchTypeProducts:  searchProducts,
+	models.SearchTypeSuppliers: searchSuppliers,
+	models.SearchTypeReceipts:  searchReceipts,
+}
+
+// Search - GET /search?q=luva nitrílica&types=products,receipts&limit=10
+// Busca textual em requisições, produtos, fornecedores e recebimentos, com os resultados
+// ordenados por relevância e agrupados por tipo. Cada grupo respeita a visibilidade do usuário.
+func Search(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		term := strings.TrimSpace(c.Query("q"))
+		if length := len([]rune(term)); length < searchMinLength || length > searchMaxLength {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Informe de 2 a 200 caracteres para a busca"})
+			return
+		}
+
+		limit := searchDefaultLimit
+		if raw := c.Query("limit"); raw != "" {
+			value, err := strconv.Atoi(raw)
+			if err != nil || value < 1 || value > searchMaxLimit {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "limit deve estar entre 1 e 50"})
+				return
+			}
+			limit = value
+		}
+
+		types := queryListParam(c, "types")
+		if len(types) == 0 {
+			types = models.SearchTypes
+		}
+		for _, t := range types {
+			if _, ok := searchers[t]; !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Tipo de busca inválido: " + t, "allowedTypes": models.SearchTypes})
+				return
+			}
+		}
+
+		response := models.SearchResponse{Query: term, Groups: []models.SearchGroup{}}
+		for _, t := range models.SearchTypes {
+			if !containsString(types, t) {
+				continue
+			}
+			group, err := searchers[t](db, c, term, limit)
+			if err != nil {
+				fmt.Printf("❌ Erro na busca de %s: %v\n", t, err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao realizar a busca"})
+				return
+			}
+			response.Total += group.Total
+			response.Groups = append(response.Groups, group)
+		}
+
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// searchRequests busca nas observações e notas das requisições visíveis ao usuário
+func searchRequests(db *gorm.DB, c *gin.Context, term string, limit int) (models.SearchGroup, error) {
+	group := models.SearchGroup{Type: models.SearchTypeRequests}
+
+	query := db.Model(&models.PurchaseRequest{}).
+		Where("("+models.SearchDocumentRequests+") @@ "+searchQuerySQL, term)
+	query = scopeVisibleRequests(db, c, query)
+
+	err := runSearch(query, "purchase_requests.id", &group, limit, `
+		purchase_requests.id,
+		'Requisição #' || purchase_requests.id AS title,
+		(SELECT users.name FROM users WHERE users.id = purchase_requests.requester_id) AS subtitle,
+		purchase_requests.status,
+		ts_headline('`+models.SearchConfig+`', coalesce(purchase_requests.observations, '') || ' ' || coalesce(purchase_requests.admin_notes, ''), `+searchQuerySQL+`) AS snippet,
+		ts_rank(`+models.SearchDocumentRequests+`, `+searchQuerySQL+`) AS rank`,
+		term, term)
+	return group, err
+}
+
+// searchProducts busca no nome e na descrição dos produtos (sem products:manage,
+// apenas os disponíveis do setor do usuário, como na listagem)
+func searchProducts(db *gorm.DB, c *gin.Context, term string, limit int) (models.SearchGroup, error) {
+	group := models.SearchGroup{Type: models.SearchTypeProducts}
+
+	query := db.Model(&models.Product{}).
+		Where("("+models.SearchDocumentProducts+") @@ "+searchQuerySQL, term)
+	if !rbac.Has(c, models.PermProductsManage) {
+		var user models.User
+		if err := db.Select("sector_id").First(&user, c.GetString("userID")).Error; err != nil {
+			return group, err
+		}
+		query = query.Where("products.sector_id = ? AND products.status = ?", user.SectorID, "available")
+	}
+
+	err := runSearch(query, "products.id", &group, limit, `
+		products.id,
+		products.name AS title,
+		products.unit AS subtitle,
+		products.status,
+		ts_headline('`+models.SearchConfig+`', coalesce(products.description, ''), `+searchQuerySQL+`) AS snippet,
+		ts_rank(`+models.SearchDocumentProducts+`, `+searchQuerySQL+`) AS rank`,
+		term, term)
+	return group, err
+}
+
+// searchSuppliers busca no nome dos fornecedores e no CNPJ (só pelos dígitos, com ou sem pontuação)
+func searchSuppliers(db *gorm.DB, c *gin.Context, term string, limit int) (models.SearchGroup, error) {
+	group := models.SearchGroup{Type: models.SearchTypeSuppliers}
+
+	// CNPJ só é comparado quando o termo é um número (ex: 12.345.678/0001-90 ou 12345678)
+	digits := cnpjSearchDigits(term)
+	cnpjMatch := "FALSE"
+	var cnpjArgs []interface{}
+	if digits != "" {
+		cnpjMatch = `regexp_replace(suppliers.cnpj, '\D', '', 'g') LIKE ?`
+		cnpjArgs = []interface{}{"%" + digits + "%"}
+	}
+
+	query := db.Model(&models.Supplier{}).
+		Where("("+models.SearchDocumentSuppliers+" @@ "+searchQuerySQL+" OR "+cnpjMatch+")", append([]interface{}{term}, cnpjArgs...)...)
+
+	err := runSearch(query, "suppliers.id", &group, limit, `
+		suppliers.id,
+		suppliers.name AS title,
+		suppliers.cnpj AS subtitle,
+		ts_headline('`+models.SearchConfig+`', suppliers.name, `+searchQuerySQL+`) AS snippet,
+		ts_rank(`+models.SearchDocumentSuppliers+`, `+searchQuerySQL+`) + CASE WHEN `+cnpjMatch+` THEN 1 ELSE 0 END AS rank`,
+		append([]interface{}{term, term}, cnpjArgs...)...)
+	return group, err
+}
+
+// searchReceipts busca pelo número da nota fiscal e do lote (também por trecho);
+// sem receipts:view, apenas os recebimentos das próprias requisições
+func searchReceipts(db *gorm.DB, c *gin.Context, term string, limit int) (models.SearchGroup, error) {
+	group := models.SearchGroup{Type: models.SearchTypeReceipts}
+
+	simpleQuery := "websearch_to_tsquery('simple', ?)"
+	pattern := containsPattern(term)
+	identifierMatch := "(item_receipts.invoice_number ILIKE ? OR item_receipts.lot_number ILIKE ?)"
+
+	query := db.Model(&models.ItemReceipt{}).
+		Joins("INNER JOIN request_items ON request_items.id = item_receipts.request_item_id AND request_items.deleted_at IS NULL").
+		Joins("INNER JOIN purchase_requests ON purchase_requests.id = request_items.purchase_request_id AND purchase_requests.deleted_at IS NULL").
+		Joins("LEFT JOIN products ON products.id = request_items.product_id").
+		Where("("+models.SearchDocumentReceipts+" @@ "+simpleQuery+" OR "+identifierMatch+")", term, pattern, pattern)
+	if !rbac.Has(c, models.PermReceiptsView) {
+		query = query.Where("purchase_requests.requester_id = ?", c.GetString("userID"))
+	}
+
+	err := runSearch(query, "item_receipts.id", &group, limit, `
+		item_receipts.id,
+		'NF ' || item_receipts.invoice_number AS title,
+		coalesce(products.name, '') AS subtitle,
+		item_receipts.receipt_condition AS status,
+		CASE WHEN coalesce(item_receipts.lot_number, '') <> '' THEN 'Lote ' || item_receipts.lot_number ELSE '' END AS snippet,
+		ts_rank(`+models.SearchDocumentReceipts+`, `+simpleQuery+`) + CASE WHEN `+identifierMatch+` THEN 1 ELSE 0 END AS rank,
+		request_items.purchase_request_id AS request_id`,
+		term, pattern, pattern)
+	return group, err
+}
+
+// runSearch conta os registros encontrados e carrega os mais relevantes no grupo
+func runSearch(query *gorm.DB, idColumn string, group *models.SearchGroup, limit int, selectSQL string, args ...interface{}) error {
+	base := query.Session(&gorm.Session{})
+	group.Results = []models.SearchResult{}
+	if err := base.Count(&group.Total).Error; err != nil {
+		return err
+	}
+	if group.Total == 0 {
+		return nil
+	}
+
+	if err := base.Select(selectSQL, args...).
+		Order("rank DESC, " + idColumn + " DESC").
+		Limit(limit).
+		Scan(&group.Results).Error; err != nil {
+		return err
+	}
+	for i := range group.Results {
+		group.Results[i].Type = group.Type
+	}
+	return nil
+}
+
+// cnpjSearchDigits retorna os dígitos do termo quando ele parece um CNPJ (completo ou parcial)
+func cnpjSearchDigits(term string) string {
+	var digits strings.Builder
+	for _, r := range term {
+		switch {
+		case unicode.IsDigit(r):
+			digits.WriteRune(r)
+		case r == '.' || r == '/' || r == '-' || r == ' ':
+		default:
+			return ""
+		}
+	}
+	if digits.Len() < 3 {
+		return ""
+	}
+	return digits.String()
+}
+
+// containsPattern monta o padrão LIKE "contém", escapando os curingas do termo
+func containsPattern(term string) string {
+	return "%" + strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(term) + "%"
+}
+
+// containsString indica se a lista contém o valor
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,735 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/models"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/rbac"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type createSectorBudgetInput struct {
+	SectorID    uint    `json:"sectorId" binding:"required"`
+	PeriodType  string  `json:"periodType" binding:"required,oneof=monthly quarterly yearly"`
+	Year        int     `json:"year" binding:"required"`
+	Period      int     `json:"period"` // mês (1-12) ou trimestre (1-4); ignorado nas verbas anuais
+	Amount      float64 `json:"amount" binding:"required,gt=0"`
+	Enforcement string  `json:"enforcement" binding:"omitempty,oneof=warn block"`
+	Notes       string  `json:"notes"`
+}
+
+type updateSectorBudgetInput struct {
+	Amount      *float64 `json:"amount" binding:"omitempty,gt=0"`
+	Enforcement *string  `json:"enforcement" binding:"omitempty,oneof=warn block"`
+	Notes       *string  `json:"notes"`
+}
+
+// sectorBudgetFilters - Filtros da listagem e do relatório de verbas
+type sectorBudgetFilters struct {
+	SectorID   *uint  `form:"sectorId"` // inclui os subsetores
+	Year       int    `form:"year"`
+	PeriodType string `form:"periodType" binding:"omitempty,oneof=monthly quarterly yearly"`
+}
+
+// budgetLedgerBalance - Saldo de um item em uma verba
+type budgetLedgerBalance struct {
+	SectorBudgetID uint
+	RequestItemID  uint
+	Committed      float64
+	Consumed       float64
+}
+
+// budgetMovement - Empenho (positivo) ou estorno (negativo) necessário para ajustar a verba
+type budgetMovement struct {
+	BudgetID uint
+	ItemID   uint
+	Amount   float64
+}
+
+// ListSectorBudgets - Lista as verbas (sector-budgets:manage, reports:view ou gestor do setor)
+func ListSectorBudgets(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		budgets, ok := findSectorBudgets(c, db)
+		if !ok {
+			return
+		}
+		c.JSON(http.StatusOK, budgets)
+	}
+}
+
+// GetSectorBudget - Verba com a situação atual (orçado x empenhado x realizado)
+func GetSectorBudget(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		budget, ok := loadSectorBudget(c, db)
+		if !ok {
+			return
+		}
+
+		statuses, err := sectorBudgetStatuses(db, []models.SectorBudget{*budget})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao calcular saldo da verba"})
+			return
+		}
+		c.JSON(http.StatusOK, statuses[0])
+	}
+}
+
+// ListSectorBudgetEntries - Movimentos (empenhos, estornos e consumos) de uma verba
+func ListSectorBudgetEntries(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		budget, ok := loadSectorBudget(c, db)
+		if !ok {
+			return
+		}
+
+		var entries []models.SectorBudgetEntry
+		if err := db.Where("sector_budget_id = ?", budget.ID).Order("created_at DESC, id DESC").Find(&entries).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar movimentos da verba"})
+			return
+		}
+		c.JSON(http.StatusOK, entries)
+	}
+}
+
+// CreateSectorBudget - Cadastra a verba de um setor para um período (sector-budgets:manage)
+func CreateSectorBudget(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input createSectorBudgetInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		start, end, err := models.BudgetPeriodBounds(input.PeriodType, input.Year, input.Period)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var sector models.Sector
+		if err := db.First(&sector, input.SectorID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Setor não encontrado"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar setor"})
+			}
+			return
+		}
+
+		var existing int64
+		if err := db.Model(&models.SectorBudget{}).
+			Where("sector_id = ? AND period_type = ? AND period_start = ?", sector.ID, input.PeriodType, start).
+			Count(&existing).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao verificar verbas do setor"})
+			return
+		}
+		if existing > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("O setor %s já possui verba para o período %s",
+				sector.Name, models.BudgetPeriodLabel(input.PeriodType, start))})
+			return
+		}
+
+		if input.Enforcement == "" {
+			input.Enforcement = models.BudgetEnforcementWarn
+		}
+
+		budget := models.SectorBudget{
+			SectorID:    sector.ID,
+			PeriodType:  input.PeriodType,
+			PeriodStart: start,
+			PeriodEnd:   end,
+			Amount:      roundMoney(input.Amount),
+			Enforcement: input.Enforcement,
+			Notes:       strings.TrimSpace(input.Notes),
+			CreatedBy:   utils.ParseUint(c.GetString("userID")),
+		}
+		if err := db.Create(&budget).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao criar verba"})
+			return
+		}
+
+		budget.Sector = sector
+		c.JSON(http.StatusCreated, budget)
+	}
+}
+
+// UpdateSectorBudget - Altera valor, política e observações da verba (sector-budgets:manage)
+func UpdateSectorBudget(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var budget models.SectorBudget
+		if err := db.Preload("Sector").First(&budget, c.Param("id")).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Verba não encontrada"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar verba"})
+			}
+			return
+		}
+
+		var input updateSectorBudgetInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		updates := map[string]interface{}{}
+		if input.Amount != nil {
+			updates["amount"] = roundMoney(*input.Amount)
+		}
+		if input.Enforcement != nil {
+			updates["enforcement"] = *input.Enforcement
+		}
+		if input.Notes != nil {
+			updates["notes"] = strings.TrimSpace(*input.Notes)
+		}
+		if len(updates) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Nenhum campo para atualizar"})
+			return
+		}
+
+		if err := db.Model(&budget).Updates(updates).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar verba"})
+			return
+		}
+		c.JSON(http.StatusOK, budget)
+	}
+}
+
+// DeleteSectorBudget - Exclui a verba (soft-delete; os movimentos são mantidos como histórico)
+func DeleteSectorBudget(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var budget models.SectorBudget
+		if err := db.First(&budget, c.Param("id")).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Verba não encontrada"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar verba"})
+			}
+			return
+		}
+
+		if err := db.Delete(&budget).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao excluir verba"})
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// GetSectorBudgetReport - Orçado x empenhado x realizado das verbas filtradas
+func GetSectorBudgetReport(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		statuses, ok := sectorBudgetReport(c, db)
+		if !ok {
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"budgets": statuses,
+			"totals":  sumSectorBudgetStatuses(statuses),
+		})
+	}
+}
+
+// ExportSectorBudgetReport - Relatório de verbas em Excel
+func ExportSectorBudgetReport(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		statuses, ok := sectorBudgetReport(c, db)
+		if !ok {
+			return
+		}
+
+		f, err := buildSectorBudgetWorkbook(statuses, sumSectorBudgetStatuses(statuses))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Erro ao gerar planilha: %v", err)})
+			return
+		}
+		defer f.Close()
+
+		filename := fmt.Sprintf("relatorio_verbas_%s.xlsx", time.Now().Format("2006-01-02"))
+
+		c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+		_ = f.Write(c.Writer)
+	}
+}
+
+// sectorBudgetReport aplica os filtros e calcula a situação das verbas
+func sectorBudgetReport(c *gin.Context, db *gorm.DB) ([]models.SectorBudgetStatus, bool) {
+	budgets, ok := findSectorBudgets(c, db)
+	if !ok {
+		return nil, false
+	}
+
+	statuses, err := sectorBudgetStatuses(db, budgets)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Erro ao gerar relatório: %v", err)})
+		return nil, false
+	}
+	return statuses, true
+}
+
+// findSectorBudgets busca as verbas visíveis ao usuário conforme os filtros da query
+func findSectorBudgets(c *gin.Context, db *gorm.DB) ([]models.SectorBudget, bool) {
+	var filters sectorBudgetFilters
+	if err := c.ShouldBindQuery(&filters); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	visible, ok := visibleBudgetSectors(c, db)
+	if !ok {
+		return nil, false
+	}
+
+	query := db.Preload("Sector", func(tx *gorm.DB) *gorm.DB { return tx.Unscoped() })
+	if visible != nil {
+		query = query.Where("sector_id IN ?", visible)
+	}
+	if filters.SectorID != nil {
+		query = query.Where("sector_id IN ?", sectorSubtreeIDs(db, []uint{*filters.SectorID}))
+	}
+	if filters.Year != 0 {
+		start := time.Date(filters.Year, time.January, 1, 0, 0, 0, 0, time.Local)
+		query = query.Where("period_start >= ? AND period_start < ?", start, start.AddDate(1, 0, 0))
+	}
+	if filters.PeriodType != "" {
+		query = query.Where("period_type = ?", filters.PeriodType)
+	}
+
+	var budgets []models.SectorBudget
+	if err := query.Order("period_start DESC, sector_id").Find(&budgets).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao consultar verbas"})
+		return nil, false
+	}
+	return budgets, true
+}
+
+// loadSectorBudget carrega a verba do parâmetro :id respeitando a visibilidade do usuário
+func loadSectorBudget(c *gin.Context, db *gorm.DB) (*models.SectorBudget, bool) {
+	var budget models.SectorBudget
+	if err := db.Preload("Sector", func(tx *gorm.DB) *gorm.DB { return tx.Unscoped() }).First(&budget, c.Param("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Verba não encontrada"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar verba"})
+		}
+		return nil, false
+	}
+
+	visible, ok := visibleBudgetSectors(c, db)
+	if !ok {
+		return nil, false
+	}
+	if visible != nil {
+		for _, id := range visible {
+			if id == budget.SectorID {
+				return &budget, true
+			}
+		}
+		c.JSON(http.StatusForbidden, gin.H{"error": "Acesso negado à verba deste setor"})
+		return nil, false
+	}
+	return &budget, true
+}
+
+// visibleBudgetSectors retorna nil quando o usuário vê todas as verbas (sector-budgets:manage ou
+// reports:view) ou os setores geridos por ele, incluindo subsetores. Responde 403 para os demais.
+func visibleBudgetSectors(c *gin.Context, db *gorm.DB) ([]uint, bool) {
+	if rbac.Has(c, models.PermSectorBudgetsManage) || rbac.Has(c, models.PermReportsView) {
+		return nil, true
+	}
+
+	managed := managedSectorIDs(db, utils.ParseUint(c.GetString("userID")))
+	if len(managed) == 0 {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Permissão insuficiente", "permission": models.PermSectorBudgetsManage})
+		return nil, false
+	}
+	return managed, true
+}
+
+// sectorBudgetStatuses calcula empenhado, realizado e disponível de cada verba
+func sectorBudgetStatuses(db *gorm.DB, budgets []models.SectorBudget) ([]models.SectorBudgetStatus, error) {
+	statuses := make([]models.SectorBudgetStatus, 0, len(budgets))
+	if len(budgets) == 0 {
+		return statuses, nil
+	}
+
+	ids := make([]uint, len(budgets))
+	for i, b := range budgets {
+		ids[i] = b.ID
+	}
+
+	var balances []struct {
+		SectorBudgetID uint
+		Committed      float64
+		Consumed       float64
+	}
+	if err := db.Model(&models.SectorBudgetEntry{}).
+		Select(`sector_budget_id,
+			COALESCE(SUM(CASE WHEN type = ? THEN amount WHEN type = ? THEN -amount ELSE 0 END), 0) AS committed,
+			COALESCE(SUM(CASE WHEN type = ? THEN amount ELSE 0 END), 0) AS consumed`,
+			models.BudgetEntryCommitment, models.BudgetEntryRelease, models.BudgetEntryConsumption).
+		Where("sector_budget_id IN ?", ids).
+		Group("sector_budget_id").
+		Scan(&balances).Error; err != nil {
+		return nil, err
+	}
+	byBudget := make(map[uint]int, len(balances))
+	for i, b := range balances {
+		byBudget[b.SectorBudgetID] = i
+	}
+
+	for _, b := range budgets {
+		status := models.SectorBudgetStatus{
+			BudgetID:    b.ID,
+			SectorID:    b.SectorID,
+			SectorName:  b.Sector.Name,
+			CostCenter:  b.Sector.CostCenter,
+			PeriodType:  b.PeriodType,
+			PeriodLabel: models.BudgetPeriodLabel(b.PeriodType, b.PeriodStart),
+			PeriodStart: b.PeriodStart,
+			PeriodEnd:   b.PeriodEnd,
+			Enforcement: b.Enforcement,
+			Amount:      b.Amount,
+		}
+		if i, ok := byBudget[b.ID]; ok {
+			status.Committed = roundMoney(balances[i].Committed)
+			status.Consumed = roundMoney(balances[i].Consumed)
+		}
+		status.Available = roundMoney(status.Amount - status.Committed - status.Consumed)
+		if status.Amount > 0 {
+			status.UsagePercent = math.Round((status.Committed+status.Consumed)/status.Amount*10000) / 100
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// sumSectorBudgetStatuses soma os valores das verbas do relatório
+func sumSectorBudgetStatuses(statuses []models.SectorBudgetStatus) gin.H {
+	var amount, committed, consumed float64
+	for _, s := range statuses {
+		amount += s.Amount
+		committed += s.Committed
+		consumed += s.Consumed
+	}
+
+	usage := 0.0
+	if amount > 0 {
+		usage = math.Round((committed+consumed)/amount*10000) / 100
+	}
+	return gin.H{
+		"amount":       roundMoney(amount),
+		"committed":    roundMoney(committed),
+		"consumed":     roundMoney(consumed),
+		"available":    roundMoney(amount - committed - consumed),
+		"usagePercent": usage,
+	}
+}
+
+// enforceSectorBudgets verifica as verbas antes de a requisição passar para o status informado.
+// Com política "block" responde 409 e retorna false; com "warn" define o cabeçalho
+// X-Budget-Warning e devolve o aviso para o histórico de status.
+func enforceSectorBudgets(c *gin.Context, db *gorm.DB, request *models.PurchaseRequest, status string) (string, bool) {
+	exceeded, err := checkBudgetAvailability(db, request, status)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao verificar verba do setor"})
+		return "", false
+	}
+	if len(exceeded) == 0 {
+		return "", true
+	}
+
+	for _, s := range exceeded {
+		if s.Enforcement == models.BudgetEnforcementBlock {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":   "A aprovação excede a verba disponível do setor",
+				"budgets": exceeded,
+			})
+			return "", false
+		}
+	}
+
+	ids := make([]string, len(exceeded))
+	parts := make([]string, len(exceeded))
+	for i, s := range exceeded {
+		ids[i] = utils.UintToString(s.BudgetID)
+		parts[i] = fmt.Sprintf("%s %s (disponível R$ %.2f, empenho R$ %.2f)", s.SectorName, s.PeriodLabel, s.Available, s.Requested)
+	}
+	c.Header("X-Budget-Warning", "budget-exceeded; budgets="+strings.Join(ids, ","))
+	return "Verba do setor excedida: " + strings.Join(parts, "; "), true
+}
+
+// applyBudgetChange reaplica a política de verba do setor e sincroniza os empenhos depois de
+// uma alteração que muda o valor da requisição (itens, orçamentos, reabertura), na mesma
+// transação da alteração. Responde 409 quando a política bloqueia e 500 em caso de erro;
+// nesses casos o chamador desfaz a transação.
+func applyBudgetChange(c *gin.Context, tx *gorm.DB, requestID uint, userID string) (string, bool) {
+	var request models.PurchaseRequest
+	if err := tx.First(&request, requestID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar requisição"})
+		return "", false
+	}
+
+	warning := ""
+	if request.Status == models.StatusApproved || request.Status == models.StatusPartial {
+		var ok bool
+		if warning, ok = enforceSectorBudgets(c, tx, &request, request.Status); !ok {
+			return "", false
+		}
+	}
+
+	if err := syncBudgetCommitments(tx, requestID, userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar verba do setor"})
+		return "", false
+	}
+	return warning, true
+}
+
+// appendBudgetWarning acrescenta o aviso de verba excedida às observações do histórico
+func appendBudgetWarning(notes, warning string) string {
+	if warning == "" {
+		return notes
+	}
+	if strings.TrimSpace(notes) == "" {
+		return warning
+	}
+	return notes + "\n" + warning
+}
+
+// checkBudgetAvailability retorna as verbas que seriam excedidas se a requisição passasse
+// para o status informado (Requested = valor líquido que a mudança empenharia)
+func checkBudgetAvailability(db *gorm.DB, request *models.PurchaseRequest, status string) ([]models.SectorBudgetStatus, error) {
+	movements, err := planBudgetCommitments(db, request, status)
+	if err != nil {
+		return nil, err
+	}
+
+	requested := map[uint]float64{}
+	for _, m := range movements {
+		requested[m.BudgetID] += m.Amount
+	}
+	var ids []uint
+	for id, amount := range requested {
+		if amount > 0.005 {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	// Trava as verbas (em ordem de ID) antes de somar o razão: aprovações paralelas no
+	// mesmo setor esperam o empenho desta transação em vez de verem o mesmo saldo
+	var budgets []models.SectorBudget
+	if err := db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Preload("Sector", func(tx *gorm.DB) *gorm.DB { return tx.Unscoped() }).
+		Where("id IN ?", ids).Order("id").Find(&budgets).Error; err != nil {
+		return nil, err
+	}
+	statuses, err := sectorBudgetStatuses(db, budgets)
+	if err != nil {
+		return nil, err
+	}
+
+	var exceeded []models.SectorBudgetStatus
+	for _, s := range statuses {
+		s.Requested = roundMoney(requested[s.BudgetID])
+		if s.Requested > s.Available+0.005 {
+			exceeded = append(exceeded, s)
+		}
+	}
+	sort.Slice(exceeded, func(i, j int) bool { return exceeded[i].BudgetID < exceeded[j].BudgetID })
+	return exceeded, nil
+}
+
+// syncBudgetCommitments ajusta os empenhos da requisição ao seu estado atual: requisições
+// aprovadas (ou parciais) empenham o valor ainda não recebido dos itens ativos; rejeitadas,
+// concluídas ou de volta a pendente estornam o que restar. Idempotente: só grava a diferença.
+func syncBudgetCommitments(tx *gorm.DB, requestID uint, userID string) error {
+	var request models.PurchaseRequest
+	if err := tx.First(&request, requestID).Error; err != nil {
+		return err
+	}
+
+	movements, err := planBudgetCommitments(tx, &request, request.Status)
+	if err != nil {
+		return err
+	}
+
+	var createdBy *uint
+	if id := utils.ParseUint(userID); id != 0 {
+		createdBy = &id
+	}
+	for _, m := range movements {
+		entry := models.SectorBudgetEntry{
+			SectorBudgetID:    m.BudgetID,
+			PurchaseRequestID: request.ID,
+			RequestItemID:     m.ItemID,
+			Type:              models.BudgetEntryCommitment,
+			Amount:            m.Amount,
+			CreatedBy:         createdBy,
+			Notes:             fmt.Sprintf("Requisição #%d (%s)", request.ID, request.Status),
+		}
+		if m.Amount < 0 {
+			entry.Type = models.BudgetEntryRelease
+			entry.Amount = -m.Amount
+		}
+		if err := tx.Create(&entry).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordBudgetConsumption registra o consumo das verbas pelo recebimento (quantidade aceita ×
+// preço unitário). O empenho correspondente é estornado pela sincronização seguinte.
+func recordBudgetConsumption(tx *gorm.DB, request *models.PurchaseRequest, receipt *models.ItemReceipt, unitPrice float64, userID string) error {
+	amount := roundMoney(float64(receipt.QuantityReceived-receipt.RejectedQuantity) * unitPrice)
+	if amount <= 0 {
+		return nil
+	}
+
+	budgetIDs, err := itemBudgetIDs(tx, request, receipt.RequestItemID)
+	if err != nil {
+		return err
+	}
+
+	var createdBy *uint
+	if id := utils.ParseUint(userID); id != 0 {
+		createdBy = &id
+	}
+	for _, budgetID := range budgetIDs {
+		entry := models.SectorBudgetEntry{
+			SectorBudgetID:    budgetID,
+			PurchaseRequestID: request.ID,
+			RequestItemID:     receipt.RequestItemID,
+			ItemReceiptID:     &receipt.ID,
+			Type:              models.BudgetEntryConsumption,
+			Amount:            amount,
+			CreatedBy:         createdBy,
+			Notes:             fmt.Sprintf("Recebimento NF %s", receipt.InvoiceNumber),
+		}
+		if err := tx.Create(&entry).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// planBudgetCommitments calcula os empenhos/estornos que deixam as verbas coerentes com a
+// requisição no status informado
+func planBudgetCommitments(db *gorm.DB, request *models.PurchaseRequest, status string) ([]budgetMovement, error) {
+	items, err := requestItemCosts(db, request.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var balances []budgetLedgerBalance
+	if err := db.Model(&models.SectorBudgetEntry{}).
+		Select(`sector_budget_id, request_item_id,
+			COALESCE(SUM(CASE WHEN type = ? THEN amount WHEN type = ? THEN -amount ELSE 0 END), 0) AS committed,
+			COALESCE(SUM(CASE WHEN type = ? THEN amount ELSE 0 END), 0) AS consumed`,
+			models.BudgetEntryCommitment, models.BudgetEntryRelease, models.BudgetEntryConsumption).
+		Where("purchase_request_id = ?", request.ID).
+		Group("sector_budget_id, request_item_id").
+		Scan(&balances).Error; err != nil {
+		return nil, err
+	}
+
+	// Saldos por item; itens excluídos continuam aqui e têm o empenho estornado
+	ledger := map[uint]map[uint]budgetLedgerBalance{}
+	for _, b := range balances {
+		if ledger[b.RequestItemID] == nil {
+			ledger[b.RequestItemID] = map[uint]budgetLedgerBalance{}
+		}
+		ledger[b.RequestItemID][b.SectorBudgetID] = b
+	}
+
+	active := status == models.StatusApproved || status == models.StatusPartial
+	cost := map[uint]float64{}
+	for _, item := range items {
+		if active && item.Counts() {
+			cost[item.ID] = item.Cost()
+		}
+		if _, ok := ledger[item.ID]; !ok {
+			ledger[item.ID] = map[uint]budgetLedgerBalance{}
+		}
+	}
+
+	itemIDs := make([]uint, 0, len(ledger))
+	for id := range ledger {
+		itemIDs = append(itemIDs, id)
+	}
+	sort.Slice(itemIDs, func(i, j int) bool { return itemIDs[i] < itemIDs[j] })
+
+	var movements []budgetMovement
+	for _, itemID := range itemIDs {
+		budgetIDs, err := itemBudgetIDs(db, request, itemID)
+		if err != nil {
+			return nil, err
+		}
+		for _, budgetID := range budgetIDs {
+			balance := ledger[itemID][budgetID]
+			desired := 0.0
+			if cost[itemID] > 0 {
+				desired = math.Max(0, cost[itemID]-balance.Consumed)
+			}
+			if diff := roundMoney(desired - balance.Committed); math.Abs(diff) >= 0.01 {
+				movements = append(movements, budgetMovement{BudgetID: budgetID, ItemID: itemID, Amount: diff})
+			}
+		}
+	}
+	return movements, nil
+}
+
+// itemBudgetIDs retorna as verbas que respondem pelo item: as que já têm movimentos dele ou,
+// na falta delas, as verbas vigentes do setor da requisição e dos setores acima
+func itemBudgetIDs(db *gorm.DB, request *models.PurchaseRequest, itemID uint) ([]uint, error) {
+	var ids []uint
+	if err := db.Model(&models.SectorBudgetEntry{}).
+		Joins("INNER JOIN sector_budgets sb ON sb.id = sector_budget_entries.sector_budget_id AND sb.deleted_at IS NULL").
+		Where("sector_budget_entries.request_item_id = ?", itemID).
+		Distinct().
+		Pluck("sector_budget_entries.sector_budget_id", &ids).Error; err != nil {
+		return nil, err
+	}
+	if len(ids) > 0 {
+		return ids, nil
+	}
+
+	now := time.Now()
+	err := db.Model(&models.SectorBudget{}).
+		Where("sector_id IN ? AND period_start <= ? AND period_end > ?", sectorAncestorIDs(db, request.SectorID), now, now).
+		Order("id").
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+// itemReferencePrice retorna o preço de referência de um item para a verba
+func itemReferencePrice(db *gorm.DB, requestID, itemID uint) (float64, error) {
+	items, err := requestItemCosts(db, requestID)
+	if err != nil {
+		return 0, err
+	}
+	for _, item := range items {
+		if item.ID == itemID {
+			return item.UnitPrice, nil
+		}
+	}
+	return 0, nil
+}
+
+// roundMoney arredonda valores monetários para centavos
+func roundMoney(value float64) float64 {
+	return math.Round(value*100) / 100
+}
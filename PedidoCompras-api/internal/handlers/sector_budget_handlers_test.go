@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/models"
+)
+
+// As verbas são travadas antes da soma do razão, para que duas aprovações paralelas
+// não empenhem o mesmo saldo
+func TestCheckBudgetAvailabilityLocksBudgets(t *testing.T) {
+	f, db := newFakeDB(t)
+	f.on("ri.estimated_unit_price, 0) AS unit_price", []string{"id", "quantity", "status", "unit_price"},
+		[]driver.Value{int64(1), int64(2), models.ItemStatusPending, 100.0})
+	f.on(`SELECT "id" FROM "sector_budgets"`, []string{"id"}, []driver.Value{int64(5)})
+
+	now := time.Now()
+	f.on("FOR UPDATE", []string{"id", "sector_id", "period_type", "period_start", "period_end", "amount", "enforcement"},
+		[]driver.Value{int64(5), int64(3), models.BudgetPeriodMonthly, now.AddDate(0, 0, -1), now.AddDate(0, 1, 0), 150.0, models.BudgetEnforcementBlock})
+
+	request := &models.PurchaseRequest{ID: 42, SectorID: 3, Status: models.StatusPending}
+	exceeded, err := checkBudgetAvailability(db, request, models.StatusApproved)
+	if err != nil {
+		t.Fatalf("checkBudgetAvailability: %v", err)
+	}
+	if len(exceeded) != 1 || exceeded[0].BudgetID != 5 || exceeded[0].Requested != 200 || exceeded[0].Available != 150 {
+		t.Fatalf("exceeded = %+v", exceeded)
+	}
+
+	lock := f.index(`FROM "sector_budgets"`, "FOR UPDATE")
+	ledger := f.index(`FROM "sector_budget_entries"`, "sector_budget_id IN")
+	if lock < 0 || ledger < 0 || lock > ledger {
+		t.Errorf("trava das verbas em %d, soma do razão em %d: a trava deve vir antes", lock, ledger)
+	}
+}
@@ -2,6 +2,7 @@ package handlers // Declara que o arquivo pertence ao pacote 'handlers'.
 
 import (
 	"net/http" // Importa o pacote 'http' para lidar com códigos de status HTTP.
+	"strings"  // Importa o pacote 'strings' para normalizar o centro de custo.
 
 	"github.com/gin-gonic/gin" // Importa o framework Gin, usado para construir APIs web.
 	"gorm.io/gorm"             // Importa o GORM, um ORM (Object-Relational Mapper) para Go, usado para interagir com o banco de dados.
@@ -28,7 +29,9 @@ func ListSectors(databaseConnection *gorm.DB) gin.HandlerFunc { // Define a fun
 // CreateSector cadastra um novo setor.
 func CreateSector(databaseConnection *gorm.DB) gin.HandlerFunc { // Define a função CreateSector que recebe uma conexão GORM com o banco de dados e retorna um gin.HandlerFunc.
 	type createSectorInput struct { // Define uma nova estrutura 'createSectorInput' para representar o corpo da requisição de criação de setor.
-		Name string `json:"name" binding:"required"` // Define o campo 'Name' como uma string, que será mapeada do JSON e é um campo obrigatório.
+		Name       string `json:"name" binding:"required"` // Define o campo 'Name' como uma string, que será mapeada do JSON e é um campo obrigatório.
+		CostCenter string `json:"costCenter"`              // Centro de custo (opcional, único entre os setores).
+		ParentID   *uint  `json:"parentId"`                // Setor pai na hierarquia (nil = setor raiz).
 	}
 
 	return func(ctx *gin.Context) { // Retorna uma função anônima que será o manipulador de rota do Gin.
@@ -41,7 +44,19 @@ func CreateSector(databaseConnection *gorm.DB) gin.HandlerFunc { // Define a fun
 			return // Interrompe a execução da função.
 		}
 
-		newSector := models.Sector{Name: input.Name} // Cria uma nova instância de 'models.Sector' com o nome fornecido na entrada.
+		input.CostCenter = strings.TrimSpace(input.CostCenter)
+		// Valida o centro de custo e o setor pai antes de gravar.
+		if status, message := checkCostCenterAvailable(databaseConnection, input.CostCenter, 0); status != 0 {
+			ctx.JSON(status, gin.H{"error": message})
+			return
+		}
+		if status, message := checkSectorParent(databaseConnection, 0, input.ParentID); status != 0 {
+			ctx.JSON(status, gin.H{"error": message})
+			return
+		}
+
+		// Cria uma nova instância de 'models.Sector' com os dados fornecidos na entrada.
+		newSector := models.Sector{Name: input.Name, CostCenter: input.CostCenter, ParentID: input.ParentID}
 		// Tenta criar um novo setor no banco de dados usando os dados de 'newSector'.
 		// Se ocorrer um erro durante a criação, ele é capturado.
 		if err := databaseConnection.Create(&newSector).Error; err != nil {
@@ -54,10 +69,11 @@ func CreateSector(databaseConnection *gorm.DB) gin.HandlerFunc { // Define a fun
 	}
 }
 
-// UpdateSector altera o nome de um setor existente.
+// UpdateSector altera o nome e o centro de custo de um setor existente (a posição na árvore muda por MoveSector).
 func UpdateSector(databaseConnection *gorm.DB) gin.HandlerFunc { // Define a função UpdateSector que recebe uma conexão GORM com o banco de dados e retorna um gin.HandlerFunc.
 	type updateSectorInput struct { // Define uma nova estrutura 'updateSectorInput' para representar o corpo da requisição de atualização de setor.
-		Name string `json:"name" binding:"required"` // Define o campo 'Name' como uma string, que será mapeada do JSON e é um campo obrigatório.
+		Name       string  `json:"name" binding:"required"` // Define o campo 'Name' como uma string, que será mapeada do JSON e é um campo obrigatório.
+		CostCenter *string `json:"costCenter"`              // Centro de custo (nil mantém o atual).
 	}
 
 	return func(ctx *gin.Context) { // Retorna uma função anônima que será o manipulador de rota do Gin.
@@ -82,6 +98,15 @@ func UpdateSector(databaseConnection *gorm.DB) gin.HandlerFunc { // Define a fun
 		}
 
 		existingSector.Name = input.Name // Atualiza o nome do setor existente com o novo nome fornecido na entrada.
+		if input.CostCenter != nil {
+			// Valida e atualiza o centro de custo quando informado.
+			costCenter := strings.TrimSpace(*input.CostCenter)
+			if status, message := checkCostCenterAvailable(databaseConnection, costCenter, existingSector.ID); status != 0 {
+				ctx.JSON(status, gin.H{"error": message})
+				return
+			}
+			existingSector.CostCenter = costCenter
+		}
 		// Tenta salvar as alterações no setor existente no banco de dados.
 		// Se ocorrer um erro durante o salvamento, ele é capturado.
 		if err := databaseConnection.Save(&existingSector).Error; err != nil {
@@ -108,6 +133,17 @@ func DeleteSector(databaseConnection *gorm.DB) gin.HandlerFunc { // Define a fun
 			return // Interrompe a execução da função.
 		}
 
+		// Setores com subsetores não podem ser excluídos (os subsetores precisam ser movidos antes).
+		var childCount int64
+		if err := databaseConnection.Model(&models.Sector{}).Where("parent_id = ?", sector.ID).Count(&childCount).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao verificar subsetores"})
+			return
+		}
+		if childCount > 0 {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "Setor possui subsetores. Mova-os antes de excluir"})
+			return
+		}
+
 		// Tenta realizar um soft-delete do setor no banco de dados.
 		// Um soft-delete geralmente marca o registro como excluído em vez de removê-lo fisicamente.
 		// Se ocorrer um erro durante a exclusão, ele é capturado.
@@ -0,0 +1,383 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/models"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/notifications"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/rbac"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/utils"
+	"gorm.io/gorm"
+)
+
+type assignSectorManagerInput struct {
+	ManagerID *uint `json:"managerId"` // nil remove o gestor
+}
+
+type sectorApprovalInput struct {
+	Decision string `json:"decision" binding:"required,oneof=approved rejected"`
+	Comment  string `json:"comment"`
+}
+
+type requestCommentInput struct {
+	Body string `json:"body" binding:"required"`
+}
+
+// AssignSectorManager - Define ou remove o gestor de um setor (sectors:manage)
+func AssignSectorManager(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var sector models.Sector
+		if err := db.First(&sector, c.Param("id")).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Setor não encontrado"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar setor"})
+			}
+			return
+		}
+
+		var input assignSectorManagerInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if input.ManagerID != nil {
+			var manager models.User
+			if err := db.First(&manager, *input.ManagerID).Error; err != nil {
+				if err == gorm.ErrRecordNotFound {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "Gestor não encontrado"})
+				} else {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar gestor"})
+				}
+				return
+			}
+		}
+
+		tx := db.Begin()
+		defer func() {
+			if r := recover(); r != nil {
+				tx.Rollback()
+			}
+		}()
+
+		if err := tx.Model(&sector).Update("manager_id", input.ManagerID).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar gestor do setor"})
+			return
+		}
+
+		// Sem gestor (nem herdado de um setor acima), as requisições que aguardavam
+		// pré-aprovação seguem direto para compras
+		if input.ManagerID == nil {
+			var orphanSectors []uint
+			for _, id := range sectorSubtreeIDs(tx, []uint{sector.ID}) {
+				if effectiveSectorManager(tx, id) == nil {
+					orphanSectors = append(orphanSectors, id)
+				}
+			}
+			if len(orphanSectors) > 0 {
+				if err := tx.Model(&models.PurchaseRequest{}).
+					Where("sector_id IN ? AND sector_approval_status = ?", orphanSectors, models.SectorApprovalPending).
+					Update("sector_approval_status", models.SectorApprovalNotRequired).Error; err != nil {
+					tx.Rollback()
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao liberar requisições pendentes"})
+					return
+				}
+			}
+		}
+
+		if err := tx.Commit().Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao finalizar atualização"})
+			return
+		}
+
+		if err := db.Preload("Manager").First(&sector, sector.ID).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao carregar setor"})
+			return
+		}
+
+		c.JSON(http.StatusOK, sector)
+	}
+}
+
+// ListManagedSectors - Setores geridos pelo usuário logado
+func ListManagedSectors(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var sectors []models.Sector
+		if err := db.Where("manager_id = ?", utils.ParseUint(c.GetString("userID"))).
+			Order("name").
+			Find(&sectors).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao consultar setores"})
+			return
+		}
+
+		c.JSON(http.StatusOK, sectors)
+	}
+}
+
+// DecideSectorApproval - Pré-aprovação (ou rejeição) da requisição pelo gestor do setor
+func DecideSectorApproval(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userIDStr := c.GetString("userID")
+		userID := utils.ParseUint(userIDStr)
+
+		var input sectorApprovalInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var request models.PurchaseRequest
+		if err := db.First(&request, c.Param("id")).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Requisição não encontrada"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar requisição"})
+			}
+			return
+		}
+
+		// Apenas o gestor do setor ou de um setor acima (ou quem administra os setores) decide
+		if !isSectorManager(db, userID, request.SectorID) && !rbac.Has(c, models.PermSectorsManage) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Você não é o gestor do setor desta requisição"})
+			return
+		}
+
+		if !request.AwaitingSectorApproval() || request.Status != models.StatusPending {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":                "Requisição não está aguardando pré-aprovação do setor",
+				"status":               request.Status,
+				"sectorApprovalStatus": request.SectorApprovalStatus,
+			})
+			return
+		}
+
+		tx := db.Begin()
+		defer func() {
+			if r := recover(); r != nil {
+				tx.Rollback()
+			}
+		}()
+
+		now := time.Now()
+		oldStatus := request.Status
+		request.SectorApprovalStatus = input.Decision
+		request.SectorApprovedBy = &userID
+		request.SectorApprovedAt = &now
+		request.SectorApprovalNotes = input.Comment
+
+		// Rejeição do gestor encerra a requisição antes de chegar às compras
+		if input.Decision == models.SectorApprovalRejected {
+			if err := request.TransitionTo(models.StatusRejected); err != nil {
+				tx.Rollback()
+				respondTransitionError(c, err)
+				return
+			}
+			request.ReviewedAt = &now
+			request.ReviewedBy = &userID
+		}
+
+		if err := tx.Save(&request).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao registrar pré-aprovação"})
+			return
+		}
+		if err := recordStatusTransition(tx, request.ID, nil, oldStatus, request.Status, userIDStr,
+			fmt.Sprintf("Rejeitada pelo gestor do setor: %s", input.Comment)); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao registrar histórico"})
+			return
+		}
+
+		if err := tx.Commit().Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao finalizar pré-aprovação"})
+			return
+		}
+
+		if err := db.Preload("Requester").
+			Preload("Sector").
+			Preload("Items.Product").
+			First(&request, request.ID).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao carregar requisição"})
+			return
+		}
+
+		c.JSON(http.StatusOK, request)
+
+		notifications.Publish(
+			fmt.Sprintf("sector-approval:%d:%s", request.ID, request.SectorApprovalStatus),
+		)
+	}
+}
+
+// ListRequestComments - Comentários da requisição (quem pode ver a requisição)
+func ListRequestComments(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request models.PurchaseRequest
+		if err := db.First(&request, c.Param("id")).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Requisição não encontrada"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar requisição"})
+			}
+			return
+		}
+
+		if !canViewRequest(db, c, &request) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Acesso negado"})
+			return
+		}
+
+		var comments []models.RequestComment
+		if err := db.Preload("Author").
+			Where("purchase_request_id = ?", request.ID).
+			Order("created_at, id").
+			Find(&comments).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar comentários"})
+			return
+		}
+
+		c.JSON(http.StatusOK, comments)
+	}
+}
+
+// CreateRequestComment - Adiciona um comentário à requisição
+func CreateRequestComment(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input requestCommentInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		body := strings.TrimSpace(input.Body)
+		if body == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Comentário não pode ser vazio"})
+			return
+		}
+
+		var request models.PurchaseRequest
+		if err := db.First(&request, c.Param("id")).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Requisição não encontrada"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar requisição"})
+			}
+			return
+		}
+
+		if !canViewRequest(db, c, &request) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Acesso negado"})
+			return
+		}
+
+		comment := models.RequestComment{
+			PurchaseRequestID: request.ID,
+			AuthorID:          utils.ParseUint(c.GetString("userID")),
+			Body:              body,
+		}
+		if err := db.Create(&comment).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao criar comentário"})
+			return
+		}
+		db.Preload("Author").First(&comment, comment.ID)
+
+		c.JSON(http.StatusCreated, comment)
+
+		notifications.Publish(fmt.Sprintf("request-comment:%d:%d", request.ID, comment.ID))
+	}
+}
+
+// directlyManagedSectorIDs retorna os setores em que o usuário é o gestor direto
+func directlyManagedSectorIDs(db *gorm.DB, userID uint) []uint {
+	var ids []uint
+	if userID == 0 {
+		return ids
+	}
+	if err := db.Model(&models.Sector{}).Where("manager_id = ?", userID).Pluck("id", &ids).Error; err != nil {
+		fmt.Printf("❌ Erro ao buscar setores geridos pelo usuário %d: %v\n", userID, err)
+	}
+	return ids
+}
+
+// managedSectorIDs retorna os setores geridos pelo usuário, incluindo os subsetores
+func managedSectorIDs(db *gorm.DB, userID uint) []uint {
+	return sectorSubtreeIDs(db, directlyManagedSectorIDs(db, userID))
+}
+
+// isSectorManager indica se o usuário é gestor do setor informado
+func isSectorManager(db *gorm.DB, userID, sectorID uint) bool {
+	for _, id := range managedSectorIDs(db, userID) {
+		if id == sectorID {
+			return true
+		}
+	}
+	return false
+}
+
+// canViewRequest autoriza a leitura da requisição: requests:view-all, dono ou gestor do setor
+func canViewRequest(db *gorm.DB, c *gin.Context, request *models.PurchaseRequest) bool {
+	if rbac.Has(c, models.PermRequestsViewAll) {
+		return true
+	}
+	userID := utils.ParseUint(c.GetString("userID"))
+	if request.RequesterID == userID {
+		return true
+	}
+	return isSectorManager(db, userID, request.SectorID)
+}
+
+// scopeVisibleRequests restringe a consulta às requisições que o usuário pode ver
+// (mesma regra de canViewRequest): todas com requests:view-all, senão as próprias
+// e as dos setores geridos
+func scopeVisibleRequests(db *gorm.DB, c *gin.Context, query *gorm.DB) *gorm.DB {
+	if rbac.Has(c, models.PermRequestsViewAll) {
+		return query
+	}
+	userID := utils.ParseUint(c.GetString("userID"))
+	if sectorIDs := managedSectorIDs(db, userID); len(sectorIDs) > 0 {
+		return query.Where("purchase_requests.requester_id = ? OR purchase_requests.sector_id IN ?", userID, sectorIDs)
+	}
+	return query.Where("purchase_requests.requester_id = ?", userID)
+}
+
+// initialSectorApproval define se a nova requisição depende do gestor do setor
+// (ou do mais próximo acima dele) e retorna o gestor a ser avisado
+// (nil quando não há pré-aprovação)
+func initialSectorApproval(db *gorm.DB, sectorID, requesterID uint) (string, *uint) {
+	managerID := effectiveSectorManager(db, sectorID)
+	// O gestor não pré-aprova as próprias requisições
+	if managerID == nil || *managerID == requesterID {
+		return models.SectorApprovalNotRequired, nil
+	}
+	return models.SectorApprovalPending, managerID
+}
+
+// resubmitSectorApproval devolve ao gestor do setor a requisição que ele rejeitou quando ela
+// volta para pendente. Retorna o gestor a ser avisado (nil quando não há nova pré-aprovação)
+func resubmitSectorApproval(db *gorm.DB, request *models.PurchaseRequest) *uint {
+	if request.Status != models.StatusPending || request.SectorApprovalStatus != models.SectorApprovalRejected {
+		return nil
+	}
+	status, managerID := initialSectorApproval(db, request.SectorID, request.RequesterID)
+	request.SectorApprovalStatus = status
+	request.SectorApprovedBy = nil
+	request.SectorApprovedAt = nil
+	return managerID
+}
+
+// respondSectorApprovalPending responde 409 quando a requisição ainda aguarda (ou foi rejeitada pelo) gestor do setor
+func respondSectorApprovalPending(c *gin.Context, request *models.PurchaseRequest) {
+	message := "Requisição aguardando pré-aprovação do gestor do setor"
+	if request.SectorApprovalStatus == models.SectorApprovalRejected {
+		message = "Requisição rejeitada pelo gestor do setor; volte-a para pendente para nova pré-aprovação"
+	}
+	c.JSON(http.StatusConflict, gin.H{
+		"error":                message,
+		"sectorApprovalStatus": request.SectorApprovalStatus,
+	})
+}
@@ -0,0 +1,356 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/models"
+	"gorm.io/gorm"
+)
+
+// sectorSubtreeSQL - IDs dos setores informados e de todos os seus descendentes.
+// Inclui setores excluídos para não perder o histórico das requisições já atribuídas a eles.
+const sectorSubtreeSQL = `WITH RECURSIVE sector_tree AS (
+	SELECT id FROM sectors WHERE id IN (?)
+	UNION
+	SELECT s.id FROM sectors s INNER JOIN sector_tree t ON s.parent_id = t.id
+) SELECT id FROM sector_tree`
+
+type moveSectorInput struct {
+	ParentID *uint `json:"parentId"` // nil torna o setor raiz
+}
+
+// GetSectorTree - Setores organizados em árvore
+func GetSectorTree(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var sectors []models.Sector
+		if err := db.Order("name").Find(&sectors).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao consultar setores"})
+			return
+		}
+
+		children := map[uint][]models.Sector{}
+		known := map[uint]bool{}
+		for _, s := range sectors {
+			known[s.ID] = true
+		}
+		var roots []models.Sector
+		for _, s := range sectors {
+			if s.ParentID == nil || !known[*s.ParentID] {
+				roots = append(roots, s)
+			} else {
+				children[*s.ParentID] = append(children[*s.ParentID], s)
+			}
+		}
+
+		var attach func(s *models.Sector, depth int)
+		attach = func(s *models.Sector, depth int) {
+			if depth > len(sectors) {
+				return // proteção contra ciclos gravados manualmente no banco
+			}
+			s.Children = children[s.ID]
+			for i := range s.Children {
+				attach(&s.Children[i], depth+1)
+			}
+		}
+		for i := range roots {
+			attach(&roots[i], 0)
+		}
+
+		c.JSON(http.StatusOK, roots)
+	}
+}
+
+// MoveSector - Move o setor para outro ponto da árvore (sectors:manage).
+// As requisições continuam atribuídas ao próprio setor; apenas os totais acumulados mudam.
+func MoveSector(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var sector models.Sector
+		if err := db.First(&sector, c.Param("id")).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Setor não encontrado"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar setor"})
+			}
+			return
+		}
+
+		var input moveSectorInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if status, message := checkSectorParent(db, sector.ID, input.ParentID); status != 0 {
+			c.JSON(status, gin.H{"error": message})
+			return
+		}
+
+		if err := db.Model(&sector).Update("parent_id", input.ParentID).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao mover setor"})
+			return
+		}
+
+		if err := db.Preload("Parent").First(&sector, sector.ID).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao carregar setor"})
+			return
+		}
+
+		c.JSON(http.StatusOK, sector)
+	}
+}
+
+// GetSectorRollupReport - Requisições e gastos por setor, acumulados pela árvore
+func GetSectorRollupReport(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var filters RequestsReportFilters
+		if err := c.ShouldBindQuery(&filters); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		reportFilters, err := parseReportFilters(filters)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Filtros inválidos: %v", err)})
+			return
+		}
+		if !scopeReportFilters(c, db, &reportFilters) {
+			return
+		}
+
+		nodes, err := sectorRollup(db, reportFilters)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Erro ao gerar relatório: %v", err)})
+			return
+		}
+
+		c.JSON(http.StatusOK, nodes)
+	}
+}
+
+// sectorSubtreeIDs retorna os setores informados e todos os seus descendentes
+func sectorSubtreeIDs(db *gorm.DB, rootIDs []uint) []uint {
+	var ids []uint
+	if len(rootIDs) == 0 {
+		return ids
+	}
+	if err := db.Raw(sectorSubtreeSQL, rootIDs).Scan(&ids).Error; err != nil {
+		fmt.Printf("❌ Erro ao buscar subsetores de %v: %v\n", rootIDs, err)
+		return rootIDs
+	}
+	return ids
+}
+
+// effectiveSectorManager retorna o gestor do setor ou, na falta dele, o do setor mais próximo acima
+func effectiveSectorManager(db *gorm.DB, sectorID uint) *uint {
+	visited := map[uint]bool{}
+	current := &sectorID
+	for current != nil && !visited[*current] {
+		visited[*current] = true
+
+		var sector models.Sector
+		if err := db.First(&sector, *current).Error; err != nil {
+			return nil
+		}
+		if sector.ManagerID != nil {
+			return sector.ManagerID
+		}
+		current = sector.ParentID
+	}
+	return nil
+}
+
+// checkSectorParent valida o setor pai: precisa existir e não pode ser o próprio setor
+// nem um de seus descendentes (o que criaria um ciclo). Retorna status 0 quando válido.
+func checkSectorParent(db *gorm.DB, sectorID uint, parentID *uint) (int, string) {
+	if parentID == nil {
+		return 0, ""
+	}
+
+	var parent models.Sector
+	if err := db.First(&parent, *parentID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return http.StatusBadRequest, "Setor pai não encontrado"
+		}
+		return http.StatusInternalServerError, "Erro ao buscar setor pai"
+	}
+
+	if sectorID == 0 {
+		return 0, ""
+	}
+	for _, id := range sectorSubtreeIDs(db, []uint{sectorID}) {
+		if id == *parentID {
+			return http.StatusConflict, "O setor pai não pode ser o próprio setor nem um de seus subsetores"
+		}
+	}
+	return 0, ""
+}
+
+// checkCostCenterAvailable garante que o centro de custo não está em uso por outro setor
+func checkCostCenterAvailable(db *gorm.DB, costCenter string, exceptID uint) (int, string) {
+	if costCenter == "" {
+		return 0, ""
+	}
+
+	var count int64
+	if err := db.Model(&models.Sector{}).
+		Where("LOWER(cost_center) = ? AND id <> ?", strings.ToLower(costCenter), exceptID).
+		Count(&count).Error; err != nil {
+		return http.StatusInternalServerError, "Erro ao verificar centro de custo"
+	}
+	if count > 0 {
+		return http.StatusConflict, fmt.Sprintf("Centro de custo %s já está em uso", costCenter)
+	}
+	return 0, ""
+}
+
+// sectorRollup monta a árvore de setores com as requisições e os gastos (pedidos de compra
+// não cancelados) de cada setor, acumulando os totais dos subsetores nos setores acima.
+// Com filtro de setor, retorna apenas a subárvore desse setor.
+func sectorRollup(db *gorm.DB, filters models.ReportFilters) ([]*models.SectorRollupNode, error) {
+	var requestCounts []struct {
+		SectorID uint
+		Total    int
+	}
+	if err := applyFilters(db.Model(&models.PurchaseRequest{}), filters).
+		Select("sector_id, COUNT(*) as total").
+		Group("sector_id").
+		Scan(&requestCounts).Error; err != nil {
+		return nil, err
+	}
+
+	var spending []struct {
+		SectorID uint
+		Total    float64
+	}
+	if err := db.Table("purchase_order_lines pol").
+		Select("pr.sector_id, COALESCE(SUM(pol.total_price), 0) as total").
+		Joins("INNER JOIN purchase_orders po ON po.id = pol.purchase_order_id").
+		Joins("INNER JOIN purchase_requests pr ON pr.id = pol.purchase_request_id").
+		Where("pol.deleted_at IS NULL AND po.deleted_at IS NULL AND po.status <> ?", models.PurchaseOrderStatusCancelled).
+		Where("pol.purchase_request_id IN (?)", applyFilters(db.Model(&models.PurchaseRequest{}).Select("id"), filters)).
+		Group("pr.sector_id").
+		Scan(&spending).Error; err != nil {
+		return nil, err
+	}
+
+	// Setores excluídos entram para não perder o histórico, mas só aparecem se tiverem movimento
+	var sectors []models.Sector
+	if err := db.Unscoped().Order("name").Find(&sectors).Error; err != nil {
+		return nil, err
+	}
+
+	nodes := make(map[uint]*models.SectorRollupNode, len(sectors))
+	deleted := map[uint]bool{}
+	for _, s := range sectors {
+		nodes[s.ID] = &models.SectorRollupNode{ID: s.ID, Name: s.Name, CostCenter: s.CostCenter, ParentID: s.ParentID}
+		deleted[s.ID] = s.DeletedAt.Valid
+	}
+	for _, rc := range requestCounts {
+		if node, ok := nodes[rc.SectorID]; ok {
+			node.Requests = rc.Total
+		}
+	}
+	for _, sp := range spending {
+		if node, ok := nodes[sp.SectorID]; ok {
+			node.Spend = sp.Total
+		}
+	}
+
+	var roots []*models.SectorRollupNode
+	for _, s := range sectors {
+		node := nodes[s.ID]
+		if s.ParentID != nil {
+			if parent, ok := nodes[*s.ParentID]; ok {
+				parent.Children = append(parent.Children, node)
+				continue
+			}
+		}
+		roots = append(roots, node)
+	}
+
+	var accumulate func(node *models.SectorRollupNode, depth int)
+	accumulate = func(node *models.SectorRollupNode, depth int) {
+		node.TotalRequests = node.Requests
+		node.TotalSpend = node.Spend
+		if depth > len(sectors) {
+			return // proteção contra ciclos gravados manualmente no banco
+		}
+
+		kept := node.Children[:0]
+		for _, child := range node.Children {
+			accumulate(child, depth+1)
+			node.TotalRequests += child.TotalRequests
+			node.TotalSpend += child.TotalSpend
+			if !deleted[child.ID] || child.TotalRequests > 0 || child.TotalSpend > 0 {
+				kept = append(kept, child)
+			}
+		}
+		node.Children = kept
+	}
+	for _, root := range roots {
+		accumulate(root, 0)
+	}
+
+	if filters.SectorID != nil {
+		if node, ok := nodes[*filters.SectorID]; ok {
+			return []*models.SectorRollupNode{node}, nil
+		}
+		return []*models.SectorRollupNode{}, nil
+	}
+
+	kept := roots[:0]
+	for _, root := range roots {
+		if !deleted[root.ID] || root.TotalRequests > 0 || root.TotalSpend > 0 {
+			kept = append(kept, root)
+		}
+	}
+	return kept, nil
+}
+
+// sectorRanking gera o ranking de requisições por setor acumulado pela árvore: sem filtro,
+// compara os setores raiz; com filtro, os subsetores diretos do setor filtrado (mais as
+// requisições feitas diretamente nele)
+func sectorRanking(nodes []*models.SectorRollupNode, filters models.ReportFilters) []models.ChartDataPoint {
+	var ranking []models.ChartDataPoint
+	buckets := nodes
+	if filters.SectorID != nil && len(nodes) == 1 {
+		if nodes[0].Requests > 0 {
+			ranking = append(ranking, models.ChartDataPoint{Label: nodes[0].Name, Value: nodes[0].Requests})
+		}
+		buckets = nodes[0].Children
+	}
+
+	for _, node := range buckets {
+		if node.TotalRequests > 0 {
+			ranking = append(ranking, models.ChartDataPoint{Label: node.Name, Value: node.TotalRequests})
+		}
+	}
+
+	sort.SliceStable(ranking, func(i, j int) bool { return ranking[i].Value > ranking[j].Value })
+	if len(ranking) > 10 {
+		ranking = ranking[:10]
+	}
+	return ranking
+}
+
+// sectorAncestorIDs retorna o setor e todos os setores acima dele, do mais próximo à raiz
+func sectorAncestorIDs(db *gorm.DB, sectorID uint) []uint {
+	var ids []uint
+	visited := map[uint]bool{}
+	current := &sectorID
+	for current != nil && !visited[*current] {
+		visited[*current] = true
+		ids = append(ids, *current)
+
+		var sector models.Sector
+		if err := db.Unscoped().Select("id", "parent_id").First(&sector, *current).Error; err != nil {
+			break
+		}
+		current = sector.ParentID
+	}
+	return ids
+}
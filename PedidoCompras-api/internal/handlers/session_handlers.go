@@ -0,0 +1,351 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/config"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/models"
+	"gorm.io/gorm"
+)
+
+type refreshInput struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
+
+// RefreshToken troca um refresh token válido por um novo par de tokens (rotação).
+// Reutilizar um refresh token já trocado encerra a sessão inteira.
+func RefreshToken(databaseConnection *gorm.DB, appConfig *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input refreshInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var stored models.RefreshToken
+		if err := databaseConnection.Where("token_hash = ?", hashToken(input.RefreshToken)).First(&stored).Error; err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token inválido"})
+			return
+		}
+
+		now := time.Now()
+		if stored.UsedAt != nil {
+			// Token já rotacionado sendo reapresentado: possível roubo, encerra a sessão
+			revokeSession(databaseConnection, stored.SessionID, "token-reuse")
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token já utilizado. Faça login novamente"})
+			return
+		}
+		if !stored.IsUsable(now) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token expirado ou revogado"})
+			return
+		}
+
+		var session models.UserSession
+		if err := databaseConnection.First(&session, "id = ?", stored.SessionID).Error; err != nil || session.IsRevoked() {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Sessão encerrada. Faça login novamente"})
+			return
+		}
+
+		// Recarrega o usuário: papel e setor podem ter mudado desde o login
+		var usuario models.User
+		if err := databaseConnection.Preload("Sector").First(&usuario, stored.UserID).Error; err != nil {
+			revokeSession(databaseConnection, session.ID, "user-deleted")
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuário não encontrado"})
+			return
+		}
+
+		// Senha expirou durante a sessão: exige novo login (que libera só a troca de senha)
+		settings, err := loadSystemSettings(databaseConnection)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao carregar configurações"})
+			return
+		}
+		if usuario.PasswordExpired(settings.PasswordExpirationDays, time.Now()) {
+			revokeSession(databaseConnection, session.ID, "password-expired")
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":           "Senha expirada. Faça login e altere sua senha",
+				"passwordExpired": true,
+			})
+			return
+		}
+
+		tx := databaseConnection.Begin()
+
+		// Marca o token como usado apenas se ninguém o usou em paralelo
+		result := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND used_at IS NULL", stored.ID).
+			Update("used_at", now)
+		if result.Error != nil || result.RowsAffected == 0 {
+			tx.Rollback()
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token já utilizado"})
+			return
+		}
+
+		newRefreshToken, err := issueRefreshToken(tx, appConfig, session)
+		if err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Não foi possível gerar refresh token"})
+			return
+		}
+
+		if err := tx.Model(&session).Update("last_used_at", now).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar sessão"})
+			return
+		}
+
+		if err := tx.Commit().Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao finalizar renovação"})
+			return
+		}
+
+		accessToken, expiresIn, err := issueAccessToken(databaseConnection, appConfig, usuario, session.ID, false)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Não foi possível gerar token"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"token":        accessToken,
+			"refreshToken": newRefreshToken,
+			"expiresIn":    expiresIn,
+		})
+	}
+}
+
+// Logout encerra a sessão do token atual (access e refresh tokens)
+func Logout(databaseConnection *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sessionID := c.GetString("sessionID")
+		if sessionID == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Sessão não identificada"})
+			return
+		}
+
+		if err := revokeSession(databaseConnection, sessionID, "logout"); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao encerrar sessão"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Sessão encerrada com sucesso"})
+	}
+}
+
+// RevokeUserSessions encerra todas as sessões de um usuário (users:manage)
+func RevokeUserSessions(databaseConnection *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var usuario models.User
+		if err := databaseConnection.First(&usuario, c.Param("id")).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Usuário não encontrado"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar usuário"})
+			}
+			return
+		}
+
+		revoked, err := revokeAllUserSessions(databaseConnection, usuario.ID, "logout-all")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao encerrar sessões"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"message":         "Todas as sessões do usuário foram encerradas",
+			"revokedSessions": revoked,
+		})
+	}
+}
+
+// Validade do token restrito emitido quando a senha está expirada
+const passwordChangeTokenLifetime = 15 * time.Minute
+
+// startSession cria a sessão de login e devolve o primeiro refresh token
+func startSession(db *gorm.DB, appConfig *config.Config, c *gin.Context, userID uint) (models.UserSession, string, error) {
+	var session models.UserSession
+	var refreshToken string
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if session, err = createSession(tx, c, userID); err != nil {
+			return err
+		}
+		refreshToken, err = issueRefreshToken(tx, appConfig, session)
+		return err
+	})
+	return session, refreshToken, err
+}
+
+// startPasswordChangeSession encerra as sessões abertas do usuário, cuja senha expirou, e cria
+// a sessão do token restrito. Ela não tem refresh token: termina com o token ou com a troca
+// de senha, e o próximo login com a senha expirada a encerra junto com as demais.
+func startPasswordChangeSession(db *gorm.DB, c *gin.Context, userID uint) (models.UserSession, error) {
+	var session models.UserSession
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if _, err := revokeAllUserSessions(tx, userID, "password-expired"); err != nil {
+			return err
+		}
+		var err error
+		session, err = createSession(tx, c, userID)
+		return err
+	})
+	return session, err
+}
+
+// createSession registra a sessão (sem refresh token)
+func createSession(db *gorm.DB, c *gin.Context, userID uint) (models.UserSession, error) {
+	sessionID, err := randomToken(16)
+	if err != nil {
+		return models.UserSession{}, err
+	}
+
+	session := models.UserSession{
+		ID:         sessionID,
+		UserID:     userID,
+		LastUsedAt: time.Now(),
+		IPAddress:  c.ClientIP(),
+		UserAgent:  c.Request.UserAgent(),
+	}
+	if len(session.UserAgent) > 500 {
+		session.UserAgent = session.UserAgent[:500]
+	}
+
+	return session, db.Create(&session).Error
+}
+
+// issueAccessToken gera o JWT com a validade de SystemSettings.SessionTimeoutMinutes.
+// Com passwordExpired o token é curto e só permite trocar a senha.
+// Retorna o token e a validade em segundos.
+func issueAccessToken(db *gorm.DB, appConfig *config.Config, usuario models.User, sessionID string, passwordExpired bool) (string, int, error) {
+	settings, err := loadSystemSettings(db)
+	if err != nil {
+		return "", 0, err
+	}
+
+	timeout := settings.SessionTimeoutMinutes
+	if timeout <= 0 {
+		timeout = defaultSystemSettings().SessionTimeoutMinutes
+	}
+	lifetime := time.Duration(timeout) * time.Minute
+	if passwordExpired {
+		lifetime = passwordChangeTokenLifetime
+	}
+
+	now := time.Now()
+	myClaims := MyClaims{
+		Role:       usuario.Role,
+		Name:       usuario.Name,
+		Email:      usuario.Email,
+		SectorID:   usuario.SectorID,
+		SectorName: usuario.Sector.Name,
+
+		PasswordExpired: passwordExpired,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        sessionID, // jti = sessão, permite revogação no AuthMiddleware
+			Subject:   strconv.FormatUint(uint64(usuario.ID), 10),
+			ExpiresAt: jwt.NewNumericDate(now.Add(lifetime)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+
+	tokenString, err := jwt.NewWithClaims(jwt.SigningMethodHS256, myClaims).
+		SignedString([]byte(appConfig.JWTSecretKey))
+	if err != nil {
+		return "", 0, err
+	}
+	return tokenString, int(lifetime.Seconds()), nil
+}
+
+// issueRefreshToken gera um novo refresh token para a sessão (guarda só o hash)
+func issueRefreshToken(tx *gorm.DB, appConfig *config.Config, session models.UserSession) (string, error) {
+	token, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	days := appConfig.RefreshTokenDays
+	if days <= 0 {
+		days = 7
+	}
+
+	record := models.RefreshToken{
+		SessionID: session.ID,
+		UserID:    session.UserID,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().AddDate(0, 0, days),
+	}
+	if err := tx.Create(&record).Error; err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// revokeSession encerra a sessão e invalida seus refresh tokens
+func revokeSession(db *gorm.DB, sessionID, reason string) error {
+	now := time.Now()
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.UserSession{}).
+			Where("id = ? AND revoked_at IS NULL", sessionID).
+			Updates(map[string]interface{}{"revoked_at": now, "revoked_reason": reason}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.RefreshToken{}).
+			Where("session_id = ? AND revoked_at IS NULL", sessionID).
+			Update("revoked_at", now).Error
+	})
+}
+
+// revokeAllUserSessions encerra todas as sessões do usuário e invalida
+// qualquer access token emitido até agora
+func revokeAllUserSessions(db *gorm.DB, userID uint, reason string) (int64, error) {
+	now := time.Now()
+	var revoked int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.UserSession{}).
+			Where("user_id = ? AND revoked_at IS NULL", userID).
+			Updates(map[string]interface{}{"revoked_at": now, "revoked_reason": reason})
+		if result.Error != nil {
+			return result.Error
+		}
+		revoked = result.RowsAffected
+
+		if err := tx.Model(&models.RefreshToken{}).
+			Where("user_id = ? AND revoked_at IS NULL", userID).
+			Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+		return invalidateUserTokens(tx, userID)
+	})
+	return revoked, err
+}
+
+// invalidateUserTokens faz o AuthMiddleware rejeitar os access tokens já emitidos
+// para o usuário (ex: após troca de papel). Refresh tokens continuam válidos e
+// geram tokens com os dados atualizados.
+func invalidateUserTokens(db *gorm.DB, userID uint) error {
+	return db.Model(&models.User{}).
+		Where("id = ?", userID).
+		Update("tokens_valid_after", time.Now()).Error
+}
+
+// hashToken calcula o SHA-256 usado para armazenar refresh tokens
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomToken gera um valor aleatório em hexadecimal
+func randomToken(size int) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("erro ao gerar token aleatório: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -2,15 +2,15 @@ package handlers
 
 import (
 	"fmt"
-	"io"
 	"net/http"
-	"os"
 	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/audit"
 	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/models"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/storage"
 	"gorm.io/gorm"
 )
 
@@ -37,17 +37,23 @@ type systemSettingsInput struct {
 	BackupRetention        int    `json:"backupRetention" binding:"min=1"`
 	LogRetentionDays       int    `json:"logRetentionDays" binding:"min=1"`
 	AuditLogEnabled        bool   `json:"auditLogEnabled"`
+	MinQuotesPerItem       *int   `json:"minQuotesPerItem" binding:"omitempty,min=0,max=10"`
+
+	// Anexos (opcionais): tamanho máximo em MB e tipos MIME aceitos, separados por vírgula
+	MaxAttachmentSizeMB    *int    `json:"maxAttachmentSizeMb" binding:"omitempty,min=1,max=500"`
+	AllowedAttachmentTypes *string `json:"allowedAttachmentTypes"`
+
+	// Bloqueio de login (opcionais para manter compatibilidade com clientes antigos)
+	MaxLoginAttempts          *int `json:"maxLoginAttempts" binding:"omitempty,min=1,max=100"`
+	MaxLoginAttemptsPerIP     *int `json:"maxLoginAttemptsPerIp" binding:"omitempty,min=1,max=1000"`
+	LoginAttemptWindowMinutes *int `json:"loginAttemptWindowMinutes" binding:"omitempty,min=1,max=1440"`
+	LockoutMinutes            *int `json:"lockoutMinutes" binding:"omitempty,min=1,max=1440"`
+	MaxLockoutMinutes         *int `json:"maxLockoutMinutes" binding:"omitempty,min=1,max=43200"`
 }
 
 // GetCompanySettings - Busca configurações da empresa
 func GetCompanySettings(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Verificar se é admin
-		if c.GetString("role") != "admin" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Acesso restrito a administradores"})
-			return
-		}
-
 		var settings models.CompanySettings
 		// Busca a primeira configuração ou cria uma padrão
 		if err := db.First(&settings).Error; err != nil {
@@ -75,12 +81,6 @@ func GetCompanySettings(db *gorm.DB) gin.HandlerFunc {
 // UpdateCompanySettings - Atualiza configurações da empresa
 func UpdateCompanySettings(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Verificar se é admin
-		if c.GetString("role") != "admin" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Acesso restrito a administradores"})
-			return
-		}
-
 		var input companySettingsInput
 		if err := c.ShouldBindJSON(&input); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -116,14 +116,8 @@ func UpdateCompanySettings(db *gorm.DB) gin.HandlerFunc {
 }
 
 // UploadCompanyLogo - Upload do logo da empresa
-func UploadCompanyLogo(db *gorm.DB) gin.HandlerFunc {
+func UploadCompanyLogo(db *gorm.DB, store storage.Storage) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Verificar se é admin
-		if c.GetString("role") != "admin" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Acesso restrito a administradores"})
-			return
-		}
-
 		// Parse do multipart form
 		file, header, err := c.Request.FormFile("logo")
 		if err != nil {
@@ -161,32 +155,15 @@ func UploadCompanyLogo(db *gorm.DB) gin.HandlerFunc {
 		// Reset file pointer
 		file.Seek(0, 0)
 
-		// Criar diretório se não existir
-		uploadDir := "./uploads/logos"
-		if err := os.MkdirAll(uploadDir, os.ModePerm); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao criar diretório"})
-			return
-		}
-
-		// Gerar nome único para o arquivo
+		// Gerar chave única para o arquivo
 		filename := fmt.Sprintf("logo_%d%s", time.Now().Unix(), ext)
-		filePath := filepath.Join(uploadDir, filename)
-
-		// Salvar arquivo
-		out, err := os.Create(filePath)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao salvar arquivo"})
-			return
-		}
-		defer out.Close()
+		key := "logos/" + filename
 
-		// Reset file pointer antes de copiar
+		// Salvar arquivo (reset do ponteiro antes de copiar)
 		file.Seek(0, 0)
-		_, err = io.Copy(out, file)
-		if err != nil {
-			// Limpar arquivo parcialmente criado
-			os.Remove(filePath)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao copiar arquivo"})
+		if err := store.Put(c.Request.Context(), key, file, header.Size, allowedTypes[ext]); err != nil {
+			fmt.Printf("❌ Erro ao salvar logo em %s: %v\n", store.Name(), err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao salvar arquivo"})
 			return
 		}
 
@@ -197,34 +174,27 @@ func UploadCompanyLogo(db *gorm.DB) gin.HandlerFunc {
 				settings = models.CompanySettings{CompanyName: "PedidoCompras"}
 			} else {
 				// Limpar arquivo se erro na DB
-				os.Remove(filePath)
+				removeStored(c, store, key)
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar configurações"})
 				return
 			}
 		}
 
-		// ✅ REMOVER LOGO ANTERIOR se existir (cleanup)
-		if settings.LogoPath != "" {
-			oldPath := settings.LogoPath
-			if _, err := os.Stat(oldPath); err == nil {
-				if removeErr := os.Remove(oldPath); removeErr != nil {
-					// Log mas não falha - arquivo antigo pode estar em uso
-					fmt.Printf("Aviso: Não foi possível remover logo anterior: %v\n", removeErr)
-				}
-			}
-		}
-
 		// Atualizar configurações
-		settings.LogoPath = filePath
+		previousKey := settings.LogoPath
+		settings.LogoPath = key
 		settings.LogoFilename = header.Filename
 
 		if err := db.Save(&settings).Error; err != nil {
 			// Limpar arquivo se erro ao salvar
-			os.Remove(filePath)
+			removeStored(c, store, key)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao salvar configurações"})
 			return
 		}
 
+		// ✅ REMOVER LOGO ANTERIOR se existir (cleanup; falha apenas registrada no log)
+		removeStored(c, store, previousKey)
+
 		// ✅ RESPOSTA MELHORADA
 		c.JSON(http.StatusOK, gin.H{
 			"message":      "Logo enviado com sucesso",
@@ -238,7 +208,7 @@ func UploadCompanyLogo(db *gorm.DB) gin.HandlerFunc {
 }
 
 // GetCompanyLogo - Serve o logo da empresa
-func GetCompanyLogo(db *gorm.DB) gin.HandlerFunc {
+func GetCompanyLogo(db *gorm.DB, store storage.Storage) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var settings models.CompanySettings
 		if err := db.First(&settings).Error; err != nil || settings.LogoPath == "" {
@@ -247,65 +217,22 @@ func GetCompanyLogo(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
-		// Verificar se arquivo existe
-		if _, err := os.Stat(settings.LogoPath); os.IsNotExist(err) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Arquivo de logo não encontrado"})
-			return
-		}
-
 		// ✅ HEADERS PARA CACHE (importante para performance)
 		c.Header("Cache-Control", "public, max-age=3600") // Cache por 1 hora
-		c.Header("Content-Type", getContentType(settings.LogoPath))
-
-		// Servir arquivo
-		c.File(settings.LogoPath)
-	}
-}
 
-// ✅ HELPER para determinar content-type baseado na extensão
-func getContentType(filePath string) string {
-	ext := strings.ToLower(filepath.Ext(filePath))
-	switch ext {
-	case ".jpg", ".jpeg":
-		return "image/jpeg"
-	case ".png":
-		return "image/png"
-	case ".gif":
-		return "image/gif"
-	case ".webp":
-		return "image/webp"
-	default:
-		return "application/octet-stream"
+		// Servir arquivo (404 se não estiver no armazenamento); o upload só aceita extensões de imagem
+		serveStored(c, store, settings.LogoPath, storage.ContentTypeOf(settings.LogoPath), "inline", "")
 	}
 }
 
 // GetSystemSettings - Busca configurações do sistema
 func GetSystemSettings(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Verificar se é admin
-		if c.GetString("role") != "admin" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Acesso restrito a administradores"})
-			return
-		}
-
 		var settings models.SystemSettings
 		// Busca a primeira configuração ou cria uma padrão
 		if err := db.First(&settings).Error; err != nil {
 			if err == gorm.ErrRecordNotFound {
-				settings = models.SystemSettings{
-					MinPasswordLength:      6,
-					RequireUppercase:       false,
-					RequireLowercase:       true,
-					RequireNumbers:         false,
-					RequireSpecialChars:    false,
-					PasswordExpirationDays: 0,
-					SessionTimeoutMinutes:  60,
-					BackupEnabled:          false,
-					BackupFrequency:        "daily",
-					BackupRetention:        30,
-					LogRetentionDays:       90,
-					AuditLogEnabled:        true,
-				}
+				settings = defaultSystemSettings()
 				db.Create(&settings)
 			} else {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar configurações"})
@@ -320,12 +247,6 @@ func GetSystemSettings(db *gorm.DB) gin.HandlerFunc {
 // UpdateSystemSettings - Atualiza configurações do sistema
 func UpdateSystemSettings(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Verificar se é admin
-		if c.GetString("role") != "admin" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Acesso restrito a administradores"})
-			return
-		}
-
 		var input systemSettingsInput
 		if err := c.ShouldBindJSON(&input); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -356,12 +277,101 @@ func UpdateSystemSettings(db *gorm.DB) gin.HandlerFunc {
 		settings.BackupRetention = input.BackupRetention
 		settings.LogRetentionDays = input.LogRetentionDays
 		settings.AuditLogEnabled = input.AuditLogEnabled
+		if input.MinQuotesPerItem != nil {
+			settings.MinQuotesPerItem = *input.MinQuotesPerItem
+		}
+		if input.MaxAttachmentSizeMB != nil {
+			settings.MaxAttachmentSizeMB = *input.MaxAttachmentSizeMB
+		}
+		if input.AllowedAttachmentTypes != nil {
+			types, ok := normalizeMIMEList(*input.AllowedAttachmentTypes)
+			if !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "allowedAttachmentTypes deve ser uma lista de tipos MIME separados por vírgula (ex: application/pdf,image/png)"})
+				return
+			}
+			settings.AllowedAttachmentTypes = types
+		}
+		if input.MaxLoginAttempts != nil {
+			settings.MaxLoginAttempts = *input.MaxLoginAttempts
+		}
+		if input.MaxLoginAttemptsPerIP != nil {
+			settings.MaxLoginAttemptsPerIP = *input.MaxLoginAttemptsPerIP
+		}
+		if input.LoginAttemptWindowMinutes != nil {
+			settings.LoginAttemptWindowMinutes = *input.LoginAttemptWindowMinutes
+		}
+		if input.LockoutMinutes != nil {
+			settings.LockoutMinutes = *input.LockoutMinutes
+		}
+		if input.MaxLockoutMinutes != nil {
+			settings.MaxLockoutMinutes = *input.MaxLockoutMinutes
+		}
 
 		if err := db.Save(&settings).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao salvar configurações"})
 			return
 		}
+		audit.InvalidateSettings()
 
 		c.JSON(http.StatusOK, settings)
 	}
 }
+
+// defaultSystemSettings - Valores padrão usados quando ainda não há configuração salva
+func defaultSystemSettings() models.SystemSettings {
+	return models.SystemSettings{
+		MinPasswordLength:      6,
+		RequireUppercase:       false,
+		RequireLowercase:       true,
+		RequireNumbers:         false,
+		RequireSpecialChars:    false,
+		PasswordExpirationDays: 0,
+		SessionTimeoutMinutes:  60,
+		BackupEnabled:          false,
+		BackupFrequency:        "daily",
+		BackupRetention:        30,
+		LogRetentionDays:       90,
+		AuditLogEnabled:        true,
+		MinQuotesPerItem:       0,
+		MaxAttachmentSizeMB:    models.DefaultMaxAttachmentSizeMB,
+		AllowedAttachmentTypes: models.DefaultAttachmentTypes,
+
+		MaxLoginAttempts:          5,
+		MaxLoginAttemptsPerIP:     20,
+		LoginAttemptWindowMinutes: 15,
+		LockoutMinutes:            15,
+		MaxLockoutMinutes:         1440,
+	}
+}
+
+// loadSystemSettings - Carrega as configurações do sistema (ou os padrões, se não houver registro)
+func loadSystemSettings(db *gorm.DB) (models.SystemSettings, error) {
+	var settings models.SystemSettings
+	if err := db.First(&settings).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return defaultSystemSettings(), nil
+		}
+		return settings, err
+	}
+	return settings, nil
+}
+
+// normalizeMIMEList valida e padroniza uma lista de tipos MIME separados por vírgula
+func normalizeMIMEList(list string) (string, bool) {
+	types := []string{}
+	for _, t := range strings.Split(list, ",") {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t == "" {
+			continue
+		}
+		parts := strings.Split(t, "/")
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" || strings.ContainsAny(t, " ;") {
+			return "", false
+		}
+		types = append(types, t)
+	}
+	if len(types) == 0 {
+		return "", false
+	}
+	return strings.Join(types, ","), true
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/models"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/utils"
+	"gorm.io/gorm"
+)
+
+// ListRequestStatusHistory - GET /requests/:id/history
+func ListRequestStatusHistory(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request models.PurchaseRequest
+		if err := db.First(&request, c.Param("id")).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Requisição não encontrada"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar requisição"})
+			}
+			return
+		}
+
+		if !canViewRequest(db, c, &request) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Acesso negado"})
+			return
+		}
+
+		var history []models.RequestStatusHistory
+		if err := db.Preload("Changer").
+			Where("purchase_request_id = ?", request.ID).
+			Order("created_at, id").
+			Find(&history).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar histórico"})
+			return
+		}
+
+		c.JSON(http.StatusOK, history)
+	}
+}
+
+// recordStatusTransition grava a transição no histórico (ignora quando o status não mudou)
+func recordStatusTransition(tx *gorm.DB, requestID uint, itemID *uint, from, to, userID, notes string) error {
+	if from == to {
+		return nil
+	}
+
+	entry := models.RequestStatusHistory{
+		PurchaseRequestID: requestID,
+		RequestItemID:     itemID,
+		FromStatus:        from,
+		ToStatus:          to,
+		Notes:             notes,
+	}
+	if id := utils.ParseUint(userID); id != 0 {
+		entry.ChangedBy = &id
+	}
+
+	return tx.Create(&entry).Error
+}
+
+// respondTransitionError responde 409 com os próximos status permitidos
+func respondTransitionError(c *gin.Context, err error) bool {
+	var transitionErr *models.StatusTransitionError
+	if !errors.As(err, &transitionErr) {
+		return false
+	}
+
+	c.JSON(http.StatusConflict, gin.H{
+		"error":         transitionErr.Error(),
+		"allowedStatus": transitionErr.Allowed,
+	})
+	return true
+}
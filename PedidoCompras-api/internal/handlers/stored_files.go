@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/storage"
+	"gorm.io/gorm"
+)
+
+// sniffLength - Bytes lidos para detectar o tipo do arquivo (limite de http.DetectContentType)
+const sniffLength = 512
+
+// multipartOverhead - Folga para os cabeçalhos e campos do formulário multipart
+const multipartOverhead = 1 << 20
+
+// maxInlineFileSize - Limite dos arquivos lidos para a memória (mesmo limite do upload do logo)
+const maxInlineFileSize = 5 * 1024 * 1024
+
+// saveUpload grava o arquivo enviado no armazenamento configurado
+func saveUpload(c *gin.Context, store storage.Storage, key string, header *multipart.FileHeader, contentType string) error {
+	file, err := header.Open()
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return store.Put(c.Request.Context(), key, file, header.Size, contentType)
+}
+
+// readValidatedUpload lê o campo "file" do formulário e confere tamanho e tipo
+// (pelo conteúdo) contra a política de anexos de SystemSettings.
+// Responde 400/413 e devolve false quando o arquivo é recusado.
+func readValidatedUpload(db *gorm.DB, c *gin.Context) (*multipart.FileHeader, string, bool) {
+	settings, err := loadSystemSettings(db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao carregar configurações"})
+		return nil, "", false
+	}
+	maxBytes := settings.AttachmentMaxBytes()
+
+	// Corta o corpo acima do limite (mais uma folga para os campos do multipart)
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes+multipartOverhead)
+	header, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("Arquivo muito grande. Máximo %d MB", maxBytes>>20)})
+		} else {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Falha ao ler arquivo: " + err.Error()})
+		}
+		return nil, "", false
+	}
+	if header.Size > maxBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("Arquivo muito grande. Máximo %d MB", maxBytes>>20)})
+		return nil, "", false
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Falha ao ler arquivo: " + err.Error()})
+		return nil, "", false
+	}
+	defer file.Close()
+
+	head := make([]byte, sniffLength)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Falha ao ler arquivo: " + err.Error()})
+		return nil, "", false
+	}
+
+	contentType, err := settings.ValidateAttachment(header.Filename, header.Size, head[:n])
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":        err.Error(),
+			"allowedTypes": settings.AttachmentTypes(),
+			"maxSizeMb":    maxBytes >> 20,
+		})
+		return nil, "", false
+	}
+	return header, contentType, true
+}
+
+// serveStored envia o arquivo em streaming a partir do armazenamento com o tipo informado
+// (o validado no upload, nunca o deduzido da extensão pelo backend; vazio = octet-stream).
+// disposition: "attachment" (download) ou "inline"; downloadName vazio omite Content-Disposition.
+func serveStored(c *gin.Context, store storage.Storage, key, contentType, disposition, downloadName string) {
+	reader, info, err := store.Open(c.Request.Context(), key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Arquivo não encontrado no servidor"})
+		} else {
+			fmt.Printf("❌ Erro ao abrir arquivo %s em %s: %v\n", key, store.Name(), err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao ler arquivo"})
+		}
+		return
+	}
+	defer reader.Close()
+
+	// nosniff: o navegador não reinterpreta o arquivo (ex: texto como HTML)
+	headers := map[string]string{"X-Content-Type-Options": "nosniff"}
+	if downloadName != "" {
+		headers["Content-Disposition"] = contentDisposition(disposition, downloadName)
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	c.DataFromReader(http.StatusOK, info.Size, contentType, reader, headers)
+}
+
+// contentDisposition monta o cabeçalho conforme a RFC 6266: filename com uma versão
+// ASCII do nome e filename* com o nome original em UTF-8 (RFC 5987)
+func contentDisposition(disposition, filename string) string {
+	fallback := strings.Map(func(r rune) rune {
+		if r < 0x20 || r > 0x7e || r == '"' || r == '\\' || r == '%' || r == ';' {
+			return '_'
+		}
+		return r
+	}, filename)
+
+	var encoded strings.Builder
+	for _, b := range []byte(filename) {
+		if isRFC5987AttrChar(b) {
+			encoded.WriteByte(b)
+		} else {
+			fmt.Fprintf(&encoded, "%%%02X", b)
+		}
+	}
+	return fmt.Sprintf(`%s; filename="%s"; filename*=UTF-8''%s`, disposition, fallback, encoded.String())
+}
+
+// isRFC5987AttrChar - Caracteres que podem aparecer sem codificação em filename*
+func isRFC5987AttrChar(b byte) bool {
+	switch {
+	case 'a' <= b && b <= 'z', 'A' <= b && b <= 'Z', '0' <= b && b <= '9':
+		return true
+	}
+	return strings.IndexByte("!#$&+-.^_`|~", b) >= 0
+}
+
+// readStored lê um arquivo pequeno (ex: logo) inteiro para a memória
+func readStored(ctx context.Context, store storage.Storage, key string) ([]byte, storage.ObjectInfo, error) {
+	reader, info, err := store.Open(ctx, key)
+	if err != nil {
+		return nil, info, err
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(io.LimitReader(reader, maxInlineFileSize+1))
+	if err != nil {
+		return nil, info, err
+	}
+	if len(data) > maxInlineFileSize {
+		return nil, info, fmt.Errorf("arquivo %s maior que %d bytes", key, maxInlineFileSize)
+	}
+	return data, info, nil
+}
+
+// removeStored apaga um arquivo substituído; falhas apenas vão para o log
+func removeStored(c *gin.Context, store storage.Storage, key string) {
+	if key == "" {
+		return
+	}
+	if err := store.Delete(c.Request.Context(), key); err != nil {
+		fmt.Printf("❌ Não foi possível remover o arquivo %s: %v\n", key, err)
+	}
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"mime"
+	"testing"
+)
+
+func TestContentDisposition(t *testing.T) {
+	tests := []struct {
+		disposition string
+		filename    string
+		want        string
+	}{
+		{"attachment", "nota.pdf", `attachment; filename="nota.pdf"; filename*=UTF-8''nota.pdf`},
+		{"inline", "foto 1.png", `inline; filename="foto 1.png"; filename*=UTF-8''foto%201.png`},
+		{"attachment", "orçamento.pdf", `attachment; filename="or_amento.pdf"; filename*=UTF-8''or%C3%A7amento.pdf`},
+		{"attachment", `a"b\c.txt`, `attachment; filename="a_b_c.txt"; filename*=UTF-8''a%22b%5Cc.txt`},
+		{"attachment", "x.pdf\r\nSet-Cookie: a=b", `attachment; filename="x.pdf__Set-Cookie: a=b"; filename*=UTF-8''x.pdf%0D%0ASet-Cookie%3A%20a%3Db`},
+		{"attachment", "a;b%20.txt", `attachment; filename="a_b_20.txt"; filename*=UTF-8''a%3Bb%2520.txt`},
+	}
+
+	for _, tt := range tests {
+		got := contentDisposition(tt.disposition, tt.filename)
+		if got != tt.want {
+			t.Errorf("contentDisposition(%q, %q)\n got: %s\nwant: %s", tt.disposition, tt.filename, got, tt.want)
+			continue
+		}
+
+		// O navegador deve recuperar o nome original a partir de filename*
+		disposition, params, err := mime.ParseMediaType(got)
+		if err != nil {
+			t.Errorf("cabeçalho inválido %q: %v", got, err)
+			continue
+		}
+		if disposition != tt.disposition || params["filename"] != tt.filename {
+			t.Errorf("ParseMediaType(%q) = %q, %q", got, disposition, params["filename"])
+		}
+	}
+}
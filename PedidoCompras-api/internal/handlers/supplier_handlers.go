@@ -103,15 +103,35 @@ func formatCNPJ(cnpj string) string {
 	return cnpj[:2] + "." + cnpj[2:5] + "." + cnpj[5:8] + "/" + cnpj[8:12] + "-" + cnpj[12:]
 }
 
-// ListSuppliers retorna todos os fornecedores
+// supplierListSpec - Ordenação e busca aceitas em GET /suppliers
+var supplierListSpec = listSpec{
+	Table: "suppliers",
+	SortFields: map[string]string{
+		"id":        "suppliers.id",
+		"name":      "suppliers.name",
+		"createdAt": "suppliers.created_at",
+		"updatedAt": "suppliers.updated_at",
+	},
+	DefaultSort:   "name",
+	SearchColumns: []string{"suppliers.name", "suppliers.cnpj", "suppliers.contact", "suppliers.email"},
+	DateColumn:    "suppliers.created_at",
+}
+
+// ListSuppliers retorna os fornecedores (parâmetros comuns de listagem; q busca em nome, CNPJ, contato e e-mail)
 func ListSuppliers(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		lq, ok := parseListQuery(c, supplierListSpec)
+		if !ok {
+			return
+		}
+
 		var suppliers []models.Supplier
-		if err := db.Find(&suppliers).Error; err != nil {
+		pagination, err := lq.find(db.Model(&models.Supplier{}), &suppliers)
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao listar fornecedores"})
 			return
 		}
-		c.JSON(http.StatusOK, suppliers)
+		respondList(c, lq, "suppliers", suppliers, pagination)
 	}
 }
 
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
@@ -10,17 +11,51 @@ import (
 	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/models"
 )
 
-// ListUsers retorna todos os usuários cadastrados.
+// userListSpec - Ordenação e busca aceitas em GET /users
+var userListSpec = listSpec{
+	Table: "users",
+	SortFields: map[string]string{
+		"id":        "users.id",
+		"name":      "users.name",
+		"email":     "users.email",
+		"role":      "users.role",
+		"createdAt": "users.created_at",
+	},
+	DefaultSort:   "name",
+	SearchColumns: []string{"users.name", "users.email"},
+	DateColumn:    "users.created_at",
+}
+
+// ListUsers retorna os usuários cadastrados.
+// Filtros: role e sectorId, além dos parâmetros comuns de listagem.
 func ListUsers(databaseConnection *gorm.DB) gin.HandlerFunc {
 	return func(context *gin.Context) {
+		lq, ok := parseListQuery(context, userListSpec)
+		if !ok {
+			return
+		}
+
+		query := databaseConnection.Model(&models.User{})
+		if roles := queryListParam(context, "role"); len(roles) > 0 {
+			query = query.Where("role IN ?", roles)
+		}
+		sectorID, ok := queryUintParam(context, "sectorId")
+		if !ok {
+			return
+		}
+		if sectorID != nil {
+			query = query.Where("sector_id = ?", *sectorID)
+		}
+
 		var usuarios []models.User
-		if resultado := databaseConnection.Find(&usuarios); resultado.Error != nil {
+		pagination, err := lq.find(query, &usuarios)
+		if err != nil {
 			context.JSON(http.StatusInternalServerError, gin.H{
 				"error": "Erro ao consultar usuários",
 			})
 			return
 		}
-		context.JSON(http.StatusOK, usuarios)
+		respondList(context, lq, "users", usuarios, pagination)
 	}
 }
 
@@ -30,8 +65,8 @@ func CreateUserUpdated(databaseConnection *gorm.DB) gin.HandlerFunc {
 	type userInput struct {
 		Name     string `json:"name" binding:"required"`
 		Email    string `json:"email" binding:"required,email"`
-		Password string `json:"password" binding:"required,min=6"`
-		Role     string `json:"role" binding:"required,oneof=admin requester"`
+		Password string `json:"password" binding:"required"`
+		Role     string `json:"role" binding:"required"`
 		SectorID uint   `json:"sectorId" binding:"required"`
 	}
 
@@ -42,15 +77,10 @@ func CreateUserUpdated(databaseConnection *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
-		// Se está tentando criar admin, verificar permissões
-		if dadosEntrada.Role == "admin" {
-			currentRole, exists := context.Get("role")
-			if !exists || currentRole != "admin" {
-				context.JSON(http.StatusForbidden, gin.H{
-					"error": "Apenas administradores podem criar outros administradores",
-				})
-				return
-			}
+		// Papel precisa existir; só administradores concedem o papel de administrador
+		if status, message := checkAssignableRole(databaseConnection, context, dadosEntrada.Role); status != 0 {
+			context.JSON(status, gin.H{"error": message})
+			return
 		}
 
 		// Verificar se email já existe
@@ -71,6 +101,19 @@ func CreateUserUpdated(databaseConnection *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
+		// Valida a senha contra a política configurada
+		policyErr, err := checkPasswordPolicy(databaseConnection, "password", dadosEntrada.Password)
+		if err != nil {
+			context.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Erro ao carregar política de senhas",
+			})
+			return
+		}
+		if policyErr != nil {
+			context.JSON(http.StatusBadRequest, policyErr)
+			return
+		}
+
 		// Gera o hash da senha
 		senhaHash, err := bcrypt.GenerateFromPassword(
 			[]byte(dadosEntrada.Password),
@@ -83,12 +126,14 @@ func CreateUserUpdated(databaseConnection *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
+		agora := time.Now()
 		usuario := models.User{
-			Name:         dadosEntrada.Name,
-			Email:        dadosEntrada.Email,
-			PasswordHash: string(senhaHash),
-			Role:         dadosEntrada.Role,
-			SectorID:     dadosEntrada.SectorID,
+			Name:              dadosEntrada.Name,
+			Email:             dadosEntrada.Email,
+			PasswordHash:      string(senhaHash),
+			Role:              dadosEntrada.Role,
+			SectorID:          dadosEntrada.SectorID,
+			PasswordChangedAt: &agora,
 		}
 
 		if resultado := databaseConnection.Create(&usuario); resultado.Error != nil {
@@ -125,20 +170,11 @@ func UpdateUser(databaseConnection *gorm.DB) gin.HandlerFunc {
 		Name     *string `json:"name"`
 		Email    *string `json:"email"`
 		Password *string `json:"password"` // Opcional - se fornecida, será alterada
-		Role     *string `json:"role" binding:"omitempty,oneof=admin requester"`
+		Role     *string `json:"role"`
 		SectorID *uint   `json:"sectorId"`
 	}
 
 	return func(context *gin.Context) {
-		// Verificar se usuário é admin
-		currentRole, exists := context.Get("role")
-		if !exists || currentRole != "admin" {
-			context.JSON(http.StatusForbidden, gin.H{
-				"error": "Apenas administradores podem editar usuários",
-			})
-			return
-		}
-
 		// Obter ID do usuário atual e do usuário a ser editado
 		currentUserID, _ := context.Get("userID")
 		targetUserID := context.Param("id")
@@ -166,6 +202,17 @@ func UpdateUser(databaseConnection *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
+		if status, message := checkManageableUser(context, usuario); status != 0 {
+			context.JSON(status, gin.H{"error": message})
+			return
+		}
+		if dadosEntrada.Role != nil {
+			if status, message := checkAssignableRole(databaseConnection, context, *dadosEntrada.Role); status != 0 {
+				context.JSON(status, gin.H{"error": message})
+				return
+			}
+		}
+
 		// Verificar se é o último admin (não pode alterar role)
 		if dadosEntrada.Role != nil && *dadosEntrada.Role != "admin" && usuario.Role == "admin" {
 			var adminCount int64
@@ -209,8 +256,20 @@ func UpdateUser(databaseConnection *gorm.DB) gin.HandlerFunc {
 			updates["sector_id"] = *dadosEntrada.SectorID
 		}
 
-		// Se senha foi fornecida, gerar novo hash
+		// Se senha foi fornecida, validar a política e gerar novo hash
 		if dadosEntrada.Password != nil && *dadosEntrada.Password != "" {
+			policyErr, err := checkPasswordPolicy(databaseConnection, "password", *dadosEntrada.Password)
+			if err != nil {
+				context.JSON(http.StatusInternalServerError, gin.H{
+					"error": "Erro ao carregar política de senhas",
+				})
+				return
+			}
+			if policyErr != nil {
+				context.JSON(http.StatusBadRequest, policyErr)
+				return
+			}
+
 			senhaHash, err := bcrypt.GenerateFromPassword(
 				[]byte(*dadosEntrada.Password),
 				bcrypt.DefaultCost,
@@ -222,6 +281,12 @@ func UpdateUser(databaseConnection *gorm.DB) gin.HandlerFunc {
 				return
 			}
 			updates["password_hash"] = string(senhaHash)
+			updates["password_changed_at"] = time.Now()
+		}
+
+		// Troca de papel ou de senha invalida os access tokens já emitidos
+		if (dadosEntrada.Role != nil && *dadosEntrada.Role != usuario.Role) || updates["password_hash"] != nil {
+			updates["tokens_valid_after"] = time.Now()
 		}
 
 		// Executar atualização
@@ -260,15 +325,6 @@ func UpdateUser(databaseConnection *gorm.DB) gin.HandlerFunc {
 // DeleteUser realiza soft delete de um usuário
 func DeleteUser(databaseConnection *gorm.DB) gin.HandlerFunc {
 	return func(context *gin.Context) {
-		// Verificar se usuário é admin
-		currentRole, exists := context.Get("role")
-		if !exists || currentRole != "admin" {
-			context.JSON(http.StatusForbidden, gin.H{
-				"error": "Apenas administradores podem excluir usuários",
-			})
-			return
-		}
-
 		// Obter IDs
 		currentUserID, _ := context.Get("userID")
 		targetUserID := context.Param("id")
@@ -290,6 +346,11 @@ func DeleteUser(databaseConnection *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
+		if status, message := checkManageableUser(context, usuario); status != 0 {
+			context.JSON(status, gin.H{"error": message})
+			return
+		}
+
 		// Verificar se é o último admin
 		if usuario.Role == "admin" {
 			var adminCount int64
@@ -323,6 +384,14 @@ func DeleteUser(databaseConnection *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
+		// Encerrar sessões abertas do usuário excluído
+		if _, err := revokeAllUserSessions(databaseConnection, usuario.ID, "user-deleted"); err != nil {
+			context.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Usuário excluído, mas houve erro ao encerrar suas sessões",
+			})
+			return
+		}
+
 		context.JSON(http.StatusOK, gin.H{
 			"message": "Usuário excluído com sucesso",
 			"deletedUser": gin.H{
@@ -341,12 +410,8 @@ func DeleteUser(databaseConnection *gorm.DB) gin.HandlerFunc {
 // PromoteToAdmin promove um usuário requester para admin
 func PromoteToAdmin(databaseConnection *gorm.DB) gin.HandlerFunc {
 	return func(context *gin.Context) {
-		// Verificar se usuário é admin
-		currentRole, exists := context.Get("role")
-		if !exists || currentRole != "admin" {
-			context.JSON(http.StatusForbidden, gin.H{
-				"error": "Apenas administradores podem promover usuários",
-			})
+		if status, message := checkAssignableRole(databaseConnection, context, models.RoleAdmin); status != 0 {
+			context.JSON(status, gin.H{"error": message})
 			return
 		}
 
@@ -369,8 +434,11 @@ func PromoteToAdmin(databaseConnection *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
-		// Promover para admin
-		if resultado := databaseConnection.Model(&usuario).Update("role", "admin"); resultado.Error != nil {
+		// Promover para admin (tokens antigos deixam de valer, o novo papel vem no refresh)
+		if resultado := databaseConnection.Model(&usuario).Updates(map[string]interface{}{
+			"role":               "admin",
+			"tokens_valid_after": time.Now(),
+		}); resultado.Error != nil {
 			context.JSON(http.StatusInternalServerError, gin.H{
 				"error": "Erro ao promover usuário",
 			})
@@ -404,15 +472,6 @@ func PromoteToAdmin(databaseConnection *gorm.DB) gin.HandlerFunc {
 // GetUser retorna dados de um usuário específico
 func GetUser(databaseConnection *gorm.DB) gin.HandlerFunc {
 	return func(context *gin.Context) {
-		// Verificar se usuário é admin
-		currentRole, exists := context.Get("role")
-		if !exists || currentRole != "admin" {
-			context.JSON(http.StatusForbidden, gin.H{
-				"error": "Apenas administradores podem visualizar detalhes de usuários",
-			})
-			return
-		}
-
 		userID := context.Param("id")
 
 		var usuario models.User
@@ -0,0 +1,54 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// Message - E-mail a ser enviado
+type Message struct {
+	To       []string
+	Subject  string
+	TextBody string
+	HTMLBody string // opcional; quando presente o e-mail vai como multipart/alternative
+
+	// Imagens embutidas no HTML, referenciadas por "cid:<ContentID>"
+	Inline []InlineFile
+}
+
+// InlineFile - Arquivo embutido no corpo HTML (ex: logo da empresa)
+type InlineFile struct {
+	ContentID   string
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// Mailer - Envio de e-mails. Implementações: SMTPMailer e LogMailer
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// New devolve o SMTPMailer quando há servidor configurado; sem SMTP_HOST
+// os e-mails são apenas escritos no log (útil em desenvolvimento)
+func New(cfg SMTPConfig) Mailer {
+	if cfg.Host == "" {
+		log.Println("Aviso: SMTP_HOST não configurado, e-mails serão apenas registrados no log")
+		return LogMailer{}
+	}
+	return NewSMTPMailer(cfg)
+}
+
+// LogMailer - Escreve os e-mails no log em vez de enviá-los
+type LogMailer struct{}
+
+// Send registra o e-mail no log
+func (LogMailer) Send(ctx context.Context, msg Message) error {
+	if len(msg.To) == 0 {
+		return fmt.Errorf("e-mail sem destinatário")
+	}
+	log.Printf("📧 E-mail para %s | %s\n%s\n", strings.Join(msg.To, ", "), msg.Subject, msg.TextBody)
+	return nil
+}
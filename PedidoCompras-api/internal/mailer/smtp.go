@@ -0,0 +1,264 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Modos de segurança da conexão SMTP
+const (
+	TLSModeStartTLS = "starttls" // STARTTLS quando o servidor oferece (padrão)
+	TLSModeImplicit = "tls"      // TLS desde a conexão (porta 465)
+	TLSModeNone     = "none"     // sem criptografia (servidor local / testes)
+)
+
+// SMTPConfig - Configuração do servidor SMTP
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string // endereço do remetente
+	FromName string // nome exibido do remetente
+	TLSMode  string
+	Timeout  time.Duration
+}
+
+// SMTPMailer - Envio de e-mails via SMTP
+type SMTPMailer struct {
+	cfg SMTPConfig
+}
+
+// NewSMTPMailer cria o mailer aplicando os padrões de porta, modo TLS e timeout
+func NewSMTPMailer(cfg SMTPConfig) *SMTPMailer {
+	if cfg.Port == 0 {
+		cfg.Port = 587
+	}
+	if cfg.TLSMode == "" {
+		cfg.TLSMode = TLSModeStartTLS
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 15 * time.Second
+	}
+	if cfg.From == "" {
+		cfg.From = cfg.Username
+	}
+	return &SMTPMailer{cfg: cfg}
+}
+
+// Send entrega a mensagem ao servidor SMTP
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	if len(msg.To) == 0 {
+		return fmt.Errorf("e-mail sem destinatário")
+	}
+
+	from, err := mail.ParseAddress(m.cfg.From)
+	if err != nil {
+		return fmt.Errorf("remetente inválido %q: %w", m.cfg.From, err)
+	}
+	recipients := make([]string, 0, len(msg.To))
+	for _, to := range msg.To {
+		address, err := mail.ParseAddress(to)
+		if err != nil {
+			return fmt.Errorf("destinatário inválido %q: %w", to, err)
+		}
+		recipients = append(recipients, address.Address)
+	}
+
+	body, err := m.build(from.Address, recipients, msg)
+	if err != nil {
+		return err
+	}
+
+	client, err := m.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if m.cfg.TLSMode == TLSModeStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(&tls.Config{ServerName: m.cfg.Host}); err != nil {
+				return fmt.Errorf("erro no STARTTLS: %w", err)
+			}
+		}
+	}
+
+	if m.cfg.Username != "" {
+		if ok, _ := client.Extension("AUTH"); ok {
+			auth := smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)
+			if err := client.Auth(auth); err != nil {
+				return fmt.Errorf("erro de autenticação SMTP: %w", err)
+			}
+		}
+	}
+
+	if err := client.Mail(from.Address); err != nil {
+		return fmt.Errorf("erro no MAIL FROM: %w", err)
+	}
+	for _, rcpt := range recipients {
+		if err := client.Rcpt(rcpt); err != nil {
+			return fmt.Errorf("erro no RCPT TO %s: %w", rcpt, err)
+		}
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("erro no DATA: %w", err)
+	}
+	if _, err := writer.Write(body); err != nil {
+		writer.Close()
+		return fmt.Errorf("erro ao enviar corpo do e-mail: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("servidor recusou o e-mail: %w", err)
+	}
+
+	return client.Quit()
+}
+
+// dial abre a conexão respeitando o contexto, o timeout e o modo TLS
+func (m *SMTPMailer) dial(ctx context.Context) (*smtp.Client, error) {
+	address := net.JoinHostPort(m.cfg.Host, strconv.Itoa(m.cfg.Port))
+
+	ctx, cancel := context.WithTimeout(ctx, m.cfg.Timeout)
+	defer cancel()
+
+	dialer := &net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao conectar ao SMTP %s: %w", address, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		// o prazo vale para toda a conversa SMTP
+		conn.SetDeadline(deadline.Add(m.cfg.Timeout))
+	}
+
+	if m.cfg.TLSMode == TLSModeImplicit {
+		conn = tls.Client(conn, &tls.Config{ServerName: m.cfg.Host})
+	}
+
+	client, err := smtp.NewClient(conn, m.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("erro na saudação SMTP: %w", err)
+	}
+	return client, nil
+}
+
+// build monta a mensagem MIME (texto, HTML opcional e imagens embutidas)
+func (m *SMTPMailer) build(from string, to []string, msg Message) ([]byte, error) {
+	var buf bytes.Buffer
+
+	fromHeader := from
+	if m.cfg.FromName != "" {
+		fromHeader = (&mail.Address{Name: m.cfg.FromName, Address: from}).String()
+	}
+
+	headers := []string{
+		"From: " + fromHeader,
+		"To: " + strings.Join(to, ", "),
+		"Subject: " + mime.QEncoding.Encode("utf-8", msg.Subject),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"Message-ID: " + messageID(from),
+		"MIME-Version: 1.0",
+	}
+	for _, header := range headers {
+		buf.WriteString(header + "\r\n")
+	}
+
+	if msg.HTMLBody == "" {
+		buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+		buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+		if err := writeQuotedPrintable(&buf, msg.TextBody); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	alternative := randomBoundary()
+	related := randomBoundary()
+	hasInline := len(msg.Inline) > 0
+
+	if hasInline {
+		fmt.Fprintf(&buf, "Content-Type: multipart/related; boundary=%q\r\n\r\n", related)
+		fmt.Fprintf(&buf, "--%s\r\n", related)
+	}
+	fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", alternative)
+
+	fmt.Fprintf(&buf, "--%s\r\n", alternative)
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+	if err := writeQuotedPrintable(&buf, msg.TextBody); err != nil {
+		return nil, err
+	}
+
+	fmt.Fprintf(&buf, "\r\n--%s\r\n", alternative)
+	buf.WriteString("Content-Type: text/html; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+	if err := writeQuotedPrintable(&buf, msg.HTMLBody); err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(&buf, "\r\n--%s--\r\n", alternative)
+
+	if hasInline {
+		for _, file := range msg.Inline {
+			fmt.Fprintf(&buf, "\r\n--%s\r\n", related)
+			fmt.Fprintf(&buf, "Content-Type: %s\r\n", file.ContentType)
+			buf.WriteString("Content-Transfer-Encoding: base64\r\n")
+			fmt.Fprintf(&buf, "Content-ID: <%s>\r\n", file.ContentID)
+			fmt.Fprintf(&buf, "Content-Disposition: inline; filename=%q\r\n\r\n", file.Filename)
+			writeBase64(&buf, file.Data)
+		}
+		fmt.Fprintf(&buf, "\r\n--%s--\r\n", related)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func writeQuotedPrintable(buf *bytes.Buffer, text string) error {
+	writer := quotedprintable.NewWriter(buf)
+	if _, err := writer.Write([]byte(text)); err != nil {
+		return fmt.Errorf("erro ao codificar e-mail: %w", err)
+	}
+	return writer.Close()
+}
+
+// writeBase64 codifica em linhas de 76 caracteres (RFC 2045)
+func writeBase64(buf *bytes.Buffer, data []byte) {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		buf.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	buf.WriteString(encoded + "\r\n")
+}
+
+func randomBoundary() string {
+	buf := make([]byte, 12)
+	rand.Read(buf)
+	return "b_" + hex.EncodeToString(buf)
+}
+
+func messageID(from string) string {
+	domain := "localhost"
+	if at := strings.LastIndex(from, "@"); at >= 0 {
+		domain = from[at+1:]
+	}
+	buf := make([]byte, 12)
+	rand.Read(buf)
+	return fmt.Sprintf("<%d.%s@%s>", time.Now().UnixNano(), hex.EncodeToString(buf), domain)
+}
@@ -0,0 +1,251 @@
+package mailer
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+)
+
+// smtpSession - O que o servidor falso recebeu numa conversa SMTP
+type smtpSession struct {
+	From string
+	To   []string
+	Data string
+}
+
+// startFakeSMTP sobe um servidor SMTP mínimo (sem STARTTLS nem AUTH) que aceita
+// uma conversa e a devolve no canal
+func startFakeSMTP(t *testing.T) (string, int, <-chan smtpSession) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	sessions := make(chan smtpSession, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+		reader := bufio.NewReader(conn)
+		reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+
+		var session smtpSession
+		reply("220 fake.local ESMTP")
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			command := strings.ToUpper(line)
+
+			switch {
+			case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+				reply("250-fake.local")
+				reply("250 8BITMIME")
+			case strings.HasPrefix(command, "MAIL FROM:"):
+				session.From = pathAddress(line[len("MAIL FROM:"):])
+				reply("250 OK")
+			case strings.HasPrefix(command, "RCPT TO:"):
+				session.To = append(session.To, pathAddress(line[len("RCPT TO:"):]))
+				reply("250 OK")
+			case command == "DATA":
+				reply("354 fim com <CRLF>.<CRLF>")
+				var data strings.Builder
+				for {
+					dataLine, err := reader.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if dataLine == ".\r\n" {
+						break
+					}
+					data.WriteString(strings.TrimPrefix(dataLine, "."))
+				}
+				session.Data = data.String()
+				reply("250 OK")
+			case command == "QUIT":
+				reply("221 tchau")
+				sessions <- session
+				return
+			default:
+				reply("250 OK")
+			}
+		}
+	}()
+
+	addr := listener.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port, sessions
+}
+
+// pathAddress extrai o endereço de "<endereço> [parâmetros]"
+func pathAddress(arg string) string {
+	arg = strings.TrimSpace(arg)
+	if end := strings.Index(arg, ">"); strings.HasPrefix(arg, "<") && end > 0 {
+		return arg[1:end]
+	}
+	return arg
+}
+
+func receiveSession(t *testing.T, sessions <-chan smtpSession) smtpSession {
+	t.Helper()
+	select {
+	case session := <-sessions:
+		return session
+	case <-time.After(5 * time.Second):
+		t.Fatal("servidor SMTP falso não recebeu a mensagem")
+		return smtpSession{}
+	}
+}
+
+func decodeQuotedPrintable(t *testing.T, r io.Reader) string {
+	t.Helper()
+	decoded, err := io.ReadAll(quotedprintable.NewReader(r))
+	if err != nil {
+		t.Fatalf("quoted-printable: %v", err)
+	}
+	return string(decoded)
+}
+
+func TestSMTPMailerSendText(t *testing.T) {
+	host, port, sessions := startFakeSMTP(t)
+	m := NewSMTPMailer(SMTPConfig{
+		Host:     host,
+		Port:     port,
+		From:     "compras@empresa.com",
+		FromName: "Pedidos de Compra",
+		TLSMode:  TLSModeNone,
+		Timeout:  5 * time.Second,
+	})
+
+	err := m.Send(context.Background(), Message{
+		To:       []string{"Ana <ana@empresa.com>", "bruno@empresa.com"},
+		Subject:  "Requisição #42 aprovada",
+		TextBody: "Sua requisição foi aprovada.\nValor: R$ 1.234,56",
+	})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	session := receiveSession(t, sessions)
+	if session.From != "compras@empresa.com" {
+		t.Errorf("MAIL FROM = %q", session.From)
+	}
+	if got := strings.Join(session.To, ","); got != "ana@empresa.com,bruno@empresa.com" {
+		t.Errorf("RCPT TO = %q", got)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(session.Data))
+	if err != nil {
+		t.Fatalf("mensagem inválida: %v", err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		t.Fatalf("Subject: %v", err)
+	}
+	if subject != "Requisição #42 aprovada" {
+		t.Errorf("Subject = %q", subject)
+	}
+	if to := msg.Header.Get("To"); to != "ana@empresa.com, bruno@empresa.com" {
+		t.Errorf("To = %q", to)
+	}
+	if from := msg.Header.Get("From"); !strings.Contains(from, "<compras@empresa.com>") {
+		t.Errorf("From = %q", from)
+	}
+	if ct := msg.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	// o fim do DATA acrescenta uma quebra de linha ao corpo
+	body := strings.TrimSuffix(strings.ReplaceAll(decodeQuotedPrintable(t, msg.Body), "\r\n", "\n"), "\n")
+	if body != "Sua requisição foi aprovada.\nValor: R$ 1.234,56" {
+		t.Errorf("corpo = %q", body)
+	}
+}
+
+func TestSMTPMailerSendHTMLWithInline(t *testing.T) {
+	host, port, sessions := startFakeSMTP(t)
+	m := NewSMTPMailer(SMTPConfig{Host: host, Port: port, From: "compras@empresa.com", TLSMode: TLSModeNone})
+
+	err := m.Send(context.Background(), Message{
+		To:       []string{"ana@empresa.com"},
+		Subject:  "Relatório",
+		TextBody: "Veja o relatório",
+		HTMLBody: `<p>Veja o <b>relatório</b></p><img src="cid:logo">`,
+		Inline:   []InlineFile{{ContentID: "logo", Filename: "logo.png", ContentType: "image/png", Data: []byte("png")}},
+	})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(receiveSession(t, sessions).Data))
+	if err != nil {
+		t.Fatalf("mensagem inválida: %v", err)
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/related" {
+		t.Fatalf("Content-Type = %q (%v)", msg.Header.Get("Content-Type"), err)
+	}
+
+	related := multipart.NewReader(msg.Body, params["boundary"])
+	alternativePart, err := related.NextPart()
+	if err != nil {
+		t.Fatalf("parte alternative: %v", err)
+	}
+	_, altParams, _ := mime.ParseMediaType(alternativePart.Header.Get("Content-Type"))
+	alternative := multipart.NewReader(alternativePart, altParams["boundary"])
+
+	bodies := map[string]string{}
+	for {
+		part, err := alternative.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("parte do corpo: %v", err)
+		}
+		partType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		// multipart.Reader já decodifica quoted-printable
+		content, _ := io.ReadAll(part)
+		bodies[partType] = string(content)
+	}
+	if bodies["text/plain"] != "Veja o relatório" {
+		t.Errorf("texto = %q", bodies["text/plain"])
+	}
+	if !strings.Contains(bodies["text/html"], "<b>relatório</b>") {
+		t.Errorf("html = %q", bodies["text/html"])
+	}
+
+	inline, err := related.NextPart()
+	if err != nil {
+		t.Fatalf("imagem embutida: %v", err)
+	}
+	if id := inline.Header.Get("Content-ID"); id != "<logo>" {
+		t.Errorf("Content-ID = %q", id)
+	}
+}
+
+func TestSMTPMailerRejectsInvalidRecipient(t *testing.T) {
+	m := NewSMTPMailer(SMTPConfig{Host: "127.0.0.1", Port: 1, From: "compras@empresa.com", TLSMode: TLSModeNone})
+
+	if err := m.Send(context.Background(), Message{Subject: "x", TextBody: "x"}); err == nil {
+		t.Error("esperava erro sem destinatário")
+	}
+	if err := m.Send(context.Background(), Message{To: []string{"não é e-mail"}, Subject: "x", TextBody: "x"}); err == nil {
+		t.Error("esperava erro com destinatário inválido")
+	}
+}
@@ -6,6 +6,8 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v4"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/rbac"
+	"gorm.io/gorm"
 )
 
 // TokenClaims estende RegisteredClaims com dados completos do usuário
@@ -15,11 +17,20 @@ type TokenClaims struct {
 	Email      string `json:"email"`      // ✅ ADICIONADO
 	SectorID   uint   `json:"sectorId"`   // ✅ ADICIONADO
 	SectorName string `json:"sectorName"` // ✅ ADICIONADO
+
+	// Senha expirada: o token só permite trocar a senha
+	PasswordExpired bool `json:"pwdExpired,omitempty"`
 	jwt.RegisteredClaims
 }
 
-// AuthMiddleware valida o Bearer token e injeta dados completos no contexto
-func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
+// Rotas liberadas para tokens emitidos com senha expirada
+var passwordChangeRoutes = map[string]bool{
+	"/api/v1/profile/password": true,
+	"/api/v1/auth/logout":      true,
+}
+
+// AuthMiddleware valida o Bearer token (assinatura e sessão) e injeta dados completos no contexto
+func AuthMiddleware(jwtSecret string, db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 1) Header Authorization
 		headerAutorizacao := c.GetHeader("Authorization")
@@ -54,6 +65,21 @@ func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
 			return
 		}
 
+		// 4) Sessão revogada ou token anterior à troca de papel
+		if err := validateSession(db, claims); err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Sessão expirada ou revogada"})
+			return
+		}
+
+		// 5) Senha expirada: apenas a troca de senha (e o logout) é permitida
+		if claims.PasswordExpired && !passwordChangeRoutes[c.FullPath()] {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error":           "Senha expirada. Altere sua senha para continuar",
+				"passwordExpired": true,
+			})
+			return
+		}
+
 		// ✅ INJETAR TODOS OS DADOS NO CONTEXTO
 		c.Set("userID", claims.Subject)
 		c.Set("role", claims.Role)
@@ -61,6 +87,11 @@ func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
 		c.Set("userEmail", claims.Email)       // ✅ EMAIL
 		c.Set("sectorID", claims.SectorID)     // ✅ SETOR ID
 		c.Set("sectorName", claims.SectorName) // ✅ SETOR NOME
+		c.Set("sessionID", claims.ID)          // sessão (jti) para logout
+		c.Set("passwordExpired", claims.PasswordExpired)
+
+		// Permissões do papel (usadas por RequirePermission e pelos handlers)
+		c.Set(rbac.ContextKey, rbac.Permissions(db, claims.Role))
 
 		c.Next()
 	}
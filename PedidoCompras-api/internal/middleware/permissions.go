@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/rbac"
+)
+
+// RequirePermission bloqueia a rota para quem não possui a permissão.
+// Deve ser usado depois do AuthMiddleware, que carrega as permissões do papel.
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !rbac.Has(c, permission) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error":      "Permissão insuficiente",
+				"permission": permission,
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireAnyPermission bloqueia a rota para quem não possui nenhuma das permissões
+func RequireAnyPermission(permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, permission := range permissions {
+			if rbac.Has(c, permission) {
+				c.Next()
+				return
+			}
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"error":       "Permissão insuficiente",
+			"permissions": permissions,
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"errors"
+	"time"
+
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/models"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/utils"
+	"gorm.io/gorm"
+)
+
+// validateSession rejeita tokens de sessões encerradas ou emitidos antes
+// da última invalidação do usuário (troca de papel, logout geral)
+func validateSession(db *gorm.DB, claims *TokenClaims) error {
+	if claims.ID == "" {
+		return errors.New("token sem sessão")
+	}
+
+	var session models.UserSession
+	if err := db.Select("id", "user_id", "revoked_at").First(&session, "id = ?", claims.ID).Error; err != nil {
+		return errors.New("sessão não encontrada")
+	}
+	if session.IsRevoked() || claims.Subject == "" || session.UserID != utils.ParseUint(claims.Subject) {
+		return errors.New("sessão encerrada")
+	}
+
+	var user models.User
+	if err := db.Select("id", "tokens_valid_after").First(&user, session.UserID).Error; err != nil {
+		return errors.New("usuário não encontrado")
+	}
+	if user.TokensValidAfter != nil && claims.IssuedAt != nil &&
+		claims.IssuedAt.Time.Before(user.TokensValidAfter.Truncate(time.Second)) {
+		return errors.New("token emitido antes da última alteração do usuário")
+	}
+
+	return nil
+}
@@ -7,10 +7,12 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v4"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/rbac"
+	"gorm.io/gorm"
 )
 
 // SSEAuthMiddleware - Middleware especial para SSE que aceita token via query string
-func SSEAuthMiddleware(jwtSecret string) gin.HandlerFunc {
+func SSEAuthMiddleware(jwtSecret string, db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var tokenString string
 
@@ -64,14 +66,28 @@ func SSEAuthMiddleware(jwtSecret string) gin.HandlerFunc {
 			return
 		}
 
+		if claims.PasswordExpired {
+			fmt.Printf("❌ SSE Auth: Token restrito (senha expirada)\n")
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Senha expirada", "passwordExpired": true})
+			return
+		}
+
+		if err := validateSession(db, claims); err != nil {
+			fmt.Printf("❌ SSE Auth: Sessão inválida - %v\n", err)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Sessão expirada ou revogada"})
+			return
+		}
+
 		fmt.Printf("✅ SSE Auth: Usuário autenticado - %s (%s)\n", claims.Name, claims.Subject)
 
 		c.Set("userID", claims.Subject)
 		c.Set("role", claims.Role)
+		c.Set(rbac.ContextKey, rbac.Permissions(db, claims.Role)) // permissões do papel
 		c.Set("userName", claims.Name)
 		c.Set("userEmail", claims.Email)
 		c.Set("sectorID", claims.SectorID)
 		c.Set("sectorName", claims.SectorName)
+		c.Set("sessionID", claims.ID)
 
 		c.Next()
 	}
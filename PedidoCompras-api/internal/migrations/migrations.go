@@ -0,0 +1,310 @@
+// Package migrations aplica as migrações SQL versionadas do banco (embutidas no binário).
+//
+// Cada migração é um par de arquivos em sql/: NNNN_nome.up.sql e NNNN_nome.down.sql.
+// As versões aplicadas ficam na tabela schema_migrations; cada migração roda em uma
+// transação própria, junto com o registro da versão.
+package migrations
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//go:embed sql/*.sql
+var files embed.FS
+
+// lockKey - Chave do advisory lock que impede duas instâncias de migrarem ao mesmo tempo
+const lockKey = 72616301
+
+// Migration - Migração versionada
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status - Situação de uma migração no banco
+type Status struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time // nil quando pendente
+	Unknown   bool       // aplicada no banco, mas inexistente neste binário
+}
+
+// schemaMigration - Linha de schema_migrations
+type schemaMigration struct {
+	Version   int `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	AppliedAt time.Time
+}
+
+func (schemaMigration) TableName() string { return "schema_migrations" }
+
+// All retorna as migrações embutidas, em ordem de versão
+func All() ([]Migration, error) {
+	entries, err := fs.ReadDir(files, "sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		name := entry.Name()
+		var direction string
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(name, ".down.sql"):
+			direction = "down"
+		default:
+			return nil, fmt.Errorf("arquivo de migração inválido: %s", name)
+		}
+
+		base := strings.TrimSuffix(name, "."+direction+".sql")
+		prefix, label, ok := strings.Cut(base, "_")
+		version, err := strconv.Atoi(prefix)
+		if !ok || err != nil || version <= 0 {
+			return nil, fmt.Errorf("nome de migração inválido: %s (esperado NNNN_nome.up.sql)", name)
+		}
+
+		content, err := files.ReadFile(path.Join("sql", name))
+		if err != nil {
+			return nil, err
+		}
+
+		m, exists := byVersion[version]
+		if !exists {
+			m = &Migration{Version: version, Name: label}
+			byVersion[version] = m
+		} else if m.Name != label {
+			return nil, fmt.Errorf("versão %d duplicada: %s e %s", version, m.Name, label)
+		}
+		if direction == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	list := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migração %04d_%s sem arquivo up ou down", m.Version, m.Name)
+		}
+		list = append(list, *m)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+	return list, nil
+}
+
+// Latest retorna a versão mais recente embutida no binário
+func Latest() (int, error) {
+	list, err := All()
+	if err != nil || len(list) == 0 {
+		return 0, err
+	}
+	return list[len(list)-1].Version, nil
+}
+
+// ensureTable cria schema_migrations quando ainda não existe
+func ensureTable(db *gorm.DB) error {
+	return db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version integer PRIMARY KEY,
+		name varchar(255) NOT NULL,
+		applied_at timestamptz NOT NULL DEFAULT now()
+	)`).Error
+}
+
+// applied retorna as migrações registradas no banco, por versão
+func applied(db *gorm.DB) (map[int]schemaMigration, error) {
+	var rows []schemaMigration
+	if err := db.Order("version").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	result := make(map[int]schemaMigration, len(rows))
+	for _, row := range rows {
+		result[row.Version] = row
+	}
+	return result, nil
+}
+
+// Current retorna a maior versão aplicada (0 quando nenhuma)
+func Current(db *gorm.DB) (int, error) {
+	if err := ensureTable(db); err != nil {
+		return 0, err
+	}
+	var version int
+	err := db.Raw("SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version).Error
+	return version, err
+}
+
+// StatusList lista todas as migrações conhecidas e as aplicadas que este binário não conhece
+func StatusList(db *gorm.DB) ([]Status, error) {
+	list, err := All()
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureTable(db); err != nil {
+		return nil, err
+	}
+	done, err := applied(db)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(list))
+	for _, m := range list {
+		s := Status{Version: m.Version, Name: m.Name}
+		if row, ok := done[m.Version]; ok {
+			appliedAt := row.AppliedAt
+			s.AppliedAt = &appliedAt
+			delete(done, m.Version)
+		}
+		statuses = append(statuses, s)
+	}
+	for _, row := range done {
+		appliedAt := row.AppliedAt
+		statuses = append(statuses, Status{Version: row.Version, Name: row.Name, AppliedAt: &appliedAt, Unknown: true})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+// Check confere se o banco está exatamente na versão mais recente deste binário
+func Check(db *gorm.DB) error {
+	statuses, err := StatusList(db)
+	if err != nil {
+		return err
+	}
+	var pending []string
+	for _, s := range statuses {
+		if s.Unknown {
+			return fmt.Errorf("o banco tem a migração %04d_%s, desconhecida por esta versão da aplicação (banco mais novo que o binário)", s.Version, s.Name)
+		}
+		if s.AppliedAt == nil {
+			pending = append(pending, fmt.Sprintf("%04d_%s", s.Version, s.Name))
+		}
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("migrações pendentes: %s (execute \"migrate up\")", strings.Join(pending, ", "))
+	}
+	return nil
+}
+
+// Up aplica as migrações pendentes, em ordem; steps > 0 limita a quantidade aplicada
+func Up(db *gorm.DB, steps int) ([]Migration, error) {
+	list, err := All()
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureTable(db); err != nil {
+		return nil, err
+	}
+	done, err := applied(db)
+	if err != nil {
+		return nil, err
+	}
+	latest := 0
+	if len(list) > 0 {
+		latest = list[len(list)-1].Version
+	}
+	for version, row := range done {
+		if version > latest {
+			return nil, fmt.Errorf("o banco tem a migração %04d_%s, desconhecida por esta versão da aplicação", version, row.Name)
+		}
+	}
+
+	var ran []Migration
+	for _, m := range list {
+		if steps > 0 && len(ran) >= steps {
+			break
+		}
+		if _, ok := done[m.Version]; ok {
+			continue
+		}
+		applyErr := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", lockKey).Error; err != nil {
+				return err
+			}
+			// Outra instância pode ter aplicado enquanto esperávamos o lock
+			var count int64
+			if err := tx.Model(&schemaMigration{}).Where("version = ?", m.Version).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return nil
+			}
+			if err := tx.Exec(m.Up).Error; err != nil {
+				return err
+			}
+			return tx.Create(&schemaMigration{Version: m.Version, Name: m.Name, AppliedAt: time.Now()}).Error
+		})
+		if applyErr != nil {
+			return ran, fmt.Errorf("erro ao aplicar a migração %04d_%s: %w", m.Version, m.Name, applyErr)
+		}
+		ran = append(ran, m)
+	}
+	return ran, nil
+}
+
+// Down reverte as últimas migrações aplicadas (steps, no mínimo 1), da mais nova para a mais antiga
+func Down(db *gorm.DB, steps int) ([]Migration, error) {
+	if steps < 1 {
+		steps = 1
+	}
+	list, err := All()
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureTable(db); err != nil {
+		return nil, err
+	}
+	done, err := applied(db)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]Migration, len(list))
+	for _, m := range list {
+		byVersion[m.Version] = m
+	}
+	versions := make([]int, 0, len(done))
+	for version := range done {
+		versions = append(versions, version)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(versions)))
+
+	var ran []Migration
+	for _, version := range versions {
+		if len(ran) >= steps {
+			break
+		}
+		m, ok := byVersion[version]
+		if !ok {
+			return ran, fmt.Errorf("a migração %04d_%s não existe nesta versão da aplicação e não pode ser revertida", version, done[version].Name)
+		}
+		revertErr := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", lockKey).Error; err != nil {
+				return err
+			}
+			if err := tx.Exec(m.Down).Error; err != nil {
+				return err
+			}
+			return tx.Where("version = ?", m.Version).Delete(&schemaMigration{}).Error
+		})
+		if revertErr != nil {
+			return ran, fmt.Errorf("erro ao reverter a migração %04d_%s: %w", m.Version, m.Name, revertErr)
+		}
+		ran = append(ran, m)
+	}
+	return ran, nil
+}
@@ -0,0 +1,13 @@
+-- Remove todas as tabelas do esquema inicial (apaga todos os dados)
+DROP TABLE IF EXISTS "system_settings" CASCADE;
+DROP TABLE IF EXISTS "company_settings" CASCADE;
+DROP TABLE IF EXISTS "product_registration_requests" CASCADE;
+DROP TABLE IF EXISTS "item_receipts" CASCADE;
+DROP TABLE IF EXISTS "item_budgets" CASCADE;
+DROP TABLE IF EXISTS "attachments" CASCADE;
+DROP TABLE IF EXISTS "request_items" CASCADE;
+DROP TABLE IF EXISTS "purchase_requests" CASCADE;
+DROP TABLE IF EXISTS "suppliers" CASCADE;
+DROP TABLE IF EXISTS "products" CASCADE;
+DROP TABLE IF EXISTS "users" CASCADE;
+DROP TABLE IF EXISTS "sectors" CASCADE;
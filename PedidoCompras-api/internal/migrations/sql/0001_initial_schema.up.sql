@@ -0,0 +1,226 @@
+-- Esquema inicial: exatamente o que o AutoMigrate criava na versão anterior às migrações
+-- versionadas (incluindo is_priority, adicionada na inicialização). Usa IF NOT EXISTS para
+-- que esses bancos sejam adotados sem alterações; o que veio depois fica nas migrações seguintes
+-- (tabelas e colunas das funcionalidades novas em 0009_upgrade_existing_schema).
+
+CREATE TABLE IF NOT EXISTS "sectors" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "name" varchar(100) NOT NULL,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_sectors_name" ON "sectors" ("name");
+CREATE INDEX IF NOT EXISTS "idx_sectors_deleted_at" ON "sectors" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "users" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "name" varchar(100) NOT NULL,
+    "email" varchar(100) NOT NULL,
+    "password_hash" varchar(255) NOT NULL,
+    "role" varchar(20) NOT NULL,
+    "sector_id" bigint NOT NULL,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_users_sector" FOREIGN KEY ("sector_id") REFERENCES "sectors"("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_users_email" ON "users" ("email");
+CREATE INDEX IF NOT EXISTS "idx_users_deleted_at" ON "users" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "products" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "name" varchar(255) NOT NULL,
+    "description" text,
+    "unit" varchar(50) NOT NULL,
+    "sector_id" bigint NOT NULL,
+    "status" varchar(20) NOT NULL DEFAULT 'available',
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_products_sector" FOREIGN KEY ("sector_id") REFERENCES "sectors"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_products_deleted_at" ON "products" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "suppliers" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "name" varchar(255) NOT NULL,
+    "cnpj" varchar(20),
+    "contact" varchar(255),
+    "phone" varchar(50),
+    "email" varchar(255),
+    "observations" text,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_suppliers_deleted_at" ON "suppliers" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "purchase_requests" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "requester_id" bigint NOT NULL,
+    "sector_id" bigint NOT NULL,
+    "status" varchar(20) NOT NULL DEFAULT 'pending',
+    "observations" text,
+    "priority" varchar(20) NOT NULL DEFAULT 'normal',
+    "priority_by" bigint,
+    "priority_at" timestamptz,
+    "priority_notes" text,
+    "admin_notes" text,
+    "reviewed_by" bigint,
+    "reviewed_at" timestamptz,
+    "completion_notes" text,
+    "completed_by" bigint,
+    "completed_at" timestamptz,
+    "is_priority" boolean NOT NULL DEFAULT false, -- patch manual da inicialização (removida em 0003)
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_purchase_requests_requester" FOREIGN KEY ("requester_id") REFERENCES "users"("id"),
+    CONSTRAINT "fk_purchase_requests_sector" FOREIGN KEY ("sector_id") REFERENCES "sectors"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_purchase_requests_priority_by" ON "purchase_requests" ("priority_by");
+CREATE INDEX IF NOT EXISTS "idx_purchase_requests_deleted_at" ON "purchase_requests" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "request_items" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "purchase_request_id" bigint NOT NULL,
+    "product_id" bigint NOT NULL,
+    "quantity" bigint NOT NULL,
+    "status" varchar(20) NOT NULL DEFAULT 'pending',
+    "deadline" timestamptz,
+    "admin_notes" text,
+    "suspension_reason" text,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_purchase_requests_items" FOREIGN KEY ("purchase_request_id") REFERENCES "purchase_requests"("id"),
+    CONSTRAINT "fk_request_items_product" FOREIGN KEY ("product_id") REFERENCES "products"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_request_items_deleted_at" ON "request_items" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "attachments" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "purchase_request_id" bigint NOT NULL,
+    "file_name" varchar(255) NOT NULL,
+    "file_path" varchar(512) NOT NULL,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_attachments_purchase_request" FOREIGN KEY ("purchase_request_id") REFERENCES "purchase_requests"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_attachments_deleted_at" ON "attachments" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "item_budgets" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "purchase_request_id" bigint,
+    "request_item_id" bigint,
+    "supplier_id" bigint,
+    "unit_price" decimal,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_item_budgets_supplier" FOREIGN KEY ("supplier_id") REFERENCES "suppliers"("id"),
+    CONSTRAINT "fk_item_budgets_purchase_request" FOREIGN KEY ("purchase_request_id") REFERENCES "purchase_requests"("id"),
+    CONSTRAINT "fk_item_budgets_request_item" FOREIGN KEY ("request_item_id") REFERENCES "request_items"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_item_budgets_deleted_at" ON "item_budgets" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "item_receipts" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "request_item_id" bigint NOT NULL,
+    "quantity_received" bigint NOT NULL,
+    "received_by" bigint NOT NULL,
+    "invoice_number" varchar(100) NOT NULL,
+    "invoice_date" timestamptz,
+    "lot_number" varchar(100),
+    "expiration_date" timestamptz,
+    "supplier_id" bigint,
+    "notes" text,
+    "attachment_path" varchar(512),
+    "receipt_condition" varchar(50) DEFAULT 'good',
+    "quality_checked" boolean DEFAULT false,
+    "quality_notes" text,
+    "rejected_quantity" bigint DEFAULT 0,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_item_receipts_request_item" FOREIGN KEY ("request_item_id") REFERENCES "request_items"("id"),
+    CONSTRAINT "fk_item_receipts_receiver" FOREIGN KEY ("received_by") REFERENCES "users"("id"),
+    CONSTRAINT "fk_item_receipts_supplier" FOREIGN KEY ("supplier_id") REFERENCES "suppliers"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_item_receipts_supplier_id" ON "item_receipts" ("supplier_id");
+CREATE INDEX IF NOT EXISTS "idx_item_receipts_deleted_at" ON "item_receipts" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "product_registration_requests" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "product_name" varchar(255) NOT NULL,
+    "product_description" text,
+    "product_unit" varchar(50) NOT NULL,
+    "justification" text NOT NULL,
+    "requester_id" bigint NOT NULL,
+    "sector_id" bigint NOT NULL,
+    "status" varchar(20) NOT NULL DEFAULT 'pending',
+    "admin_notes" text,
+    "processed_by" bigint,
+    "processed_at" timestamptz,
+    "created_product_id" bigint,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_product_registration_requests_requester" FOREIGN KEY ("requester_id") REFERENCES "users"("id"),
+    CONSTRAINT "fk_product_registration_requests_sector" FOREIGN KEY ("sector_id") REFERENCES "sectors"("id"),
+    CONSTRAINT "fk_product_registration_requests_processor" FOREIGN KEY ("processed_by") REFERENCES "users"("id"),
+    CONSTRAINT "fk_product_registration_requests_created_product" FOREIGN KEY ("created_product_id") REFERENCES "products"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_product_registration_requests_created_product_id" ON "product_registration_requests" ("created_product_id");
+CREATE INDEX IF NOT EXISTS "idx_product_registration_requests_processed_by" ON "product_registration_requests" ("processed_by");
+CREATE INDEX IF NOT EXISTS "idx_product_registration_requests_deleted_at" ON "product_registration_requests" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "company_settings" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "company_name" varchar(255) NOT NULL,
+    "cnpj" varchar(20),
+    "address" text,
+    "phone" varchar(20),
+    "email" varchar(100),
+    "website" varchar(255),
+    "logo_path" varchar(500),
+    "logo_filename" varchar(255),
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_company_settings_deleted_at" ON "company_settings" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "system_settings" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "min_password_length" bigint DEFAULT 6,
+    "require_uppercase" boolean DEFAULT false,
+    "require_lowercase" boolean DEFAULT true,
+    "require_numbers" boolean DEFAULT false,
+    "require_special_chars" boolean DEFAULT false,
+    "password_expiration_days" bigint DEFAULT 0,
+    "session_timeout_minutes" bigint DEFAULT 60,
+    "backup_enabled" boolean DEFAULT false,
+    "backup_frequency" varchar(20) DEFAULT 'daily',
+    "backup_retention" bigint DEFAULT 30,
+    "log_retention_days" bigint DEFAULT 90,
+    "audit_log_enabled" boolean DEFAULT true,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_system_settings_deleted_at" ON "system_settings" ("deleted_at");
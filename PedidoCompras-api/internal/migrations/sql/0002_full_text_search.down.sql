@@ -0,0 +1,6 @@
+-- A extensão unaccent é mantida (pode ser usada por outros objetos do banco)
+DROP INDEX IF EXISTS idx_item_receipts_search;
+DROP INDEX IF EXISTS idx_suppliers_search;
+DROP INDEX IF EXISTS idx_purchase_requests_search;
+DROP INDEX IF EXISTS idx_products_search;
+DROP TEXT SEARCH CONFIGURATION IF EXISTS portuguese_unaccent;
@@ -0,0 +1,32 @@
+-- Busca textual: português com stemming e sem acentos, e índices GIN dos documentos pesquisáveis.
+-- As expressões dos índices devem ser idênticas às de models.SearchDocument*.
+CREATE EXTENSION IF NOT EXISTS unaccent;
+
+-- CREATE TEXT SEARCH CONFIGURATION não tem IF NOT EXISTS
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_ts_config WHERE cfgname = 'portuguese_unaccent') THEN
+        CREATE TEXT SEARCH CONFIGURATION portuguese_unaccent (COPY = portuguese);
+        ALTER TEXT SEARCH CONFIGURATION portuguese_unaccent
+            ALTER MAPPING FOR hword, hword_part, word WITH unaccent, portuguese_stem;
+    END IF;
+END
+$$;
+
+CREATE INDEX IF NOT EXISTS idx_products_search ON products USING GIN ((
+    setweight(to_tsvector('portuguese_unaccent', coalesce(products.name, '')), 'A') ||
+    setweight(to_tsvector('portuguese_unaccent', coalesce(products.description, '')), 'B')
+));
+
+CREATE INDEX IF NOT EXISTS idx_purchase_requests_search ON purchase_requests USING GIN ((
+    setweight(to_tsvector('portuguese_unaccent', coalesce(purchase_requests.observations, '')), 'A') ||
+    setweight(to_tsvector('portuguese_unaccent', coalesce(purchase_requests.admin_notes, '')), 'B')
+));
+
+CREATE INDEX IF NOT EXISTS idx_suppliers_search ON suppliers USING GIN ((
+    to_tsvector('portuguese_unaccent', coalesce(suppliers.name, ''))
+));
+
+CREATE INDEX IF NOT EXISTS idx_item_receipts_search ON item_receipts USING GIN ((
+    to_tsvector('simple', coalesce(item_receipts.invoice_number, '') || ' ' || coalesce(item_receipts.lot_number, ''))
+));
@@ -0,0 +1 @@
+ALTER TABLE purchase_requests ADD COLUMN IF NOT EXISTS is_priority BOOLEAN NOT NULL DEFAULT false;
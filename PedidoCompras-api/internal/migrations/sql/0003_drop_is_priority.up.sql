@@ -0,0 +1,3 @@
+-- is_priority era adicionada por um patch manual na inicialização e nunca foi usada
+-- (a prioridade fica em purchase_requests.priority)
+ALTER TABLE purchase_requests DROP COLUMN IF EXISTS is_priority;
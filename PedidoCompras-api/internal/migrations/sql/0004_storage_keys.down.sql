@@ -0,0 +1,8 @@
+UPDATE attachments SET file_path = 'uploads/' || file_path
+WHERE file_path <> '' AND file_path !~ '^(\./)?uploads/';
+
+UPDATE item_receipts SET attachment_path = 'uploads/' || attachment_path
+WHERE attachment_path <> '' AND attachment_path !~ '^(\./)?uploads/';
+
+UPDATE company_settings SET logo_path = 'uploads/' || logo_path
+WHERE logo_path <> '' AND logo_path !~ '^(\./)?uploads/';
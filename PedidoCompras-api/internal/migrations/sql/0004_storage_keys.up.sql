@@ -0,0 +1,10 @@
+-- Os arquivos passam a ser referenciados por chave no armazenamento (internal/storage),
+-- relativa à raiz do backend. Remove o prefixo "uploads/" dos caminhos gravados antes disso.
+UPDATE attachments SET file_path = regexp_replace(file_path, '^(\./)?uploads/', '')
+WHERE file_path ~ '^(\./)?uploads/';
+
+UPDATE item_receipts SET attachment_path = regexp_replace(attachment_path, '^(\./)?uploads/', '')
+WHERE attachment_path ~ '^(\./)?uploads/';
+
+UPDATE company_settings SET logo_path = regexp_replace(logo_path, '^(\./)?uploads/', '')
+WHERE logo_path ~ '^(\./)?uploads/';
@@ -0,0 +1,6 @@
+ALTER TABLE system_settings DROP COLUMN IF EXISTS "allowed_attachment_types";
+ALTER TABLE system_settings DROP COLUMN IF EXISTS "max_attachment_size_mb";
+
+ALTER TABLE attachments DROP COLUMN IF EXISTS "uploaded_by_id";
+ALTER TABLE attachments DROP COLUMN IF EXISTS "size";
+ALTER TABLE attachments DROP COLUMN IF EXISTS "content_type";
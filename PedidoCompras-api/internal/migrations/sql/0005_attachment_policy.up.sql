@@ -0,0 +1,6 @@
+ALTER TABLE attachments ADD COLUMN IF NOT EXISTS "content_type" varchar(100);
+ALTER TABLE attachments ADD COLUMN IF NOT EXISTS "size" bigint;
+ALTER TABLE attachments ADD COLUMN IF NOT EXISTS "uploaded_by_id" bigint;
+
+ALTER TABLE system_settings ADD COLUMN IF NOT EXISTS "max_attachment_size_mb" bigint DEFAULT 10;
+ALTER TABLE system_settings ADD COLUMN IF NOT EXISTS "allowed_attachment_types" text;
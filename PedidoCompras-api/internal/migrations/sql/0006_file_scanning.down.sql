@@ -0,0 +1,5 @@
+ALTER TABLE item_receipts DROP COLUMN IF EXISTS "attachment_scan_signature";
+ALTER TABLE item_receipts DROP COLUMN IF EXISTS "attachment_scan_status";
+
+ALTER TABLE attachments DROP COLUMN IF EXISTS "scan_signature";
+ALTER TABLE attachments DROP COLUMN IF EXISTS "scan_status";
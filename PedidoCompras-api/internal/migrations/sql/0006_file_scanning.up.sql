@@ -0,0 +1,10 @@
+ALTER TABLE attachments ADD COLUMN IF NOT EXISTS "scan_status" varchar(20) DEFAULT 'quarantined';
+ALTER TABLE attachments ADD COLUMN IF NOT EXISTS "scan_signature" varchar(255);
+
+ALTER TABLE item_receipts ADD COLUMN IF NOT EXISTS "attachment_scan_status" varchar(20);
+ALTER TABLE item_receipts ADD COLUMN IF NOT EXISTS "attachment_scan_signature" varchar(255);
+
+-- Arquivos enviados antes da verificação ficam em quarentena até a primeira verificação
+-- (tarefa periódica da quarentena ou pedidos-admin scan-files)
+UPDATE attachments SET scan_status = 'quarantined';
+UPDATE item_receipts SET attachment_scan_status = 'quarantined' WHERE attachment_path <> '';
@@ -0,0 +1,4 @@
+ALTER TABLE attachments DROP COLUMN IF EXISTS "thumbnail_path";
+ALTER TABLE attachments DROP COLUMN IF EXISTS "page_count";
+ALTER TABLE attachments DROP COLUMN IF EXISTS "height";
+ALTER TABLE attachments DROP COLUMN IF EXISTS "width";
@@ -0,0 +1,4 @@
+ALTER TABLE attachments ADD COLUMN IF NOT EXISTS "width" bigint;
+ALTER TABLE attachments ADD COLUMN IF NOT EXISTS "height" bigint;
+ALTER TABLE attachments ADD COLUMN IF NOT EXISTS "page_count" bigint;
+ALTER TABLE attachments ADD COLUMN IF NOT EXISTS "thumbnail_path" varchar(512);
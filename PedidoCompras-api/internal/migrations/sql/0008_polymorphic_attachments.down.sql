@@ -0,0 +1,25 @@
+ALTER TABLE item_receipts ADD COLUMN IF NOT EXISTS "attachment_path" varchar(512);
+ALTER TABLE item_receipts ADD COLUMN IF NOT EXISTS "attachment_scan_status" varchar(20);
+ALTER TABLE item_receipts ADD COLUMN IF NOT EXISTS "attachment_scan_signature" varchar(255);
+
+-- Volta a NF mais recente de cada recebimento para item_receipts (as demais páginas se perdem)
+UPDATE item_receipts ir
+SET attachment_path = a.file_path,
+    attachment_scan_status = a.scan_status,
+    attachment_scan_signature = a.scan_signature
+FROM (
+    SELECT DISTINCT ON (owner_id) owner_id, file_path, scan_status, scan_signature
+    FROM attachments
+    WHERE owner_type = 'receipt' AND category = 'invoice' AND deleted_at IS NULL
+    ORDER BY owner_id, id DESC
+) a
+WHERE a.owner_id = ir.id;
+
+-- Anexos de itens e orçamentos ficam na requisição; os sem requisição não têm para onde voltar
+DELETE FROM attachments WHERE owner_type = 'receipt' OR purchase_request_id IS NULL;
+
+DROP INDEX IF EXISTS "idx_attachments_owner";
+ALTER TABLE attachments ALTER COLUMN "purchase_request_id" SET NOT NULL;
+ALTER TABLE attachments DROP COLUMN IF EXISTS "category";
+ALTER TABLE attachments DROP COLUMN IF EXISTS "owner_id";
+ALTER TABLE attachments DROP COLUMN IF EXISTS "owner_type";
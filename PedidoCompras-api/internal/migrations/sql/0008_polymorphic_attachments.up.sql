@@ -0,0 +1,27 @@
+-- Anexos passam a ter um dono polimórfico (owner_type, owner_id) e uma categoria
+ALTER TABLE attachments ADD COLUMN IF NOT EXISTS "owner_type" varchar(30);
+ALTER TABLE attachments ADD COLUMN IF NOT EXISTS "owner_id" bigint;
+ALTER TABLE attachments ADD COLUMN IF NOT EXISTS "category" varchar(20) NOT NULL DEFAULT 'other';
+
+UPDATE attachments SET owner_type = 'request', owner_id = purchase_request_id WHERE owner_type IS NULL;
+
+ALTER TABLE attachments ALTER COLUMN "owner_type" SET NOT NULL;
+ALTER TABLE attachments ALTER COLUMN "owner_id" SET NOT NULL;
+ALTER TABLE attachments ALTER COLUMN "purchase_request_id" DROP NOT NULL;
+CREATE INDEX IF NOT EXISTS "idx_attachments_owner" ON "attachments" ("owner_type", "owner_id");
+
+-- A NF digitalizada de cada recebimento vira um anexo do recebimento (categoria invoice)
+INSERT INTO attachments (created_at, updated_at, deleted_at, owner_type, owner_id, category,
+                         purchase_request_id, file_name, file_path, size, scan_status, scan_signature)
+SELECT ir.created_at, ir.updated_at, ir.deleted_at, 'receipt', ir.id, 'invoice',
+       ri.purchase_request_id,
+       'NF_' || ir.invoice_number || '_' || to_char(ir.created_at, 'YYYYMMDD') ||
+           COALESCE(substring(ir.attachment_path from '\.[^./]+$'), ''),
+       ir.attachment_path, 0, COALESCE(ir.attachment_scan_status, 'quarantined'), ir.attachment_scan_signature
+FROM item_receipts ir
+JOIN request_items ri ON ri.id = ir.request_item_id
+WHERE COALESCE(ir.attachment_path, '') <> '';
+
+ALTER TABLE item_receipts DROP COLUMN IF EXISTS "attachment_scan_signature";
+ALTER TABLE item_receipts DROP COLUMN IF EXISTS "attachment_scan_status";
+ALTER TABLE item_receipts DROP COLUMN IF EXISTS "attachment_path";
@@ -0,0 +1,47 @@
+-- Volta ao esquema inicial: remove as tabelas e colunas das funcionalidades posteriores (apaga esses dados)
+ALTER TABLE "item_receipts" DROP CONSTRAINT IF EXISTS "fk_item_receipts_purchase_order_line";
+ALTER TABLE "item_receipts" DROP COLUMN IF EXISTS "purchase_order_line_id";
+
+DROP TABLE IF EXISTS "sector_budget_entries" CASCADE;
+DROP TABLE IF EXISTS "sector_budgets" CASCADE;
+DROP TABLE IF EXISTS "request_comments" CASCADE;
+DROP TABLE IF EXISTS "role_permissions" CASCADE;
+DROP TABLE IF EXISTS "roles" CASCADE;
+DROP TABLE IF EXISTS "password_reset_tokens" CASCADE;
+DROP TABLE IF EXISTS "login_throttles" CASCADE;
+DROP TABLE IF EXISTS "login_attempts" CASCADE;
+DROP TABLE IF EXISTS "refresh_tokens" CASCADE;
+DROP TABLE IF EXISTS "user_sessions" CASCADE;
+DROP TABLE IF EXISTS "audit_logs" CASCADE;
+DROP TABLE IF EXISTS "request_status_history" CASCADE;
+DROP TABLE IF EXISTS "request_approval_steps" CASCADE;
+DROP TABLE IF EXISTS "approval_policy_steps" CASCADE;
+DROP TABLE IF EXISTS "approval_policies" CASCADE;
+DROP TABLE IF EXISTS "purchase_order_lines" CASCADE;
+DROP TABLE IF EXISTS "purchase_orders" CASCADE;
+
+ALTER TABLE "system_settings" DROP COLUMN IF EXISTS "min_quotes_per_item";
+ALTER TABLE "system_settings" DROP COLUMN IF EXISTS "max_lockout_minutes";
+ALTER TABLE "system_settings" DROP COLUMN IF EXISTS "lockout_minutes";
+ALTER TABLE "system_settings" DROP COLUMN IF EXISTS "login_attempt_window_minutes";
+ALTER TABLE "system_settings" DROP COLUMN IF EXISTS "max_login_attempts_per_ip";
+ALTER TABLE "system_settings" DROP COLUMN IF EXISTS "max_login_attempts";
+
+ALTER TABLE "item_budgets" DROP COLUMN IF EXISTS "selected_at";
+ALTER TABLE "item_budgets" DROP COLUMN IF EXISTS "selected_by";
+ALTER TABLE "item_budgets" DROP COLUMN IF EXISTS "selection_justification";
+ALTER TABLE "item_budgets" DROP COLUMN IF EXISTS "selected";
+ALTER TABLE "request_items" DROP COLUMN IF EXISTS "estimated_unit_price";
+
+ALTER TABLE "purchase_requests" DROP COLUMN IF EXISTS "sector_approval_notes";
+ALTER TABLE "purchase_requests" DROP COLUMN IF EXISTS "sector_approved_at";
+ALTER TABLE "purchase_requests" DROP COLUMN IF EXISTS "sector_approved_by";
+ALTER TABLE "purchase_requests" DROP COLUMN IF EXISTS "sector_approval_status";
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "password_changed_at";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "tokens_valid_after";
+
+ALTER TABLE "sectors" DROP CONSTRAINT IF EXISTS "fk_sectors_children";
+ALTER TABLE "sectors" DROP COLUMN IF EXISTS "manager_id";
+ALTER TABLE "sectors" DROP COLUMN IF EXISTS "parent_id";
+ALTER TABLE "sectors" DROP COLUMN IF EXISTS "cost_center";
@@ -0,0 +1,349 @@
+-- Tabelas e colunas das funcionalidades posteriores ao esquema inicial (0001).
+-- Bancos vindos do AutoMigrate antigo estão em 0001 e recebem tudo aqui; bancos criados
+-- com uma versão anterior desta migração já têm esses objetos (tudo usa IF NOT EXISTS).
+
+-- Hierarquia, centro de custo e gestor dos setores
+ALTER TABLE "sectors" ADD COLUMN IF NOT EXISTS "cost_center" varchar(30);
+ALTER TABLE "sectors" ADD COLUMN IF NOT EXISTS "parent_id" bigint;
+ALTER TABLE "sectors" ADD COLUMN IF NOT EXISTS "manager_id" bigint;
+CREATE INDEX IF NOT EXISTS "idx_sectors_manager_id" ON "sectors" ("manager_id");
+CREATE INDEX IF NOT EXISTS "idx_sectors_parent_id" ON "sectors" ("parent_id");
+CREATE INDEX IF NOT EXISTS "idx_sectors_cost_center" ON "sectors" ("cost_center");
+
+-- Sessões e política de senha
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "tokens_valid_after" timestamptz;
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "password_changed_at" timestamptz;
+
+-- Pré-aprovação do gestor do setor (requisições existentes não dependem dela)
+ALTER TABLE "purchase_requests" ADD COLUMN IF NOT EXISTS "sector_approval_status" varchar(20) NOT NULL DEFAULT 'not_required';
+ALTER TABLE "purchase_requests" ADD COLUMN IF NOT EXISTS "sector_approved_by" bigint;
+ALTER TABLE "purchase_requests" ADD COLUMN IF NOT EXISTS "sector_approved_at" timestamptz;
+ALTER TABLE "purchase_requests" ADD COLUMN IF NOT EXISTS "sector_approval_notes" text;
+
+-- Valores dos itens e seleção do orçamento vencedor
+ALTER TABLE "request_items" ADD COLUMN IF NOT EXISTS "estimated_unit_price" decimal;
+ALTER TABLE "item_budgets" ADD COLUMN IF NOT EXISTS "selected" boolean NOT NULL DEFAULT false;
+ALTER TABLE "item_budgets" ADD COLUMN IF NOT EXISTS "selection_justification" text;
+ALTER TABLE "item_budgets" ADD COLUMN IF NOT EXISTS "selected_by" bigint;
+ALTER TABLE "item_budgets" ADD COLUMN IF NOT EXISTS "selected_at" timestamptz;
+
+-- Bloqueio de login e cotações mínimas
+ALTER TABLE "system_settings" ADD COLUMN IF NOT EXISTS "max_login_attempts" bigint DEFAULT 5;
+ALTER TABLE "system_settings" ADD COLUMN IF NOT EXISTS "max_login_attempts_per_ip" bigint DEFAULT 20;
+ALTER TABLE "system_settings" ADD COLUMN IF NOT EXISTS "login_attempt_window_minutes" bigint DEFAULT 15;
+ALTER TABLE "system_settings" ADD COLUMN IF NOT EXISTS "lockout_minutes" bigint DEFAULT 15;
+ALTER TABLE "system_settings" ADD COLUMN IF NOT EXISTS "max_lockout_minutes" bigint DEFAULT 1440;
+ALTER TABLE "system_settings" ADD COLUMN IF NOT EXISTS "min_quotes_per_item" bigint DEFAULT 0;
+ALTER TABLE "system_settings" ALTER COLUMN "min_quotes_per_item" SET DEFAULT 0; -- 0 = sem exigência
+
+CREATE TABLE IF NOT EXISTS "purchase_orders" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "sequence" bigint NOT NULL,
+    "number" varchar(20) NOT NULL,
+    "supplier_id" bigint NOT NULL,
+    "status" varchar(20) NOT NULL DEFAULT 'draft',
+    "notes" text,
+    "total_quantity" bigint NOT NULL DEFAULT 0,
+    "total_amount" decimal NOT NULL DEFAULT 0,
+    "created_by" bigint NOT NULL,
+    "issued_at" timestamptz,
+    "acknowledged_at" timestamptz,
+    "received_at" timestamptz,
+    "cancelled_at" timestamptz,
+    "cancellation_reason" text,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_purchase_orders_supplier" FOREIGN KEY ("supplier_id") REFERENCES "suppliers"("id"),
+    CONSTRAINT "fk_purchase_orders_creator" FOREIGN KEY ("created_by") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_purchase_orders_supplier_id" ON "purchase_orders" ("supplier_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_purchase_orders_number" ON "purchase_orders" ("number");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_purchase_orders_sequence" ON "purchase_orders" ("sequence");
+CREATE INDEX IF NOT EXISTS "idx_purchase_orders_deleted_at" ON "purchase_orders" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "purchase_order_lines" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "purchase_order_id" bigint NOT NULL,
+    "purchase_request_id" bigint NOT NULL,
+    "request_item_id" bigint NOT NULL,
+    "item_budget_id" bigint NOT NULL,
+    "quantity" bigint NOT NULL,
+    "unit_price" decimal NOT NULL,
+    "total_price" decimal NOT NULL,
+    "quantity_received" bigint NOT NULL DEFAULT 0,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_purchase_order_lines_request_item" FOREIGN KEY ("request_item_id") REFERENCES "request_items"("id"),
+    CONSTRAINT "fk_purchase_order_lines_item_budget" FOREIGN KEY ("item_budget_id") REFERENCES "item_budgets"("id"),
+    CONSTRAINT "fk_purchase_orders_lines" FOREIGN KEY ("purchase_order_id") REFERENCES "purchase_orders"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_purchase_order_lines_request_item_id" ON "purchase_order_lines" ("request_item_id");
+CREATE INDEX IF NOT EXISTS "idx_purchase_order_lines_purchase_request_id" ON "purchase_order_lines" ("purchase_request_id");
+CREATE INDEX IF NOT EXISTS "idx_purchase_order_lines_purchase_order_id" ON "purchase_order_lines" ("purchase_order_id");
+CREATE INDEX IF NOT EXISTS "idx_purchase_order_lines_deleted_at" ON "purchase_order_lines" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "approval_policies" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "name" varchar(100) NOT NULL,
+    "active" boolean NOT NULL DEFAULT true,
+    "sector_id" bigint,
+    "min_estimated_value" decimal NOT NULL DEFAULT 0,
+    "urgent_only" boolean NOT NULL DEFAULT false,
+    "priority" bigint NOT NULL DEFAULT 0,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_approval_policies_sector" FOREIGN KEY ("sector_id") REFERENCES "sectors"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_approval_policies_sector_id" ON "approval_policies" ("sector_id");
+CREATE INDEX IF NOT EXISTS "idx_approval_policies_deleted_at" ON "approval_policies" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "approval_policy_steps" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "approval_policy_id" bigint NOT NULL,
+    "step_order" bigint NOT NULL,
+    "name" varchar(100) NOT NULL,
+    "approver_type" varchar(20) NOT NULL,
+    "approver_user_id" bigint,
+    "approver_role" varchar(20),
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_approval_policy_steps_approver_user" FOREIGN KEY ("approver_user_id") REFERENCES "users"("id"),
+    CONSTRAINT "fk_approval_policies_steps" FOREIGN KEY ("approval_policy_id") REFERENCES "approval_policies"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_approval_policy_steps_approver_user_id" ON "approval_policy_steps" ("approver_user_id");
+CREATE INDEX IF NOT EXISTS "idx_approval_policy_steps_approval_policy_id" ON "approval_policy_steps" ("approval_policy_id");
+CREATE INDEX IF NOT EXISTS "idx_approval_policy_steps_deleted_at" ON "approval_policy_steps" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "request_approval_steps" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "purchase_request_id" bigint NOT NULL,
+    "approval_policy_id" bigint NOT NULL,
+    "policy_step_id" bigint NOT NULL,
+    "sequence" bigint NOT NULL,
+    "request_value" decimal NOT NULL DEFAULT 0,
+    "name" varchar(100) NOT NULL,
+    "approver_type" varchar(20) NOT NULL,
+    "approver_user_id" bigint,
+    "approver_role" varchar(20),
+    "status" varchar(20) NOT NULL DEFAULT 'pending',
+    "decided_by" bigint,
+    "decided_at" timestamptz,
+    "comment" text,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_request_approval_steps_approver_user" FOREIGN KEY ("approver_user_id") REFERENCES "users"("id"),
+    CONSTRAINT "fk_request_approval_steps_decider" FOREIGN KEY ("decided_by") REFERENCES "users"("id"),
+    CONSTRAINT "fk_purchase_requests_approval_steps" FOREIGN KEY ("purchase_request_id") REFERENCES "purchase_requests"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_request_approval_steps_approver_user_id" ON "request_approval_steps" ("approver_user_id");
+CREATE INDEX IF NOT EXISTS "idx_request_approval_steps_purchase_request_id" ON "request_approval_steps" ("purchase_request_id");
+CREATE INDEX IF NOT EXISTS "idx_request_approval_steps_deleted_at" ON "request_approval_steps" ("deleted_at");
+-- Valor em que a cadeia foi instanciada (a cadeia é refeita quando o valor muda)
+ALTER TABLE "request_approval_steps" ADD COLUMN IF NOT EXISTS "request_value" decimal NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS "request_status_history" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "purchase_request_id" bigint NOT NULL,
+    "request_item_id" bigint,
+    "from_status" varchar(20),
+    "to_status" varchar(20) NOT NULL,
+    "changed_by" bigint,
+    "notes" text,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_request_status_history_changer" FOREIGN KEY ("changed_by") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_request_status_history_request_item_id" ON "request_status_history" ("request_item_id");
+CREATE INDEX IF NOT EXISTS "idx_request_status_history_purchase_request_id" ON "request_status_history" ("purchase_request_id");
+
+CREATE TABLE IF NOT EXISTS "audit_logs" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "actor_id" bigint,
+    "actor_name" varchar(100),
+    "actor_email" varchar(100),
+    "actor_role" varchar(20),
+    "entity" varchar(50) NOT NULL,
+    "entity_id" bigint,
+    "action" varchar(50) NOT NULL,
+    "changes" text,
+    "method" varchar(10),
+    "path" varchar(255),
+    "status_code" bigint,
+    "ip_address" varchar(45),
+    "user_agent" varchar(500),
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_audit_logs_action" ON "audit_logs" ("action");
+CREATE INDEX IF NOT EXISTS "idx_audit_logs_entity_id" ON "audit_logs" ("entity_id");
+CREATE INDEX IF NOT EXISTS "idx_audit_logs_entity" ON "audit_logs" ("entity");
+CREATE INDEX IF NOT EXISTS "idx_audit_logs_actor_id" ON "audit_logs" ("actor_id");
+CREATE INDEX IF NOT EXISTS "idx_audit_logs_created_at" ON "audit_logs" ("created_at");
+
+CREATE TABLE IF NOT EXISTS "user_sessions" (
+    "id" varchar(64),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "user_id" bigint NOT NULL,
+    "last_used_at" timestamptz,
+    "revoked_at" timestamptz,
+    "revoked_reason" varchar(50),
+    "ip_address" varchar(45),
+    "user_agent" varchar(500),
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_user_sessions_user" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_user_sessions_user_id" ON "user_sessions" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "refresh_tokens" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "session_id" varchar(64) NOT NULL,
+    "user_id" bigint NOT NULL,
+    "token_hash" varchar(64) NOT NULL,
+    "expires_at" timestamptz NOT NULL,
+    "used_at" timestamptz,
+    "revoked_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_refresh_tokens_token_hash" ON "refresh_tokens" ("token_hash");
+CREATE INDEX IF NOT EXISTS "idx_refresh_tokens_user_id" ON "refresh_tokens" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_refresh_tokens_session_id" ON "refresh_tokens" ("session_id");
+
+CREATE TABLE IF NOT EXISTS "login_attempts" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "email" varchar(100),
+    "user_id" bigint,
+    "ip_address" varchar(45),
+    "user_agent" varchar(500),
+    "success" boolean,
+    "reason" varchar(30),
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_login_attempts_ip_address" ON "login_attempts" ("ip_address");
+CREATE INDEX IF NOT EXISTS "idx_login_attempts_user_id" ON "login_attempts" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_login_attempts_email" ON "login_attempts" ("email");
+CREATE INDEX IF NOT EXISTS "idx_login_attempts_created_at" ON "login_attempts" ("created_at");
+
+CREATE TABLE IF NOT EXISTS "login_throttles" (
+    "key" varchar(100),
+    "updated_at" timestamptz,
+    "failures" bigint NOT NULL DEFAULT 0,
+    "last_failure_at" timestamptz,
+    "lockout_count" bigint NOT NULL DEFAULT 0,
+    "locked_until" timestamptz,
+    PRIMARY KEY ("key")
+);
+
+CREATE TABLE IF NOT EXISTS "password_reset_tokens" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "user_id" bigint NOT NULL,
+    "token_hash" varchar(64) NOT NULL,
+    "expires_at" timestamptz NOT NULL,
+    "used_at" timestamptz,
+    "request_ip" varchar(45),
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_password_reset_tokens_user" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_password_reset_tokens_token_hash" ON "password_reset_tokens" ("token_hash");
+CREATE INDEX IF NOT EXISTS "idx_password_reset_tokens_user_id" ON "password_reset_tokens" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "roles" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "name" varchar(20) NOT NULL,
+    "description" varchar(255),
+    "is_system" boolean NOT NULL DEFAULT false,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_roles_name" ON "roles" ("name");
+
+CREATE TABLE IF NOT EXISTS "role_permissions" (
+    "id" bigserial,
+    "role_id" bigint NOT NULL,
+    "permission" varchar(50) NOT NULL,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_roles_permissions" FOREIGN KEY ("role_id") REFERENCES "roles"("id") ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_role_permission" ON "role_permissions" ("role_id","permission");
+
+CREATE TABLE IF NOT EXISTS "request_comments" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "purchase_request_id" bigint NOT NULL,
+    "author_id" bigint NOT NULL,
+    "body" text NOT NULL,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_request_comments_author" FOREIGN KEY ("author_id") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_request_comments_purchase_request_id" ON "request_comments" ("purchase_request_id");
+CREATE INDEX IF NOT EXISTS "idx_request_comments_deleted_at" ON "request_comments" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "sector_budgets" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "sector_id" bigint NOT NULL,
+    "period_type" varchar(20) NOT NULL,
+    "period_start" date NOT NULL,
+    "period_end" date NOT NULL,
+    "amount" decimal NOT NULL,
+    "enforcement" varchar(10) NOT NULL DEFAULT 'warn',
+    "notes" text,
+    "created_by" bigint NOT NULL,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_sector_budgets_sector" FOREIGN KEY ("sector_id") REFERENCES "sectors"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_sector_budgets_sector_id" ON "sector_budgets" ("sector_id");
+CREATE INDEX IF NOT EXISTS "idx_sector_budgets_deleted_at" ON "sector_budgets" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "sector_budget_entries" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "sector_budget_id" bigint NOT NULL,
+    "purchase_request_id" bigint NOT NULL,
+    "request_item_id" bigint NOT NULL,
+    "item_receipt_id" bigint,
+    "type" varchar(20) NOT NULL,
+    "amount" decimal NOT NULL,
+    "created_by" bigint,
+    "notes" varchar(255),
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_sector_budget_entries_item_receipt_id" ON "sector_budget_entries" ("item_receipt_id");
+CREATE INDEX IF NOT EXISTS "idx_sector_budget_entries_request_item_id" ON "sector_budget_entries" ("request_item_id");
+CREATE INDEX IF NOT EXISTS "idx_sector_budget_entries_purchase_request_id" ON "sector_budget_entries" ("purchase_request_id");
+CREATE INDEX IF NOT EXISTS "idx_sector_budget_entries_sector_budget_id" ON "sector_budget_entries" ("sector_budget_id");
+
+
+-- Linha do pedido de compra atendida por cada recebimento
+ALTER TABLE "item_receipts" ADD COLUMN IF NOT EXISTS "purchase_order_line_id" bigint;
+CREATE INDEX IF NOT EXISTS "idx_item_receipts_purchase_order_line_id" ON "item_receipts" ("purchase_order_line_id");
+
+-- ADD CONSTRAINT não tem IF NOT EXISTS
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_sectors_children') THEN
+        ALTER TABLE "sectors" ADD CONSTRAINT "fk_sectors_children" FOREIGN KEY ("parent_id") REFERENCES "sectors"("id");
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_item_receipts_purchase_order_line') THEN
+        ALTER TABLE "item_receipts" ADD CONSTRAINT "fk_item_receipts_purchase_order_line" FOREIGN KEY ("purchase_order_line_id") REFERENCES "purchase_order_lines"("id");
+    END IF;
+END
+$$;
@@ -0,0 +1,122 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ApprovalPolicy - Política de aprovação aplicada às requisições que atendem às condições
+type ApprovalPolicy struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+
+	Name   string `gorm:"size:100;not null"`
+	Active bool   `gorm:"not null;default:true"`
+
+	// Condições (todas precisam ser atendidas)
+	SectorID          *uint   `gorm:"index"` // nil = qualquer setor
+	Sector            *Sector `gorm:"foreignKey:SectorID"`
+	MinEstimatedValue float64 `gorm:"not null;default:0"`     // valor estimado mínimo (R$)
+	UrgentOnly        bool    `gorm:"not null;default:false"` // aplica apenas a requisições urgentes
+
+	// Ordem de avaliação: políticas com menor Priority geram as primeiras etapas
+	Priority int `gorm:"not null;default:0"`
+
+	Steps []ApprovalPolicyStep `gorm:"foreignKey:ApprovalPolicyID"`
+}
+
+// ApprovalPolicyStep - Etapa de uma política (quem precisa aprovar)
+type ApprovalPolicyStep struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+
+	ApprovalPolicyID uint `gorm:"not null;index"`
+
+	StepOrder      int    `gorm:"not null"`
+	Name           string `gorm:"size:100;not null"`         // ex: "Gestor do setor", "Diretor financeiro"
+	ApproverType   string `gorm:"size:20;not null"`          // user, role
+	ApproverUserID *uint  `gorm:"index"`                     // quando ApproverType = user
+	ApproverUser   *User  `gorm:"foreignKey:ApproverUserID"` // aprovador fixo
+	ApproverRole   string `gorm:"size:20"`                   // quando ApproverType = role
+}
+
+// RequestApprovalStep - Etapa de aprovação instanciada para uma requisição
+type RequestApprovalStep struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+
+	PurchaseRequestID uint `gorm:"not null;index"`
+	ApprovalPolicyID  uint `gorm:"not null"`
+	PolicyStepID      uint `gorm:"not null"`
+
+	Sequence       int     `gorm:"not null"`           // ordem global na cadeia da requisição
+	RequestValue   float64 `gorm:"not null;default:0"` // valor estimado quando a cadeia foi instanciada
+	Name           string  `gorm:"size:100;not null"`
+	ApproverType   string  `gorm:"size:20;not null"`
+	ApproverUserID *uint   `gorm:"index"`
+	ApproverUser   *User   `gorm:"foreignKey:ApproverUserID"`
+	ApproverRole   string  `gorm:"size:20"`
+
+	// Decisão
+	Status    string `gorm:"size:20;not null;default:'pending'"` // pending, approved, rejected, skipped
+	DecidedBy *uint
+	Decider   *User `gorm:"foreignKey:DecidedBy"`
+	DecidedAt *time.Time
+	Comment   string `gorm:"type:text"`
+}
+
+// Constantes de tipo de aprovador
+const (
+	ApproverTypeUser = "user"
+	ApproverTypeRole = "role"
+)
+
+// Constantes de status das etapas de aprovação
+const (
+	ApprovalStepPending  = "pending"
+	ApprovalStepApproved = "approved"
+	ApprovalStepRejected = "rejected"
+	ApprovalStepSkipped  = "skipped"
+)
+
+// Matches verifica se a política se aplica à requisição com o valor estimado informado
+func (p *ApprovalPolicy) Matches(request *PurchaseRequest, estimatedValue float64) bool {
+	if !p.Active {
+		return false
+	}
+	if p.SectorID != nil && *p.SectorID != request.SectorID {
+		return false
+	}
+	if estimatedValue < p.MinEstimatedValue {
+		return false
+	}
+	if p.UrgentOnly && !request.IsUrgent() {
+		return false
+	}
+	return true
+}
+
+// CanBeDecidedBy verifica se o usuário pode decidir esta etapa. role é o papel com que o
+// usuário decide etapas por papel (vazio quando ele não tem approvals:decide)
+func (s *RequestApprovalStep) CanBeDecidedBy(userID uint, role string) bool {
+	switch s.ApproverType {
+	case ApproverTypeUser:
+		return s.ApproverUserID != nil && *s.ApproverUserID == userID
+	case ApproverTypeRole:
+		return role != "" && s.ApproverRole == role
+	default:
+		return false
+	}
+}
+
+// IsPending indica se a etapa ainda aguarda decisão
+func (s *RequestApprovalStep) IsPending() bool {
+	return s.Status == ApprovalStepPending
+}
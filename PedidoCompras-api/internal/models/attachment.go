@@ -1,6 +1,7 @@
 package models
 
 import (
+	"fmt"
 	"time"
 
 	"gorm.io/gorm"
@@ -12,9 +13,90 @@ type Attachment struct {
 	UpdatedAt time.Time
 	DeletedAt gorm.DeletedAt `gorm:"index"`
 
-	PurchaseRequestID uint            `gorm:"not null"`
-	PurchaseRequest   PurchaseRequest `gorm:"foreignKey:PurchaseRequestID"`
+	// Dono do anexo (polimórfico): requisição, item, orçamento, recebimento,
+	// fornecedor ou pedido de cadastro de produto; vários arquivos por dono
+	OwnerType string `gorm:"size:30;not null;index:idx_attachments_owner"`
+	OwnerID   uint   `gorm:"not null;index:idx_attachments_owner"`
+	Category  string `gorm:"size:20;not null;default:'other'"` // quote, invoice, spec, contract, photo, other
+
+	// Requisição a que o dono pertence (vazio para fornecedores e pedidos de cadastro)
+	PurchaseRequestID *uint
+	PurchaseRequest   *PurchaseRequest `gorm:"foreignKey:PurchaseRequestID"`
 
 	FileName string `gorm:"size:255;not null"`
-	FilePath string `gorm:"size:512;not null"` // caminho no disco
+	FilePath string `gorm:"size:512;not null"` // chave no armazenamento (storage)
+
+	ContentType  string `gorm:"size:100"` // tipo detectado pelo conteúdo no upload
+	Size         int64
+	UploadedByID *uint // vazio em anexos anteriores ao registro do autor
+
+	// Verificação de malware: quarantined até o scanner responder; infected não pode ser baixado
+	ScanStatus    string `gorm:"size:20;default:'quarantined'"`
+	ScanSignature string `gorm:"size:255"` // ameaça encontrada
+
+	// Pré-visualização (imagens e PDFs): dimensões em pixels, ou em pontos da primeira página do PDF
+	Width         int
+	Height        int
+	PageCount     int
+	ThumbnailPath string `gorm:"size:512"` // chave da miniatura JPEG no armazenamento; vazio = sem miniatura
+}
+
+// Situação da verificação de malware dos arquivos enviados
+const (
+	ScanStatusQuarantined = "quarantined" // aguardando verificação (ou scanner indisponível)
+	ScanStatusClean       = "clean"
+	ScanStatusInfected    = "infected"
+)
+
+// Donos possíveis de um anexo (OwnerType)
+const (
+	AttachmentOwnerRequest        = "request"
+	AttachmentOwnerItem           = "item"
+	AttachmentOwnerBudget         = "budget"
+	AttachmentOwnerReceipt        = "receipt"
+	AttachmentOwnerSupplier       = "supplier"
+	AttachmentOwnerProductRequest = "product_request"
+)
+
+// Categorias de anexo
+const (
+	AttachmentCategoryQuote    = "quote"    // cotação/proposta do fornecedor
+	AttachmentCategoryInvoice  = "invoice"  // nota fiscal (uma ou mais páginas)
+	AttachmentCategorySpec     = "spec"     // especificação/ficha técnica
+	AttachmentCategoryContract = "contract" // contrato
+	AttachmentCategoryPhoto    = "photo"
+	AttachmentCategoryOther    = "other" // padrão quando não informada
+)
+
+// AttachmentCategories - Categorias aceitas no upload
+var AttachmentCategories = []string{
+	AttachmentCategoryQuote,
+	AttachmentCategoryInvoice,
+	AttachmentCategorySpec,
+	AttachmentCategoryContract,
+	AttachmentCategoryPhoto,
+	AttachmentCategoryOther,
+}
+
+// IsValidAttachmentCategory verifica se a categoria existe
+func IsValidAttachmentCategory(category string) bool {
+	for _, c := range AttachmentCategories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+// StorageDir - Pasta do anexo no armazenamento: a da requisição quando o dono
+// pertence a uma, senão a do fornecedor ou do pedido de cadastro
+func (a Attachment) StorageDir() string {
+	switch {
+	case a.PurchaseRequestID != nil:
+		return fmt.Sprintf("requests/%d", *a.PurchaseRequestID)
+	case a.OwnerType == AttachmentOwnerSupplier:
+		return fmt.Sprintf("suppliers/%d", a.OwnerID)
+	default:
+		return fmt.Sprintf("product-requests/%d", a.OwnerID)
+	}
 }
@@ -0,0 +1,123 @@
+package models
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+// Padrões da política de anexos (usados enquanto SystemSettings não define os valores)
+const (
+	DefaultMaxAttachmentSizeMB = 10
+	DefaultAttachmentTypes     = "application/pdf,image/jpeg,image/png,image/gif,image/webp,text/plain,text/csv," +
+		"application/msword,application/vnd.openxmlformats-officedocument.wordprocessingml.document," +
+		"application/vnd.ms-excel,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet," +
+		"application/vnd.oasis.opendocument.text,application/vnd.oasis.opendocument.spreadsheet"
+)
+
+// attachmentExtensionTypes - Tipo esperado para cada extensão conhecida
+var attachmentExtensionTypes = map[string]string{
+	".pdf":  "application/pdf",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".png":  "image/png",
+	".gif":  "image/gif",
+	".webp": "image/webp",
+	".txt":  "text/plain",
+	".csv":  "text/csv",
+	".doc":  "application/msword",
+	".xls":  "application/vnd.ms-excel",
+	".docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	".xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	".odt":  "application/vnd.oasis.opendocument.text",
+	".ods":  "application/vnd.oasis.opendocument.spreadsheet",
+	".zip":  "application/zip",
+}
+
+// oleSignature - Início dos arquivos do Office 97-2003 (.doc, .xls)
+var oleSignature = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+
+// AttachmentMaxBytes - Tamanho máximo de um anexo em bytes
+func (s SystemSettings) AttachmentMaxBytes() int64 {
+	sizeMB := s.MaxAttachmentSizeMB
+	if sizeMB <= 0 {
+		sizeMB = DefaultMaxAttachmentSizeMB
+	}
+	return int64(sizeMB) << 20
+}
+
+// AttachmentTypes - Tipos MIME aceitos nos anexos
+func (s SystemSettings) AttachmentTypes() []string {
+	list := s.AllowedAttachmentTypes
+	if strings.TrimSpace(list) == "" {
+		list = DefaultAttachmentTypes
+	}
+	types := []string{}
+	for _, t := range strings.Split(list, ",") {
+		if t = strings.ToLower(strings.TrimSpace(t)); t != "" {
+			types = append(types, t)
+		}
+	}
+	return types
+}
+
+// ValidateAttachment confere tamanho e tipo do arquivo. O tipo vem do conteúdo
+// (head = primeiros bytes, ao menos 512) e precisa bater com a extensão do nome,
+// que deve ser uma das conhecidas.
+// Devolve o tipo detectado ou a mensagem para o usuário.
+func (s SystemSettings) ValidateAttachment(filename string, size int64, head []byte) (string, error) {
+	if size <= 0 {
+		return "", fmt.Errorf("Arquivo vazio")
+	}
+	if maxBytes := s.AttachmentMaxBytes(); size > maxBytes {
+		return "", fmt.Errorf("Arquivo muito grande. Máximo %d MB", maxBytes>>20)
+	}
+
+	// Só extensões conhecidas: o tipo servido no download é sempre o validado aqui,
+	// e extensões como .html ou .svg passariam como text/plain
+	ext := strings.ToLower(filepath.Ext(filename))
+	expected, known := attachmentExtensionTypes[ext]
+	if !known {
+		return "", fmt.Errorf("Extensão de arquivo não permitida (%s)", ext)
+	}
+	detected := DetectAttachmentType(ext, head)
+	if expected != detected {
+		return "", fmt.Errorf("O conteúdo do arquivo (%s) não corresponde à extensão %s", detected, ext)
+	}
+
+	for _, allowed := range s.AttachmentTypes() {
+		if allowed == detected {
+			return detected, nil
+		}
+	}
+	return "", fmt.Errorf("Tipo de arquivo não permitido (%s)", detected)
+}
+
+// DetectAttachmentType identifica o tipo pelo conteúdo (http.DetectContentType),
+// usando a extensão apenas para distinguir formatos com a mesma assinatura
+// (documentos do Office/ODF são ZIP ou OLE; CSV é texto)
+func DetectAttachmentType(ext string, head []byte) string {
+	detected := http.DetectContentType(head)
+	if i := strings.Index(detected, ";"); i >= 0 {
+		detected = detected[:i]
+	}
+
+	switch detected {
+	case "application/zip":
+		switch ext {
+		case ".docx", ".xlsx", ".odt", ".ods":
+			return attachmentExtensionTypes[ext]
+		}
+	case "application/octet-stream":
+		if bytes.HasPrefix(head, oleSignature) && (ext == ".doc" || ext == ".xls") {
+			return attachmentExtensionTypes[ext]
+		}
+	case "text/plain":
+		if ext == ".csv" {
+			return "text/csv"
+		}
+	}
+	return detected
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestValidateAttachment(t *testing.T) {
+	pdf := []byte("%PDF-1.4\n1 0 obj\n<<>>\nendobj\n")
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	zip := []byte("PK\x03\x04\x14\x00\x00\x00\x08\x00")
+	ole := append(bytes.Clone(oleSignature), make([]byte, 32)...)
+	html := []byte("<!DOCTYPE html><html><script>alert(1)</script></html>")
+
+	defaults := SystemSettings{}
+	onlyPDF := SystemSettings{AllowedAttachmentTypes: " Application/PDF , "}
+	smallLimit := SystemSettings{MaxAttachmentSizeMB: 1}
+
+	tests := []struct {
+		name     string
+		settings SystemSettings
+		filename string
+		size     int64
+		head     []byte
+		want     string // tipo detectado; vazio quando deve ser rejeitado
+		errMsg   string
+	}{
+		{"pdf", defaults, "orcamento.pdf", 100, pdf, "application/pdf", ""},
+		{"extensão em maiúsculas", defaults, "FOTO.PNG", 100, png, "image/png", ""},
+		{"texto", defaults, "notas.txt", 10, []byte("pedido 123"), "text/plain", ""},
+		{"csv é texto", defaults, "itens.csv", 10, []byte("a;b;c\n1;2;3"), "text/csv", ""},
+		{"docx é zip", defaults, "proposta.docx", 100, zip, "application/vnd.openxmlformats-officedocument.wordprocessingml.document", ""},
+		{"xls é OLE", defaults, "planilha.xls", 100, ole, "application/vnd.ms-excel", ""},
+
+		{"vazio", defaults, "a.pdf", 0, pdf, "", "Arquivo vazio"},
+		{"acima do padrão de 10 MB", defaults, "a.pdf", 10<<20 + 1, pdf, "", "Máximo 10 MB"},
+		{"acima do limite configurado", smallLimit, "a.pdf", 1<<20 + 1, pdf, "", "Máximo 1 MB"},
+		{"no limite", smallLimit, "a.pdf", 1 << 20, pdf, "application/pdf", ""},
+		{"html", defaults, "pagina.html", 100, html, "", "Extensão de arquivo não permitida (.html)"},
+		{"svg", defaults, "logo.svg", 100, []byte("<svg/>"), "", "Extensão de arquivo não permitida (.svg)"},
+		{"sem extensão", defaults, "LEIAME", 100, pdf, "", "Extensão de arquivo não permitida"},
+		{"html com extensão de texto", defaults, "notas.txt", 100, html, "", "não corresponde à extensão .txt"},
+		{"png disfarçado de pdf", defaults, "nota.pdf", 100, png, "", "não corresponde à extensão .pdf"},
+		{"zip genérico com extensão .doc", defaults, "velho.doc", 100, zip, "", "não corresponde à extensão .doc"},
+		{"zip fora da lista padrão", defaults, "pacote.zip", 100, zip, "", "Tipo de arquivo não permitido (application/zip)"},
+		{"lista configurada aceita pdf", onlyPDF, "a.pdf", 100, pdf, "application/pdf", ""},
+		{"lista configurada recusa png", onlyPDF, "a.png", 100, png, "", "Tipo de arquivo não permitido (image/png)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.settings.ValidateAttachment(tt.filename, tt.size, tt.head)
+			if tt.want != "" {
+				if err != nil || got != tt.want {
+					t.Errorf("ValidateAttachment(%q) = %q, %v; want %q", tt.filename, got, err, tt.want)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("ValidateAttachment(%q) aceitou como %q", tt.filename, got)
+			}
+			if !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("erro = %q, want %q", err, tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestDetectAttachmentTypeOLEOnlyForOfficeExtensions(t *testing.T) {
+	ole := append(bytes.Clone(oleSignature), make([]byte, 32)...)
+	if got := DetectAttachmentType(".pdf", ole); got != "application/octet-stream" {
+		t.Errorf("DetectAttachmentType(.pdf, OLE) = %q", got)
+	}
+	if got := DetectAttachmentType(".doc", ole); got != "application/msword" {
+		t.Errorf("DetectAttachmentType(.doc, OLE) = %q", got)
+	}
+}
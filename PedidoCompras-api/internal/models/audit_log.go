@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// AuditLog - Registro de auditoria de uma alteração feita via API
+type AuditLog struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `gorm:"index" json:"createdAt"`
+
+	// Quem fez (dados do token JWT)
+	ActorID    *uint  `gorm:"index" json:"actorId"`
+	ActorName  string `gorm:"size:100" json:"actorName"`
+	ActorEmail string `gorm:"size:100" json:"actorEmail"`
+	ActorRole  string `gorm:"size:20" json:"actorRole"`
+
+	// O que foi alterado
+	Entity   string `gorm:"size:50;not null;index" json:"entity"` // purchase_request, request_item, item_budget...
+	EntityID *uint  `gorm:"index" json:"entityId"`
+	Action   string `gorm:"size:50;not null;index" json:"action"` // create, update, delete, review...
+
+	// Diferença antes/depois: {"campo": {"before": ..., "after": ...}}
+	Changes string `gorm:"type:text" json:"changes"`
+
+	// Requisição HTTP
+	Method     string `gorm:"size:10" json:"method"`
+	Path       string `gorm:"size:255" json:"path"`
+	StatusCode int    `json:"statusCode"`
+	IPAddress  string `gorm:"size:45" json:"ipAddress"`
+	UserAgent  string `gorm:"size:500" json:"userAgent"`
+}
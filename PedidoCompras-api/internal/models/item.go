@@ -25,4 +25,13 @@ type RequestItem struct {
 
 	// ✅ NOVO CAMPO
 	SuspensionReason string `gorm:"type:text"` // motivo da suspensão (quando status = suspended)
+
+	// VALORES (R$)
+	EstimatedUnitPrice *float64 // preço unitário estimado (padrão: último preço de compra do produto)
+
+	// Calculados na leitura (não persistidos)
+	EstimatedValue  float64  `gorm:"-"` // quantidade × preço estimado
+	QuotedUnitPrice *float64 `gorm:"-"` // preço do orçamento selecionado
+	QuotedValue     float64  `gorm:"-"` // quantidade × preço do orçamento selecionado
+	ActualValue     float64  `gorm:"-"` // recebido (aceito) × preço do pedido de compra ou do orçamento
 }
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"time"
+
 	"gorm.io/gorm"
 )
 
@@ -11,6 +13,12 @@ type ItemBudget struct {
 	SupplierID        uint    `json:"supplierId" binding:"required"`
 	UnitPrice         float64 `json:"unitPrice" binding:"required,gt=0"`
 
+	// Seleção do orçamento vencedor (justificativa obrigatória quando não é o menor preço)
+	Selected               bool       `json:"selected" gorm:"not null;default:false"`
+	SelectionJustification string     `json:"selectionJustification" gorm:"type:text"`
+	SelectedBy             *uint      `json:"selectedBy"`
+	SelectedAt             *time.Time `json:"selectedAt"`
+
 	// relações opcionais para preload
 	PurchaseRequest PurchaseRequest `gorm:"foreignKey:PurchaseRequestID"`
 	RequestItem     RequestItem     `gorm:"foreignKey:RequestItemID"`
@@ -31,9 +31,12 @@ type ItemReceipt struct {
 	SupplierID *uint     `gorm:"index"`
 	Supplier   *Supplier `gorm:"foreignKey:SupplierID"`
 
-	// Observações e anexos
+	// Linha do pedido de compra atendida por este recebimento
+	PurchaseOrderLineID *uint              `gorm:"index"`
+	PurchaseOrderLine   *PurchaseOrderLine `gorm:"foreignKey:PurchaseOrderLineID"`
+
+	// Observações (a NF digitalizada é um Attachment do recebimento, categoria invoice)
 	Notes            string `gorm:"type:text"`
-	AttachmentPath   string `gorm:"size:512"`               // Caminho para NF digitalizada
 	ReceiptCondition string `gorm:"size:50;default:'good'"` // good, damaged, partial_damage
 
 	// Campos para controle de qualidade
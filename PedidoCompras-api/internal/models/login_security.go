@@ -0,0 +1,70 @@
+package models
+
+import "time"
+
+// LoginAttempt - Registro de cada tentativa de login (sucesso ou falha)
+type LoginAttempt struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `gorm:"index" json:"createdAt"`
+
+	Email     string `gorm:"size:100;index" json:"email"`
+	UserID    *uint  `gorm:"index" json:"userId"` // nil quando o e-mail não existe
+	IPAddress string `gorm:"size:45;index" json:"ipAddress"`
+	UserAgent string `gorm:"size:500" json:"userAgent"`
+
+	Success bool   `json:"success"`
+	Reason  string `gorm:"size:30" json:"reason"` // ok, invalid-password, unknown-user, account-locked, ip-locked
+}
+
+// LoginThrottle - Contador de falhas e bloqueio por conta ou por IP
+type LoginThrottle struct {
+	Key       string    `gorm:"primaryKey;size:100" json:"key"` // "user:<id>" ou "ip:<endereço>"
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	Failures      int        `gorm:"not null;default:0" json:"failures"` // falhas na janela atual
+	LastFailureAt *time.Time `json:"lastFailureAt"`
+	LockoutCount  int        `gorm:"not null;default:0" json:"lockoutCount"` // bloqueios consecutivos (backoff)
+	LockedUntil   *time.Time `json:"lockedUntil"`
+}
+
+// Motivos registrados nas tentativas de login
+const (
+	LoginReasonOK              = "ok"
+	LoginReasonInvalidPassword = "invalid-password"
+	LoginReasonUnknownUser     = "unknown-user"
+	LoginReasonAccountLocked   = "account-locked"
+	LoginReasonIPLocked        = "ip-locked"
+)
+
+// IsLocked indica se o bloqueio ainda está em vigor
+func (t *LoginThrottle) IsLocked(now time.Time) bool {
+	return t.LockedUntil != nil && now.Before(*t.LockedUntil)
+}
+
+// RegisterFailure contabiliza uma falha e aplica o bloqueio com backoff exponencial
+// ao atingir o limite. Retorna true quando a falha gerou um novo bloqueio.
+func (t *LoginThrottle) RegisterFailure(now time.Time, maxAttempts int, window, baseLockout, maxLockout time.Duration) bool {
+	if t.LastFailureAt == nil || now.Sub(*t.LastFailureAt) > window {
+		t.Failures = 0
+	}
+	t.Failures++
+	t.LastFailureAt = &now
+
+	if t.Failures < maxAttempts {
+		return false
+	}
+
+	t.LockoutCount++
+	lockout := baseLockout
+	for i := 1; i < t.LockoutCount && lockout < maxLockout; i++ {
+		lockout *= 2
+	}
+	if lockout > maxLockout {
+		lockout = maxLockout
+	}
+
+	until := now.Add(lockout)
+	t.LockedUntil = &until
+	t.Failures = 0
+	return true
+}
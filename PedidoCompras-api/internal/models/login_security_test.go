@@ -0,0 +1,70 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoginThrottleRegisterFailure(t *testing.T) {
+	now := time.Date(2025, 3, 10, 14, 0, 0, 0, time.UTC)
+	at := func(ago time.Duration) *time.Time {
+		value := now.Add(-ago)
+		return &value
+	}
+
+	const (
+		maxAttempts = 3
+		window      = 15 * time.Minute
+		baseLockout = 5 * time.Minute
+		maxLockout  = time.Hour
+	)
+
+	tests := []struct {
+		name         string
+		throttle     LoginThrottle
+		failures     int // falhas registradas, um segundo entre elas
+		locked       bool
+		wantFailures int
+		wantLockouts int
+		lockout      time.Duration // duração do bloqueio contada da última falha (0 = sem bloqueio)
+	}{
+		{"abaixo do limite", LoginThrottle{}, 2, false, 2, 0, 0},
+		{"atinge o limite", LoginThrottle{}, 3, true, 0, 1, 5 * time.Minute},
+		{"dentro da janela soma às anteriores", LoginThrottle{Failures: 2, LastFailureAt: at(10 * time.Minute)}, 1, true, 0, 1, 5 * time.Minute},
+		{"janela expirada zera a contagem", LoginThrottle{Failures: 2, LastFailureAt: at(20 * time.Minute)}, 1, false, 1, 0, 0},
+		{"segundo bloqueio dobra", LoginThrottle{Failures: 2, LastFailureAt: at(time.Minute), LockoutCount: 1}, 1, true, 0, 2, 10 * time.Minute},
+		{"quarto bloqueio", LoginThrottle{Failures: 2, LastFailureAt: at(time.Minute), LockoutCount: 3}, 1, true, 0, 4, 40 * time.Minute},
+		{"limitado ao máximo", LoginThrottle{Failures: 2, LastFailureAt: at(time.Minute), LockoutCount: 5}, 1, true, 0, 6, time.Hour},
+		{"muitos bloqueios não estouram", LoginThrottle{Failures: 2, LastFailureAt: at(time.Minute), LockoutCount: 200}, 1, true, 0, 201, time.Hour},
+		{"depois de um bloqueio a contagem recomeça", LoginThrottle{LastFailureAt: at(time.Minute), LockoutCount: 1, LockedUntil: at(-4 * time.Minute)}, 1, false, 1, 1, 4 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			throttle := tt.throttle
+			var locked bool
+			var last time.Time
+			for i := 0; i < tt.failures; i++ {
+				last = now.Add(time.Duration(i) * time.Second)
+				locked = throttle.RegisterFailure(last, maxAttempts, window, baseLockout, maxLockout)
+			}
+
+			if locked != tt.locked {
+				t.Errorf("RegisterFailure = %v, want %v", locked, tt.locked)
+			}
+			if throttle.Failures != tt.wantFailures || throttle.LockoutCount != tt.wantLockouts {
+				t.Errorf("falhas = %d, bloqueios = %d, want %d, %d", throttle.Failures, throttle.LockoutCount, tt.wantFailures, tt.wantLockouts)
+			}
+			if throttle.LastFailureAt == nil || !throttle.LastFailureAt.Equal(last) {
+				t.Errorf("LastFailureAt = %v, want %v", throttle.LastFailureAt, last)
+			}
+
+			switch {
+			case tt.lockout == 0 && throttle.LockedUntil != nil:
+				t.Errorf("LockedUntil = %v, want nil", throttle.LockedUntil)
+			case tt.lockout != 0 && (throttle.LockedUntil == nil || !throttle.LockedUntil.Equal(last.Add(tt.lockout))):
+				t.Errorf("LockedUntil = %v, want %v", throttle.LockedUntil, last.Add(tt.lockout))
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package models
+
+import (
+	"fmt"
+	"time"
+	"unicode"
+)
+
+// PasswordRuleViolation - Regra da política de senhas não atendida
+type PasswordRuleViolation struct {
+	Rule    string `json:"rule"` // minLength, uppercase, lowercase, number, specialChar
+	Message string `json:"message"`
+}
+
+// ValidatePassword confere a senha contra a política configurada e
+// devolve todas as regras não atendidas (vazio = senha válida)
+func (s SystemSettings) ValidatePassword(password string) []PasswordRuleViolation {
+	violations := []PasswordRuleViolation{}
+
+	minLength := s.MinPasswordLength
+	if minLength <= 0 {
+		minLength = 6
+	}
+
+	var length int
+	var hasUpper, hasLower, hasNumber, hasSpecial bool
+	for _, r := range password {
+		length++
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasNumber = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSpecial = true
+		}
+	}
+
+	if length < minLength {
+		violations = append(violations, PasswordRuleViolation{
+			Rule:    "minLength",
+			Message: fmt.Sprintf("A senha deve ter pelo menos %d caracteres", minLength),
+		})
+	}
+	if s.RequireUppercase && !hasUpper {
+		violations = append(violations, PasswordRuleViolation{
+			Rule:    "uppercase",
+			Message: "A senha deve conter pelo menos uma letra maiúscula",
+		})
+	}
+	if s.RequireLowercase && !hasLower {
+		violations = append(violations, PasswordRuleViolation{
+			Rule:    "lowercase",
+			Message: "A senha deve conter pelo menos uma letra minúscula",
+		})
+	}
+	if s.RequireNumbers && !hasNumber {
+		violations = append(violations, PasswordRuleViolation{
+			Rule:    "number",
+			Message: "A senha deve conter pelo menos um número",
+		})
+	}
+	if s.RequireSpecialChars && !hasSpecial {
+		violations = append(violations, PasswordRuleViolation{
+			Rule:    "specialChar",
+			Message: "A senha deve conter pelo menos um caractere especial",
+		})
+	}
+
+	return violations
+}
+
+// PasswordExpired indica se a senha passou do prazo de expiração (0 = nunca expira).
+// Usuários sem data de troca registrada contam a partir da criação.
+func (u *User) PasswordExpired(expirationDays int, now time.Time) bool {
+	if expirationDays <= 0 {
+		return false
+	}
+
+	changedAt := u.CreatedAt
+	if u.PasswordChangedAt != nil {
+		changedAt = *u.PasswordChangedAt
+	}
+
+	return now.After(changedAt.AddDate(0, 0, expirationDays))
+}
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidatePassword(t *testing.T) {
+	strict := SystemSettings{
+		MinPasswordLength:   8,
+		RequireUppercase:    true,
+		RequireLowercase:    true,
+		RequireNumbers:      true,
+		RequireSpecialChars: true,
+	}
+
+	tests := []struct {
+		name     string
+		settings SystemSettings
+		password string
+		rules    []string
+	}{
+		{"atende a todas as regras", strict, "Compras#2025", nil},
+		{"curta", strict, "Ab#1", []string{"minLength"}},
+		{"sem maiúscula", strict, "compras#2025", []string{"uppercase"}},
+		{"sem minúscula", strict, "COMPRAS#2025", []string{"lowercase"}},
+		{"sem número", strict, "Compras#Abc", []string{"number"}},
+		{"sem caractere especial", strict, "Compras2025", []string{"specialChar"}},
+		{"espaço conta como especial", strict, "Compras 2025", nil},
+		{"várias violações na ordem das regras", strict, "abc", []string{"minLength", "uppercase", "number", "specialChar"}},
+		{"tamanho conta caracteres, não bytes", SystemSettings{MinPasswordLength: 6}, "ação12", nil},
+		{"acentuadas valem como maiúscula e minúscula", strict, "ÇÃO#ação1", nil},
+		{"mínimo não configurado usa 6", SystemSettings{}, "12345", []string{"minLength"}},
+		{"regras desligadas", SystemSettings{MinPasswordLength: 4}, "abcd", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var rules []string
+			for _, violation := range tt.settings.ValidatePassword(tt.password) {
+				rules = append(rules, violation.Rule)
+				if violation.Message == "" {
+					t.Errorf("regra %s sem mensagem", violation.Rule)
+				}
+			}
+			if strings.Join(rules, ",") != strings.Join(tt.rules, ",") {
+				t.Errorf("ValidatePassword(%q) = %v, want %v", tt.password, rules, tt.rules)
+			}
+		})
+	}
+
+	if violations := (SystemSettings{}).ValidatePassword("123"); len(violations) != 1 || !strings.Contains(violations[0].Message, "6 caracteres") {
+		t.Errorf("mensagem do tamanho mínimo padrão = %+v", violations)
+	}
+}
+
+func TestPasswordExpired(t *testing.T) {
+	now := time.Date(2025, 6, 30, 12, 0, 0, 0, time.UTC)
+	daysAgo := func(days int) time.Time { return now.AddDate(0, 0, -days) }
+	ptr := func(value time.Time) *time.Time { return &value }
+
+	tests := []struct {
+		name           string
+		createdAt      time.Time
+		changedAt      *time.Time
+		expirationDays int
+		want           bool
+	}{
+		{"expiração desligada", daysAgo(1000), nil, 0, false},
+		{"expiração negativa vale como desligada", daysAgo(1000), nil, -30, false},
+		{"trocada dentro do prazo", daysAgo(400), ptr(daysAgo(10)), 90, false},
+		{"trocada fora do prazo", daysAgo(400), ptr(daysAgo(91)), 90, true},
+		{"exatamente no prazo ainda vale", daysAgo(400), ptr(daysAgo(90)), 90, false},
+		{"sem troca registrada usa a criação (vencida)", daysAgo(120), nil, 90, true},
+		{"sem troca registrada usa a criação (no prazo)", daysAgo(30), nil, 90, false},
+		{"troca recente prevalece sobre a criação antiga", daysAgo(1000), ptr(daysAgo(1)), 90, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := User{PasswordChangedAt: tt.changedAt}
+			user.CreatedAt = tt.createdAt
+			if got := user.PasswordExpired(tt.expirationDays, now); got != tt.want {
+				t.Errorf("PasswordExpired(%d) = %v, want %v", tt.expirationDays, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// PasswordResetToken - Token de uso único para redefinição de senha (apenas o hash SHA-256 é armazenado)
+type PasswordResetToken struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+
+	UserID uint `gorm:"not null;index"`
+	User   User `gorm:"foreignKey:UserID"`
+
+	TokenHash string     `gorm:"size:64;not null;uniqueIndex"`
+	ExpiresAt time.Time  `gorm:"not null"`
+	UsedAt    *time.Time // preenchido na redefinição ou quando um novo token é solicitado
+
+	RequestIP string `gorm:"size:45"`
+}
+
+// IsUsable indica se o token ainda pode ser usado para redefinir a senha
+func (t *PasswordResetToken) IsUsable(now time.Time) bool {
+	return t.UsedAt == nil && now.Before(t.ExpiresAt)
+}
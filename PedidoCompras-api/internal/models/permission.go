@@ -0,0 +1,156 @@
+package models
+
+import "time"
+
+// Papéis de sistema (não podem ser removidos)
+const (
+	RoleAdmin     = "admin"     // possui todas as permissões, sempre
+	RoleRequester = "requester" // papel padrão dos solicitantes
+)
+
+// Permissões nomeadas verificadas pelas rotas e handlers
+const (
+	PermRequestsCreate     = "requests:create"     // criar requisições e itens próprios
+	PermRequestsViewAll    = "requests:view-all"   // ver requisições de todos os usuários
+	PermRequestsReview     = "requests:review"     // revisar, concluir e reabrir requisições e itens
+	PermRequestsPrioritize = "requests:prioritize" // definir prioridade e urgência
+
+	PermBudgetsManage = "budgets:manage" // cadastrar, alterar e selecionar orçamentos
+
+	PermReceiptsCreate = "receipts:create" // registrar recebimentos e notas fiscais
+	PermReceiptsView   = "receipts:view"   // ver recebimentos de qualquer requisição
+
+	PermPurchaseOrdersView   = "purchase-orders:view"
+	PermPurchaseOrdersManage = "purchase-orders:manage"
+
+	PermProductsManage        = "products:manage"
+	PermProductRequestsReview = "product-requests:review" // processar pedidos de cadastro de produto
+	PermSuppliersManage       = "suppliers:manage"
+	PermSectorsManage         = "sectors:manage"
+	PermSectorBudgetsManage   = "sector-budgets:manage" // cadastrar verbas por setor e período
+
+	PermUsersView   = "users:view"
+	PermUsersManage = "users:manage"
+	PermRolesManage = "roles:manage"
+
+	PermSettingsManage         = "settings:manage"
+	PermApprovalPoliciesManage = "approval-policies:manage"
+	PermApprovalsDecide        = "approvals:decide" // decidir etapas de aprovação atribuídas ao próprio papel
+	PermReportsView            = "reports:view"
+	PermAuditView              = "audit:view"
+	PermSecurityManage         = "security:manage" // tentativas de login e desbloqueios
+)
+
+// PermissionInfo - Descrição de uma permissão do catálogo
+type PermissionInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// Permissions - Catálogo de todas as permissões existentes
+var Permissions = []PermissionInfo{
+	{PermRequestsCreate, "Criar requisições de compra"},
+	{PermRequestsViewAll, "Ver requisições de todos os usuários"},
+	{PermRequestsReview, "Revisar, concluir e reabrir requisições"},
+	{PermRequestsPrioritize, "Definir prioridade e urgência das requisições"},
+	{PermBudgetsManage, "Gerenciar orçamentos"},
+	{PermReceiptsCreate, "Registrar recebimentos e notas fiscais"},
+	{PermReceiptsView, "Ver recebimentos de qualquer requisição"},
+	{PermPurchaseOrdersView, "Ver pedidos de compra"},
+	{PermPurchaseOrdersManage, "Gerar pedidos de compra e alterar seu status"},
+	{PermProductsManage, "Gerenciar produtos"},
+	{PermProductRequestsReview, "Processar pedidos de cadastro de produto"},
+	{PermSuppliersManage, "Gerenciar fornecedores"},
+	{PermSectorsManage, "Gerenciar setores e decidir a pré-aprovação no lugar do gestor"},
+	{PermSectorBudgetsManage, "Gerenciar verbas dos setores"},
+	{PermUsersView, "Ver usuários"},
+	{PermUsersManage, "Gerenciar usuários"},
+	{PermRolesManage, "Gerenciar papéis e permissões"},
+	{PermSettingsManage, "Alterar configurações da empresa e do sistema"},
+	{PermApprovalPoliciesManage, "Gerenciar políticas de aprovação"},
+	{PermApprovalsDecide, "Decidir etapas de aprovação atribuídas ao próprio papel"},
+	{PermReportsView, "Ver e exportar relatórios"},
+	{PermAuditView, "Consultar o log de auditoria"},
+	{PermSecurityManage, "Consultar tentativas de login e desbloquear acessos"},
+}
+
+// IsValidPermission indica se a permissão existe no catálogo
+func IsValidPermission(name string) bool {
+	for _, p := range Permissions {
+		if p.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// AllPermissionNames devolve os nomes de todas as permissões do catálogo
+func AllPermissionNames() []string {
+	names := make([]string, 0, len(Permissions))
+	for _, p := range Permissions {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+// Role - Papel de usuário (User.Role guarda o Name)
+type Role struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	Name        string `gorm:"size:20;uniqueIndex;not null"` // identificador, imutável
+	Description string `gorm:"size:255"`
+	IsSystem    bool   `gorm:"not null;default:false"` // admin e requester
+
+	Permissions []RolePermission `gorm:"foreignKey:RoleID;constraint:OnDelete:CASCADE"`
+}
+
+// RolePermission - Permissão concedida a um papel
+type RolePermission struct {
+	ID         uint   `gorm:"primaryKey"`
+	RoleID     uint   `gorm:"not null;uniqueIndex:idx_role_permission"`
+	Permission string `gorm:"size:50;not null;uniqueIndex:idx_role_permission"`
+}
+
+// PermissionNames devolve os nomes das permissões do papel
+func (r *Role) PermissionNames() []string {
+	if r.Name == RoleAdmin {
+		return AllPermissionNames()
+	}
+	names := make([]string, 0, len(r.Permissions))
+	for _, p := range r.Permissions {
+		names = append(names, p.Permission)
+	}
+	return names
+}
+
+// DefaultRoles - Papéis criados na primeira inicialização (papéis já existentes não são alterados)
+var DefaultRoles = []struct {
+	Name        string
+	Description string
+	IsSystem    bool
+	Permissions []string
+}{
+	{RoleAdmin, "Administrador (todas as permissões)", true, nil},
+	{RoleRequester, "Solicitante", true, []string{PermRequestsCreate}},
+	{"buyer", "Comprador", false, []string{
+		PermRequestsCreate, PermRequestsViewAll, PermBudgetsManage, PermReceiptsView,
+		PermPurchaseOrdersView, PermPurchaseOrdersManage, PermProductsManage,
+		PermProductRequestsReview, PermSuppliersManage, PermReportsView,
+	}},
+	{"approver", "Aprovador", false, []string{
+		PermRequestsViewAll, PermRequestsReview, PermRequestsPrioritize, PermApprovalsDecide, PermReportsView,
+	}},
+	{"receiver", "Recebimento (almoxarifado)", false, []string{
+		PermRequestsViewAll, PermReceiptsCreate, PermReceiptsView, PermPurchaseOrdersView,
+	}},
+	// A visibilidade do gestor vem da atribuição em Sector.ManagerID (apenas o próprio setor)
+	{"sector-manager", "Gestor de setor", false, []string{
+		PermRequestsCreate,
+	}},
+	{"auditor", "Auditor (somente leitura)", false, []string{
+		PermRequestsViewAll, PermReceiptsView, PermPurchaseOrdersView, PermUsersView,
+		PermReportsView, PermAuditView,
+	}},
+}
@@ -0,0 +1,135 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PurchaseOrder - Pedido de compra emitido para um fornecedor a partir dos orçamentos vencedores
+type PurchaseOrder struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+
+	// Numeração sequencial (Sequence) e número formatado exibido ao usuário (ex: OC-000042)
+	Sequence uint   `gorm:"uniqueIndex;not null"`
+	Number   string `gorm:"size:20;uniqueIndex;not null"`
+
+	SupplierID uint     `gorm:"not null;index"`
+	Supplier   Supplier `gorm:"foreignKey:SupplierID"`
+
+	Status string `gorm:"size:20;not null;default:'draft'"` // draft, issued, acknowledged, partially_received, received, cancelled
+	Notes  string `gorm:"type:text"`
+
+	// Totais calculados a partir das linhas
+	TotalQuantity int     `gorm:"not null;default:0"`
+	TotalAmount   float64 `gorm:"not null;default:0"`
+
+	// Controle administrativo
+	CreatedBy          uint `gorm:"not null"`
+	Creator            User `gorm:"foreignKey:CreatedBy"`
+	IssuedAt           *time.Time
+	AcknowledgedAt     *time.Time
+	ReceivedAt         *time.Time
+	CancelledAt        *time.Time
+	CancellationReason string `gorm:"type:text"`
+
+	Lines []PurchaseOrderLine `gorm:"foreignKey:PurchaseOrderID"`
+}
+
+// PurchaseOrderLine - Linha do pedido de compra (um item aprovado de uma requisição)
+type PurchaseOrderLine struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+
+	PurchaseOrderID uint          `gorm:"not null;index"`
+	PurchaseOrder   PurchaseOrder `gorm:"foreignKey:PurchaseOrderID"`
+
+	PurchaseRequestID uint        `gorm:"not null;index"`
+	RequestItemID     uint        `gorm:"not null;index"`
+	RequestItem       RequestItem `gorm:"foreignKey:RequestItemID"`
+	ItemBudgetID      uint        `gorm:"not null"`
+	ItemBudget        ItemBudget  `gorm:"foreignKey:ItemBudgetID"`
+
+	Quantity         int     `gorm:"not null"`
+	UnitPrice        float64 `gorm:"not null"`
+	TotalPrice       float64 `gorm:"not null"`
+	QuantityReceived int     `gorm:"not null;default:0"` // soma dos recebimentos vinculados (descontando rejeitados)
+}
+
+// Constantes de status do pedido de compra
+const (
+	PurchaseOrderStatusDraft             = "draft"
+	PurchaseOrderStatusIssued            = "issued"
+	PurchaseOrderStatusAcknowledged      = "acknowledged"
+	PurchaseOrderStatusPartiallyReceived = "partially_received"
+	PurchaseOrderStatusReceived          = "received"
+	PurchaseOrderStatusCancelled         = "cancelled"
+)
+
+// purchaseOrderTransitions - Transições manuais permitidas (recebimentos são calculados automaticamente)
+var purchaseOrderTransitions = map[string][]string{
+	PurchaseOrderStatusDraft:        {PurchaseOrderStatusIssued, PurchaseOrderStatusCancelled},
+	PurchaseOrderStatusIssued:       {PurchaseOrderStatusAcknowledged, PurchaseOrderStatusCancelled},
+	PurchaseOrderStatusAcknowledged: {PurchaseOrderStatusCancelled},
+}
+
+// FormatPurchaseOrderNumber - Formata o número exibido a partir da sequência
+func FormatPurchaseOrderNumber(sequence uint) string {
+	return fmt.Sprintf("OC-%06d", sequence)
+}
+
+// AllowedNextStatuses retorna os status para os quais o pedido pode ser movido manualmente
+func (po *PurchaseOrder) AllowedNextStatuses() []string {
+	return purchaseOrderTransitions[po.Status]
+}
+
+// CanTransitionTo verifica se a mudança manual de status é permitida
+func (po *PurchaseOrder) CanTransitionTo(status string) bool {
+	for _, allowed := range po.AllowedNextStatuses() {
+		if allowed == status {
+			return true
+		}
+	}
+	return false
+}
+
+// CanReceive indica se o pedido aceita vínculo de novos recebimentos
+func (po *PurchaseOrder) CanReceive() bool {
+	return po.Status == PurchaseOrderStatusIssued ||
+		po.Status == PurchaseOrderStatusAcknowledged ||
+		po.Status == PurchaseOrderStatusPartiallyReceived
+}
+
+// RecalculateTotals atualiza os totais do pedido a partir das linhas carregadas
+func (po *PurchaseOrder) RecalculateTotals() {
+	po.TotalQuantity = 0
+	po.TotalAmount = 0
+	for _, line := range po.Lines {
+		po.TotalQuantity += line.Quantity
+		po.TotalAmount += line.TotalPrice
+	}
+}
+
+// ReceivingStatus calcula o status de recebimento a partir das linhas carregadas
+func (po *PurchaseOrder) ReceivingStatus() string {
+	ordered, received := 0, 0
+	for _, line := range po.Lines {
+		ordered += line.Quantity
+		received += line.QuantityReceived
+	}
+
+	switch {
+	case received == 0:
+		return po.Status
+	case received < ordered:
+		return PurchaseOrderStatusPartiallyReceived
+	default:
+		return PurchaseOrderStatusReceived
+	}
+}
@@ -18,9 +18,23 @@ type RequestsReportData struct {
 	Summary    RequestsReportSummary `json:"summary"`
 	Requests   []RequestReportItem   `json:"requests"`
 	Charts     RequestsReportCharts  `json:"charts"`
+	Sectors    []*SectorRollupNode   `json:"sectors"` // árvore de setores com totais acumulados
 	Pagination PaginationInfo        `json:"pagination"`
 }
 
+// SectorRollupNode - Setor com totais próprios e acumulados dos subsetores
+type SectorRollupNode struct {
+	ID            uint                `json:"id"`
+	Name          string              `json:"name"`
+	CostCenter    string              `json:"costCenter"`
+	ParentID      *uint               `json:"parentId"`
+	Requests      int                 `json:"requests"`      // requisições do próprio setor
+	TotalRequests int                 `json:"totalRequests"` // incluindo os subsetores
+	Spend         float64             `json:"spend"`         // pedidos de compra não cancelados do próprio setor
+	TotalSpend    float64             `json:"totalSpend"`    // incluindo os subsetores
+	Children      []*SectorRollupNode `json:"children,omitempty"`
+}
+
 // RequestsReportSummary - Resumo geral do relatório
 type RequestsReportSummary struct {
 	TotalRequests       int64   `json:"totalRequests"`
@@ -33,6 +47,12 @@ type RequestsReportSummary struct {
 	TotalItems          int     `json:"totalItems"`
 	MostActiveSector    string  `json:"mostActiveSector"`
 	MostActiveRequester string  `json:"mostActiveRequester"`
+
+	// Valores (R$)
+	TotalEstimatedValue float64 `json:"totalEstimatedValue"`
+	TotalQuotedValue    float64 `json:"totalQuotedValue"`
+	TotalActualValue    float64 `json:"totalActualValue"`
+	AverageRequestValue float64 `json:"averageRequestValue"` // média do valor de referência por requisição
 }
 
 // RequestReportItem - Item individual do relatório
@@ -48,6 +68,9 @@ type RequestReportItem struct {
 	CompletedAt    *time.Time                `json:"completedAt"`
 	ProcessDays    *int                      `json:"processDays"`
 	TotalItems     int                       `json:"totalItems"`
+	EstimatedValue float64                   `json:"estimatedValue"`
+	QuotedValue    float64                   `json:"quotedValue"`
+	ActualValue    float64                   `json:"actualValue"`
 	Observations   string                    `json:"observations"`
 	AdminNotes     string                    `json:"adminNotes"`
 	Items          []RequestReportItemDetail `json:"items,omitempty"`
@@ -60,14 +83,35 @@ type RequestReportItemDetail struct {
 	Unit        string     `json:"unit"`
 	Status      string     `json:"status"`
 	Deadline    *time.Time `json:"deadline"`
+
+	EstimatedUnitPrice *float64 `json:"estimatedUnitPrice"`
+	QuotedUnitPrice    *float64 `json:"quotedUnitPrice"`
+	ActualValue        float64  `json:"actualValue"`
 }
 
 // RequestsReportCharts - Dados para gráficos
 type RequestsReportCharts struct {
 	StatusDistribution []ChartDataPoint `json:"statusDistribution"`
 	TimelineDays       []ChartDataPoint `json:"timelineDays"`
-	SectorRanking      []ChartDataPoint `json:"sectorRanking"`
+	SectorRanking      []ChartDataPoint `json:"sectorRanking"` // acumulado pela hierarquia de setores
 	PriorityBreakdown  []ChartDataPoint `json:"priorityBreakdown"`
+
+	// Valores por status, setor e prioridade
+	ValueByStatus   []ValueDataPoint `json:"valueByStatus"`
+	ValueBySector   []ValueDataPoint `json:"valueBySector"`
+	ValueByPriority []ValueDataPoint `json:"valueByPriority"`
+}
+
+// ValueDataPoint - Totais e média de valor de um grupo de requisições. O valor de referência
+// de cada requisição é o realizado, na falta dele o cotado e, por último, o estimado.
+type ValueDataPoint struct {
+	Label          string  `json:"label"`
+	Requests       int     `json:"requests"`
+	EstimatedValue float64 `json:"estimatedValue"`
+	QuotedValue    float64 `json:"quotedValue"`
+	ActualValue    float64 `json:"actualValue"`
+	TotalValue     float64 `json:"totalValue"`   // soma dos valores de referência
+	AverageValue   float64 `json:"averageValue"` // média dos valores de referência
 }
 
 // ChartDataPoint - Ponto de dados para gráficos
@@ -83,4 +127,7 @@ type PaginationInfo struct {
 	PageSize   int `json:"pageSize"`
 	TotalPages int `json:"totalPages"`
 	TotalItems int `json:"totalItems"`
+
+	// Listagens por cursor: valor de cursor da próxima página (vazio na última)
+	NextCursor string `json:"nextCursor,omitempty"`
 }
@@ -38,8 +38,22 @@ type PurchaseRequest struct {
 	CompletedBy     *uint      // ID do admin que concluiu
 	CompletedAt     *time.Time // data/hora da conclusão
 
+	// PRÉ-APROVAÇÃO DO GESTOR DO SETOR (antes de chegar às compras)
+	SectorApprovalStatus string     `gorm:"size:20;not null;default:'not_required'"` // not_required, pending, approved, rejected
+	SectorApprovedBy     *uint      // ID do gestor que decidiu
+	SectorApprovedAt     *time.Time // quando a decisão foi registrada
+	SectorApprovalNotes  string     `gorm:"type:text"`
+
 	// RELACIONAMENTO COM ITEMS
 	Items []RequestItem `gorm:"foreignKey:PurchaseRequestID"`
+
+	// VALORES TOTAIS (R$), calculados na leitura a partir dos itens não rejeitados
+	EstimatedValue float64 `gorm:"-"`
+	QuotedValue    float64 `gorm:"-"`
+	ActualValue    float64 `gorm:"-"` // inclui tudo o que foi recebido
+
+	// CADEIA DE APROVAÇÃO (quando alguma política se aplica)
+	ApprovalSteps []RequestApprovalStep `gorm:"foreignKey:PurchaseRequestID"`
 }
 
 // CONSTANTES PARA STATUS
@@ -51,6 +65,14 @@ const (
 	StatusCompleted = "completed" // Concluída/Atendida
 )
 
+// CONSTANTES PARA PRÉ-APROVAÇÃO DO SETOR
+const (
+	SectorApprovalNotRequired = "not_required" // setor sem gestor ou gestor é o próprio solicitante
+	SectorApprovalPending     = "pending"      // aguardando o gestor do setor
+	SectorApprovalApproved    = "approved"     // pré-aprovada pelo gestor
+	SectorApprovalRejected    = "rejected"     // rejeitada pelo gestor
+)
+
 // ✅ NOVAS CONSTANTES PARA PRIORIDADE
 const (
 	PriorityUrgent = "urgent" // Urgente - vermelho
@@ -83,6 +105,17 @@ func (pr *PurchaseRequest) IsCompleted() bool {
 	return pr.Status == StatusCompleted
 }
 
+// AwaitingSectorApproval indica se a requisição ainda depende do gestor do setor
+func (pr *PurchaseRequest) AwaitingSectorApproval() bool {
+	return pr.SectorApprovalStatus == SectorApprovalPending
+}
+
+// SectorApprovalBlocking indica se o gestor do setor ainda não liberou a requisição para compras
+// (pré-aprovação pendente ou rejeitada)
+func (pr *PurchaseRequest) SectorApprovalBlocking() bool {
+	return pr.SectorApprovalStatus == SectorApprovalPending || pr.SectorApprovalStatus == SectorApprovalRejected
+}
+
 // ✅ NOVOS MÉTODOS PARA PRIORIDADE
 func (pr *PurchaseRequest) IsUrgent() bool {
 	return pr.Priority == PriorityUrgent
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RequestComment - Comentário em uma requisição (solicitante, gestor do setor ou compras)
+type RequestComment struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+
+	PurchaseRequestID uint `gorm:"not null;index"`
+
+	AuthorID uint `gorm:"not null"`
+	Author   User `gorm:"foreignKey:AuthorID"`
+
+	Body string `gorm:"type:text;not null"`
+}
@@ -0,0 +1,55 @@
+package models
+
+// SearchConfig - Configuração de busca textual do PostgreSQL: português com stemming e sem acentos
+// (criada pela migração 0002_full_text_search a partir de "portuguese" + unaccent)
+const SearchConfig = "portuguese_unaccent"
+
+// Documentos pesquisáveis: as expressões devem ser idênticas às dos índices GIN
+// (migração 0002_full_text_search) para que o PostgreSQL use os índices
+const (
+	SearchDocumentProducts = `setweight(to_tsvector('portuguese_unaccent', coalesce(products.name, '')), 'A') || ` +
+		`setweight(to_tsvector('portuguese_unaccent', coalesce(products.description, '')), 'B')`
+	SearchDocumentRequests = `setweight(to_tsvector('portuguese_unaccent', coalesce(purchase_requests.observations, '')), 'A') || ` +
+		`setweight(to_tsvector('portuguese_unaccent', coalesce(purchase_requests.admin_notes, '')), 'B')`
+	SearchDocumentSuppliers = `to_tsvector('portuguese_unaccent', coalesce(suppliers.name, ''))`
+	SearchDocumentReceipts  = `to_tsvector('simple', coalesce(item_receipts.invoice_number, '') || ' ' || coalesce(item_receipts.lot_number, ''))`
+)
+
+// Tipos de resultado da busca
+const (
+	SearchTypeRequests  = "requests"
+	SearchTypeProducts  = "products"
+	SearchTypeSuppliers = "suppliers"
+	SearchTypeReceipts  = "receipts"
+)
+
+// SearchTypes - Ordem dos grupos na resposta da busca
+var SearchTypes = []string{SearchTypeRequests, SearchTypeProducts, SearchTypeSuppliers, SearchTypeReceipts}
+
+// SearchResult - Registro encontrado pela busca
+type SearchResult struct {
+	Type     string  `json:"type"`
+	ID       uint    `json:"id"`
+	Title    string  `json:"title"`
+	Subtitle string  `json:"subtitle"`
+	Snippet  string  `json:"snippet"` // trecho com os termos destacados (<b>...</b>)
+	Status   string  `json:"status,omitempty"`
+	Rank     float64 `json:"rank"`
+
+	// Requisição relacionada (recebimentos)
+	RequestID *uint `json:"requestId,omitempty"`
+}
+
+// SearchGroup - Resultados de um tipo, do mais relevante para o menos relevante
+type SearchGroup struct {
+	Type    string         `json:"type"`
+	Total   int64          `json:"total"` // total encontrado (os resultados são limitados)
+	Results []SearchResult `json:"results"`
+}
+
+// SearchResponse - Resposta de GET /search
+type SearchResponse struct {
+	Query  string        `json:"query"`
+	Total  int64         `json:"total"`
+	Groups []SearchGroup `json:"groups"`
+}
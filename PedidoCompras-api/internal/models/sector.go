@@ -12,7 +12,19 @@ type Sector struct {
 	UpdatedAt time.Time
 	DeletedAt gorm.DeletedAt `gorm:"index"`
 
-	sector_ID uint `gorm:"not null"`
-
 	Name string `gorm:"size:100;uniqueIndex;not null"`
+
+	// Centro de custo (ex: "CC-1020"); único entre os setores quando informado
+	CostCenter string `gorm:"size:30;index"`
+
+	// Hierarquia (ex: Diretoria > Manutenção > Elétrica); nil = setor raiz.
+	// As requisições guardam o setor de origem, então mover um setor não altera o histórico.
+	ParentID *uint    `gorm:"index"`
+	Parent   *Sector  `gorm:"foreignKey:ParentID"`
+	Children []Sector `gorm:"foreignKey:ParentID"`
+
+	// Gestor do setor: vê e pré-aprova as requisições do setor (e dos subsetores) antes das compras.
+	// Sem constraint no banco: users já referencia sectors e a FK circular quebraria a migração.
+	ManagerID *uint `gorm:"index"`
+	Manager   *User `gorm:"foreignKey:ManagerID;-:migration"`
 }
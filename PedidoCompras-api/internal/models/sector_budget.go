@@ -0,0 +1,139 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SectorBudget - Verba de um setor para um período (mensal, trimestral ou anual).
+// Vale também para os subsetores: requisições de Elétrica consomem a verba de Manutenção.
+type SectorBudget struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+
+	SectorID uint   `gorm:"not null;index"`
+	Sector   Sector `gorm:"foreignKey:SectorID"`
+
+	PeriodType  string    `gorm:"size:20;not null"`                // monthly, quarterly, yearly
+	PeriodStart time.Time `gorm:"type:date;not null"`              // primeiro dia do período
+	PeriodEnd   time.Time `gorm:"type:date;not null"`              // primeiro dia do período seguinte (exclusivo)
+	Amount      float64   `gorm:"not null"`                        // valor orçado (R$)
+	Enforcement string    `gorm:"size:10;not null;default:'warn'"` // warn, block: o que fazer quando a aprovação excede o saldo
+	Notes       string    `gorm:"type:text"`
+
+	CreatedBy uint `gorm:"not null"`
+}
+
+// SectorBudgetEntry - Movimento da verba: empenho na aprovação, estorno e consumo no recebimento
+type SectorBudgetEntry struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+
+	SectorBudgetID    uint  `gorm:"not null;index"`
+	PurchaseRequestID uint  `gorm:"not null;index"`
+	RequestItemID     uint  `gorm:"not null;index"`
+	ItemReceiptID     *uint `gorm:"index"` // preenchido nos consumos
+
+	Type   string  `gorm:"size:20;not null"` // commitment, release, consumption
+	Amount float64 `gorm:"not null"`         // sempre positivo; o tipo define o efeito no saldo
+
+	CreatedBy *uint
+	Notes     string `gorm:"size:255"`
+}
+
+// Constantes de período das verbas
+const (
+	BudgetPeriodMonthly   = "monthly"
+	BudgetPeriodQuarterly = "quarterly"
+	BudgetPeriodYearly    = "yearly"
+)
+
+// Constantes de política quando a aprovação excede o saldo
+const (
+	BudgetEnforcementWarn  = "warn"  // aprova e avisa
+	BudgetEnforcementBlock = "block" // impede a aprovação
+)
+
+// Constantes de tipo de movimento da verba
+const (
+	BudgetEntryCommitment  = "commitment"  // empenho (requisição aprovada)
+	BudgetEntryRelease     = "release"     // estorno de empenho (rejeição, item recebido, preço alterado)
+	BudgetEntryConsumption = "consumption" // consumo (item recebido)
+)
+
+// SectorBudgetStatus - Situação da verba: orçado x empenhado x realizado
+type SectorBudgetStatus struct {
+	BudgetID     uint      `json:"budgetId"`
+	SectorID     uint      `json:"sectorId"`
+	SectorName   string    `json:"sectorName"`
+	CostCenter   string    `json:"costCenter"`
+	PeriodType   string    `json:"periodType"`
+	PeriodLabel  string    `json:"periodLabel"`
+	PeriodStart  time.Time `json:"periodStart"`
+	PeriodEnd    time.Time `json:"periodEnd"`
+	Enforcement  string    `json:"enforcement"`
+	Amount       float64   `json:"amount"`
+	Committed    float64   `json:"committed"` // empenhado e ainda não recebido
+	Consumed     float64   `json:"consumed"`  // realizado (recebido)
+	Available    float64   `json:"available"` // orçado - empenhado - realizado
+	UsagePercent float64   `json:"usagePercent"`
+
+	// Preenchido na checagem de aprovação: valor que a aprovação empenharia
+	Requested float64 `json:"requested,omitempty"`
+}
+
+// IsValidBudgetPeriod indica se o tipo de período é suportado
+func IsValidBudgetPeriod(periodType string) bool {
+	switch periodType {
+	case BudgetPeriodMonthly, BudgetPeriodQuarterly, BudgetPeriodYearly:
+		return true
+	}
+	return false
+}
+
+// BudgetPeriodBounds calcula início e fim (exclusivo) do período. Period é o mês (1-12)
+// para verbas mensais, o trimestre (1-4) para trimestrais e é ignorado nas anuais.
+func BudgetPeriodBounds(periodType string, year, period int) (time.Time, time.Time, error) {
+	if year < 2000 || year > 2100 {
+		return time.Time{}, time.Time{}, fmt.Errorf("ano inválido: %d", year)
+	}
+
+	switch periodType {
+	case BudgetPeriodMonthly:
+		if period < 1 || period > 12 {
+			return time.Time{}, time.Time{}, fmt.Errorf("mês inválido: %d", period)
+		}
+		start := time.Date(year, time.Month(period), 1, 0, 0, 0, 0, time.Local)
+		return start, start.AddDate(0, 1, 0), nil
+	case BudgetPeriodQuarterly:
+		if period < 1 || period > 4 {
+			return time.Time{}, time.Time{}, fmt.Errorf("trimestre inválido: %d", period)
+		}
+		start := time.Date(year, time.Month((period-1)*3+1), 1, 0, 0, 0, 0, time.Local)
+		return start, start.AddDate(0, 3, 0), nil
+	case BudgetPeriodYearly:
+		start := time.Date(year, time.January, 1, 0, 0, 0, 0, time.Local)
+		return start, start.AddDate(1, 0, 0), nil
+	}
+	return time.Time{}, time.Time{}, fmt.Errorf("período inválido: %s", periodType)
+}
+
+// Covers indica se a data cai dentro do período da verba
+func (b *SectorBudget) Covers(t time.Time) bool {
+	return !t.Before(b.PeriodStart) && t.Before(b.PeriodEnd)
+}
+
+// BudgetPeriodLabel descreve o período para relatórios: 03/2025, T1/2025 ou 2025
+func BudgetPeriodLabel(periodType string, start time.Time) string {
+	switch periodType {
+	case BudgetPeriodMonthly:
+		return start.Format("01/2006")
+	case BudgetPeriodQuarterly:
+		return fmt.Sprintf("T%d/%d", (int(start.Month())-1)/3+1, start.Year())
+	}
+	return fmt.Sprintf("%d", start.Year())
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBudgetPeriodBounds(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.Local)
+	}
+
+	tests := []struct {
+		name       string
+		periodType string
+		year       int
+		period     int
+		start, end time.Time
+		label      string
+		wantErr    bool
+	}{
+		{"janeiro", BudgetPeriodMonthly, 2025, 1, date(2025, 1, 1), date(2025, 2, 1), "01/2025", false},
+		{"fevereiro bissexto", BudgetPeriodMonthly, 2024, 2, date(2024, 2, 1), date(2024, 3, 1), "02/2024", false},
+		{"dezembro vira o ano", BudgetPeriodMonthly, 2025, 12, date(2025, 12, 1), date(2026, 1, 1), "12/2025", false},
+		{"mês zero", BudgetPeriodMonthly, 2025, 0, time.Time{}, time.Time{}, "", true},
+		{"mês 13", BudgetPeriodMonthly, 2025, 13, time.Time{}, time.Time{}, "", true},
+		{"primeiro trimestre", BudgetPeriodQuarterly, 2025, 1, date(2025, 1, 1), date(2025, 4, 1), "T1/2025", false},
+		{"terceiro trimestre", BudgetPeriodQuarterly, 2025, 3, date(2025, 7, 1), date(2025, 10, 1), "T3/2025", false},
+		{"quarto trimestre vira o ano", BudgetPeriodQuarterly, 2025, 4, date(2025, 10, 1), date(2026, 1, 1), "T4/2025", false},
+		{"trimestre 5", BudgetPeriodQuarterly, 2025, 5, time.Time{}, time.Time{}, "", true},
+		{"anual ignora o período", BudgetPeriodYearly, 2025, 7, date(2025, 1, 1), date(2026, 1, 1), "2025", false},
+		{"ano antes de 2000", BudgetPeriodYearly, 1999, 0, time.Time{}, time.Time{}, "", true},
+		{"ano depois de 2100", BudgetPeriodMonthly, 2101, 1, time.Time{}, time.Time{}, "", true},
+		{"tipo desconhecido", "weekly", 2025, 1, time.Time{}, time.Time{}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end, err := BudgetPeriodBounds(tt.periodType, tt.year, tt.period)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("esperava erro, recebeu %v - %v", start, end)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("BudgetPeriodBounds: %v", err)
+			}
+			if !start.Equal(tt.start) || !end.Equal(tt.end) {
+				t.Errorf("período = %v - %v, want %v - %v", start, end, tt.start, tt.end)
+			}
+			if label := BudgetPeriodLabel(tt.periodType, start); label != tt.label {
+				t.Errorf("BudgetPeriodLabel = %q, want %q", label, tt.label)
+			}
+
+			// o fim é exclusivo
+			budget := SectorBudget{PeriodStart: start, PeriodEnd: end}
+			if !budget.Covers(start) || !budget.Covers(end.Add(-time.Nanosecond)) || budget.Covers(end) {
+				t.Errorf("Covers não respeita [%v, %v)", start, end)
+			}
+		})
+	}
+}
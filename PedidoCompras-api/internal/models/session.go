@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// UserSession - Sessão de login (identificada pelo jti dos access tokens)
+type UserSession struct {
+	ID        string `gorm:"primaryKey;size:64"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	UserID uint `gorm:"not null;index"`
+	User   User `gorm:"foreignKey:UserID"`
+
+	LastUsedAt    time.Time
+	RevokedAt     *time.Time
+	RevokedReason string `gorm:"size:50"` // logout, logout-all, token-reuse, user-deleted, password-expired, password-changed
+
+	IPAddress string `gorm:"size:45"`
+	UserAgent string `gorm:"size:500"`
+}
+
+// RefreshToken - Refresh token rotativo (apenas o hash SHA-256 é armazenado)
+type RefreshToken struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+
+	SessionID string `gorm:"size:64;not null;index"`
+	UserID    uint   `gorm:"not null;index"`
+
+	TokenHash string     `gorm:"size:64;not null;uniqueIndex"`
+	ExpiresAt time.Time  `gorm:"not null"`
+	UsedAt    *time.Time // preenchido quando o token é trocado por um novo
+	RevokedAt *time.Time
+}
+
+// IsRevoked indica se a sessão foi encerrada
+func (s *UserSession) IsRevoked() bool {
+	return s.RevokedAt != nil
+}
+
+// IsUsable indica se o refresh token ainda pode ser trocado
+func (t *RefreshToken) IsUsable(now time.Time) bool {
+	return t.UsedAt == nil && t.RevokedAt == nil && now.Before(t.ExpiresAt)
+}
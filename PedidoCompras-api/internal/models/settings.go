@@ -22,7 +22,7 @@ type CompanySettings struct {
 	Website     string `gorm:"size:255"`
 
 	// Logo
-	LogoPath     string `gorm:"size:500"` // Caminho do arquivo
+	LogoPath     string `gorm:"size:500"` // Chave do arquivo no armazenamento
 	LogoFilename string `gorm:"size:255"` // Nome original do arquivo
 }
 
@@ -44,6 +44,13 @@ type SystemSettings struct {
 	// Sessão
 	SessionTimeoutMinutes int `gorm:"default:60"`
 
+	// Proteção contra força bruta no login
+	MaxLoginAttempts          int `gorm:"default:5"`  // falhas por conta antes do bloqueio
+	MaxLoginAttemptsPerIP     int `gorm:"default:20"` // falhas por IP antes do bloqueio
+	LoginAttemptWindowMinutes int `gorm:"default:15"` // janela de contagem das falhas
+	LockoutMinutes            int `gorm:"default:15"` // primeiro bloqueio; dobra a cada reincidência
+	MaxLockoutMinutes         int `gorm:"default:1440"`
+
 	// Backup
 	BackupEnabled   bool   `gorm:"default:false"`
 	BackupFrequency string `gorm:"size:20;default:'daily'"` // daily, weekly, monthly
@@ -52,4 +59,11 @@ type SystemSettings struct {
 	// Logs
 	LogRetentionDays int  `gorm:"default:90"`
 	AuditLogEnabled  bool `gorm:"default:true"`
+
+	// Compras
+	MinQuotesPerItem int `gorm:"default:0"` // orçamentos mínimos por item para aprovar a requisição (0 = sem exigência)
+
+	// Anexos (ver attachment_policy.go)
+	MaxAttachmentSizeMB    int    `gorm:"default:10"`
+	AllowedAttachmentTypes string `gorm:"type:text"` // tipos MIME separados por vírgula; vazio = DefaultAttachmentTypes
 }
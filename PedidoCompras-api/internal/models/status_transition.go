@@ -0,0 +1,148 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// RequestStatusHistory - Registro de cada transição de status de requisição ou item
+type RequestStatusHistory struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+
+	PurchaseRequestID uint  `gorm:"not null;index"`
+	RequestItemID     *uint `gorm:"index"` // preenchido quando a transição é de um item
+
+	FromStatus string `gorm:"size:20"` // vazio na criação
+	ToStatus   string `gorm:"size:20;not null"`
+
+	ChangedBy *uint
+	Changer   *User  `gorm:"foreignKey:ChangedBy"`
+	Notes     string `gorm:"type:text"`
+}
+
+// TableName mantém o nome da tabela no singular
+func (RequestStatusHistory) TableName() string {
+	return "request_status_history"
+}
+
+// Constantes de status dos itens
+const (
+	ItemStatusPending   = "pending"
+	ItemStatusApproved  = "approved"
+	ItemStatusRejected  = "rejected"
+	ItemStatusSuspended = "suspended"
+)
+
+// requestTransitions - Transições de status permitidas para requisições.
+// Requisições concluídas só saem desse estado pela reabertura.
+var requestTransitions = map[string][]string{
+	StatusPending:   {StatusApproved, StatusPartial, StatusRejected},
+	StatusApproved:  {StatusPending, StatusPartial, StatusRejected, StatusCompleted},
+	StatusPartial:   {StatusPending, StatusApproved, StatusRejected, StatusCompleted},
+	StatusRejected:  {StatusPending, StatusApproved, StatusPartial},
+	StatusCompleted: {StatusApproved, StatusPartial},
+}
+
+// itemTransitions - Transições de status permitidas para itens
+var itemTransitions = map[string][]string{
+	ItemStatusPending:   {ItemStatusApproved, ItemStatusRejected, ItemStatusSuspended},
+	ItemStatusApproved:  {ItemStatusRejected, ItemStatusSuspended},
+	ItemStatusRejected:  {ItemStatusApproved, ItemStatusSuspended},
+	ItemStatusSuspended: {ItemStatusApproved, ItemStatusRejected},
+}
+
+// StatusTransitionError - Erro de transição inválida (respondido com 409)
+type StatusTransitionError struct {
+	From    string
+	To      string
+	Allowed []string
+}
+
+func (e *StatusTransitionError) Error() string {
+	return fmt.Sprintf("Não é possível alterar o status de '%s' para '%s'", e.From, e.To)
+}
+
+// requestTransitionGuard aplica as regras de negócio além da tabela de transições
+func (pr *PurchaseRequest) requestTransitionGuard(to string) bool {
+	switch {
+	case to == StatusCompleted:
+		return pr.CanBeCompleted()
+	case pr.Status == StatusCompleted:
+		return pr.CanBeReopened()
+	default:
+		return true
+	}
+}
+
+// AllowedNextStatuses retorna os status para os quais a requisição pode ser movida
+func (pr *PurchaseRequest) AllowedNextStatuses() []string {
+	allowed := []string{}
+	for _, status := range requestTransitions[pr.Status] {
+		if pr.requestTransitionGuard(status) {
+			allowed = append(allowed, status)
+		}
+	}
+	return allowed
+}
+
+// CanTransitionTo verifica se a mudança de status é permitida
+func (pr *PurchaseRequest) CanTransitionTo(status string) bool {
+	for _, allowed := range pr.AllowedNextStatuses() {
+		if allowed == status {
+			return true
+		}
+	}
+	return false
+}
+
+// TransitionTo valida e aplica a mudança de status. Manter o mesmo status não é transição.
+func (pr *PurchaseRequest) TransitionTo(status string) error {
+	if status == pr.Status && status != StatusCompleted {
+		return nil
+	}
+	if !pr.CanTransitionTo(status) {
+		return &StatusTransitionError{From: pr.Status, To: status, Allowed: pr.AllowedNextStatuses()}
+	}
+	pr.Status = status
+	return nil
+}
+
+// itemTransitionGuard impede alterações em itens de requisições concluídas
+func (ri *RequestItem) itemTransitionGuard() bool {
+	return ri.PurchaseRequest.ID == 0 || !ri.PurchaseRequest.IsCompleted()
+}
+
+// AllowedNextStatuses retorna os status para os quais o item pode ser movido
+func (ri *RequestItem) AllowedNextStatuses() []string {
+	if !ri.itemTransitionGuard() {
+		return []string{}
+	}
+	allowed := itemTransitions[ri.Status]
+	if allowed == nil {
+		return []string{}
+	}
+	return allowed
+}
+
+// CanTransitionTo verifica se a mudança de status do item é permitida
+func (ri *RequestItem) CanTransitionTo(status string) bool {
+	for _, allowed := range ri.AllowedNextStatuses() {
+		if allowed == status {
+			return true
+		}
+	}
+	return false
+}
+
+// TransitionTo valida e aplica a mudança de status do item
+func (ri *RequestItem) TransitionTo(status string) error {
+	if status == ri.Status && ri.itemTransitionGuard() {
+		return nil
+	}
+	if !ri.CanTransitionTo(status) {
+		return &StatusTransitionError{From: ri.Status, To: status, Allowed: ri.AllowedNextStatuses()}
+	}
+	ri.Status = status
+	return nil
+}
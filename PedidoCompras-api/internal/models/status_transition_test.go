@@ -0,0 +1,134 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPurchaseRequestTransitionTo(t *testing.T) {
+	tests := []struct {
+		from, to string
+		ok       bool
+	}{
+		{StatusPending, StatusApproved, true},
+		{StatusPending, StatusPartial, true},
+		{StatusPending, StatusRejected, true},
+		{StatusPending, StatusCompleted, false},
+		{StatusPending, StatusPending, true}, // mesmo status não é transição
+		{StatusApproved, StatusCompleted, true},
+		{StatusApproved, StatusPending, true},
+		{StatusPartial, StatusCompleted, true},
+		{StatusRejected, StatusCompleted, false},
+		{StatusRejected, StatusPending, true},
+		{StatusCompleted, StatusApproved, true}, // reabertura
+		{StatusCompleted, StatusPartial, true},
+		{StatusCompleted, StatusPending, false},
+		{StatusCompleted, StatusRejected, false},
+		{StatusCompleted, StatusCompleted, false},
+		{StatusPending, "desconhecido", false},
+		{"desconhecido", StatusApproved, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from+"->"+tt.to, func(t *testing.T) {
+			pr := &PurchaseRequest{Status: tt.from}
+			err := pr.TransitionTo(tt.to)
+
+			if tt.ok {
+				if err != nil {
+					t.Fatalf("TransitionTo(%q) = %v", tt.to, err)
+				}
+				if pr.Status != tt.to {
+					t.Errorf("Status = %q, want %q", pr.Status, tt.to)
+				}
+				return
+			}
+
+			var transitionErr *StatusTransitionError
+			if !errors.As(err, &transitionErr) {
+				t.Fatalf("TransitionTo(%q) = %v, want StatusTransitionError", tt.to, err)
+			}
+			if transitionErr.From != tt.from || transitionErr.To != tt.to {
+				t.Errorf("erro = %+v", transitionErr)
+			}
+			if pr.Status != tt.from {
+				t.Errorf("status alterado para %q numa transição inválida", pr.Status)
+			}
+		})
+	}
+}
+
+func TestPurchaseRequestAllowedNextStatuses(t *testing.T) {
+	tests := []struct {
+		status string
+		want   []string
+	}{
+		{StatusPending, []string{StatusApproved, StatusPartial, StatusRejected}},
+		{StatusApproved, []string{StatusPending, StatusPartial, StatusRejected, StatusCompleted}},
+		{StatusRejected, []string{StatusPending, StatusApproved, StatusPartial}},
+		{StatusCompleted, []string{StatusApproved, StatusPartial}},
+		{"desconhecido", []string{}},
+	}
+	for _, tt := range tests {
+		got := (&PurchaseRequest{Status: tt.status}).AllowedNextStatuses()
+		if !equalStrings(got, tt.want) {
+			t.Errorf("AllowedNextStatuses(%q) = %v, want %v", tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestRequestItemTransitionTo(t *testing.T) {
+	tests := []struct {
+		name          string
+		from, to      string
+		requestStatus string
+		ok            bool
+	}{
+		{"pendente para aprovado", ItemStatusPending, ItemStatusApproved, StatusPending, true},
+		{"pendente para suspenso", ItemStatusPending, ItemStatusSuspended, StatusPending, true},
+		{"rejeitado para aprovado", ItemStatusRejected, ItemStatusApproved, StatusPartial, true},
+		{"suspenso para rejeitado", ItemStatusSuspended, ItemStatusRejected, StatusPending, true},
+		{"aprovado não volta a pendente", ItemStatusApproved, ItemStatusPending, StatusApproved, false},
+		{"mesmo status", ItemStatusApproved, ItemStatusApproved, StatusApproved, true},
+		{"requisição concluída", ItemStatusApproved, ItemStatusRejected, StatusCompleted, false},
+		{"mesmo status em requisição concluída", ItemStatusApproved, ItemStatusApproved, StatusCompleted, false},
+		{"sem requisição carregada", ItemStatusPending, ItemStatusRejected, "", true},
+		{"status desconhecido", ItemStatusPending, "desconhecido", StatusPending, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := &RequestItem{Status: tt.from}
+			if tt.requestStatus != "" {
+				item.PurchaseRequest = PurchaseRequest{Status: tt.requestStatus}
+				item.PurchaseRequest.ID = 1
+			}
+
+			err := item.TransitionTo(tt.to)
+			if tt.ok && err != nil {
+				t.Fatalf("TransitionTo(%q) = %v", tt.to, err)
+			}
+			if !tt.ok {
+				var transitionErr *StatusTransitionError
+				if !errors.As(err, &transitionErr) {
+					t.Fatalf("TransitionTo(%q) = %v, want StatusTransitionError", tt.to, err)
+				}
+				if item.Status != tt.from {
+					t.Errorf("status alterado para %q numa transição inválida", item.Status)
+				}
+			}
+		})
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
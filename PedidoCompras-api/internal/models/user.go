@@ -18,4 +18,10 @@ type User struct {
 	Role         string `gorm:"size:20;not null"`
 	SectorID     uint   `gorm:"not null"`
 	Sector       Sector `gorm:"foreignKey:SectorID"`
+
+	// Tokens emitidos antes desta data são rejeitados (troca de papel, logout geral)
+	TokensValidAfter *time.Time
+
+	// Última troca de senha (base para SystemSettings.PasswordExpirationDays)
+	PasswordChangedAt *time.Time
 }
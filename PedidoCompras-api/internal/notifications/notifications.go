@@ -3,21 +3,22 @@ package notifications
 import (
 	"context"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 )
 
 // Client representa um cliente conectado com mais metadados
 type Client struct {
-	ID        string
-	Channel   chan string
-	UserID    string
-	Role      string
-	UserName  string
-	Connected time.Time
-	LastPing  time.Time
-	Context   context.Context
-	Cancel    context.CancelFunc
+	ID          string
+	Channel     chan string
+	UserID      string
+	Permissions map[string]bool // permissões do papel na conexão, para avisos por permissão
+	UserName    string
+	Connected   time.Time
+	LastPing    time.Time
+	Context     context.Context
+	Cancel      context.CancelFunc
 }
 
 // ClientManager gerencia clientes conectados de forma mais robusta
@@ -38,8 +39,8 @@ func init() {
 	go manager.cleanupWorker()
 }
 
-// RegisterClient registra um novo cliente com contexto
-func RegisterClient(userID, role, userName string, parentCtx context.Context) (string, chan string) {
+// RegisterClient registra um novo cliente com contexto e as permissões do usuário
+func RegisterClient(userID, userName string, permissions []string, parentCtx context.Context) (string, chan string) {
 	manager.mu.Lock()
 	defer manager.mu.Unlock()
 
@@ -47,16 +48,21 @@ func RegisterClient(userID, role, userName string, parentCtx context.Context) (s
 	ctx, cancel := context.WithCancel(parentCtx)
 	ch := make(chan string, 100)
 
+	granted := make(map[string]bool, len(permissions))
+	for _, permission := range permissions {
+		granted[permission] = true
+	}
+
 	client := &Client{
-		ID:        clientID,
-		Channel:   ch,
-		UserID:    userID,
-		Role:      role,
-		UserName:  userName,
-		Connected: time.Now(),
-		LastPing:  time.Now(),
-		Context:   ctx,
-		Cancel:    cancel,
+		ID:          clientID,
+		Channel:     ch,
+		UserID:      userID,
+		Permissions: granted,
+		UserName:    userName,
+		Connected:   time.Now(),
+		LastPing:    time.Now(),
+		Context:     ctx,
+		Cancel:      cancel,
 	}
 
 	manager.clients[clientID] = client
@@ -107,6 +113,18 @@ func Publish(message string, targetUserID ...string) {
 	}
 }
 
+// PublishToPermission envia a mensagem apenas para os clientes conectados cujo papel tem a permissão
+func PublishToPermission(message string, permission string) {
+	manager.mu.RLock()
+	defer manager.mu.RUnlock()
+
+	for _, client := range manager.clients {
+		if client.Permissions[permission] {
+			manager.sendToClient(client, message)
+		}
+	}
+}
+
 // sendToClient envia mensagem para um cliente específico
 func (cm *ClientManager) sendToClient(client *Client, message string) {
 	select {
@@ -169,23 +187,28 @@ func GetConnectedClients() map[string]interface{} {
 	defer manager.mu.RUnlock()
 
 	stats := map[string]interface{}{
-		"total":   len(manager.clients),
-		"by_role": make(map[string]int),
-		"clients": make([]map[string]interface{}, 0),
+		"total":         len(manager.clients),
+		"by_permission": make(map[string]int),
+		"clients":       make([]map[string]interface{}, 0),
 	}
 
-	roleCount := make(map[string]int)
+	permissionCount := make(map[string]int)
 	for _, client := range manager.clients {
-		roleCount[client.Role]++
+		permissions := make([]string, 0, len(client.Permissions))
+		for permission := range client.Permissions {
+			permissionCount[permission]++
+			permissions = append(permissions, permission)
+		}
+		sort.Strings(permissions)
 		stats["clients"] = append(stats["clients"].([]map[string]interface{}), map[string]interface{}{
-			"id":        client.ID,
-			"user_name": client.UserName,
-			"role":      client.Role,
-			"connected": client.Connected,
-			"last_ping": client.LastPing,
+			"id":          client.ID,
+			"user_name":   client.UserName,
+			"permissions": permissions,
+			"connected":   client.Connected,
+			"last_ping":   client.LastPing,
 		})
 	}
-	stats["by_role"] = roleCount
+	stats["by_permission"] = permissionCount
 
 	return stats
 }
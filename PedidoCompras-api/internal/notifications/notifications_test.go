@@ -0,0 +1,34 @@
+package notifications
+
+import (
+	"context"
+	"testing"
+)
+
+func TestPublishToPermission(t *testing.T) {
+	securityID, security := RegisterClient("1", "Ana", []string{"security:manage", "users:view"}, context.Background())
+	defer UnregisterClient(securityID)
+	otherID, other := RegisterClient("2", "Bruno", []string{"users:view"}, context.Background())
+	defer UnregisterClient(otherID)
+
+	PublishToPermission("security-locked:ana@empresa.com", "security:manage")
+
+	select {
+	case msg := <-security:
+		if msg != "security-locked:ana@empresa.com" {
+			t.Errorf("mensagem = %q", msg)
+		}
+	default:
+		t.Error("cliente com security:manage não recebeu o aviso")
+	}
+	select {
+	case msg := <-other:
+		t.Errorf("cliente sem a permissão recebeu %q", msg)
+	default:
+	}
+
+	stats := GetConnectedClients()
+	if counts := stats["by_permission"].(map[string]int); counts["users:view"] < 2 || counts["security:manage"] < 1 {
+		t.Errorf("by_permission = %v", counts)
+	}
+}
@@ -0,0 +1,84 @@
+package preview
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+
+	// decodificadores registrados para image.Decode
+	_ "image/gif"
+	_ "image/png"
+)
+
+// generateImage lê as dimensões e gera a miniatura de JPEG, PNG ou GIF (primeiro quadro)
+func (g *Generator) generateImage(data []byte) (Result, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Result{}, fmt.Errorf("imagem inválida: %w", err)
+	}
+	result := Result{Width: cfg.Width, Height: cfg.Height, PageCount: 1}
+	if cfg.Width*cfg.Height > maxImagePixels {
+		return result, nil // metadados apenas; grande demais para decodificar
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return result, fmt.Errorf("imagem inválida: %w", err)
+	}
+	result.Thumbnail, err = encodeThumbnail(img, g.maxSize)
+	return result, err
+}
+
+// encodeThumbnail reduz a imagem para caber em maxSize x maxSize e codifica em JPEG
+func encodeThumbnail(img image.Image, maxSize int) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, scaleDown(img, maxSize), &jpeg.Options{Quality: thumbQuality}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// scaleDown reduz mantendo a proporção (média de cada bloco de pixels de origem).
+// Transparência é composta sobre fundo branco, já que JPEG não tem canal alfa.
+func scaleDown(src image.Image, maxSize int) *image.RGBA {
+	bounds := src.Bounds()
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+	dstW, dstH := srcW, srcH
+	if srcW > maxSize || srcH > maxSize {
+		if srcW >= srcH {
+			dstW, dstH = maxSize, max(1, srcH*maxSize/srcW)
+		} else {
+			dstW, dstH = max(1, srcW*maxSize/srcH), maxSize
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dstW, dstH))
+	for y := 0; y < dstH; y++ {
+		y0 := bounds.Min.Y + y*srcH/dstH
+		y1 := max(y0+1, bounds.Min.Y+(y+1)*srcH/dstH)
+		for x := 0; x < dstW; x++ {
+			x0 := bounds.Min.X + x*srcW/dstW
+			x1 := max(x0+1, bounds.Min.X+(x+1)*srcW/dstW)
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := src.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa)
+					n++
+				}
+			}
+			// valores pré-multiplicados: branco entra na proporção da transparência
+			white := (0xffff*n - a)
+			dst.SetRGBA(x, y, color.RGBA{
+				R: uint8((r + white) / n >> 8),
+				G: uint8((g + white) / n >> 8),
+				B: uint8((b + white) / n >> 8),
+				A: 0xff,
+			})
+		}
+	}
+	return dst
+}
@@ -0,0 +1,153 @@
+package preview
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+)
+
+// PDFRenderer - Renderiza a primeira página de um PDF (não há renderizador em Go puro)
+type PDFRenderer interface {
+	RenderFirstPage(ctx context.Context, pdf []byte, maxSize int) (image.Image, error)
+	Name() string
+}
+
+// Pdftoppm - Renderização com o pdftoppm do poppler-utils
+type Pdftoppm struct {
+	Path string
+}
+
+// Name identifica o renderizador no log
+func (p Pdftoppm) Name() string {
+	return "pdftoppm"
+}
+
+// RenderFirstPage grava o PDF num diretório temporário e converte a página 1 em PNG
+func (p Pdftoppm) RenderFirstPage(ctx context.Context, pdf []byte, maxSize int) (image.Image, error) {
+	dir, err := os.MkdirTemp("", "preview-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	input := filepath.Join(dir, "input.pdf")
+	if err := os.WriteFile(input, pdf, 0o600); err != nil {
+		return nil, err
+	}
+	output := filepath.Join(dir, "page")
+
+	cmd := exec.CommandContext(ctx, p.Path,
+		"-f", "1", "-l", "1", "-singlefile", "-png",
+		"-scale-to", strconv.Itoa(maxSize),
+		input, output)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("%w: %s", err, bytes.TrimSpace(out))
+	}
+
+	file, err := os.Open(output + ".png")
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return png.Decode(file)
+}
+
+var (
+	// Objeto de página (/Type /Page, mas não /Pages)
+	pdfPageObject = regexp.MustCompile(`/Type\s*/Page\b`)
+	// Tamanho da página: /MediaBox [x0 y0 x1 y1]
+	pdfMediaBox = regexp.MustCompile(`/MediaBox\s*\[\s*(-?[\d.]+)\s+(-?[\d.]+)\s+(-?[\d.]+)\s+(-?[\d.]+)\s*\]`)
+	// Fluxos de objetos compactados (PDF 1.5+), onde as páginas podem estar
+	pdfObjectStream = regexp.MustCompile(`/Type\s*/ObjStm\b`)
+)
+
+// maxObjectStreamSize - Limite de cada fluxo de objetos descompactado
+const maxObjectStreamSize = 16 << 20
+
+// PDFPageCount conta os objetos de página, inclusive dentro de fluxos de objetos
+// compactados (FlateDecode). Devolve 0 se não encontrar nenhuma página.
+func PDFPageCount(data []byte) int {
+	streams := pdfObjectStreams(data)
+	count := 0
+	for _, loc := range pdfPageObject.FindAllIndex(data, -1) {
+		// conteúdo de fluxo gravado sem compressão é contado na versão descompactada
+		inside := false
+		for _, stream := range streams {
+			if loc[0] >= stream.start && loc[0] < stream.end {
+				inside = true
+				break
+			}
+		}
+		if !inside {
+			count++
+		}
+	}
+	for _, stream := range streams {
+		count += len(pdfPageObject.FindAllIndex(stream.content, -1))
+	}
+	return count
+}
+
+// PDFPageSize devolve largura e altura (em pontos) do primeiro /MediaBox encontrado
+func PDFPageSize(data []byte) (int, int) {
+	box := pdfMediaBox.FindSubmatch(data)
+	if box == nil {
+		for _, stream := range pdfObjectStreams(data) {
+			if box = pdfMediaBox.FindSubmatch(stream.content); box != nil {
+				break
+			}
+		}
+	}
+	if box == nil {
+		return 0, 0
+	}
+
+	var v [4]float64
+	for i := range v {
+		v[i], _ = strconv.ParseFloat(string(box[i+1]), 64)
+	}
+	return int(math.Round(math.Abs(v[2] - v[0]))), int(math.Round(math.Abs(v[3] - v[1])))
+}
+
+// pdfStream - Fluxo /ObjStm descompactado e sua posição no arquivo
+type pdfStream struct {
+	start, end int
+	content    []byte
+}
+
+// pdfObjectStreams descompacta os fluxos /ObjStm
+func pdfObjectStreams(data []byte) []pdfStream {
+	var streams []pdfStream
+	for _, loc := range pdfObjectStream.FindAllIndex(data, -1) {
+		// o conteúdo começa após a palavra "stream" e a quebra de linha seguinte
+		keyword := bytes.Index(data[loc[1]:], []byte("stream"))
+		if keyword < 0 {
+			continue
+		}
+		start := loc[1] + keyword + len("stream")
+		start += len(data[start:]) - len(bytes.TrimLeft(data[start:], "\r\n"))
+		end := bytes.Index(data[start:], []byte("endstream"))
+		if end < 0 {
+			continue
+		}
+		reader, err := zlib.NewReader(bytes.NewReader(data[start : start+end]))
+		if err != nil {
+			continue
+		}
+		// o fim do fluxo pode ter bytes extras (quebra de linha); o conteúdo lido até o erro vale
+		stream, _ := io.ReadAll(io.LimitReader(reader, maxObjectStreamSize))
+		reader.Close()
+		streams = append(streams, pdfStream{start: start, end: start + end, content: stream})
+	}
+	return streams
+}
@@ -0,0 +1,101 @@
+package preview
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// testPDF monta um PDF mínimo com as páginas informadas como objetos soltos
+func testPDF(pages int, mediaBox string) []byte {
+	var b strings.Builder
+	b.WriteString("%PDF-1.4\n1 0 obj\n<< /Type /Catalog /Pages 2 0 R >>\nendobj\n")
+	fmt.Fprintf(&b, "2 0 obj\n<< /Type /Pages /Count %d /Kids [] >>\nendobj\n", pages)
+	for i := 0; i < pages; i++ {
+		fmt.Fprintf(&b, "%d 0 obj\n<< /Type /Page /Parent 2 0 R /MediaBox %s >>\nendobj\n", i+3, mediaBox)
+	}
+	b.WriteString("trailer\n<< /Root 1 0 R >>\n%%EOF\n")
+	return []byte(b.String())
+}
+
+// testObjectStreamPDF guarda as páginas num fluxo /ObjStm compactado (PDF 1.5+)
+func testObjectStreamPDF(t *testing.T, pages int, compress bool) []byte {
+	t.Helper()
+	var objects strings.Builder
+	for i := 0; i < pages; i++ {
+		fmt.Fprintf(&objects, "<< /Type /Page /Parent 2 0 R /MediaBox [0 0 842 595] >>\n")
+	}
+	content := []byte(objects.String())
+	filter := ""
+	if compress {
+		var buf bytes.Buffer
+		w := zlib.NewWriter(&buf)
+		w.Write(content)
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		content = buf.Bytes()
+		filter = " /Filter /FlateDecode"
+	}
+
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.5\n1 0 obj\n<< /Type /Catalog /Pages 2 0 R >>\nendobj\n")
+	b.WriteString("2 0 obj\n<< /Type /Pages /Kids [] >>\nendobj\n")
+	fmt.Fprintf(&b, "3 0 obj\n<< /Type /ObjStm /N %d /Length %d%s >>\nstream\r\n", pages, len(content), filter)
+	b.Write(content)
+	b.WriteString("\nendstream\nendobj\n%%EOF\n")
+	return b.Bytes()
+}
+
+func TestPDFPageCount(t *testing.T) {
+	mixed := append(testObjectStreamPDF(t, 2, true), []byte("9 0 obj\n<< /Type /Page >>\nendobj\n")...)
+
+	tests := []struct {
+		name string
+		data []byte
+		want int
+	}{
+		{"uma página", testPDF(1, "[0 0 595 842]"), 1},
+		{"várias páginas", testPDF(7, "[0 0 595 842]"), 7},
+		{"/Pages não conta", []byte("<< /Type /Pages /Count 3 >>"), 0},
+		{"sem espaço após /Type", []byte("<</Type/Page>> <</Type/Page/Parent 2 0 R>>"), 2},
+		{"fluxo de objetos compactado", testObjectStreamPDF(t, 4, true), 4},
+		{"fluxo compactado mais página solta", mixed, 3},
+		{"fluxo sem compressão conta no arquivo", testObjectStreamPDF(t, 2, false), 2},
+		{"fluxo corrompido", []byte("<< /Type /ObjStm >>\nstream\nnão é zlib\nendstream"), 0},
+		{"vazio", nil, 0},
+		{"não é PDF", []byte("apenas texto"), 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PDFPageCount(tt.data); got != tt.want {
+				t.Errorf("PDFPageCount = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPDFPageSize(t *testing.T) {
+	tests := []struct {
+		name          string
+		data          []byte
+		width, height int
+	}{
+		{"A4 retrato", testPDF(1, "[0 0 595 842]"), 595, 842},
+		{"origem deslocada e decimais", testPDF(1, "[ -10 -10 602.4 782.2 ]"), 612, 792},
+		{"dentro do fluxo compactado", testObjectStreamPDF(t, 1, true), 842, 595},
+		{"sem MediaBox", []byte("<< /Type /Page >>"), 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			width, height := PDFPageSize(tt.data)
+			if width != tt.width || height != tt.height {
+				t.Errorf("PDFPageSize = %dx%d, want %dx%d", width, height, tt.width, tt.height)
+			}
+		})
+	}
+}
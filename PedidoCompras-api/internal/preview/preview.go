@@ -0,0 +1,112 @@
+// Package preview gera miniaturas (JPEG) e extrai metadados dos anexos:
+// dimensões das imagens e número de páginas/tamanho da primeira página dos PDFs.
+package preview
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os/exec"
+)
+
+// Padrões da geração de miniaturas
+const (
+	DefaultMaxSize = 320 // lado maior da miniatura, em pixels
+	thumbQuality   = 80  // qualidade JPEG da miniatura
+
+	// maxImagePixels - Imagens maiores não são decodificadas (proteção contra "bombas" de descompressão)
+	maxImagePixels = 50_000_000
+)
+
+// ErrUnsupported - Tipo de arquivo sem pré-visualização
+var ErrUnsupported = errors.New("tipo de arquivo sem pré-visualização")
+
+// Result - Metadados e miniatura de um arquivo
+type Result struct {
+	Width     int    // pixels (imagens) ou pontos da primeira página (PDF)
+	Height    int    // idem
+	PageCount int    // PDFs; 1 para imagens
+	Thumbnail []byte // JPEG; vazio quando não foi possível gerar
+}
+
+// Config - Configuração da geração de pré-visualizações
+type Config struct {
+	MaxSize int // lado maior da miniatura (padrão DefaultMaxSize)
+
+	// PDFRenderer: caminho do pdftoppm (poppler-utils) para a primeira página dos PDFs;
+	// vazio procura "pdftoppm" no PATH e "none" desativa (os PDFs ficam só com os metadados)
+	PDFRenderer string
+}
+
+// Generator - Gera miniaturas de imagens (Go puro) e de PDFs (via PDFRenderer, se disponível)
+type Generator struct {
+	maxSize int
+	pdf     PDFRenderer
+}
+
+// New cria o gerador; sem renderizador de PDF disponível apenas imagens ganham miniatura
+func New(cfg Config) *Generator {
+	g := &Generator{maxSize: cfg.MaxSize}
+	if g.maxSize <= 0 {
+		g.maxSize = DefaultMaxSize
+	}
+
+	switch cfg.PDFRenderer {
+	case "none":
+	case "":
+		if path, err := exec.LookPath("pdftoppm"); err == nil {
+			g.pdf = Pdftoppm{Path: path}
+		}
+	default:
+		g.pdf = Pdftoppm{Path: cfg.PDFRenderer}
+	}
+	return g
+}
+
+// Name descreve o gerador no log
+func (g *Generator) Name() string {
+	if g.pdf == nil {
+		return fmt.Sprintf("imagens (%dpx); PDFs sem miniatura", g.maxSize)
+	}
+	return fmt.Sprintf("imagens e PDFs (%dpx, %s)", g.maxSize, g.pdf.Name())
+}
+
+// Supports indica se o tipo tem metadados/miniatura
+func Supports(contentType string) bool {
+	switch contentType {
+	case "image/jpeg", "image/png", "image/gif", "application/pdf":
+		return true
+	}
+	return false
+}
+
+// Generate extrai os metadados e gera a miniatura do arquivo
+func (g *Generator) Generate(ctx context.Context, contentType string, data []byte) (Result, error) {
+	switch contentType {
+	case "image/jpeg", "image/png", "image/gif":
+		return g.generateImage(data)
+	case "application/pdf":
+		return g.generatePDF(ctx, data)
+	default:
+		return Result{}, ErrUnsupported
+	}
+}
+
+// generatePDF lê páginas e tamanho em Go puro; a miniatura depende do renderizador
+func (g *Generator) generatePDF(ctx context.Context, data []byte) (Result, error) {
+	result := Result{PageCount: PDFPageCount(data)}
+	result.Width, result.Height = PDFPageSize(data)
+	if g.pdf == nil {
+		return result, nil
+	}
+
+	page, err := g.pdf.RenderFirstPage(ctx, data, g.maxSize)
+	if err != nil {
+		// metadados continuam válidos; a miniatura fica para uma nova geração
+		log.Printf("⚠️  Falha ao renderizar a primeira página do PDF (%s): %v", g.pdf.Name(), err)
+		return result, nil
+	}
+	result.Thumbnail, err = encodeThumbnail(page, g.maxSize)
+	return result, err
+}
@@ -0,0 +1,101 @@
+package rbac
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/models"
+	"gorm.io/gorm"
+)
+
+// Chave do contexto Gin com o conjunto de permissões do usuário logado
+const ContextKey = "permissions"
+
+const cacheTTL = time.Minute
+
+type cacheEntry struct {
+	permissions map[string]bool
+	loadedAt    time.Time
+}
+
+var (
+	cacheMu sync.Mutex
+	cache   = map[string]cacheEntry{}
+)
+
+// Permissions devolve as permissões do papel, com cache curto para não
+// consultar o banco a cada requisição autenticada
+func Permissions(db *gorm.DB, role string) map[string]bool {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+
+	if entry, ok := cache[role]; ok && time.Since(entry.loadedAt) < cacheTTL {
+		return entry.permissions
+	}
+
+	permissions := map[string]bool{}
+	if role == models.RoleAdmin {
+		for _, name := range models.AllPermissionNames() {
+			permissions[name] = true
+		}
+	} else {
+		var names []string
+		db.Model(&models.RolePermission{}).
+			Joins("JOIN roles ON roles.id = role_permissions.role_id").
+			Where("roles.name = ?", role).
+			Pluck("role_permissions.permission", &names)
+		for _, name := range names {
+			permissions[name] = true
+		}
+	}
+
+	cache[role] = cacheEntry{permissions: permissions, loadedAt: time.Now()}
+	return permissions
+}
+
+// UserIDs devolve os usuários cujo papel possui a permissão (administradores sempre),
+// para avisos enviados por permissão
+func UserIDs(db *gorm.DB, permission string) ([]uint, error) {
+	roles := db.Model(&models.RolePermission{}).
+		Select("roles.name").
+		Joins("JOIN roles ON roles.id = role_permissions.role_id").
+		Where("role_permissions.permission = ?", permission)
+
+	var ids []uint
+	err := db.Model(&models.User{}).
+		Where("role = ? OR role IN (?)", models.RoleAdmin, roles).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+// Invalidate descarta o cache (chamado quando papéis ou permissões mudam)
+func Invalidate() {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	cache = map[string]cacheEntry{}
+}
+
+// Has indica se o usuário da requisição possui a permissão
+func Has(c *gin.Context, permission string) bool {
+	value, exists := c.Get(ContextKey)
+	if !exists {
+		return false
+	}
+	permissions, ok := value.(map[string]bool)
+	return ok && permissions[permission]
+}
+
+// List devolve as permissões do usuário da requisição
+func List(c *gin.Context) []string {
+	value, _ := c.Get(ContextKey)
+	permissions, _ := value.(map[string]bool)
+
+	names := []string{}
+	for _, name := range models.AllPermissionNames() {
+		if permissions[name] {
+			names = append(names, name)
+		}
+	}
+	return names
+}
@@ -1,10 +1,19 @@
 package routes
 
 import (
+	"log"
+	"time"
+
 	"github.com/gin-gonic/gin"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/audit"
 	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/config"
 	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/handlers"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/mailer"
 	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/middleware"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/models"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/preview"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/scanner"
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/storage"
 	"gorm.io/gorm"
 )
 
@@ -13,33 +22,78 @@ func Setup(router *gin.Engine, databaseConnection *gorm.DB, appConfig *config.Co
 	// Health-check aberto
 	router.GET("/api/v1/health", handlers.Health)
 
+	// Envio de e-mails (SMTP ou apenas log, conforme .env)
+	mail := mailer.New(mailer.SMTPConfig{
+		Host:     appConfig.SMTPHost,
+		Port:     appConfig.SMTPPort,
+		Username: appConfig.SMTPUser,
+		Password: appConfig.SMTPPassword,
+		From:     appConfig.SMTPFrom,
+		FromName: appConfig.SMTPFromName,
+		TLSMode:  appConfig.SMTPTLSMode,
+	})
+
+	// Armazenamento dos arquivos enviados (disco local ou S3, conforme .env)
+	store, err := storage.New(appConfig.StorageConfig())
+	if err != nil {
+		log.Fatalf("Erro na configuração do armazenamento: %v", err)
+	}
+	log.Printf("Armazenamento de arquivos: %s", store.Name())
+
+	// Verificação de malware dos uploads (clamd ou nenhuma, conforme .env)
+	scan, err := scanner.New(appConfig.ScannerConfig())
+	if err != nil {
+		log.Fatalf("Erro na configuração do scanner: %v", err)
+	}
+	log.Printf("Verificação de malware: %s", scan.Name())
+
+	// Miniaturas e metadados dos anexos (imagens; PDFs se houver renderizador)
+	previews := preview.New(appConfig.PreviewConfig())
+	handlers.StartQuarantineScanJob(databaseConnection, store, scan, previews, 5*time.Minute)
+	log.Printf("Pré-visualização de anexos: %s", previews.Name())
+
 	// Grupo de rotas v1
 	apiGroup := router.Group("/api/v1")
+
+	// Grupos protegidos: autenticação e, só depois dela, auditoria das rotas de escrita
+	authenticated := []gin.HandlerFunc{
+		middleware.AuthMiddleware(appConfig.JWTSecretKey, databaseConnection),
+		audit.Middleware(databaseConnection),
+	}
 	{
 		// ✅ CRÍTICO: ROTA PÚBLICA DO LOGO (DEVE VIR ANTES DAS PROTEGIDAS)
-		apiGroup.GET("/settings/company/logo", handlers.GetCompanyLogo(databaseConnection))
+		apiGroup.GET("/settings/company/logo", handlers.GetCompanyLogo(databaseConnection, store))
 
 		// Autenticação (aberto)
 		authGroup := apiGroup.Group("/auth")
 		{
 			authGroup.POST("/login", handlers.Login(databaseConnection, appConfig))
+			authGroup.POST("/refresh", handlers.RefreshToken(databaseConnection, appConfig))
+			authGroup.POST("/forgot-password", handlers.ForgotPassword(databaseConnection, appConfig, mail, store))
+			authGroup.POST("/reset-password", handlers.ResetPassword(databaseConnection))
+			authGroup.POST("/logout",
+				middleware.AuthMiddleware(appConfig.JWTSecretKey, databaseConnection),
+				handlers.Logout(databaseConnection),
+			)
 		}
 
 		// Usuários (protegido)
 		userGroup := apiGroup.Group("/users")
-		userGroup.Use(middleware.AuthMiddleware(appConfig.JWTSecretKey))
+		userGroup.Use(authenticated...)
 		{
-			userGroup.GET("", handlers.ListUsers(databaseConnection))
-			userGroup.POST("", handlers.CreateUserUpdated(databaseConnection))           // Versão atualizada
-			userGroup.GET("/:id", handlers.GetUser(databaseConnection))                  // Nova rota
-			userGroup.PUT("/:id", handlers.UpdateUser(databaseConnection))               // Nova rota
-			userGroup.DELETE("/:id", handlers.DeleteUser(databaseConnection))            // Nova rota
-			userGroup.PATCH("/:id/promote", handlers.PromoteToAdmin(databaseConnection)) // Nova rota
+			userGroup.GET("", middleware.RequirePermission(models.PermUsersView), handlers.ListUsers(databaseConnection))
+			userGroup.POST("", middleware.RequirePermission(models.PermUsersManage), handlers.CreateUserUpdated(databaseConnection))           // Versão atualizada
+			userGroup.GET("/:id", middleware.RequirePermission(models.PermUsersView), handlers.GetUser(databaseConnection))                    // Nova rota
+			userGroup.PUT("/:id", middleware.RequirePermission(models.PermUsersManage), handlers.UpdateUser(databaseConnection))               // Nova rota
+			userGroup.DELETE("/:id", middleware.RequirePermission(models.PermUsersManage), handlers.DeleteUser(databaseConnection))            // Nova rota
+			userGroup.PATCH("/:id/promote", middleware.RequirePermission(models.PermUsersManage), handlers.PromoteToAdmin(databaseConnection)) // Nova rota
+			userGroup.POST("/:id/logout-all", middleware.RequirePermission(models.PermUsersManage), handlers.RevokeUserSessions(databaseConnection))
+			userGroup.POST("/:id/unlock", middleware.RequirePermission(models.PermSecurityManage), handlers.UnlockUserLogin(databaseConnection))
 		}
 
 		// Produtos (protegido)
 		productsGroup := apiGroup.Group("/products")
-		productsGroup.Use(middleware.AuthMiddleware(appConfig.JWTSecretKey))
+		productsGroup.Use(authenticated...)
 		{
 			productsGroup.GET("", handlers.ListProducts(databaseConnection))
 			productsGroup.POST("", handlers.CreateProduct(databaseConnection))
@@ -50,21 +104,25 @@ func Setup(router *gin.Engine, databaseConnection *gorm.DB, appConfig *config.Co
 
 		// Requisições (protegido)
 		requestsGroup := apiGroup.Group("/requests")
-		requestsGroup.Use(middleware.AuthMiddleware(appConfig.JWTSecretKey))
+		requestsGroup.Use(authenticated...)
 		{
 			requestsGroup.GET("", handlers.ListPurchaseRequests(databaseConnection))
-			requestsGroup.POST("", handlers.CreatePurchaseRequest(databaseConnection))
+			requestsGroup.POST("", middleware.RequirePermission(models.PermRequestsCreate), handlers.CreatePurchaseRequest(databaseConnection))
 			requestsGroup.GET("/:id", handlers.GetPurchaseRequest(databaseConnection))
+
+			// Dossiê de auditoria (ZIP com anexos, notas fiscais e resumo)
+			requestsGroup.GET("/dossiers.zip", handlers.ExportRequestDossiers(databaseConnection, store))
+			requestsGroup.GET("/:id/dossier.zip", handlers.ExportRequestDossier(databaseConnection, store))
 			requestsGroup.PATCH("/:id", handlers.UpdatePurchaseRequest(databaseConnection))
 
 			// Rotas administrativas para revisão
-			requestsGroup.PATCH("/:id/review", handlers.ReviewRequest(databaseConnection))
-			requestsGroup.PATCH("/:id/items/:itemId/review", handlers.ReviewRequestItem(databaseConnection))
+			requestsGroup.PATCH("/:id/review", middleware.RequirePermission(models.PermRequestsReview), handlers.ReviewRequest(databaseConnection))
+			requestsGroup.PATCH("/:id/items/:itemId/review", middleware.RequirePermission(models.PermRequestsReview), handlers.ReviewRequestItem(databaseConnection))
 
 			// Rotas para prioridade
-			requestsGroup.PATCH("/:id/priority", handlers.SetRequestPriority(databaseConnection))
-			requestsGroup.DELETE("/:id/priority", handlers.RemoveRequestPriority(databaseConnection))
-			requestsGroup.POST("/:id/toggle-urgent", handlers.ToggleUrgentPriority(databaseConnection))
+			requestsGroup.PATCH("/:id/priority", middleware.RequirePermission(models.PermRequestsPrioritize), handlers.SetRequestPriority(databaseConnection))
+			requestsGroup.DELETE("/:id/priority", middleware.RequirePermission(models.PermRequestsPrioritize), handlers.RemoveRequestPriority(databaseConnection))
+			requestsGroup.POST("/:id/toggle-urgent", middleware.RequirePermission(models.PermRequestsPrioritize), handlers.ToggleUrgentPriority(databaseConnection))
 
 			// Itens de cada requisição (incluindo recebimentos)
 			itemsGroup := requestsGroup.Group("/:id/items")
@@ -76,24 +134,37 @@ func Setup(router *gin.Engine, databaseConnection *gorm.DB, appConfig *config.Co
 				itemsGroup.DELETE("/:itemId", handlers.DeleteItem(databaseConnection))
 
 				// Recebimentos por item
-				itemsGroup.POST("/:itemId/receipts", handlers.CreateReceipt(databaseConnection))
+				itemsGroup.POST("/:itemId/receipts", middleware.RequirePermission(models.PermReceiptsCreate), handlers.CreateReceipt(databaseConnection))
 				itemsGroup.GET("/:itemId/receipts", handlers.ListItemReceipts(databaseConnection))
 			}
 
 			// Anexos de cada requisição
 			attachmentsGroup := requestsGroup.Group("/:id/attachments")
 			{
-				attachmentsGroup.POST("", handlers.UploadAttachment(databaseConnection))
+				attachmentsGroup.POST("", handlers.UploadAttachment(databaseConnection, store, scan, previews))
 				attachmentsGroup.GET("", handlers.ListAttachments(databaseConnection))
-				attachmentsGroup.GET("/:attachmentId", handlers.DownloadAttachment(databaseConnection))
+				attachmentsGroup.GET("/:attachmentId", handlers.DownloadAttachment(databaseConnection, store))
+				attachmentsGroup.PUT("/:attachmentId", handlers.ReplaceAttachment(databaseConnection, store, scan, previews))
+				attachmentsGroup.DELETE("/:attachmentId", handlers.DeleteAttachment(databaseConnection))
 			}
 
 			// Orçamentos
-			requestsGroup.POST("/:id/items/:itemId/budgets", handlers.CreateItemBudget(databaseConnection))
+			requestsGroup.POST("/:id/items/:itemId/budgets", middleware.RequirePermission(models.PermBudgetsManage), handlers.CreateItemBudget(databaseConnection))
 			requestsGroup.GET("/:id/budgets", handlers.ListRequestBudgets(databaseConnection))
+			requestsGroup.GET("/:id/budgets/comparison", handlers.GetRequestBudgetComparison(databaseConnection))
 
-			requestsGroup.POST("/:id/complete", handlers.CompleteRequest(databaseConnection))
-			requestsGroup.POST("/:id/reopen", handlers.ReopenRequest(databaseConnection))
+			// Cadeia de aprovação
+			requestsGroup.GET("/:id/approvals", handlers.ListRequestApprovals(databaseConnection))
+			requestsGroup.POST("/:id/approvals/decide", handlers.DecideRequestApproval(databaseConnection))
+
+			requestsGroup.POST("/:id/complete", middleware.RequirePermission(models.PermRequestsReview), handlers.CompleteRequest(databaseConnection))
+			requestsGroup.POST("/:id/reopen", middleware.RequirePermission(models.PermRequestsReview), handlers.ReopenRequest(databaseConnection))
+			requestsGroup.GET("/:id/history", handlers.ListRequestStatusHistory(databaseConnection))
+
+			// Pré-aprovação e comentários do gestor do setor
+			requestsGroup.POST("/:id/sector-approval", handlers.DecideSectorApproval(databaseConnection))
+			requestsGroup.GET("/:id/comments", handlers.ListRequestComments(databaseConnection))
+			requestsGroup.POST("/:id/comments", handlers.CreateRequestComment(databaseConnection))
 
 			// Recebimentos gerais da requisição
 			receiptsGroup := requestsGroup.Group("/:id/receipts")
@@ -103,114 +174,218 @@ func Setup(router *gin.Engine, databaseConnection *gorm.DB, appConfig *config.Co
 			}
 		}
 
+		// Anexos de qualquer dono (requisição, item, orçamento, recebimento, fornecedor,
+		// pedido de cadastro); acesso conforme o dono do anexo
+		attachmentGroup := apiGroup.Group("/attachments")
+		attachmentGroup.Use(authenticated...)
+		{
+			attachmentGroup.POST("", handlers.UploadOwnerAttachment(databaseConnection, store, scan, previews))
+			attachmentGroup.GET("", handlers.ListOwnerAttachments(databaseConnection))
+			attachmentGroup.GET("/:id", handlers.DownloadAttachmentByID(databaseConnection, store))
+			attachmentGroup.GET("/:id/thumbnail", handlers.GetAttachmentThumbnail(databaseConnection, store))
+			attachmentGroup.PUT("/:id", handlers.ReplaceAttachmentByID(databaseConnection, store, scan, previews))
+			attachmentGroup.DELETE("/:id", handlers.DeleteAttachmentByID(databaseConnection))
+		}
+
 		// Rotas fora de /requests
-		apiGroup.PATCH("/budgets/:budgetID", handlers.UpdateBudget(databaseConnection))
-		apiGroup.DELETE("/budgets/:budgetID", handlers.DeleteBudget(databaseConnection))
+		budgetsGroup := apiGroup.Group("/budgets")
+		budgetsGroup.Use(
+			middleware.AuthMiddleware(appConfig.JWTSecretKey, databaseConnection),
+			middleware.RequirePermission(models.PermBudgetsManage),
+			audit.Middleware(databaseConnection),
+		)
+		{
+			budgetsGroup.PATCH("/:budgetID", handlers.UpdateBudget(databaseConnection))
+			budgetsGroup.DELETE("/:budgetID", handlers.DeleteBudget(databaseConnection))
+			budgetsGroup.PATCH("/:budgetID/select", handlers.SelectBudget(databaseConnection))
+		}
+
+		// Pedidos de compra (protegido)
+		purchaseOrdersGroup := apiGroup.Group("/purchase-orders")
+		purchaseOrdersGroup.Use(authenticated...)
+		{
+			purchaseOrdersGroup.GET("", middleware.RequirePermission(models.PermPurchaseOrdersView), handlers.ListPurchaseOrders(databaseConnection))
+			purchaseOrdersGroup.POST("", middleware.RequirePermission(models.PermPurchaseOrdersManage), handlers.CreatePurchaseOrders(databaseConnection))
+			purchaseOrdersGroup.GET("/supplier-summary", middleware.RequirePermission(models.PermPurchaseOrdersView), handlers.GetPurchaseOrderSupplierSummary(databaseConnection))
+			purchaseOrdersGroup.GET("/:id", middleware.RequirePermission(models.PermPurchaseOrdersView), handlers.GetPurchaseOrder(databaseConnection))
+			purchaseOrdersGroup.PATCH("/:id/status", middleware.RequirePermission(models.PermPurchaseOrdersManage), handlers.UpdatePurchaseOrderStatus(databaseConnection))
+		}
+
+		// Políticas de aprovação e aprovações pendentes do usuário
+		approvalPoliciesGroup := apiGroup.Group("/approval-policies")
+		approvalPoliciesGroup.Use(authenticated...)
+		{
+			approvalPoliciesGroup.GET("", middleware.RequirePermission(models.PermApprovalPoliciesManage), handlers.ListApprovalPolicies(databaseConnection))
+			approvalPoliciesGroup.POST("", middleware.RequirePermission(models.PermApprovalPoliciesManage), handlers.CreateApprovalPolicy(databaseConnection))
+			approvalPoliciesGroup.PUT("/:id", middleware.RequirePermission(models.PermApprovalPoliciesManage), handlers.UpdateApprovalPolicy(databaseConnection))
+			approvalPoliciesGroup.DELETE("/:id", middleware.RequirePermission(models.PermApprovalPoliciesManage), handlers.DeleteApprovalPolicy(databaseConnection))
+		}
+		apiGroup.GET("/approvals/pending",
+			middleware.AuthMiddleware(appConfig.JWTSecretKey, databaseConnection),
+			handlers.ListPendingApprovals(databaseConnection),
+		)
 
 		// Setores (protegido)
 		sectors := apiGroup.Group("/sectors")
-		sectors.Use(middleware.AuthMiddleware(appConfig.JWTSecretKey))
+		sectors.Use(authenticated...)
 		{
 			sectors.GET("", handlers.ListSectors(databaseConnection))
-			sectors.POST("", handlers.CreateSector(databaseConnection))
-			sectors.PATCH("/:id", handlers.UpdateSector(databaseConnection))
-			sectors.DELETE("/:id", handlers.DeleteSector(databaseConnection))
+			sectors.POST("", middleware.RequirePermission(models.PermSectorsManage), handlers.CreateSector(databaseConnection))
+			sectors.PATCH("/:id", middleware.RequirePermission(models.PermSectorsManage), handlers.UpdateSector(databaseConnection))
+			sectors.DELETE("/:id", middleware.RequirePermission(models.PermSectorsManage), handlers.DeleteSector(databaseConnection))
+			sectors.PUT("/:id/manager", middleware.RequirePermission(models.PermSectorsManage), handlers.AssignSectorManager(databaseConnection))
+			sectors.PATCH("/:id/move", middleware.RequirePermission(models.PermSectorsManage), handlers.MoveSector(databaseConnection))
+			sectors.GET("/managed", handlers.ListManagedSectors(databaseConnection))
+			sectors.GET("/tree", handlers.GetSectorTree(databaseConnection))
+		}
+
+		// Verbas dos setores: leitura para sector-budgets:manage, reports:view ou gestor do setor
+		sectorBudgets := apiGroup.Group("/sector-budgets")
+		sectorBudgets.Use(authenticated...)
+		{
+			sectorBudgets.GET("", handlers.ListSectorBudgets(databaseConnection))
+			sectorBudgets.POST("", middleware.RequirePermission(models.PermSectorBudgetsManage), handlers.CreateSectorBudget(databaseConnection))
+			sectorBudgets.GET("/report", handlers.GetSectorBudgetReport(databaseConnection))
+			sectorBudgets.GET("/report.xlsx", handlers.ExportSectorBudgetReport(databaseConnection))
+			sectorBudgets.GET("/:id", handlers.GetSectorBudget(databaseConnection))
+			sectorBudgets.PUT("/:id", middleware.RequirePermission(models.PermSectorBudgetsManage), handlers.UpdateSectorBudget(databaseConnection))
+			sectorBudgets.DELETE("/:id", middleware.RequirePermission(models.PermSectorBudgetsManage), handlers.DeleteSectorBudget(databaseConnection))
+			sectorBudgets.GET("/:id/entries", handlers.ListSectorBudgetEntries(databaseConnection))
 		}
 
 		// Solicitantes (protegido)
 		solicitantes := apiGroup.Group("/requesters")
-		solicitantes.Use(middleware.AuthMiddleware(appConfig.JWTSecretKey))
+		solicitantes.Use(authenticated...)
 		{
-			solicitantes.GET("", handlers.ListRequesters(databaseConnection))
-			solicitantes.POST("", handlers.CreateRequester(databaseConnection))
+			solicitantes.GET("", middleware.RequirePermission(models.PermUsersView), handlers.ListRequesters(databaseConnection))
+			solicitantes.POST("", middleware.RequirePermission(models.PermUsersManage), handlers.CreateRequester(databaseConnection))
 		}
 
 		// Fornecedores (protegido)
 		suppliersGroup := apiGroup.Group("/suppliers")
-		suppliersGroup.Use(middleware.AuthMiddleware(appConfig.JWTSecretKey))
+		suppliersGroup.Use(authenticated...)
 		{
 			suppliersGroup.GET("", handlers.ListSuppliers(databaseConnection))
-			suppliersGroup.POST("", handlers.CreateSupplier(databaseConnection))
+			suppliersGroup.POST("", middleware.RequirePermission(models.PermSuppliersManage), handlers.CreateSupplier(databaseConnection))
 			suppliersGroup.GET("/:id", handlers.GetSupplier(databaseConnection))
-			suppliersGroup.PATCH("/:id", handlers.UpdateSupplier(databaseConnection))
-			suppliersGroup.DELETE("/:id", handlers.DeleteSupplier(databaseConnection))
+			suppliersGroup.PATCH("/:id", middleware.RequirePermission(models.PermSuppliersManage), handlers.UpdateSupplier(databaseConnection))
+			suppliersGroup.DELETE("/:id", middleware.RequirePermission(models.PermSuppliersManage), handlers.DeleteSupplier(databaseConnection))
 		}
 
 		// Notificações com middleware SSE especial
 		apiGroup.GET("/notifications",
-			middleware.SSEAuthMiddleware(appConfig.JWTSecretKey),
+			middleware.SSEAuthMiddleware(appConfig.JWTSecretKey, databaseConnection),
 			handlers.NotificationsStream(),
 		)
 
 		// Rota de teste (temporária)
 		apiGroup.GET("/test-notification",
-			middleware.AuthMiddleware(appConfig.JWTSecretKey),
+			middleware.AuthMiddleware(appConfig.JWTSecretKey, databaseConnection),
 			handlers.TestNotification(),
 		)
 
+		// Busca textual (requisições, produtos, fornecedores e recebimentos visíveis ao usuário)
+		apiGroup.GET("/search",
+			middleware.AuthMiddleware(appConfig.JWTSecretKey, databaseConnection),
+			handlers.Search(databaseConnection),
+		)
+
 		// Relatórios em Excel
 		apiGroup.GET("/reports/requests.xlsx",
-			middleware.AuthMiddleware(appConfig.JWTSecretKey),
+			middleware.AuthMiddleware(appConfig.JWTSecretKey, databaseConnection),
+			middleware.RequirePermission(models.PermReportsView),
 			handlers.ExportRequestsExcel(databaseConnection),
 		)
 		apiGroup.GET("/reports/receipts.xlsx",
-			middleware.AuthMiddleware(appConfig.JWTSecretKey),
+			middleware.AuthMiddleware(appConfig.JWTSecretKey, databaseConnection),
+			middleware.RequirePermission(models.PermReportsView),
 			handlers.ExportReceiptsExcel(databaseConnection),
 		)
 
 		// Upload/download de nota fiscal de recebimento
 		apiGroup.POST(
 			"/receipts/:receiptId/invoice",
-			middleware.AuthMiddleware(appConfig.JWTSecretKey),
-			handlers.UploadReceiptAttachment(databaseConnection),
+			middleware.AuthMiddleware(appConfig.JWTSecretKey, databaseConnection),
+			middleware.RequirePermission(models.PermReceiptsCreate),
+			handlers.UploadReceiptAttachment(databaseConnection, store, scan, previews),
 		)
 		apiGroup.GET(
 			"/receipts/:receiptId/invoice",
-			middleware.AuthMiddleware(appConfig.JWTSecretKey),
-			handlers.DownloadReceiptInvoice(databaseConnection),
+			middleware.AuthMiddleware(appConfig.JWTSecretKey, databaseConnection),
+			handlers.DownloadReceiptInvoice(databaseConnection, store),
 		)
 
 		productRequestsGroup := apiGroup.Group("/product-requests")
-		productRequestsGroup.Use(middleware.AuthMiddleware(appConfig.JWTSecretKey))
+		productRequestsGroup.Use(authenticated...)
 		{
 			productRequestsGroup.GET("", handlers.ListProductRegistrationRequests(databaseConnection))
 			productRequestsGroup.POST("", handlers.CreateProductRegistrationRequest(databaseConnection))
-			productRequestsGroup.GET("/stats", handlers.GetProductRegistrationStats(databaseConnection))
+			productRequestsGroup.GET("/stats", middleware.RequirePermission(models.PermProductRequestsReview), handlers.GetProductRegistrationStats(databaseConnection))
 			productRequestsGroup.GET("/:id", handlers.GetProductRegistrationRequest(databaseConnection))
 			productRequestsGroup.PATCH("/:id", handlers.UpdateProductRegistrationRequest(databaseConnection))
 			productRequestsGroup.DELETE("/:id", handlers.DeleteProductRegistrationRequest(databaseConnection))
-			productRequestsGroup.PATCH("/:id/process", handlers.ProcessProductRegistrationRequest(databaseConnection))
+			productRequestsGroup.PATCH("/:id/process", middleware.RequirePermission(models.PermProductRequestsReview), handlers.ProcessProductRegistrationRequest(databaseConnection))
 		}
 
 		profileGroup := apiGroup.Group("/profile")
-		profileGroup.Use(middleware.AuthMiddleware(appConfig.JWTSecretKey))
+		profileGroup.Use(authenticated...)
 		{
 			profileGroup.GET("", handlers.GetProfile(databaseConnection))
 			profileGroup.PUT("", handlers.UpdateProfile(databaseConnection))
 			profileGroup.PATCH("/password", handlers.ChangePassword(databaseConnection))
+			profileGroup.GET("/permissions", handlers.GetMyPermissions())
 		}
 
 		// ✅ CONFIGURAÇÕES (PROTEGIDAS - EXCETO LOGO GET QUE JÁ ESTÁ ACIMA)
 		settingsGroup := apiGroup.Group("/settings")
-		settingsGroup.Use(middleware.AuthMiddleware(appConfig.JWTSecretKey))
+		settingsGroup.Use(authenticated...)
 		{
 			// Configurações da empresa
-			settingsGroup.GET("/company", handlers.GetCompanySettings(databaseConnection))
-			settingsGroup.PUT("/company", handlers.UpdateCompanySettings(databaseConnection))
-			settingsGroup.POST("/company/logo", handlers.UploadCompanyLogo(databaseConnection))
+			settingsGroup.GET("/company", middleware.RequirePermission(models.PermSettingsManage), handlers.GetCompanySettings(databaseConnection))
+			settingsGroup.PUT("/company", middleware.RequirePermission(models.PermSettingsManage), handlers.UpdateCompanySettings(databaseConnection))
+			settingsGroup.POST("/company/logo", middleware.RequirePermission(models.PermSettingsManage), handlers.UploadCompanyLogo(databaseConnection, store))
 			// ⚠️ REMOVIDO: settingsGroup.GET("/company/logo", ...) - JÁ ESTÁ PÚBLICO ACIMA
 
 			// Configurações do sistema
-			settingsGroup.GET("/system", handlers.GetSystemSettings(databaseConnection))
-			settingsGroup.PUT("/system", handlers.UpdateSystemSettings(databaseConnection))
+			settingsGroup.GET("/system", middleware.RequirePermission(models.PermSettingsManage), handlers.GetSystemSettings(databaseConnection))
+			settingsGroup.PUT("/system", middleware.RequirePermission(models.PermSettingsManage), handlers.UpdateSystemSettings(databaseConnection))
+		}
+
+		// Log de auditoria
+		apiGroup.GET("/audit",
+			middleware.AuthMiddleware(appConfig.JWTSecretKey, databaseConnection),
+			middleware.RequirePermission(models.PermAuditView),
+			handlers.ListAuditLogs(databaseConnection),
+		)
+
+		// Papéis e permissões
+		rolesGroup := apiGroup.Group("/roles")
+		rolesGroup.Use(authenticated...)
+		{
+			rolesGroup.GET("", middleware.RequireAnyPermission(models.PermRolesManage, models.PermUsersView, models.PermUsersManage, models.PermApprovalPoliciesManage), handlers.ListRoles(databaseConnection))
+			rolesGroup.GET("/permissions", handlers.ListPermissions())
+			rolesGroup.POST("", middleware.RequirePermission(models.PermRolesManage), handlers.CreateRole(databaseConnection))
+			rolesGroup.PUT("/:id", middleware.RequirePermission(models.PermRolesManage), handlers.UpdateRole(databaseConnection))
+			rolesGroup.DELETE("/:id", middleware.RequirePermission(models.PermRolesManage), handlers.DeleteRole(databaseConnection))
+		}
+
+		// Segurança de login
+		securityGroup := apiGroup.Group("/security")
+		securityGroup.Use(authenticated...)
+		{
+			securityGroup.GET("/login-attempts", middleware.RequirePermission(models.PermSecurityManage), handlers.ListLoginAttempts(databaseConnection))
+			securityGroup.GET("/lockouts", middleware.RequirePermission(models.PermSecurityManage), handlers.ListLoginLockouts(databaseConnection))
+			securityGroup.DELETE("/lockouts/ip/:ip", middleware.RequirePermission(models.PermSecurityManage), handlers.UnlockIPLogin(databaseConnection))
 		}
 
-		// Relatórios (apenas admin)
+		// Relatórios
 		reportsGroup := apiGroup.Group("/reports")
-		reportsGroup.Use(middleware.AuthMiddleware(appConfig.JWTSecretKey))
+		reportsGroup.Use(authenticated...)
 		{
+			// reports:view ou gestor de setor (relatório restrito ao próprio setor)
 			reportsGroup.GET("/requests", handlers.GetRequestsReport(databaseConnection))
 			reportsGroup.GET("/requests/export", handlers.ExportRequestsReport(databaseConnection))
+			reportsGroup.GET("/sectors", handlers.GetSectorRollupReport(databaseConnection))
 		}
 	}
 }
@@ -0,0 +1,164 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// Protocolo do clamd (daemon do ClamAV): comandos com prefixo "z" terminam em \0;
+// INSTREAM envia o arquivo em blocos precedidos do tamanho (4 bytes, big-endian)
+// e termina com um bloco de tamanho zero (https://docs.clamav.net/manual/Usage/Scanning.html)
+
+// clamdChunkSize - Tamanho dos blocos enviados no INSTREAM
+const clamdChunkSize = 64 * 1024
+
+// Clamd - Verificação via clamd, por TCP ou socket Unix
+type Clamd struct {
+	network string
+	address string
+	timeout time.Duration
+}
+
+// NewClamd aceita "tcp://host:porta", "unix:///caminho" ou apenas "host:porta"
+// (padrão tcp://127.0.0.1:3310, timeout padrão de 2 minutos)
+func NewClamd(address string, timeout time.Duration) (*Clamd, error) {
+	if address == "" {
+		address = "tcp://127.0.0.1:3310"
+	}
+	if timeout <= 0 {
+		timeout = 2 * time.Minute
+	}
+
+	c := &Clamd{network: "tcp", address: address, timeout: timeout}
+	switch {
+	case strings.HasPrefix(address, "unix://"):
+		c.network, c.address = "unix", strings.TrimPrefix(address, "unix://")
+	case strings.HasPrefix(address, "tcp://"):
+		c.address = strings.TrimPrefix(address, "tcp://")
+	}
+	if c.address == "" {
+		return nil, fmt.Errorf("CLAMD_ADDRESS inválido: %q", address)
+	}
+	return c, nil
+}
+
+// Name identifica o scanner no log
+func (c *Clamd) Name() string {
+	return BackendClamd + " (" + c.network + "://" + c.address + ")"
+}
+
+// Ping confere se o clamd está respondendo
+func (c *Clamd) Ping(ctx context.Context) error {
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte("zPING\x00")); err != nil {
+		return err
+	}
+	reply, err := readReply(conn)
+	if err != nil {
+		return err
+	}
+	if reply != "PONG" {
+		return fmt.Errorf("resposta inesperada do clamd: %q", reply)
+	}
+	return nil
+}
+
+// Scan envia o conteúdo com INSTREAM e interpreta a resposta
+// ("stream: OK" ou "stream: <assinatura> FOUND")
+func (c *Clamd) Scan(ctx context.Context, r io.Reader) (Result, error) {
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return Result{}, err
+	}
+	defer conn.Close()
+
+	if err := sendStream(conn, r); err != nil {
+		// O clamd fecha a conexão ao passar do StreamMaxLength, mas ainda envia o motivo
+		if reply, replyErr := readReply(conn); replyErr == nil && reply != "" {
+			return Result{}, fmt.Errorf("clamd: %s", reply)
+		}
+		return Result{}, err
+	}
+
+	reply, err := readReply(conn)
+	if err != nil {
+		return Result{}, err
+	}
+	return parseReply(reply)
+}
+
+// dial conecta respeitando o timeout e o prazo do contexto
+func (c *Clamd) dial(ctx context.Context) (net.Conn, error) {
+	dialer := net.Dialer{Timeout: 10 * time.Second}
+	conn, err := dialer.DialContext(ctx, c.network, c.address)
+	if err != nil {
+		return nil, fmt.Errorf("não foi possível conectar ao clamd em %s: %w", c.address, err)
+	}
+	deadline := time.Now().Add(c.timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	conn.SetDeadline(deadline)
+	return conn, nil
+}
+
+// sendStream envia o comando INSTREAM, os blocos do arquivo e o bloco final vazio
+func sendStream(conn net.Conn, r io.Reader) error {
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return err
+	}
+
+	buf := make([]byte, 4+clamdChunkSize)
+	for {
+		n, readErr := io.ReadFull(r, buf[4:])
+		if n > 0 {
+			binary.BigEndian.PutUint32(buf[:4], uint32(n))
+			if _, err := conn.Write(buf[:4+n]); err != nil {
+				return err
+			}
+		}
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			break
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+
+	_, err := conn.Write([]byte{0, 0, 0, 0})
+	return err
+}
+
+// readReply lê a resposta terminada em \0
+func readReply(conn net.Conn) (string, error) {
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && !(errors.Is(err, io.EOF) && reply != "") {
+		return "", fmt.Errorf("erro ao ler resposta do clamd: %w", err)
+	}
+	return strings.TrimSpace(strings.TrimRight(reply, "\x00")), nil
+}
+
+// parseReply interpreta a resposta do INSTREAM
+func parseReply(reply string) (Result, error) {
+	body := strings.TrimPrefix(reply, "stream: ")
+	switch {
+	case body == "OK":
+		return Result{}, nil
+	case strings.HasSuffix(body, " FOUND"):
+		return Result{Infected: true, Signature: strings.TrimSuffix(body, " FOUND")}, nil
+	default:
+		return Result{}, fmt.Errorf("clamd: %s", reply)
+	}
+}
@@ -0,0 +1,80 @@
+// Package scanner verifica os arquivos enviados (anexos, notas fiscais) contra malware.
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/kailon630/sistemas-pedidos/PedidoCompras-api/internal/storage"
+)
+
+// Scanners disponíveis (SCANNER)
+const (
+	BackendNone  = "none"
+	BackendClamd = "clamd"
+)
+
+// Result - Resultado da verificação de um arquivo
+type Result struct {
+	Infected  bool
+	Signature string // nome da ameaça encontrada (vazio quando limpo)
+}
+
+// Scanner - Verificação de arquivos. Implementações: Clamd e Noop
+type Scanner interface {
+	Scan(ctx context.Context, r io.Reader) (Result, error)
+	Name() string
+}
+
+// Config - Configuração do scanner
+type Config struct {
+	Backend      string        // none (padrão) ou clamd
+	ClamdAddress string        // tcp://host:porta ou unix:///caminho/do/socket
+	Timeout      time.Duration // tempo máximo de uma verificação
+}
+
+// New cria o scanner configurado; sem SCANNER os arquivos são aceitos sem verificação
+func New(cfg Config) (Scanner, error) {
+	switch cfg.Backend {
+	case "", BackendNone:
+		return Noop{}, nil
+	case BackendClamd:
+		return NewClamd(cfg.ClamdAddress, cfg.Timeout)
+	default:
+		return nil, fmt.Errorf("SCANNER inválido: %q (use none ou clamd)", cfg.Backend)
+	}
+}
+
+// Noop - Não verifica: todo arquivo é considerado limpo
+type Noop struct{}
+
+// Scan descarta o conteúdo e devolve "limpo"
+func (Noop) Scan(ctx context.Context, r io.Reader) (Result, error) {
+	return Result{}, nil
+}
+
+// Name identifica o scanner no log
+func (Noop) Name() string {
+	return BackendNone
+}
+
+// ScanStored verifica um arquivo já gravado no armazenamento
+func ScanStored(ctx context.Context, s Scanner, store storage.Storage, key string) (Result, error) {
+	reader, _, err := store.Open(ctx, key)
+	if err != nil {
+		return Result{}, err
+	}
+	defer reader.Close()
+
+	result, err := s.Scan(ctx, reader)
+	if err != nil {
+		return Result{}, fmt.Errorf("%s: %w", s.Name(), err)
+	}
+	if result.Infected {
+		log.Printf("🦠 Malware detectado em %s: %s", key, result.Signature)
+	}
+	return result, nil
+}
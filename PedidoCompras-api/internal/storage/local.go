@@ -0,0 +1,166 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Local - Arquivos em um diretório do servidor
+type Local struct {
+	root string
+}
+
+// NewLocal usa o diretório informado (padrão "uploads", relativo ao diretório do servidor)
+func NewLocal(root string) *Local {
+	if root == "" {
+		root = "uploads"
+	}
+	return &Local{root: root}
+}
+
+// Name identifica o backend nas mensagens e no log
+func (l *Local) Name() string {
+	return BackendLocal + " (" + l.root + ")"
+}
+
+// path resolve a chave dentro da raiz
+func (l *Local) path(key string) (string, error) {
+	cleaned, err := CleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(l.root, filepath.FromSlash(cleaned)), nil
+}
+
+// Put grava em um arquivo temporário e renomeia, para que leitores nunca vejam arquivos parciais
+func (l *Local) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	target, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), os.ModePerm); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".upload-*")
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+// Open abre o arquivo para leitura
+func (l *Local) Open(ctx context.Context, key string) (io.ReadCloser, ObjectInfo, error) {
+	target, err := l.path(key)
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+	file, err := os.Open(target)
+	if err != nil {
+		return nil, ObjectInfo{}, localError(err)
+	}
+	stat, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, ObjectInfo{}, err
+	}
+	if stat.IsDir() {
+		file.Close()
+		return nil, ObjectInfo{}, ErrNotFound
+	}
+	return file, localInfo(key, stat), nil
+}
+
+// Stat devolve os metadados do arquivo
+func (l *Local) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	target, err := l.path(key)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	stat, err := os.Stat(target)
+	if err != nil {
+		return ObjectInfo{}, localError(err)
+	}
+	if stat.IsDir() {
+		return ObjectInfo{}, ErrNotFound
+	}
+	return localInfo(key, stat), nil
+}
+
+// Delete remove o arquivo
+func (l *Local) Delete(ctx context.Context, key string) error {
+	target, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(target); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// List percorre os arquivos sob a raiz cujas chaves começam com prefix
+func (l *Local) List(ctx context.Context, prefix string, fn func(ObjectInfo) error) error {
+	err := filepath.WalkDir(l.root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".upload-") {
+			return nil
+		}
+		rel, err := filepath.Rel(l.root, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		stat, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return fn(localInfo(key, stat))
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func localInfo(key string, stat fs.FileInfo) ObjectInfo {
+	return ObjectInfo{
+		Key:         key,
+		Size:        stat.Size(),
+		ContentType: ContentTypeOf(key),
+		ModTime:     stat.ModTime(),
+	}
+}
+
+func localError(err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return ErrNotFound
+	}
+	return err
+}
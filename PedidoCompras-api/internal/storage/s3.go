@@ -0,0 +1,274 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// S3 - Objetos em um bucket compatível com S3 (AWS S3, MinIO)
+type S3 struct {
+	cfg      Config
+	endpoint *url.URL
+	client   *http.Client
+}
+
+// NewS3 valida a configuração; sem S3_ENDPOINT usa o endpoint regional da AWS
+func NewS3(cfg Config) (*S3, error) {
+	if cfg.S3Bucket == "" || cfg.S3AccessKey == "" || cfg.S3SecretKey == "" {
+		return nil, errors.New("S3_BUCKET, S3_ACCESS_KEY e S3_SECRET_KEY são obrigatórios com STORAGE_BACKEND=s3")
+	}
+	if cfg.S3Region == "" {
+		cfg.S3Region = "us-east-1"
+	}
+	if cfg.S3Endpoint == "" {
+		cfg.S3Endpoint = "https://s3." + cfg.S3Region + ".amazonaws.com"
+	}
+	endpoint, err := url.Parse(strings.TrimRight(cfg.S3Endpoint, "/"))
+	if err != nil || endpoint.Host == "" || (endpoint.Scheme != "http" && endpoint.Scheme != "https") {
+		return nil, fmt.Errorf("S3_ENDPOINT inválido: %q", cfg.S3Endpoint)
+	}
+	cfg.S3Prefix = strings.Trim(cfg.S3Prefix, "/")
+
+	return &S3{
+		cfg:      cfg,
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: 5 * time.Minute},
+	}, nil
+}
+
+// Name identifica o backend nas mensagens e no log
+func (s *S3) Name() string {
+	return BackendS3 + " (" + s.endpoint.Host + "/" + s.cfg.S3Bucket + ")"
+}
+
+// objectName - Nome do objeto no bucket (com o prefixo configurado)
+func (s *S3) objectName(key string) (string, error) {
+	cleaned, err := CleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	if s.cfg.S3Prefix != "" {
+		cleaned = s.cfg.S3Prefix + "/" + cleaned
+	}
+	return cleaned, nil
+}
+
+// objectURL monta a URL no estilo caminho (endpoint/bucket/objeto) ou subdomínio (bucket.endpoint/objeto)
+func (s *S3) objectURL(object string, query url.Values) *url.URL {
+	u := *s.endpoint
+	p := "/" + object
+	if s.cfg.S3PathStyle {
+		p = "/" + s.cfg.S3Bucket + p
+	} else {
+		u.Host = s.cfg.S3Bucket + "." + u.Host
+	}
+	u.Path = p
+	u.RawPath = uriEncode(p, false)
+	u.RawQuery = canonicalQuery(query)
+	return &u
+}
+
+// do assina e envia a requisição; respostas fora de 2xx viram erro
+func (s *S3) do(ctx context.Context, method string, u *url.URL, body io.Reader, size int64, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	payloadHash := emptyPayloadHash
+	if body != nil {
+		req.ContentLength = size
+		payloadHash = unsignedPayload
+	}
+	signV4(req, s.cfg.S3AccessKey, s.cfg.S3SecretKey, s.cfg.S3Region, payloadHash, time.Now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	var s3Err struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if xml.Unmarshal(data, &s3Err) == nil && s3Err.Code != "" {
+		return nil, fmt.Errorf("S3 %s %s: %s (%s)", method, u.Path, s3Err.Code, s3Err.Message)
+	}
+	return nil, fmt.Errorf("S3 %s %s: HTTP %d", method, u.Path, resp.StatusCode)
+}
+
+// Put envia o objeto. Com tamanho desconhecido o conteúdo é lido para a memória
+// (o S3 exige Content-Length em PUT simples).
+func (s *S3) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	object, err := s.objectName(key)
+	if err != nil {
+		return err
+	}
+	if size < 0 {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		r, size = bytes.NewReader(data), int64(len(data))
+	}
+	if contentType == "" {
+		contentType = ContentTypeOf(key)
+	}
+
+	header := http.Header{}
+	header.Set("Content-Type", contentType)
+	// Corpo vazio ainda precisa ser enviado como PUT com Content-Length 0
+	body := r
+	if size == 0 {
+		body = http.NoBody
+	}
+	resp, err := s.do(ctx, http.MethodPut, s.objectURL(object, nil), body, size, header)
+	if err != nil {
+		return err
+	}
+	io.Copy(io.Discard, resp.Body)
+	return resp.Body.Close()
+}
+
+// Open baixa o objeto em streaming
+func (s *S3) Open(ctx context.Context, key string) (io.ReadCloser, ObjectInfo, error) {
+	object, err := s.objectName(key)
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+	resp, err := s.do(ctx, http.MethodGet, s.objectURL(object, nil), nil, 0, nil)
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+	return resp.Body, s3Info(key, resp), nil
+}
+
+// Stat consulta os metadados com HEAD
+func (s *S3) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	object, err := s.objectName(key)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	resp, err := s.do(ctx, http.MethodHead, s.objectURL(object, nil), nil, 0, nil)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	resp.Body.Close()
+	return s3Info(key, resp), nil
+}
+
+// Delete remove o objeto (o S3 já responde 204 para objetos inexistentes)
+func (s *S3) Delete(ctx context.Context, key string) error {
+	object, err := s.objectName(key)
+	if err != nil {
+		return err
+	}
+	resp, err := s.do(ctx, http.MethodDelete, s.objectURL(object, nil), nil, 0, nil)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// listResult - Resposta do ListObjectsV2
+type listResult struct {
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+	Contents              []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+}
+
+// List percorre os objetos do bucket (ListObjectsV2, 1000 por página)
+func (s *S3) List(ctx context.Context, prefix string, fn func(ObjectInfo) error) error {
+	objectPrefix := prefix
+	if s.cfg.S3Prefix != "" {
+		objectPrefix = s.cfg.S3Prefix + "/" + prefix
+	}
+
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}}
+		if objectPrefix != "" {
+			query.Set("prefix", objectPrefix)
+		}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		// Estilo caminho: /bucket/ ; subdomínio: /
+		u := s.objectURL("", query)
+		resp, err := s.do(ctx, http.MethodGet, u, nil, 0, nil)
+		if err != nil {
+			return err
+		}
+		var result listResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("resposta inválida do S3 ao listar objetos: %w", err)
+		}
+
+		for _, item := range result.Contents {
+			key := item.Key
+			if s.cfg.S3Prefix != "" {
+				key = strings.TrimPrefix(key, s.cfg.S3Prefix+"/")
+			}
+			if strings.HasSuffix(key, "/") {
+				continue
+			}
+			info := ObjectInfo{Key: key, Size: item.Size, ContentType: ContentTypeOf(key), ModTime: item.LastModified}
+			if err := fn(info); err != nil {
+				return err
+			}
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+func s3Info(key string, resp *http.Response) ObjectInfo {
+	info := ObjectInfo{
+		Key:         key,
+		Size:        resp.ContentLength,
+		ContentType: resp.Header.Get("Content-Type"),
+	}
+	if size, err := strconv.ParseInt(resp.Header.Get("Content-Length"), 10, 64); err == nil {
+		info.Size = size
+	}
+	if info.ContentType == "" {
+		info.ContentType = ContentTypeOf(path.Base(key))
+	}
+	if modTime, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		info.ModTime = modTime
+	}
+	return info
+}
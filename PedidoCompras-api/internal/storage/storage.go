@@ -0,0 +1,134 @@
+// Package storage guarda os arquivos enviados (anexos, notas fiscais, logo)
+// no disco local ou em um bucket compatível com S3 (AWS, MinIO).
+//
+// O banco guarda apenas a chave do objeto (ex: "requests/12/1700000000_orcamento.pdf"),
+// que é a mesma em qualquer backend.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"path"
+	"strings"
+	"time"
+)
+
+// Backends disponíveis (STORAGE_BACKEND)
+const (
+	BackendLocal = "local"
+	BackendS3    = "s3"
+)
+
+// ErrNotFound - O objeto não existe no backend
+var ErrNotFound = errors.New("arquivo não encontrado no armazenamento")
+
+// ObjectInfo - Metadados de um objeto armazenado
+type ObjectInfo struct {
+	Key         string
+	Size        int64
+	ContentType string
+	ModTime     time.Time
+}
+
+// Storage - Armazenamento de arquivos. Implementações: Local e S3
+type Storage interface {
+	// Put grava o conteúdo sob a chave; size -1 quando desconhecido
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Open abre o objeto para leitura; o chamador deve fechar o leitor
+	Open(ctx context.Context, key string) (io.ReadCloser, ObjectInfo, error)
+	Stat(ctx context.Context, key string) (ObjectInfo, error)
+	// Delete remove o objeto; remover um objeto inexistente não é erro
+	Delete(ctx context.Context, key string) error
+	// List chama fn para cada objeto cuja chave começa com prefix
+	List(ctx context.Context, prefix string, fn func(ObjectInfo) error) error
+	Name() string
+}
+
+// Config - Configuração do armazenamento
+type Config struct {
+	Backend string // local (padrão) ou s3
+
+	LocalDir string // diretório raiz do backend local (padrão "uploads")
+
+	S3Endpoint  string // ex: http://localhost:9000 (MinIO); vazio usa a AWS
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+	S3PathStyle bool   // bucket no caminho (MinIO) em vez do subdomínio
+	S3Prefix    string // prefixo opcional das chaves dentro do bucket
+}
+
+// New cria o backend configurado
+func New(cfg Config) (Storage, error) {
+	switch cfg.Backend {
+	case "", BackendLocal:
+		return NewLocal(cfg.LocalDir), nil
+	case BackendS3:
+		return NewS3(cfg)
+	default:
+		return nil, fmt.Errorf("STORAGE_BACKEND inválido: %q (use local ou s3)", cfg.Backend)
+	}
+}
+
+// NewKey gera uma chave única em dir para o nome de arquivo enviado pelo cliente
+func NewKey(dir, filename string) string {
+	return path.Join(dir, fmt.Sprintf("%d_%s", time.Now().UnixNano(), SafeName(filename)))
+}
+
+// SafeName reduz o nome enviado pelo cliente a um nome de arquivo sem diretórios
+// nem caracteres problemáticos em URLs e sistemas de arquivos
+func SafeName(filename string) string {
+	filename = path.Base(strings.ReplaceAll(filename, "\\", "/"))
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r < 0x20, r == 0x7f:
+			return -1
+		case strings.ContainsRune(`/\:*?"<>|#%&{}+`, r):
+			return '_'
+		}
+		return r
+	}, filename)
+	name = strings.TrimLeft(strings.TrimSpace(name), ".")
+	if name == "" {
+		name = "arquivo"
+	}
+	return name
+}
+
+// CleanKey valida a chave: relativa, com "/" e sem ".." (impede acessar fora da raiz)
+func CleanKey(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return "", fmt.Errorf("chave de armazenamento inválida: %q", key)
+	}
+	cleaned := path.Clean(key)
+	if cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("chave de armazenamento inválida: %q", key)
+	}
+	return cleaned, nil
+}
+
+// ContentTypeOf deduz o tipo do conteúdo pela extensão da chave
+func ContentTypeOf(key string) string {
+	if contentType := mime.TypeByExtension(strings.ToLower(path.Ext(key))); contentType != "" {
+		return contentType
+	}
+	return "application/octet-stream"
+}
+
+// Copy copia um objeto de um backend (e chave) para outro (usado na migração de armazenamento)
+func Copy(ctx context.Context, from Storage, fromKey string, to Storage, toKey string) (int64, error) {
+	r, info, err := from.Open(ctx, fromKey)
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+
+	if err := to.Put(ctx, toKey, r, info.Size, info.ContentType); err != nil {
+		return 0, err
+	}
+	return info.Size, nil
+}
@@ -0,0 +1,285 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeS3 - Bucket em memória que responde ao subconjunto da API usado pelo backend S3
+// (PUT, GET, HEAD, DELETE e ListObjectsV2), no estilo caminho (/bucket/objeto)
+type fakeS3 struct {
+	t        *testing.T
+	bucket   string
+	pageSize int
+
+	mu      sync.Mutex
+	objects map[string]fakeObject
+}
+
+type fakeObject struct {
+	data        []byte
+	contentType string
+	modTime     time.Time
+}
+
+func newFakeS3(t *testing.T, bucket string) *httptest.Server {
+	t.Helper()
+	fake := &fakeS3{t: t, bucket: bucket, pageSize: 2, objects: map[string]fakeObject{}}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=chave/") || !strings.Contains(auth, "/us-east-1/s3/aws4_request") {
+		f.t.Errorf("%s %s: Authorization inesperado: %q", r.Method, r.URL.Path, auth)
+		http.Error(w, "", http.StatusForbidden)
+		return
+	}
+	if r.Header.Get("X-Amz-Content-Sha256") == "" || r.Header.Get("X-Amz-Date") == "" {
+		f.t.Errorf("%s %s: cabeçalhos x-amz ausentes", r.Method, r.URL.Path)
+	}
+
+	bucketPrefix := "/" + f.bucket + "/"
+	if !strings.HasPrefix(r.URL.Path, bucketPrefix) {
+		http.Error(w, "", http.StatusNotFound)
+		return
+	}
+	object := strings.TrimPrefix(r.URL.Path, bucketPrefix)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if object == "" && r.Method == http.MethodGet && r.URL.Query().Get("list-type") == "2" {
+		f.list(w, r)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "", http.StatusBadRequest)
+			return
+		}
+		if r.ContentLength != int64(len(data)) {
+			f.t.Errorf("PUT %s: Content-Length %d, corpo %d", object, r.ContentLength, len(data))
+		}
+		f.objects[object] = fakeObject{data: data, contentType: r.Header.Get("Content-Type"), modTime: time.Now().UTC().Truncate(time.Second)}
+	case http.MethodGet, http.MethodHead:
+		obj, ok := f.objects[object]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, "<Error><Code>NoSuchKey</Code></Error>")
+			return
+		}
+		w.Header().Set("Content-Type", obj.contentType)
+		w.Header().Set("Content-Length", strconv.Itoa(len(obj.data)))
+		w.Header().Set("Last-Modified", obj.modTime.Format(http.TimeFormat))
+		if r.Method == http.MethodGet {
+			w.Write(obj.data)
+		}
+	case http.MethodDelete:
+		delete(f.objects, object)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "", http.StatusMethodNotAllowed)
+	}
+}
+
+// list pagina de pageSize em pageSize para exercitar o continuation-token
+func (f *fakeS3) list(w http.ResponseWriter, r *http.Request) {
+	prefix := r.URL.Query().Get("prefix")
+	var keys []string
+	for key := range f.objects {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	start, _ := strconv.Atoi(r.URL.Query().Get("continuation-token"))
+	end := min(start+f.pageSize, len(keys))
+
+	var result listResult
+	for _, key := range keys[start:end] {
+		obj := f.objects[key]
+		result.Contents = append(result.Contents, struct {
+			Key          string    `xml:"Key"`
+			Size         int64     `xml:"Size"`
+			LastModified time.Time `xml:"LastModified"`
+		}{key, int64(len(obj.data)), obj.modTime})
+	}
+	if end < len(keys) {
+		result.IsTruncated = true
+		result.NextContinuationToken = strconv.Itoa(end)
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	xml.NewEncoder(w).Encode(struct {
+		XMLName xml.Name `xml:"ListBucketResult"`
+		listResult
+	}{listResult: result})
+}
+
+func newTestS3(t *testing.T) *S3 {
+	t.Helper()
+	server := newFakeS3(t, "pedidos")
+	s, err := NewS3(Config{
+		S3Endpoint:  server.URL,
+		S3Bucket:    "pedidos",
+		S3AccessKey: "chave",
+		S3SecretKey: "segredo",
+		S3PathStyle: true,
+		S3Prefix:    "/anexos/",
+	})
+	if err != nil {
+		t.Fatalf("NewS3: %v", err)
+	}
+	return s
+}
+
+func listKeys(t *testing.T, s Storage, prefix string) []string {
+	t.Helper()
+	var keys []string
+	if err := s.List(context.Background(), prefix, func(info ObjectInfo) error {
+		keys = append(keys, info.Key)
+		return nil
+	}); err != nil {
+		t.Fatalf("List(%q): %v", prefix, err)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestStorageRoundTrip(t *testing.T) {
+	backends := []struct {
+		name    string
+		storage func(t *testing.T) Storage
+	}{
+		{"local", func(t *testing.T) Storage { return NewLocal(t.TempDir()) }},
+		{"s3", func(t *testing.T) Storage { return newTestS3(t) }},
+	}
+
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := backend.storage(t)
+
+			files := map[string]string{
+				"requests/1/orçamento final.pdf": "%PDF-1.4 conteúdo",
+				"requests/1/foto.png":            "png",
+				"requests/2/nota.xml":            "<nfe/>",
+				"suppliers/3/contrato.pdf":       "",
+			}
+			for key, content := range files {
+				// tamanho desconhecido (-1) também precisa funcionar
+				size := int64(len(content))
+				if strings.HasSuffix(key, ".png") {
+					size = -1
+				}
+				if err := s.Put(ctx, key, strings.NewReader(content), size, ""); err != nil {
+					t.Fatalf("Put(%q): %v", key, err)
+				}
+			}
+
+			for key, content := range files {
+				r, info, err := s.Open(ctx, key)
+				if err != nil {
+					t.Fatalf("Open(%q): %v", key, err)
+				}
+				data, err := io.ReadAll(r)
+				r.Close()
+				if err != nil {
+					t.Fatalf("lendo %q: %v", key, err)
+				}
+				if string(data) != content {
+					t.Errorf("Open(%q) = %q, want %q", key, data, content)
+				}
+				if info.Key != key || info.Size != int64(len(content)) {
+					t.Errorf("Open(%q) info = %+v", key, info)
+				}
+			}
+
+			info, err := s.Stat(ctx, "requests/1/orçamento final.pdf")
+			if err != nil {
+				t.Fatalf("Stat: %v", err)
+			}
+			if info.Size != int64(len(files["requests/1/orçamento final.pdf"])) || info.ContentType != "application/pdf" || info.ModTime.IsZero() {
+				t.Errorf("Stat = %+v", info)
+			}
+
+			if got, want := strings.Join(listKeys(t, s, "requests/"), ","), "requests/1/foto.png,requests/1/orçamento final.pdf,requests/2/nota.xml"; got != want {
+				t.Errorf("List(requests/) = %s, want %s", got, want)
+			}
+			if got := len(listKeys(t, s, "")); got != len(files) {
+				t.Errorf("List(\"\") retornou %d objetos, want %d", got, len(files))
+			}
+
+			if err := s.Delete(ctx, "requests/2/nota.xml"); err != nil {
+				t.Fatalf("Delete: %v", err)
+			}
+			if err := s.Delete(ctx, "requests/2/nota.xml"); err != nil {
+				t.Errorf("Delete de objeto inexistente: %v", err)
+			}
+			if _, err := s.Stat(ctx, "requests/2/nota.xml"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Stat após Delete: err = %v, want ErrNotFound", err)
+			}
+			if _, _, err := s.Open(ctx, "requests/9/inexistente.pdf"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Open inexistente: err = %v, want ErrNotFound", err)
+			}
+
+			if err := s.Put(ctx, "../fora.txt", strings.NewReader("x"), 1, ""); err == nil {
+				t.Error("Put aceitou chave fora da raiz")
+			}
+		})
+	}
+}
+
+func TestCopyBetweenBackends(t *testing.T) {
+	ctx := context.Background()
+	local := NewLocal(t.TempDir())
+	s3 := newTestS3(t)
+
+	content := bytes.Repeat([]byte("pedido de compra "), 1000)
+	if err := local.Put(ctx, "requests/5/anexo.txt", bytes.NewReader(content), int64(len(content)), ""); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	n, err := Copy(ctx, local, "requests/5/anexo.txt", s3, "requests/5/anexo.txt")
+	if err != nil {
+		t.Fatalf("Copy local -> s3: %v", err)
+	}
+	if n != int64(len(content)) {
+		t.Errorf("Copy copiou %d bytes, want %d", n, len(content))
+	}
+
+	back := NewLocal(t.TempDir())
+	if _, err := Copy(ctx, s3, "requests/5/anexo.txt", back, "copia/anexo.txt"); err != nil {
+		t.Fatalf("Copy s3 -> local: %v", err)
+	}
+	r, _, err := back.Open(ctx, "copia/anexo.txt")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer r.Close()
+	data, _ := io.ReadAll(r)
+	if !bytes.Equal(data, content) {
+		t.Error("conteúdo diferente após ida e volta pelo S3")
+	}
+
+	if _, err := Copy(ctx, local, "requests/5/inexistente.txt", s3, "x.txt"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Copy de origem inexistente: err = %v, want ErrNotFound", err)
+	}
+}
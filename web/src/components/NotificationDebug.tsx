@@ -6,11 +6,11 @@ interface TestNotificationResponse {
   clients: number;
   stats: {
     total: number;
-    by_role: Record<string, number>;
+    by_permission: Record<string, number>;
     clients: Array<{
       id: string;
       user_name: string;
-      role: string;
+      permissions: string[];
       connected: string;
       last_ping: string;
     }>;
@@ -195,9 +195,9 @@ const NotificationDebug: React.FC = () => {
                   <span>Clientes:</span>
                   <span>{serverStats.total}</span>
                 </div>
-                {Object.entries(serverStats.by_role).map(([role, count]) => (
-                  <div key={role} className="flex justify-between pl-2">
-                    <span className="capitalize">{role}:</span>
+                {Object.entries(serverStats.by_permission).map(([permission, count]) => (
+                  <div key={permission} className="flex justify-between pl-2">
+                    <span>{permission}:</span>
                     <span>{count}</span>
                   </div>
                 ))}